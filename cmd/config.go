@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultConfigPath is where otail looks for a config file when --config is
+// not given.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "otail.json"
+	}
+	return filepath.Join(dir, "otail", "config.json")
+}
+
+// configFile returns the config file in use: --config, or else the default.
+func (g *globalOptions) configFile() string {
+	if g.configPath != "" {
+		return g.configPath
+	}
+	return defaultConfigPath()
+}
+
+// applyConfig fills in flags the user did not set from a JSON object whose
+// keys are flag names, e.g. {"endpoint": "ws://collector:12001",
+// "theme": "solarized"}. Keys that are not flags of cmd are ignored so one
+// file can serve every subcommand. A missing default file is not an error.
+func applyConfig(cmd *cobra.Command, path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	for name, v := range values {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		if err := setFlag(f, v); err != nil {
+			return fmt.Errorf("config %s: %s: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+func setFlag(f *pflag.Flag, v any) error {
+	switch v := v.(type) {
+	case string:
+		return f.Value.Set(v)
+	case []any:
+		for _, e := range v {
+			if err := f.Value.Set(fmt.Sprint(e)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return f.Value.Set(fmt.Sprint(v))
+	}
+}
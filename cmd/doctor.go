@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/transport"
+)
+
+func newDoctorCmd(g *globalOptions) *cobra.Command {
+	var (
+		wait    time.Duration
+		samples int
+	)
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the connection to the collector and summarise what it sends",
+		Long: "doctor dials the endpoint once, reports each step of the handshake, then\n" +
+			"listens for --wait and classifies the frames it receives. Use it when the\n" +
+			"TUI connects but shows nothing, or shows everything under Other.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			endpoint, err := g.endpoint()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runDoctor(ctx, cmd.OutOrStdout(), endpoint, wait, samples)
+		},
+	}
+	f := cmd.Flags()
+	f.DurationVar(&wait, "wait", 10*time.Second, "how long to listen for frames")
+	f.IntVar(&samples, "samples", 3, "frames to show per signal")
+	return cmd
+}
+
+// doctorReport tallies the frames seen while listening.
+type doctorReport struct {
+	counts  map[telemetry.Kind]int
+	samples map[telemetry.Kind][]telemetry.Message
+	first   time.Duration
+	closed  error
+	stats   transport.Stats
+}
+
+func runDoctor(ctx context.Context, w io.Writer, endpoint string, wait time.Duration, samples int) error {
+	fmt.Fprintf(w, "endpoint   %s\n", endpoint)
+
+	start := time.Now()
+	hs, s, err := transport.Probe(ctx, endpoint, "http://localhost/")
+	printHandshake(w, hs)
+	if err != nil {
+		fmt.Fprintf(w, "\nFAIL       %v\n", err)
+		for _, h := range dialHints(hs) {
+			fmt.Fprintf(w, "hint       %s\n", h)
+		}
+		return fmt.Errorf("could not connect to %s", endpoint)
+	}
+	defer s.Close()
+
+	fmt.Fprintf(w, "\nlistening for %s…\n", wait)
+	rep := listen(ctx, s, wait, samples, start)
+
+	fmt.Fprintln(w)
+	total := 0
+	for _, k := range []telemetry.Kind{telemetry.KindLogs, telemetry.KindMetrics, telemetry.KindTraces, telemetry.KindUnknown} {
+		fmt.Fprintf(w, "%-10s %d frames\n", k, rep.counts[k])
+		total += rep.counts[k]
+	}
+	if rep.stats.Dropped > 0 {
+		fmt.Fprintf(w, "%-10s %d frames\n", "dropped", rep.stats.Dropped)
+	}
+	if rep.stats.DecompressFailed > 0 {
+		fmt.Fprintf(w, "%-10s %d frames failed to decompress\n", "corrupt", rep.stats.DecompressFailed)
+	}
+	if total > 0 {
+		fmt.Fprintf(w, "%-10s %s after connecting\n", "first", rep.first.Round(time.Millisecond))
+	}
+	if rep.closed != nil {
+		fmt.Fprintf(w, "%-10s server closed the connection: %v\n", "closed", rep.closed)
+	}
+
+	for _, k := range []telemetry.Kind{telemetry.KindLogs, telemetry.KindMetrics, telemetry.KindTraces, telemetry.KindUnknown} {
+		msgs := rep.samples[k]
+		if len(msgs) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s samples:\n", k)
+		for _, msg := range msgs {
+			if k == telemetry.KindUnknown {
+				fmt.Fprintf(w, "  %s\n", truncate(string(msg.Raw), 120))
+				for _, d := range msg.Diagnostics {
+					fmt.Fprintf(w, "    // %s\n", d)
+				}
+				continue
+			}
+			for _, rec := range msg.Records() {
+				fmt.Fprintf(w, "  %s\n", rec)
+			}
+		}
+	}
+
+	hints := frameHints(rep, total)
+	if len(hints) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, h := range hints {
+		fmt.Fprintf(w, "hint       %s\n", h)
+	}
+	return nil
+}
+
+func listen(ctx context.Context, s *transport.Stream, wait time.Duration, samples int, start time.Time) doctorReport {
+	rep := doctorReport{
+		counts:  map[telemetry.Kind]int{},
+		samples: map[telemetry.Kind][]telemetry.Message{},
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	states := s.States()
+	for {
+		select {
+		case <-ctx.Done():
+			rep.stats = s.Stats()
+			return rep
+		case <-timer.C:
+			rep.stats = s.Stats()
+			return rep
+		case st, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			if st.Kind == transport.Disconnected {
+				rep.closed = st.Err
+			}
+		case frame, ok := <-s.Messages():
+			if !ok {
+				rep.stats = s.Stats()
+				return rep
+			}
+			msg := telemetry.Parse(frame)
+			if len(rep.counts) == 0 {
+				rep.first = time.Since(start)
+			}
+			rep.counts[msg.Kind]++
+			if len(rep.samples[msg.Kind]) < samples {
+				rep.samples[msg.Kind] = append(rep.samples[msg.Kind], msg)
+			}
+		}
+	}
+}
+
+func printHandshake(w io.Writer, hs *transport.Handshake) {
+	if len(hs.Addrs) > 0 {
+		fmt.Fprintf(w, "dns        %v (%s)\n", hs.Addrs, hs.Resolve.Round(time.Microsecond))
+	}
+	if hs.Remote != "" {
+		fmt.Fprintf(w, "tcp        %s (%s)\n", hs.Remote, hs.Connect.Round(time.Microsecond))
+	}
+	if st := hs.TLS; st != nil {
+		fmt.Fprintf(w, "tls        %s, %s (%s)\n", tls.VersionName(st.Version), tls.CipherSuiteName(st.CipherSuite), hs.TLSTime.Round(time.Microsecond))
+		if len(st.PeerCertificates) > 0 {
+			c := st.PeerCertificates[0]
+			fmt.Fprintf(w, "cert       %s, issued by %s, expires %s\n", c.Subject, c.Issuer, c.NotAfter.Format(time.DateOnly))
+		}
+	}
+	if hs.Status != "" {
+		fmt.Fprintf(w, "upgrade    %s (%s)\n", hs.Status, hs.Upgrade.Round(time.Microsecond))
+	}
+}
+
+// dialHints suggests causes for a failed dial based on how far it got.
+func dialHints(hs *transport.Handshake) []string {
+	switch {
+	case len(hs.Addrs) == 0:
+		return []string{"the host name did not resolve; check --endpoint"}
+	case hs.Remote == "":
+		return []string{"nothing is listening there; is the collector running with the remotetap processor enabled?"}
+	case hs.Status == "":
+		return []string{"the server did not answer the upgrade; check ws:// versus wss://"}
+	case !containsCode(hs.Status, "101"):
+		return []string{"the server rejected the websocket upgrade; a proxy or auth layer may be in the way"}
+	}
+	return nil
+}
+
+func frameHints(rep doctorReport, total int) []string {
+	var hints []string
+	if total == 0 {
+		hints = append(hints, "connected but received nothing; make sure remotetap is listed in a pipeline that has traffic")
+	}
+	if total > 0 && rep.counts[telemetry.KindUnknown] == total {
+		hints = append(hints, "no frame parsed as OTLP JSON; the processor may be configured with a different encoding")
+	}
+	if rep.stats.DecompressFailed > 0 {
+		hints = append(hints, "some frames had a gzip or zlib header but would not inflate; a proxy may be truncating them")
+	}
+	if rep.stats.Dropped > 0 {
+		hints = append(hints, "frames were dropped because the buffer filled; the collector is sending faster than otail reads")
+	}
+	return hints
+}
+
+func containsCode(status, code string) bool {
+	// "HTTP/1.1 101 Switching Protocols"
+	var proto, got string
+	fmt.Sscan(status, &proto, &got)
+	return got == code
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jwafle/otail/internal/headless"
+)
+
+func newExportCmd(g *globalOptions) *cobra.Command {
+	var (
+		output, signals string
+		count           int
+		duration        time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Record frames from the collector to a file, one JSON document per line",
+		Long: "export writes each frame as compact OTLP JSON on its own line until it is\n" +
+			"interrupted or a --count or --duration limit is reached. The file can be\n" +
+			"browsed later with `otail replay`.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			src, err := g.source()
+			if err != nil {
+				return err
+			}
+			cfg := &headless.Config{Format: headless.FormatJSON, Limit: count}
+			if cfg.Signals, err = parseSignals(signals); err != nil {
+				return err
+			}
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				cfg.Out = f
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if duration > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, duration)
+				defer cancel()
+			}
+			return headless.Run(ctx, src, cfg)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&output, "output", "o", "-", "file to write; - = stdout")
+	f.StringVar(&signals, "signals", "", "comma-separated signals to record (logs, metrics, traces, other); empty = all")
+	f.IntVarP(&count, "count", "n", 0, "stop after this many frames; 0 = no limit")
+	f.DurationVar(&duration, "duration", 0, "stop after this long; 0 = no limit")
+	return cmd
+}
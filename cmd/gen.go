@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jwafle/otail/internal/gen"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+func newGenCmd(g *globalOptions) *cobra.Command {
+	var (
+		addr  string
+		kinds []string
+		cfg   gen.Config
+	)
+	cmd := &cobra.Command{
+		Use:   "gen",
+		Short: "Serve synthetic telemetry over a websocket, for load-testing otail",
+		Long: "gen stands in for a collector's remotetap processor: every client that\n" +
+			"connects to ws://ADDR receives --rate frames per second of made-up logs,\n" +
+			"metrics, and traces. Point otail at it with --endpoint to see how the UI\n" +
+			"copes with a given load.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			for _, k := range kinds {
+				if strings.EqualFold(k, "all") {
+					cfg.Kinds = nil
+					break
+				}
+				kind, err := telemetry.ParseKind(k)
+				if err != nil {
+					return err
+				}
+				cfg.Kinds = append(cfg.Kinds, kind)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			srv := &http.Server{Addr: addr, Handler: gen.Handler(&cfg)}
+			errCh := make(chan error, 1)
+			go func() { errCh <- srv.ListenAndServe() }()
+			g.logger("[gen] ", levelInfo).Printf("serving %d frames/s on ws://%s", cfg.Rate, addr)
+
+			var err error
+			select {
+			case <-ctx.Done():
+			case err = <-errCh:
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if serr := srv.Shutdown(shutdownCtx); err == nil || errors.Is(err, http.ErrServerClosed) {
+				err = serr
+			}
+			return err
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&addr, "addr", "127.0.0.1:12001", "address to listen on")
+	f.IntVar(&cfg.Rate, "rate", 100, "frames per second sent to each client")
+	f.StringSliceVar(&kinds, "kind", []string{"all"}, "signals to send: logs, metrics, traces, other, or all")
+	f.IntVar(&cfg.Records, "records", 10, "log records, spans, or data points per frame")
+	f.IntVar(&cfg.Services, "services", 5, "distinct service.name values")
+	return cmd
+}
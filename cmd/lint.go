@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jwafle/otail/internal/lint"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Exit statuses of otail lint, so CI can tell broken instrumentation from
+// a broken invocation.
+const (
+	lintExitViolations = 1
+	lintExitError      = 2
+)
+
+func newLintCmd() *cobra.Command {
+	var (
+		ignore        []string
+		maxViolations int
+		asJSON        bool
+		listRules     bool
+	)
+	cmd := &cobra.Command{
+		Use:   "lint [FILE...]",
+		Short: "Check a recording or OTLP JSON file against the semantic conventions",
+		Long: "lint runs the checks of the TUI's lint panel over files recorded with\n" +
+			"`otail export` (one frame per line) or holding a single OTLP JSON document,\n" +
+			"or over stdin when no file or - is given, and prints how often each rule was\n" +
+			"broken. It exits 1 when more than --max violations are found and 2 when\n" +
+			"the input cannot be read or the invocation is wrong, so it can gate\n" +
+			"instrumentation changes in CI.",
+		Args: cobra.ArbitraryArgs,
+		// A bad config file, --log-level, or --debug-log is the invocation's
+		// fault, not the instrumentation's, so it must not exit 1.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmd.Root().PersistentPreRunE(cmd, args); err != nil {
+				return &exitError{code: lintExitError, err: err}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := cmd.OutOrStdout()
+			if listRules {
+				for _, r := range lint.Rules {
+					fmt.Fprintf(w, "%-30s %s\n", r.Name, r.Description)
+				}
+				return nil
+			}
+			for _, name := range ignore {
+				if !slices.ContainsFunc(lint.Rules, func(r lint.Rule) bool { return r.Name == name }) {
+					return &exitError{code: lintExitError, err: fmt.Errorf("--ignore %q: no such rule; see otail lint --list-rules", name)}
+				}
+			}
+			if len(args) == 0 {
+				args = []string{"-"}
+			}
+			res := lintResult{report: &lint.Report{}}
+			for _, path := range args {
+				if err := res.addFile(path, cmd.InOrStdin()); err != nil {
+					return &exitError{code: lintExitError, err: err}
+				}
+			}
+			res.drop(ignore)
+			if asJSON {
+				if err := res.writeJSON(w); err != nil {
+					return &exitError{code: lintExitError, err: err}
+				}
+			} else {
+				res.writeText(w)
+			}
+			if res.total > maxViolations {
+				return &exitError{code: lintExitViolations, err: fmt.Errorf("%s, more than the %d allowed", plural(res.total, "violation"), maxViolations)}
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringSliceVar(&ignore, "ignore", nil, "rules to skip, comma-separated or repeated")
+	f.IntVar(&maxViolations, "max", 0, "violations allowed before lint fails")
+	f.BoolVar(&asJSON, "json", false, "print the report as JSON")
+	f.BoolVar(&listRules, "list-rules", false, "list the rules and exit")
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &exitError{code: lintExitError, err: err}
+	})
+	return cmd
+}
+
+// lintResult is what lint found in every file it read.
+type lintResult struct {
+	report   *lint.Report
+	other    int // frames that are not OTLP
+	findings []lint.Finding
+	total    int
+}
+
+// addFile checks every frame in the file at path, or in stdin for "-".
+func (res *lintResult) addFile(path string, stdin io.Reader) error {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	// A single document may be pretty-printed over many lines; anything
+	// else is read as a recording, one frame per line.
+	if trimmed := bytes.TrimSpace(data); json.Valid(trimmed) {
+		res.add(trimmed)
+		return nil
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64<<10), len(data)+1)
+	for sc.Scan() {
+		if line := bytes.TrimSpace(sc.Bytes()); len(line) > 0 {
+			res.add(line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (res *lintResult) add(frame []byte) {
+	msg := telemetry.Parse(frame)
+	if msg.Kind == telemetry.KindUnknown {
+		res.other++
+		return
+	}
+	res.report.Add(msg)
+}
+
+// drop leaves out the ignored rules and totals the rest.
+func (res *lintResult) drop(ignore []string) {
+	for _, f := range res.report.Findings() {
+		if !slices.Contains(ignore, f.Rule.Name) {
+			res.findings = append(res.findings, f)
+			res.total += f.Count
+		}
+	}
+}
+
+func (res *lintResult) writeText(w io.Writer) {
+	fmt.Fprintf(w, "checked %s", plural(res.report.Messages, "message"))
+	if res.other > 0 {
+		fmt.Fprintf(w, " (skipped %d not OTLP)", res.other)
+	}
+	fmt.Fprintln(w)
+	for _, f := range res.findings {
+		services := make([]string, len(f.Services))
+		for i, s := range f.Services {
+			services[i] = cmp.Or(s, "(no service.name)")
+		}
+		fmt.Fprintf(w, "\n%6d %s  %s\n", f.Count, f.Rule.Name, f.Rule.Description)
+		fmt.Fprintf(w, "       services: %s\n", strings.Join(services, ", "))
+		for _, e := range f.Examples {
+			fmt.Fprintf(w, "       e.g. %s\n", e)
+		}
+	}
+	fmt.Fprintf(w, "\n%s of %s\n", plural(res.total, "violation"), plural(len(res.findings), "rule"))
+}
+
+func (res *lintResult) writeJSON(w io.Writer) error {
+	type finding struct {
+		Rule        string   `json:"rule"`
+		Description string   `json:"description"`
+		Count       int      `json:"count"`
+		Services    []string `json:"services"`
+		Examples    []string `json:"examples"`
+	}
+	out := struct {
+		Messages   int       `json:"messages"`
+		Other      int       `json:"other"`
+		Violations int       `json:"violations"`
+		Findings   []finding `json:"findings"`
+	}{Messages: res.report.Messages, Other: res.other, Violations: res.total, Findings: []finding{}}
+	for _, f := range res.findings {
+		out.Findings = append(out.Findings, finding{f.Rule.Name, f.Rule.Description, f.Count, f.Services, f.Examples})
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(out)
+}
+
+// plural formats n with noun, adding an s unless n is one.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
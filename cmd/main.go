@@ -1,26 +1,25 @@
 package main
 
 import (
-	"flag"
-
-	"github.com/jwafle/otail/internal/telemetry"
-	"github.com/jwafle/otail/internal/ui"
-	"golang.design/x/clipboard"
+	"errors"
+	"os"
 )
 
-func main() { // Init returns an error if the package is not ready for use.
-	err := clipboard.Init()
-	if err != nil {
-		panic(err)
-	}
+// exitError makes otail exit with code instead of 1.
+type exitError struct {
+	code int
+	err  error
+}
 
-	var endpoint string
-	flag.StringVar(&endpoint, "endpoint", "ws://127.0.0.1:12001", "websocket endpoint")
-	flag.StringVar(&endpoint, "e", "ws://127.0.0.1:12001", "websocket endpoint (shorthand)")
-	flag.Parse()
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
 
-	initial := telemetry.KindLogs // default; let cli flags adjust if you like
-	if err := ui.Run(endpoint, initial); err != nil {
-		panic(err)
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		var ee *exitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.code)
+		}
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jwafle/otail/internal/transport"
+)
+
+func newReplayCmd(g *globalOptions) *cobra.Command {
+	o := &tuiOptions{}
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "replay FILE [logs|metrics|traces|other]",
+		Short: "Browse a recording made by `otail export` instead of a live collector",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(g, transport.ReplayFile(args[0], interval), args[1:])
+		},
+	}
+	addTUIFlags(cmd, o)
+	cmd.Flags().DurationVar(&interval, "interval", 0, "delay between replayed frames; 0 = as fast as possible")
+	return cmd
+}
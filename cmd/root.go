@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jwafle/otail/internal/transport"
+)
+
+const defaultEndpoint = "ws://127.0.0.1:12001"
+
+// globalOptions holds the persistent flags shared by every subcommand.
+type globalOptions struct {
+	endpoints  []string
+	configPath string
+	logLevel   string
+	spillMiB   int
+	spillDir   string
+	debugLog   string
+
+	debug *slog.Logger // --debug-log; nil = none
+}
+
+func newRootCmd() *cobra.Command {
+	g := &globalOptions{}
+	tui := &tuiOptions{}
+
+	root := &cobra.Command{
+		Use:   "otail [logs|metrics|traces|other]",
+		Short: "Stream telemetry from an OpenTelemetry Collector remotetap processor",
+		Long: "otail connects to the OpenTelemetry Collector's remotetapprocessor over a\n" +
+			"websocket and shows the telemetry flowing through it. Without a subcommand\n" +
+			"it starts the terminal UI, exactly like `otail tui`.",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := applyConfig(cmd, g.configPath); err != nil {
+				return err
+			}
+			if _, err := parseLogLevel(g.logLevel); err != nil {
+				return err
+			}
+			return g.openDebugLog()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTUI(g, tui, args)
+		},
+	}
+
+	pf := root.PersistentFlags()
+	pf.StringArrayVarP(&g.endpoints, "endpoint", "e", []string{defaultEndpoint}, "websocket endpoint, optionally NAME=URL; repeat to tail several")
+	pf.StringVar(&g.configPath, "config", "", "config file (default "+defaultConfigPath()+")")
+	pf.StringVar(&g.logLevel, "log-level", "info", "log level (debug, info, warn, error)")
+	pf.StringVar(&g.debugLog, "debug-log", "", "append otail's own transport, parser, and UI events to this file, for debugging without breaking the display")
+	pf.IntVar(&g.spillMiB, "spill", 0, "when the UI falls behind, keep up to this many MiB of frames on disk instead of dropping them; 0 = drop")
+	pf.StringVar(&g.spillDir, "spill-dir", "", "directory for the --spill file (default the system temp directory)")
+	addTUIFlags(root, tui)
+
+	root.AddCommand(
+		newTUICmd(g),
+		newReplayCmd(g),
+		newExportCmd(g),
+		newDoctorCmd(g),
+		newServeCmd(g),
+		newGenCmd(g),
+		newLintCmd(),
+	)
+	return root
+}
+
+// logLevel orders the --log-level values.
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+func parseLogLevel(s string) (logLevel, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return levelDebug, nil
+	case "", "info":
+		return levelInfo, nil
+	case "warn", "warning":
+		return levelWarn, nil
+	case "error":
+		return levelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn, or error)", s)
+	}
+}
+
+// logger returns a stderr logger for messages at level, or a discarding one
+// when --log-level filters them out.
+func (g *globalOptions) logger(prefix string, level logLevel) *log.Logger {
+	min, _ := parseLogLevel(g.logLevel)
+	if level < min {
+		return log.New(io.Discard, "", 0)
+	}
+	return log.New(os.Stderr, prefix, log.LstdFlags)
+}
+
+// openDebugLog opens the --debug-log file, if any. It stays open until otail
+// exits; slog writes each record straight through, so nothing is lost.
+func (g *globalOptions) openDebugLog() error {
+	if g.debugLog == "" {
+		return nil
+	}
+	f, err := os.OpenFile(g.debugLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("--debug-log: %w", err)
+	}
+	g.debug = slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	g.debug.Info("otail started", "args", os.Args[1:])
+	return nil
+}
+
+// source validates the endpoints and returns a Source that dials them,
+// merged into one stream when there are several. The transport only logs
+// reconnect attempts, which are info-level.
+func (g *globalOptions) source() (transport.Source, error) {
+	var srcs []transport.NamedSource
+	for _, e := range g.endpoints {
+		name, endpoint, err := parseEndpoint(e)
+		if err != nil {
+			return nil, err
+		}
+		prefix := "[transport] "
+		if len(g.endpoints) > 1 {
+			prefix = "[transport " + name + "] "
+		}
+		srcs = append(srcs, transport.NamedSource{
+			Name: name,
+			Source: transport.DialSource(endpoint, "http://localhost/", &transport.Config{
+				PingInterval: 30 * time.Second,
+				Logger:       g.logger(prefix, levelInfo),
+				SpillLimit:   int64(g.spillMiB) << 20,
+				SpillDir:     g.spillDir,
+				Debug:        g.debug,
+			}),
+		})
+	}
+	switch len(srcs) {
+	case 0:
+		return nil, fmt.Errorf("no endpoint given")
+	case 1:
+		return srcs[0].Source, nil
+	default:
+		return transport.Merge(srcs), nil
+	}
+}
+
+// endpoint returns the only endpoint, for subcommands that talk to one
+// collector at a time.
+func (g *globalOptions) endpoint() (string, error) {
+	if len(g.endpoints) != 1 {
+		return "", fmt.Errorf("%d endpoints given; this command takes one", len(g.endpoints))
+	}
+	_, endpoint, err := parseEndpoint(g.endpoints[0])
+	return endpoint, err
+}
+
+// parseEndpoint splits an --endpoint value of the form [NAME=]URL. Without
+// a name the source is named after the URL's host.
+func parseEndpoint(s string) (name, endpoint string, err error) {
+	endpoint = s
+	if i := strings.Index(s, "="); i >= 0 && i < strings.Index(s, "://") {
+		name, endpoint = s[:i], s[i+1:]
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+	}
+	if name == "" {
+		name = u.Host
+	}
+	return name, endpoint, nil
+}
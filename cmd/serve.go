@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jwafle/otail/internal/hub"
+	"github.com/jwafle/otail/internal/store"
+	"github.com/jwafle/otail/internal/web"
+)
+
+func newServeCmd(g *globalOptions) *cobra.Command {
+	var (
+		addr            string
+		history, retain int
+		keepAlive       time.Duration
+		storeSpec       string
+	)
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the collector's telemetry over HTTP",
+		Long: "serve reads the collector's stream once and fans it out to any number of\n" +
+			"HTTP clients. Each signal is available as server-sent events at\n" +
+			"/logs/sse, /metrics/sse, /traces/sse, and /other/sse; new clients first\n" +
+			"receive the last --history messages. /ws carries every signal over one\n" +
+			"websocket and accepts pause, signal, and filter controls. The last --retain messages can be\n" +
+			"queried under /api, or every message with --store sqlite:FILE.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			src, err := g.source()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			st, err := store.Open(storeSpec)
+			if err != nil {
+				return err
+			}
+			h := hub.New(&hub.Config{History: history, Retain: retain})
+			if st != nil {
+				defer st.Close()
+				// Carry on from the stored history, so replay and
+				// Last-Event-ID resume reach back past the restart.
+				recent, err := st.Recent(ctx, max(retain, history))
+				if err != nil {
+					return err
+				}
+				h.Seed(recent)
+			}
+			srv := &http.Server{
+				Addr:    addr,
+				Handler: web.New(h, &web.Config{Endpoint: strings.Join(g.endpoints, ", "), KeepAlive: keepAlive, Logger: g.logger("[web] ", levelDebug), Store: st}),
+			}
+			errCh := make(chan error, 3)
+			if st != nil {
+				// The store holds up the hub rather than miss a message.
+				sub := h.Subscribe(&hub.SubscribeOptions{Block: true})
+				defer sub.Close()
+				go func() { errCh <- st.Record(sub) }()
+			}
+			go func() { errCh <- h.Run(ctx, src) }()
+			go func() { errCh <- srv.ListenAndServe() }()
+			g.logger("[web] ", levelInfo).Printf("listening on http://%s", addr)
+
+			select {
+			case <-ctx.Done():
+			case err = <-errCh:
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if serr := srv.Shutdown(shutdownCtx); err == nil || errors.Is(err, http.ErrServerClosed) {
+				err = serr
+			}
+			return err
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&addr, "addr", "127.0.0.1:8080", "address to listen on")
+	f.IntVar(&history, "history", 256, "recent messages replayed to each new client")
+	f.IntVar(&retain, "retain", 10000, "recent messages kept for /api queries and SSE resume")
+	f.StringVar(&storeSpec, "store", "memory", "where messages are kept for /api queries: memory, or sqlite:FILE to keep every message across restarts")
+	f.DurationVar(&keepAlive, "keepalive", 15*time.Second, "interval between SSE keepalive comments")
+	return cmd
+}
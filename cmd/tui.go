@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+
+	"github.com/jwafle/otail/internal/alert"
+	"github.com/jwafle/otail/internal/clip"
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/headless"
+	"github.com/jwafle/otail/internal/hub"
+	"github.com/jwafle/otail/internal/plugin"
+	"github.com/jwafle/otail/internal/query"
+	"github.com/jwafle/otail/internal/redact"
+	"github.com/jwafle/otail/internal/script"
+	"github.com/jwafle/otail/internal/sink"
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/transport"
+	"github.com/jwafle/otail/internal/ui"
+	"github.com/jwafle/otail/internal/ui/theme"
+	"github.com/jwafle/otail/internal/web"
+)
+
+// tuiOptions holds the flags of `otail tui`, which the root command shares.
+type tuiOptions struct {
+	themeName string
+	ui        ui.Config
+
+	alerts   []string
+	sortKeys bool
+	wrap     bool
+
+	tab, filter, severity string
+	temporality           string
+	ansi                  string
+	traceSample           string
+	memoryMiB             int
+	presets, customTabs   []string
+	sinks, forward        []string
+	plugins               []string
+	scripts, scriptsOff   []string
+	redact                []string
+	serve                 string
+
+	noTUI, forceTUI        bool
+	format, color, signals string
+
+	fresh bool           // do not restore the last run's State
+	flags *pflag.FlagSet // to tell flags given from defaults, which State does not override
+}
+
+func newTUICmd(g *globalOptions) *cobra.Command {
+	o := &tuiOptions{}
+	cmd := &cobra.Command{
+		Use:   "tui [logs|metrics|traces|other]",
+		Short: "Browse live telemetry in the terminal UI (default)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTUI(g, o, args)
+		},
+	}
+	addTUIFlags(cmd, o)
+	return cmd
+}
+
+func addTUIFlags(cmd *cobra.Command, o *tuiOptions) {
+	f := cmd.Flags()
+	o.flags = f
+	f.StringVar(&o.tab, "tab", "", "tab to start on: logs, metrics, traces, or other (same as the argument)")
+	f.StringVar(&o.filter, "filter", "", "filter every tab at startup, e.g. 'service=api attr.http.route=/cart dur>200ms q:timeout'")
+	f.StringVar(&o.severity, "severity", "", "show only logs at or above this severity, and only error spans for error or fatal")
+	f.IntVarP(&o.ui.Context, "context", "C", 0, "with a filter, also show this many messages before and after each match, dimmed, like grep -C")
+	f.StringArrayVar(&o.presets, "preset", nil, "named filter preset NAME=QUERY, repeatable; the first nine are bound to keys 1-9")
+	f.StringArrayVar(&o.customTabs, "custom-tab", nil, "extra tab NAME=QUERY showing the messages of any signal the query matches, repeatable; e.g. 'Errors=severity>=error'")
+	f.StringVar(&o.temporality, "temporality", "", "show sums as delta (per interval) or cumulative (running total) instead of as received")
+	f.BoolVar(&o.ui.Format.Compact, "compact", false, "show each message on one line instead of indented JSON")
+	f.BoolVar(&o.ui.Format.Bodies, "bodies", false, "show logs as time, severity, and body lines instead of OTLP JSON")
+	f.StringVar(&o.themeName, "theme", "default", "color theme ("+strings.Join(theme.Names(), ", ")+")")
+	f.IntVar(&o.ui.MaxRenderFPS, "max-render-fps", 60, "maximum screen redraws per second")
+	f.IntVar(&o.memoryMiB, "memory", 0, "MiB of heap to stay within by dropping the rendered form of old messages, which is redone if they are viewed again; 0 = no limit")
+	f.IntVar(&o.ui.ParseWorkers, "parse-workers", 0, "goroutines parsing frames on their way to the UI; 0 = one per CPU")
+	f.IntVar(&o.ui.SampleEvery, "sample-every", 0, "display only 1 of every N messages per signal (all are still buffered)")
+	f.Float64Var(&o.ui.SampleRate, "sample-rate", 0, "probability in (0,1) of displaying each message (all are still buffered)")
+	f.StringVar(&o.traceSample, "trace-sample", "", "show only whole traces kept by any of: errors, slow=DURATION, 1/N (e.g. errors,slow=500ms); all are still buffered")
+	f.StringArrayVar(&o.ui.HideScopes, "hide-scope", nil, "hide items from an instrumentation scope on every tab, as NAME or NAME@VERSION; repeatable")
+	f.StringVar(&o.ui.GroupBy, "group-by", "", "resource attribute G groups messages by (default "+ui.DefaultGroupBy+")")
+	f.BoolVar(&o.ui.Table, "table", false, "start the Logs tab in table mode")
+	f.StringSliceVar(&o.ui.TableColumns, "columns", nil, "log table columns: time, severity, service, body, or attribute keys (default "+strings.Join(ui.DefaultTableColumns, ",")+")")
+	f.StringArrayVar(&o.alerts, "alert", nil, "notification rule, repeatable: severity>=LEVEL, span.status=ERROR, body=~REGEX, name=~REGEX")
+	f.StringVar(&o.ui.AlertCommand, "alert-command", "", "shell command to run when an alert rule matches; $OTAIL_ALERT describes the match")
+	f.BoolVar(&o.sortKeys, "sort-keys", true, "sort JSON object keys (false keeps them in the order received)")
+	f.BoolVar(&o.wrap, "wrap", true, "wrap lines wider than the screen (false cuts them at the edge)")
+	f.IntVar(&o.ui.Format.MaxDepth, "max-depth", 0, "collapse JSON nested deeper than this; 0 = no limit")
+	f.IntVar(&o.ui.Format.MaxValue, "max-value", 200, "cut string values longer than this many characters short; 0 = no limit")
+	f.BoolVar(&o.ui.Format.RawAttributes, "raw-attributes", false, "show OTLP attribute lists as received instead of as key: value objects")
+	f.BoolVar(&o.ui.Format.Hex, "hex", false, "show frames on the Other tab as a hexdump")
+	f.BoolVar(&o.ui.Format.FullIDs, "full-ids", false, "show trace and span IDs in full instead of their first 8 characters")
+	f.StringVar(&o.ansi, "ansi", "escape", "ANSI escape sequences in log bodies: escape, strip, or color")
+	f.StringVar(&o.ui.Summary, "summary", "", "on exit, print a session summary (frames, parse failures, reconnects, top services, peak rate) to stdout, or to the given file")
+	f.Lookup("summary").NoOptDefVal = "-"
+	f.StringArrayVar(&o.sinks, "sink", nil, "also forward what is received to KIND=URL, repeatable; e.g. loki=http://loki:3100")
+	f.StringArrayVar(&o.forward, "forward", nil, "re-export everything received to an OTLP endpoint (otlp-grpc://host:4317 or otlp-http://host:4318), repeatable")
+	f.StringArrayVar(&o.plugins, "plugin", nil, "run every frame through this command, which answers each with JSON to tag, re-level, or drop it; repeatable")
+	f.StringArrayVar(&o.scripts, "script", nil, "run the Starlark script NAME=PATH on every frame, and for compact lines if it defines format; repeatable")
+	f.StringArrayVar(&o.scriptsOff, "script-off", nil, "load the --script of this name turned off, for :script NAME on; repeatable")
+	f.StringArrayVar(&o.redact, "redact", nil, "mask sensitive data before it is shown, yanked, exported, or served: key=GLOB, value=~REGEX, or "+strings.Join(redact.Names, ", ")+"; repeatable")
+	f.StringVar(&o.ui.ReproURL, "repro-url", ui.DefaultReproURL, "OTLP/HTTP endpoint the reproduction commands Y and :repro yank send to")
+	f.StringVar(&o.ui.TraceURL, "trace-url", "", "page U opens the selected trace on, e.g. https://jaeger.example.com/trace/{traceID}; {spanID} and {service} are filled in too")
+	f.StringVar(&o.serve, "serve", "", "also serve the stream over HTTP at this address, as otail serve does, sharing one collector connection")
+	f.BoolVar(&o.fresh, "fresh", false, "start without restoring the last run's tab, filters, theme, table columns, and bookmarks")
+	f.BoolVar(&o.noTUI, "no-tui", false, "print telemetry to stdout instead of starting the TUI")
+	f.BoolVar(&o.forceTUI, "force-tui", false, "start the TUI even when stdout is not a terminal or TERM is dumb, which otherwise print as --no-tui does")
+	f.StringVar(&o.format, "format", "compact", "--no-tui output format (compact, json)")
+	f.StringVar(&o.color, "color", "auto", "--no-tui color mode (auto, always, never)")
+	f.StringVar(&o.signals, "signals", "", "--no-tui comma-separated signals to print (logs, metrics, traces, other); empty = all")
+}
+
+func runTUI(g *globalOptions, o *tuiOptions, args []string) error {
+	src, err := g.source()
+	if err != nil {
+		return err
+	}
+	return o.run(g, src, args)
+}
+
+// run starts the TUI, or headless output with --no-tui, on src.
+func (o *tuiOptions) run(g *globalOptions, src transport.Source, args []string) error {
+	err := o.settings()
+	if err != nil {
+		return err
+	}
+
+	for _, target := range o.forward {
+		o.sinks = append(o.sinks, "otlp="+target)
+	}
+	tap := func(s transport.Source) transport.Source { return s }
+	if len(o.sinks) > 0 {
+		logger := g.logger("[sink] ", levelWarn)
+		var tee sink.Tee
+		defer func() {
+			if err := tee.Close(); err != nil {
+				logger.Print(err)
+			}
+		}()
+		for _, spec := range o.sinks {
+			s, err := sink.Parse(spec, logger)
+			if err != nil {
+				return err
+			}
+			tee = append(tee, s)
+		}
+		tap = func(s transport.Source) transport.Source { return transport.Tap(s, tee.Write) }
+	}
+
+	if len(o.plugins) > 0 {
+		logger := g.logger("[plugin] ", levelWarn)
+		var chain plugin.Chain
+		defer func() {
+			if err := chain.Close(); err != nil {
+				logger.Print(err)
+			}
+		}()
+		for _, command := range o.plugins {
+			p, err := plugin.Start(command, logger)
+			if err != nil {
+				return err
+			}
+			chain = append(chain, p)
+		}
+		tap = then(tap, chain.Process)
+	}
+
+	if len(o.scripts) > 0 {
+		set, err := script.Load(o.scripts, o.scriptsOff, g.logger("[script] ", levelWarn))
+		if err != nil {
+			return err
+		}
+		tap = then(tap, set.Transform)
+		if set.HasFormat() {
+			o.ui.Format.Line = set.Line
+		}
+		o.ui.Scripts = set
+	}
+
+	rules, err := redact.ParseAll(o.redact)
+	if err != nil {
+		return err
+	}
+	if r := redact.New(rules); r != nil {
+		tap = then(tap, r.Transform) // last, so nothing added before is shown unmasked
+	}
+	src = tap(src)
+
+	if o.serve != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if src, err = serveAlongside(ctx, g, src, o.serve); err != nil {
+			return err
+		}
+	}
+
+	if o.ui.Temporality, err = telemetry.ParseTemporality(o.temporality); err != nil {
+		return fmt.Errorf("--temporality: %w", err)
+	}
+	if o.ui.Format.ANSI, err = telemetry.ParseANSI(o.ansi); err != nil {
+		return fmt.Errorf("--ansi: %w", err)
+	}
+	if o.ui.TraceSample, err = ui.ParseTailPolicy(o.traceSample); err != nil {
+		return fmt.Errorf("--trace-sample: %w", err)
+	}
+
+	if !o.noTUI && !o.forceTUI {
+		if problem := terminalProblem(); problem != "" {
+			g.logger("", levelWarn).Printf("%s; printing telemetry instead of starting the TUI (--force-tui to override)", problem)
+			if g.debug != nil {
+				g.debug.Info("falling back to --no-tui", "reason", problem)
+			}
+			o.noTUI = true
+		}
+	}
+	if o.noTUI {
+		cfg := &headless.Config{Theme: o.ui.Theme, Filter: o.ui.Filter, Temporality: o.ui.Temporality}
+		if cfg.Format, err = headless.ParseFormat(o.format); err != nil {
+			return err
+		}
+		if cfg.Color, err = headless.ParseColor(o.color); err != nil {
+			return err
+		}
+		// Windows consoles only act on colors once asked to; older ones
+		// cannot, and would print the escape sequences as text.
+		restore, err := termenv.EnableVirtualTerminalProcessing(termenv.NewOutput(os.Stdout))
+		if err != nil && cfg.Color == headless.ColorAuto {
+			cfg.Color = headless.ColorNever
+		}
+		defer restore()
+		if cfg.Signals, err = parseSignals(o.signals); err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return headless.Run(ctx, src, cfg)
+	}
+
+	initial := telemetry.KindLogs
+	tab := o.tab
+	if len(args) > 0 {
+		if tab != "" && !strings.EqualFold(tab, args[0]) {
+			return fmt.Errorf("--tab %s conflicts with argument %s", tab, args[0])
+		}
+		tab = args[0]
+	}
+	if tab != "" {
+		if initial, err = telemetry.ParseKind(tab); err != nil {
+			return err
+		}
+	}
+
+	clip.Init()
+
+	for _, s := range o.customTabs {
+		p, err := query.ParsePreset(s)
+		if err != nil {
+			return fmt.Errorf("--custom-tab: %w", err)
+		}
+		if _, err := telemetry.ParseKind(p.Name); err == nil {
+			return fmt.Errorf("--custom-tab %s: the name is taken by a signal tab", p.Name)
+		}
+		if slices.ContainsFunc(o.ui.Tabs, func(t filter.Preset) bool { return strings.EqualFold(t.Name, p.Name) }) {
+			return fmt.Errorf("--custom-tab %s: there is already a tab of that name", p.Name)
+		}
+		o.ui.Tabs = append(o.ui.Tabs, p)
+	}
+	o.ui.Format.ReceivedOrder = !o.sortKeys
+	o.ui.NoWrap = !o.wrap
+	if o.memoryMiB > 0 {
+		o.ui.MemoryLimit = int64(o.memoryMiB) << 20
+		// Have the garbage collector work harder as the heap nears the
+		// limit too, so what is dropped is reclaimed in time.
+		debug.SetMemoryLimit(o.ui.MemoryLimit)
+	}
+	o.ui.Debug = g.debug
+	if err := o.restoreState(len(args) > 0); err != nil {
+		return err
+	}
+	o.ui.Endpoint = func(spec string) (transport.Source, error) {
+		eg := *g
+		eg.endpoints = []string{spec}
+		s, err := eg.source()
+		if err != nil {
+			return nil, err
+		}
+		return tap(s), nil
+	}
+	o.ui.ConfigFile, o.ui.Reload = g.configFile(), o.reloader(g.configPath)
+	return ui.Run(src, initial, &o.ui)
+}
+
+// then returns tap followed by fn, which may change or drop each frame.
+func then(tap func(transport.Source) transport.Source, fn func([]byte) ([]byte, bool)) func(transport.Source) transport.Source {
+	return func(s transport.Source) transport.Source { return transport.Transform(tap(s), fn) }
+}
+
+// settings fills in the part of the UI's Config that the config file can
+// change while the TUI runs.
+func (o *tuiOptions) settings() error {
+	var err error
+	if o.ui.Theme, err = theme.Lookup(o.themeName); err != nil {
+		return err
+	}
+	if o.ui.Filter, err = query.Parse(o.filter); err != nil {
+		return fmt.Errorf("--filter: %w", err)
+	}
+	if o.severity != "" {
+		if o.ui.Filter.Severity, err = filter.ParseSeverity(o.severity); err != nil {
+			return fmt.Errorf("--severity: %w", err)
+		}
+	}
+	if o.ui.Alerts, err = alert.ParseAll(o.alerts); err != nil {
+		return err
+	}
+	o.ui.Presets = nil
+	for _, s := range o.presets {
+		p, err := query.ParsePreset(s)
+		if err != nil {
+			return err
+		}
+		o.ui.Presets = append(o.ui.Presets, p)
+	}
+	return nil
+}
+
+// reloadable lists the flags whose settings apply as the config file
+// changes; the rest take a restart.
+var reloadable = map[string]bool{
+	"theme": true, "filter": true, "severity": true, "context": true, "preset": true,
+	"columns": true, "alert": true, "alert-command": true,
+}
+
+// reloader returns a function that reads the config file at path back into
+// the TUI's settings, as applyConfig did at startup: flags given on the
+// command line still win over it. It also names the changed options that
+// only a restart applies.
+func (o *tuiOptions) reloader(path string) func() (ui.Settings, []string, error) {
+	return func() (ui.Settings, []string, error) {
+		fresh := &tuiOptions{}
+		cmd := &cobra.Command{}
+		addTUIFlags(cmd, fresh)
+		var err error
+		o.flags.Visit(func(f *pflag.Flag) {
+			if err == nil {
+				err = copyFlag(fresh.flags, f)
+			}
+		})
+		if err != nil {
+			return ui.Settings{}, nil, err
+		}
+		if err := applyConfig(cmd, path); err != nil {
+			return ui.Settings{}, nil, err
+		}
+		if err := fresh.settings(); err != nil {
+			return ui.Settings{}, nil, err
+		}
+		var restart []string
+		fresh.flags.VisitAll(func(f *pflag.Flag) {
+			if was := o.flags.Lookup(f.Name); !reloadable[f.Name] && was != nil && f.Value.String() != was.Value.String() {
+				restart = append(restart, f.Name)
+			}
+		})
+		return fresh.ui.Settings, restart, nil
+	}
+}
+
+// copyFlag gives the flag of the same name in fs f's value, as if set on
+// the command line.
+func copyFlag(fs *pflag.FlagSet, f *pflag.Flag) error {
+	to := fs.Lookup(f.Name)
+	if to == nil {
+		return nil
+	}
+	if from, ok := f.Value.(pflag.SliceValue); ok {
+		to.Changed = true
+		return to.Value.(pflag.SliceValue).Replace(from.GetSlice())
+	}
+	return fs.Set(f.Name, f.Value.String())
+}
+
+// serveAlongside opens src once, fans it out through a hub served over
+// HTTP at addr until ctx is done, and returns a Source that subscribes to
+// the hub, so the TUI shares the collector connection with web clients.
+func serveAlongside(ctx context.Context, g *globalOptions, src transport.Source, addr string) (transport.Source, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("--serve: %w", err)
+	}
+	upstream, err := src(ctx)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	h := hub.New(nil)
+	logger := g.logger("[web] ", levelDebug)
+	go func() {
+		if err := h.RunStream(ctx, upstream); err != nil {
+			logger.Print(err)
+		}
+	}()
+	srv := &http.Server{Handler: web.New(h, &web.Config{Endpoint: strings.Join(g.endpoints, ", "), Logger: logger})}
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			logger.Print(err)
+		}
+	}()
+	context.AfterFunc(ctx, func() { srv.Close() })
+	return h.Source(upstream), nil
+}
+
+// parseSignals turns a comma-separated list of signal names into kinds.
+func parseSignals(s string) ([]telemetry.Kind, error) {
+	var kinds []telemetry.Kind
+	for _, name := range strings.Split(s, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		k, err := telemetry.ParseKind(name)
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, k)
+	}
+	return kinds, nil
+}
+
+// restoreState saves the UI's state on exit and, unless --fresh, restores
+// the last run's, except for what flags set this time. tabArg is whether
+// the tab was given as an argument.
+func (o *tuiOptions) restoreState(tabArg bool) error {
+	o.ui.StateFile = ui.DefaultStatePath()
+	if o.fresh || o.ui.StateFile == "" {
+		return nil
+	}
+	st, err := ui.LoadState(o.ui.StateFile)
+	if err != nil {
+		return fmt.Errorf("state %s: %w (--fresh ignores it)", o.ui.StateFile, err)
+	}
+	if st == nil {
+		return nil
+	}
+	if tabArg || o.flags.Changed("tab") {
+		st.Tab = ""
+	}
+	if o.flags.Changed("filter") || o.flags.Changed("severity") {
+		st.Filters = nil
+	}
+	if o.flags.Changed("theme") {
+		st.Theme = ""
+	}
+	if o.flags.Changed("table") {
+		st.Table = o.ui.Table
+	}
+	if o.flags.Changed("columns") {
+		st.Columns = nil
+	}
+	o.ui.Restore = st
+	return nil
+}
+
+// terminalProblem says why the TUI cannot draw where otail is running, or
+// returns "" if it can.
+func terminalProblem() string {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return "stdout is not a terminal"
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return "TERM is dumb"
+	}
+	restore, err := termenv.EnableVirtualTerminalProcessing(termenv.NewOutput(os.Stdout))
+	if err != nil {
+		return "the console does not support escape sequences"
+	}
+	restore() // the TUI sets the console up for itself
+	return ""
+}
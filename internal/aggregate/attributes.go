@@ -0,0 +1,164 @@
+package aggregate
+
+import (
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// maxValuesPerKey bounds how many distinct values are counted per attribute
+// key; values first seen after that are only counted as overflow.
+const maxValuesPerKey = 1000
+
+// Attributes counts attribute values per key over log records, data points,
+// or spans. Each item counts its own attributes together with its
+// resource's, so counts line up with what a filter on that attribute keeps.
+type Attributes struct {
+	keys map[string]*keyStats
+}
+
+type keyStats struct {
+	values   map[string]int
+	overflow int // occurrences of values beyond maxValuesPerKey
+}
+
+// ValueCount is one attribute value and how many items carried it.
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// KeySummary describes one attribute key.
+type KeySummary struct {
+	Key      string
+	Distinct int  // distinct values counted
+	Capped   bool // more distinct values exist than were counted
+	Top      []ValueCount
+}
+
+// Add counts the attributes of every item in msg.
+func (a *Attributes) Add(msg telemetry.Message) {
+	if a.keys == nil {
+		a.keys = map[string]*keyStats{}
+	}
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		rls := msg.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			res := rls.At(i).Resource().Attributes()
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					a.addItem(lrs.At(k).Attributes(), res)
+				}
+			}
+		}
+	case telemetry.KindMetrics:
+		rms := msg.Metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			res := rms.At(i).Resource().Attributes()
+			sms := rms.At(i).ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					for _, attrs := range pointAttributes(ms.At(k)) {
+						a.addItem(attrs, res)
+					}
+				}
+			}
+		}
+	case telemetry.KindTraces:
+		rss := msg.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			res := rss.At(i).Resource().Attributes()
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					a.addItem(spans.At(k).Attributes(), res)
+				}
+			}
+		}
+	}
+}
+
+func (a *Attributes) addItem(item, res pcommon.Map) {
+	item.Range(func(k string, v pcommon.Value) bool {
+		a.count(k, v.AsString())
+		return true
+	})
+	res.Range(func(k string, v pcommon.Value) bool {
+		if _, shadowed := item.Get(k); !shadowed {
+			a.count(k, v.AsString())
+		}
+		return true
+	})
+}
+
+func (a *Attributes) count(key, value string) {
+	ks, ok := a.keys[key]
+	if !ok {
+		ks = &keyStats{values: map[string]int{}}
+		a.keys[key] = ks
+	}
+	if _, seen := ks.values[value]; !seen && len(ks.values) >= maxValuesPerKey {
+		ks.overflow++
+		return
+	}
+	ks.values[value]++
+}
+
+// Top summarises every key, sorted by name, with at most n of its most
+// common values.
+func (a *Attributes) Top(n int) []KeySummary {
+	out := make([]KeySummary, 0, len(a.keys))
+	for key, ks := range a.keys {
+		sum := KeySummary{Key: key, Distinct: len(ks.values), Capped: ks.overflow > 0}
+		for v, c := range ks.values {
+			sum.Top = append(sum.Top, ValueCount{v, c})
+		}
+		sort.Slice(sum.Top, func(i, j int) bool {
+			if sum.Top[i].Count != sum.Top[j].Count {
+				return sum.Top[i].Count > sum.Top[j].Count
+			}
+			return sum.Top[i].Value < sum.Top[j].Value
+		})
+		if len(sum.Top) > n {
+			sum.Top = sum.Top[:n]
+		}
+		out = append(out, sum)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func pointAttributes(m pmetric.Metric) []pcommon.Map {
+	var out []pcommon.Map
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < m.Gauge().DataPoints().Len(); i++ {
+			out = append(out, m.Gauge().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < m.Sum().DataPoints().Len(); i++ {
+			out = append(out, m.Sum().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < m.Histogram().DataPoints().Len(); i++ {
+			out = append(out, m.Histogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < m.ExponentialHistogram().DataPoints().Len(); i++ {
+			out = append(out, m.ExponentialHistogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < m.Summary().DataPoints().Len(); i++ {
+			out = append(out, m.Summary().DataPoints().At(i).Attributes())
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package aggregate
+
+import (
+	"sort"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Group is the records of one value of a resource attribute.
+type Group struct {
+	Value    string // the attribute's value; "" for resources without it
+	Messages int    // messages with at least one record in the group
+	Records  int
+	Recent   []telemetry.Record // the newest records, oldest first
+}
+
+// BuildGroups buckets the records of msgs by the resource attribute key,
+// keeping the last recent records of each group. Groups are sorted by
+// value, with the one for resources lacking key last.
+func BuildGroups(msgs []telemetry.Message, key string, recent int) []Group {
+	byValue := map[string]*Group{}
+	for _, msg := range msgs {
+		counted := map[string]bool{}
+		for _, r := range msg.Records() {
+			value := ""
+			if v, ok := r.Resource.Get(key); ok {
+				value = v.AsString()
+			}
+			g, ok := byValue[value]
+			if !ok {
+				g = &Group{Value: value}
+				byValue[value] = g
+			}
+			if !counted[value] {
+				g.Messages++
+				counted[value] = true
+			}
+			g.Records++
+			g.Recent = append(g.Recent, r)
+			if len(g.Recent) > recent {
+				g.Recent = g.Recent[len(g.Recent)-recent:]
+			}
+		}
+	}
+	out := make([]Group, 0, len(byValue))
+	for _, g := range byValue {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Value == "") != (out[j].Value == "") {
+			return out[j].Value == ""
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
@@ -0,0 +1,135 @@
+// Package aggregate maintains running summaries of telemetry that the UI
+// updates incrementally as messages arrive, so panels never rescan the
+// buffer.
+package aggregate
+
+import (
+	"math/bits"
+	"time"
+)
+
+// histogramBase is the upper bound of the first bucket; each further bucket
+// doubles it.
+const histogramBase = time.Microsecond
+
+// numBuckets covers 1µs up to 2^40µs (about 12 days); longer durations land
+// in the last bucket.
+const numBuckets = 41
+
+// Histogram counts durations in log-scale (power-of-two) buckets. Bucket 0
+// holds durations up to 1µs and bucket i holds (2^(i-1)µs, 2^i µs].
+type Histogram struct {
+	counts   [numBuckets]uint64
+	total    uint64
+	min, max time.Duration
+	sum      time.Duration
+}
+
+// Observe adds d to the histogram.
+func (h *Histogram) Observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.counts[bucketOf(d)]++
+	if h.total == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.total++
+	h.sum += d
+}
+
+func bucketOf(d time.Duration) int {
+	us := uint64((d + histogramBase - 1) / histogramBase) // round up
+	if us <= 1 {
+		return 0
+	}
+	return min(bits.Len64(us-1), numBuckets-1)
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 { return h.total }
+
+// Min returns the smallest observation, or 0 when empty.
+func (h *Histogram) Min() time.Duration { return h.min }
+
+// Max returns the largest observation, or 0 when empty.
+func (h *Histogram) Max() time.Duration { return h.max }
+
+// Mean returns the average observation, or 0 when empty.
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.total)
+}
+
+// Quantile estimates the q-th quantile (0 ≤ q ≤ 1) as the upper bound of the
+// bucket it falls in, clamped to the observed range.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(h.total))
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen > rank || seen == h.total {
+			return max(min(BucketUpper(i), h.max), h.min)
+		}
+	}
+	return h.max
+}
+
+// Bucket is one non-empty histogram bucket.
+type Bucket struct {
+	Lower, Upper time.Duration // (Lower, Upper]
+	Count        uint64
+}
+
+// Buckets returns the buckets from the first to the last non-empty one,
+// merging neighbours pairwise until there are at most n. n ≤ 0 means no
+// limit.
+func (h *Histogram) Buckets(n int) []Bucket {
+	first, last := -1, -1
+	for i, c := range h.counts {
+		if c > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	width := 1
+	for n > 0 && (last-first)/width+1 > n {
+		width *= 2
+	}
+	var out []Bucket
+	for i := first; i <= last; i += width {
+		j := min(i+width-1, last)
+		b := Bucket{Lower: BucketLower(i), Upper: BucketUpper(j)}
+		for k := i; k <= j; k++ {
+			b.Count += h.counts[k]
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// BucketLower returns the exclusive lower bound of bucket i.
+func BucketLower(i int) time.Duration {
+	if i == 0 {
+		return 0
+	}
+	return histogramBase << (i - 1)
+}
+
+// BucketUpper returns the inclusive upper bound of bucket i.
+func BucketUpper(i int) time.Duration {
+	return histogramBase << i
+}
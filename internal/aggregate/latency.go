@@ -0,0 +1,58 @@
+package aggregate
+
+import (
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Latency tracks span duration histograms overall, per span name, and per
+// service.
+type Latency struct {
+	all       Histogram
+	byName    map[string]*Histogram
+	byService map[string]*Histogram
+}
+
+// AddTraces observes the duration of every span in t.
+func (l *Latency) AddTraces(t ptrace.Traces) {
+	if l.byName == nil {
+		l.byName = map[string]*Histogram{}
+		l.byService = map[string]*Histogram{}
+	}
+	rss := t.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		svc := ""
+		if v, ok := rs.Resource().Attributes().Get("service.name"); ok {
+			svc = v.AsString()
+		}
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				s := spans.At(k)
+				d := s.EndTimestamp().AsTime().Sub(s.StartTimestamp().AsTime())
+				l.all.Observe(d)
+				histogramFor(l.byName, s.Name()).Observe(d)
+				histogramFor(l.byService, svc).Observe(d)
+			}
+		}
+	}
+}
+
+// All returns the histogram of every span seen.
+func (l *Latency) All() *Histogram { return &l.all }
+
+// ByName returns the histogram for spans called name, or nil.
+func (l *Latency) ByName(name string) *Histogram { return l.byName[name] }
+
+// ByService returns the histogram for spans from service, or nil.
+func (l *Latency) ByService(service string) *Histogram { return l.byService[service] }
+
+func histogramFor(m map[string]*Histogram, key string) *Histogram {
+	h, ok := m[key]
+	if !ok {
+		h = &Histogram{}
+		m[key] = h
+	}
+	return h
+}
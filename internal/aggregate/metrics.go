@@ -0,0 +1,192 @@
+package aggregate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// MetricSummary describes one metric name across a metrics buffer.
+type MetricSummary struct {
+	Name        string
+	Type        string // gauge, sum, histogram, exponential histogram, or summary
+	Unit        string
+	Description string
+	Points      int     // data points seen
+	Recent      []Point // the newest data points, oldest first
+}
+
+// Point is one data point flattened for display.
+type Point struct {
+	Time   time.Time
+	Value  string // the number, or count and sum for distributions
+	Rate   string // sums only: change per second, e.g. "12.5/s"; "" if unknown
+	Labels string // service and attributes as k=v, sorted by key
+}
+
+// BuildMetrics summarises every metric name in metrics, keeping the last
+// recent data points of each. The result is sorted by name.
+func BuildMetrics(metrics []pmetric.Metrics, recent int) []MetricSummary {
+	byName := map[string]*MetricSummary{}
+	totals := map[string]total{} // last point of each cumulative series
+	for _, md := range metrics {
+		rms := md.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			rm := rms.At(i)
+			service := ""
+			if v, ok := rm.Resource().Attributes().Get("service.name"); ok {
+				service = v.AsString()
+			}
+			sms := rm.ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					m := ms.At(k)
+					s, ok := byName[m.Name()]
+					if !ok {
+						s = &MetricSummary{Name: m.Name()}
+						byName[m.Name()] = s
+					}
+					s.Type = metricType(m.Type())
+					s.Unit, s.Description = m.Unit(), m.Description()
+					eachPoint(m, func(ts pcommon.Timestamp, attrs pcommon.Map, value string) {
+						s.Points++
+						s.Recent = append(s.Recent, Point{Time: ts.AsTime(), Value: value, Labels: labels(service, attrs)})
+						if len(s.Recent) > recent {
+							s.Recent = s.Recent[len(s.Recent)-recent:]
+						}
+					}, func(dp pmetric.NumberDataPoint) {
+						key := m.Name() + "|" + s.Recent[len(s.Recent)-1].Labels
+						s.Recent[len(s.Recent)-1].Rate = sumRate(m.Sum().AggregationTemporality(), dp, totals, key)
+					})
+				}
+			}
+		}
+	}
+	out := make([]MetricSummary, 0, len(byName))
+	for _, s := range byName {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func metricType(t pmetric.MetricType) string {
+	switch t {
+	case pmetric.MetricTypeGauge:
+		return "gauge"
+	case pmetric.MetricTypeSum:
+		return "sum"
+	case pmetric.MetricTypeHistogram:
+		return "histogram"
+	case pmetric.MetricTypeExponentialHistogram:
+		return "exponential histogram"
+	case pmetric.MetricTypeSummary:
+		return "summary"
+	}
+	return "empty"
+}
+
+// total is the last point of a cumulative series.
+type total struct {
+	ts    pcommon.Timestamp
+	value float64
+}
+
+// sumRate is how fast a sum data point is changing. A delta point covers
+// its own interval; a cumulative point is compared with the series' previous
+// point in totals, which it then replaces.
+func sumRate(temp pmetric.AggregationTemporality, dp pmetric.NumberDataPoint, totals map[string]total, key string) string {
+	v := dp.DoubleValue()
+	if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+		v = float64(dp.IntValue())
+	}
+	var change float64
+	var since pcommon.Timestamp
+	switch temp {
+	case pmetric.AggregationTemporalityDelta:
+		change, since = v, dp.StartTimestamp()
+	case pmetric.AggregationTemporalityCumulative:
+		prev, ok := totals[key]
+		totals[key] = total{dp.Timestamp(), v}
+		if !ok || v < prev.value {
+			return "" // nothing to compare with, or the counter reset
+		}
+		change, since = v-prev.value, prev.ts
+	default:
+		return ""
+	}
+	if since == 0 || dp.Timestamp() <= since {
+		return ""
+	}
+	secs := time.Duration(dp.Timestamp() - since).Seconds()
+	return strconv.FormatFloat(change/secs, 'g', 4, 64) + "/s"
+}
+
+// eachPoint calls fn with every data point of m, its value formatted, then
+// for sums calls sum with the same point.
+func eachPoint(m pmetric.Metric, fn func(ts pcommon.Timestamp, attrs pcommon.Map, value string), sum func(dp pmetric.NumberDataPoint)) {
+	number := func(dps pmetric.NumberDataPointSlice, isSum bool) {
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			v := strconv.FormatInt(dp.IntValue(), 10)
+			if dp.ValueType() == pmetric.NumberDataPointValueTypeDouble {
+				v = strconv.FormatFloat(dp.DoubleValue(), 'g', -1, 64)
+			}
+			fn(dp.Timestamp(), dp.Attributes(), v)
+			if isSum {
+				sum(dp)
+			}
+		}
+	}
+	dist := func(count uint64, sum float64) string {
+		return fmt.Sprintf("count=%d sum=%s", count, strconv.FormatFloat(sum, 'g', -1, 64))
+	}
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		number(m.Gauge().DataPoints(), false)
+	case pmetric.MetricTypeSum:
+		number(m.Sum().DataPoints(), true)
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			fn(dp.Timestamp(), dp.Attributes(), dist(dp.Count(), dp.Sum()))
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			fn(dp.Timestamp(), dp.Attributes(), dist(dp.Count(), dp.Sum()))
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			fn(dp.Timestamp(), dp.Attributes(), dist(dp.Count(), dp.Sum()))
+		}
+	}
+}
+
+func labels(service string, attrs pcommon.Map) string {
+	var parts []string
+	if service != "" {
+		parts = append(parts, "service="+service)
+	}
+	keys := make([]string, 0, attrs.Len())
+	attrs.Range(func(k string, _ pcommon.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, _ := attrs.Get(k)
+		parts = append(parts, k+"="+v.AsString())
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,148 @@
+package aggregate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// LateAfter is how far behind the newest record or span of its source one
+// has to be to count as out of order; batching reorders within less.
+const LateAfter = 5 * time.Second
+
+// maxSpanIDs bounds how many span IDs Order remembers to find duplicates;
+// the oldest are forgotten first.
+const maxSpanIDs = 100_000
+
+// Order checks that each source's log records and spans arrive in about
+// the order they happened, and that no span arrives twice, as collector
+// retries without deduplication cause. A source is a service's logs or
+// spans.
+type Order struct {
+	newest map[string]time.Time // by source: log or span time
+	seen   map[spanKey]bool
+	ring   []spanKey // seen, oldest first once full
+	next   int       // where the next span ID goes in ring
+
+	Late, Duplicates int // messages flagged for each
+	lateBy           map[telemetry.Kind]int
+}
+
+type spanKey struct {
+	trace pcommon.TraceID
+	span  pcommon.SpanID
+}
+
+// Check returns why msg looks out of order or repeated, or "" if it does
+// not, and counts it. Log records are timed by their time, spans by when
+// they ended.
+func (o *Order) Check(msg telemetry.Message) string {
+	if o.newest == nil {
+		o.newest, o.seen = map[string]time.Time{}, map[spanKey]bool{}
+		o.lateBy = map[telemetry.Kind]int{}
+	}
+	var late time.Duration
+	var lateSource, dup string
+	observe := func(source string, ts pcommon.Timestamp) {
+		if ts == 0 {
+			return
+		}
+		t := ts.AsTime()
+		newest, ok := o.newest[source]
+		if d := newest.Sub(t); ok && d > late {
+			late, lateSource = d, source
+		}
+		if !ok || t.After(newest) {
+			o.newest[source] = t
+		}
+	}
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		rls := msg.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			source := serviceOf(rls.At(i).Resource()) + " logs"
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					observe(source, lrs.At(k).Timestamp())
+				}
+			}
+		}
+	case telemetry.KindTraces:
+		rss := msg.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			source := serviceOf(rss.At(i).Resource()) + " spans"
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					s := spans.At(k)
+					observe(source, s.EndTimestamp())
+					if o.remember(spanKey{s.TraceID(), s.SpanID()}) && dup == "" {
+						dup = s.SpanID().String()
+					}
+				}
+			}
+		}
+	}
+	switch {
+	case dup != "":
+		o.Duplicates++
+		return "duplicate span " + dup
+	case late > LateAfter:
+		o.Late++
+		o.lateBy[msg.Kind]++
+		return fmt.Sprintf("out of order: %s behind %s", late.Round(time.Millisecond), lateSource)
+	}
+	return ""
+}
+
+// Reset forgets what was seen of kind k, as when its messages are cleared:
+// its sources' newest times, the span IDs if k is traces, and the counts
+// of its messages flagged.
+func (o *Order) Reset(k telemetry.Kind) {
+	suffix := " logs"
+	if k == telemetry.KindTraces {
+		suffix = " spans"
+		o.seen, o.ring, o.next = map[spanKey]bool{}, nil, 0
+		o.Duplicates = 0
+	}
+	for source := range o.newest {
+		if strings.HasSuffix(source, suffix) {
+			delete(o.newest, source)
+		}
+	}
+	o.Late -= o.lateBy[k]
+	delete(o.lateBy, k)
+}
+
+// remember notes span ID k, reporting whether it was seen before.
+func (o *Order) remember(k spanKey) bool {
+	if k.span.IsEmpty() {
+		return false
+	}
+	if o.seen[k] {
+		return true
+	}
+	if len(o.ring) < maxSpanIDs {
+		o.ring = append(o.ring, k)
+	} else {
+		delete(o.seen, o.ring[o.next])
+		o.ring[o.next] = k
+		o.next = (o.next + 1) % maxSpanIDs
+	}
+	o.seen[k] = true
+	return false
+}
+
+func serviceOf(r pcommon.Resource) string {
+	if v, ok := r.Attributes().Get("service.name"); ok {
+		return v.AsString()
+	}
+	return "unknown service"
+}
@@ -0,0 +1,47 @@
+package aggregate
+
+import (
+	plog "go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Pipeline tracks log pipeline latency, the time from a record's time to
+// its observed time, overall and per service. A collector that queues
+// shows up as latency growing.
+type Pipeline struct {
+	all       Histogram
+	byService map[string]*Histogram
+}
+
+// AddLogs observes the pipeline latency of every log record in l that
+// carries both times.
+func (p *Pipeline) AddLogs(l plog.Logs) {
+	if p.byService == nil {
+		p.byService = map[string]*Histogram{}
+	}
+	rls := l.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		svc := ""
+		if v, ok := rl.Resource().Attributes().Get("service.name"); ok {
+			svc = v.AsString()
+		}
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			lrs := sls.At(j).LogRecords()
+			for k := 0; k < lrs.Len(); k++ {
+				if d, ok := telemetry.PipelineLatency(lrs.At(k)); ok {
+					p.all.Observe(d)
+					histogramFor(p.byService, svc).Observe(d)
+				}
+			}
+		}
+	}
+}
+
+// All returns the histogram of every log record seen.
+func (p *Pipeline) All() *Histogram { return &p.all }
+
+// ByService returns the histogram for log records from service, or nil.
+func (p *Pipeline) ByService(service string) *Histogram { return p.byService[service] }
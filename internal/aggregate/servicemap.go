@@ -0,0 +1,130 @@
+package aggregate
+
+import (
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// unknownService stands in for spans whose resource has no service.name.
+const unknownService = "unknown"
+
+// Edge is a caller → callee dependency between two services.
+type Edge struct {
+	From, To string
+	Calls    int // spans that evidence the call
+	Errors   int // of which had error status
+}
+
+// ServiceMap summarises which services call which.
+type ServiceMap struct {
+	Services []string // every service seen, sorted
+	Edges    []Edge   // sorted by From, then To
+}
+
+// Callees returns the edges leaving service.
+func (m ServiceMap) Callees(service string) []Edge {
+	i := sort.Search(len(m.Edges), func(i int) bool { return m.Edges[i].From >= service })
+	j := i
+	for j < len(m.Edges) && m.Edges[j].From == service {
+		j++
+	}
+	return m.Edges[i:j]
+}
+
+// BuildServiceMap derives service dependencies from spans. A child span in
+// a different service from its parent is a call from the parent's service.
+// A client or producer span with a peer.service attribute is a call to that
+// peer, unless its own children already show where it went.
+func BuildServiceMap(traces []ptrace.Traces) ServiceMap {
+	type spanKey struct {
+		trace pcommon.TraceID
+		span  pcommon.SpanID
+	}
+	type spanInfo struct {
+		key, parent spanKey
+		service     string
+		peer        string
+		err         bool
+	}
+
+	owner := map[spanKey]string{}
+	var spans []spanInfo
+	services := map[string]bool{}
+	for _, t := range traces {
+		rss := t.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			rs := rss.At(i)
+			svc := unknownService
+			if v, ok := rs.Resource().Attributes().Get("service.name"); ok && v.AsString() != "" {
+				svc = v.AsString()
+			}
+			services[svc] = true
+			sss := rs.ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				ss := sss.At(j).Spans()
+				for k := 0; k < ss.Len(); k++ {
+					s := ss.At(k)
+					info := spanInfo{
+						key:     spanKey{s.TraceID(), s.SpanID()},
+						service: svc,
+						err:     s.Status().Code() == ptrace.StatusCodeError,
+					}
+					if !s.ParentSpanID().IsEmpty() {
+						info.parent = spanKey{s.TraceID(), s.ParentSpanID()}
+					}
+					if kind := s.Kind(); kind == ptrace.SpanKindClient || kind == ptrace.SpanKindProducer {
+						if v, ok := s.Attributes().Get("peer.service"); ok {
+							info.peer = v.AsString()
+						}
+					}
+					owner[info.key] = svc
+					spans = append(spans, info)
+				}
+			}
+		}
+	}
+
+	edges := map[[2]string]*Edge{}
+	record := func(from, to string, err bool) {
+		e, ok := edges[[2]string{from, to}]
+		if !ok {
+			e = &Edge{From: from, To: to}
+			edges[[2]string{from, to}] = e
+		}
+		e.Calls++
+		if err {
+			e.Errors++
+		}
+	}
+	resolved := map[spanKey]bool{} // parents with a child in another service
+	for _, s := range spans {
+		if parent, ok := owner[s.parent]; ok && parent != s.service {
+			record(parent, s.service, s.err)
+			resolved[s.parent] = true
+		}
+	}
+	for _, s := range spans {
+		if s.peer != "" && s.peer != s.service && !resolved[s.key] {
+			record(s.service, s.peer, s.err)
+			services[s.peer] = true
+		}
+	}
+
+	var m ServiceMap
+	for svc := range services {
+		m.Services = append(m.Services, svc)
+	}
+	sort.Strings(m.Services)
+	for _, e := range edges {
+		m.Edges = append(m.Edges, *e)
+	}
+	sort.Slice(m.Edges, func(i, j int) bool {
+		if m.Edges[i].From != m.Edges[j].From {
+			return m.Edges[i].From < m.Edges[j].From
+		}
+		return m.Edges[i].To < m.Edges[j].To
+	})
+	return m
+}
@@ -0,0 +1,172 @@
+package aggregate
+
+import (
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Trace is one trace's spans as a tree, for a waterfall.
+type Trace struct {
+	ID         pcommon.TraceID
+	Start, End time.Time   // earliest start and latest end of any span
+	Spans      []TraceSpan // depth first, siblings by start time
+}
+
+// TraceSpan is a span placed in its trace.
+type TraceSpan struct {
+	ID, Parent pcommon.SpanID
+	Name       string
+	Service    string
+	Depth      int // 0 for roots, including spans whose parent is not buffered
+	Start, End time.Time
+	Error      bool
+	Events     []SpanEvent
+	Links      []SpanLink
+}
+
+// SpanEvent is an event recorded on a span.
+type SpanEvent struct {
+	Time   time.Time
+	Name   string
+	Labels string // attributes as k=v, sorted by key
+}
+
+// SpanLink points from a span to a span, usually in another trace.
+type SpanLink struct {
+	TraceID pcommon.TraceID
+	SpanID  pcommon.SpanID
+	Labels  string // attributes as k=v, sorted by key
+}
+
+// BuildTrace collects the spans of trace id from traces. Spans seen more
+// than once are kept once. A trace with no buffered spans has none.
+func BuildTrace(traces []ptrace.Traces, id pcommon.TraceID) Trace {
+	t := Trace{ID: id}
+	var spans []TraceSpan
+	seen := map[pcommon.SpanID]bool{}
+	for _, td := range traces {
+		rss := td.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			rs := rss.At(i)
+			svc := unknownService
+			if v, ok := rs.Resource().Attributes().Get("service.name"); ok && v.AsString() != "" {
+				svc = v.AsString()
+			}
+			sss := rs.ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				ss := sss.At(j).Spans()
+				for k := 0; k < ss.Len(); k++ {
+					s := ss.At(k)
+					if s.TraceID() != id || seen[s.SpanID()] {
+						continue
+					}
+					seen[s.SpanID()] = true
+					spans = append(spans, newTraceSpan(s, svc))
+				}
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return t
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+	children := map[pcommon.SpanID][]int{}
+	var roots []int
+	for i, s := range spans {
+		if s.Parent.IsEmpty() || !seen[s.Parent] || s.Parent == s.ID {
+			roots = append(roots, i)
+		} else {
+			children[s.Parent] = append(children[s.Parent], i)
+		}
+	}
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		s := spans[i]
+		s.Depth = depth
+		t.Spans = append(t.Spans, s)
+		for _, c := range children[s.ID] {
+			walk(c, depth+1)
+		}
+	}
+	for _, r := range roots {
+		walk(r, 0)
+	}
+
+	t.Start, t.End = spans[0].Start, spans[0].End
+	for _, s := range spans {
+		if s.Start.Before(t.Start) {
+			t.Start = s.Start
+		}
+		if s.End.After(t.End) {
+			t.End = s.End
+		}
+	}
+	return t
+}
+
+func newTraceSpan(s ptrace.Span, service string) TraceSpan {
+	ts := TraceSpan{
+		ID:      s.SpanID(),
+		Parent:  s.ParentSpanID(),
+		Name:    s.Name(),
+		Service: service,
+		Start:   s.StartTimestamp().AsTime(),
+		End:     s.EndTimestamp().AsTime(),
+		Error:   s.Status().Code() == ptrace.StatusCodeError,
+	}
+	if ts.End.Before(ts.Start) {
+		ts.End = ts.Start
+	}
+	for i := 0; i < s.Events().Len(); i++ {
+		e := s.Events().At(i)
+		ts.Events = append(ts.Events, SpanEvent{Time: e.Timestamp().AsTime(), Name: e.Name(), Labels: labels("", e.Attributes())})
+	}
+	sort.SliceStable(ts.Events, func(i, j int) bool { return ts.Events[i].Time.Before(ts.Events[j].Time) })
+	for i := 0; i < s.Links().Len(); i++ {
+		l := s.Links().At(i)
+		ts.Links = append(ts.Links, SpanLink{TraceID: l.TraceID(), SpanID: l.SpanID(), Labels: labels("", l.Attributes())})
+	}
+	return ts
+}
+
+// CollectTrace copies the spans of trace id out of traces with their
+// resources and scopes, for writing the trace out as it was received.
+// Spans seen more than once are kept once.
+func CollectTrace(traces []ptrace.Traces, id pcommon.TraceID) ptrace.Traces {
+	out := ptrace.NewTraces()
+	seen := map[pcommon.SpanID]bool{}
+	for _, td := range traces {
+		rss := td.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			var rs ptrace.ResourceSpans
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				var ss ptrace.ScopeSpans
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					s := spans.At(k)
+					if s.TraceID() != id || seen[s.SpanID()] {
+						continue
+					}
+					seen[s.SpanID()] = true
+					if ss == (ptrace.ScopeSpans{}) {
+						if rs == (ptrace.ResourceSpans{}) {
+							rs = out.ResourceSpans().AppendEmpty()
+							rss.At(i).Resource().CopyTo(rs.Resource())
+							rs.SetSchemaUrl(rss.At(i).SchemaUrl())
+						}
+						ss = rs.ScopeSpans().AppendEmpty()
+						sss.At(j).Scope().CopyTo(ss.Scope())
+						ss.SetSchemaUrl(sss.At(j).SchemaUrl())
+					}
+					s.CopyTo(ss.Spans().AppendEmpty())
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,197 @@
+// Package alert evaluates notification rules against incoming telemetry.
+// A rule is a single condition on a log record, span, or metric, such as
+// "severity>=ERROR", "span.status=ERROR", or "body=~timeout|refused".
+package alert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Rule is one parsed condition. Its String is the text it was parsed from.
+type Rule struct {
+	text string
+
+	field string // severity, span.status, body, or name
+	op    string // =, >=, or =~
+
+	severity telemetry.Severity
+	status   ptrace.StatusCode
+	re       *regexp.Regexp
+}
+
+// ops lists the operators Parse recognises, longest first so that ">="
+// and "=~" are not read as "=" when they start at the same position.
+var ops = []string{">=", "=~", "="}
+
+// Parse reads a rule of the form FIELD OP VALUE:
+//
+//	severity>=ERROR      log records at or above a severity (or =, exactly)
+//	span.status=ERROR    spans with status ERROR, OK, or UNSET
+//	body=~REGEX          log records whose body matches
+//	name=~REGEX          spans and metrics whose name matches
+func Parse(s string) (Rule, error) {
+	r := Rule{text: s}
+	at := -1
+	for _, op := range ops {
+		if i := strings.Index(s, op); i > 0 && (at < 0 || i < at) {
+			at, r.op = i, op
+		}
+	}
+	if at < 0 {
+		return Rule{}, fmt.Errorf("alert rule %q: want FIELD OP VALUE", r.text)
+	}
+	r.field = strings.TrimSpace(s[:at])
+	s = strings.TrimSpace(s[at+len(r.op):])
+
+	var err error
+	switch {
+	case r.field == "severity" && r.op != "=~":
+		r.severity, err = filter.ParseSeverity(s)
+	case r.field == "span.status" && r.op == "=":
+		r.status, err = parseStatus(s)
+	case (r.field == "body" || r.field == "name") && r.op == "=~":
+		r.re, err = regexp.Compile(s)
+	default:
+		err = fmt.Errorf("unsupported condition %s%s", r.field, r.op)
+	}
+	if err != nil {
+		return Rule{}, fmt.Errorf("alert rule %q: %w", r.text, err)
+	}
+	return r, nil
+}
+
+// ParseAll parses every rule in texts.
+func ParseAll(texts []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(texts))
+	for _, t := range texts {
+		r, err := Parse(t)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func parseStatus(s string) (ptrace.StatusCode, error) {
+	for _, c := range []ptrace.StatusCode{ptrace.StatusCodeUnset, ptrace.StatusCodeOk, ptrace.StatusCodeError} {
+		if strings.EqualFold(s, c.String()) {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown span status %q (want UNSET, OK, or ERROR)", s)
+}
+
+func (r Rule) String() string { return r.text }
+
+// Hit describes the first item of a message that matched a rule.
+type Hit struct {
+	Rule    Rule
+	Service string // service.name of the matching item, if any
+	Text    string // log body, span name, or metric name
+}
+
+func (h Hit) String() string {
+	if h.Service != "" {
+		return fmt.Sprintf("%s: [%s] %s", h.Rule, h.Service, h.Text)
+	}
+	return fmt.Sprintf("%s: %s", h.Rule, h.Text)
+}
+
+// Match returns a hit for the first item of msg that any rule matches.
+func Match(rules []Rule, msg telemetry.Message) (Hit, bool) {
+	for _, r := range rules {
+		if h, ok := r.Match(msg); ok {
+			return h, true
+		}
+	}
+	return Hit{}, false
+}
+
+// Match returns a hit for the first item of msg that r matches.
+func (r Rule) Match(msg telemetry.Message) (Hit, bool) {
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		if r.field != "severity" && r.field != "body" {
+			return Hit{}, false
+		}
+		rls := msg.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			svc := serviceName(rls.At(i).Resource().Attributes())
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					lr := lrs.At(k)
+					body := lr.Body().AsString()
+					if r.logOK(telemetry.SeverityOf(lr.SeverityNumber()), body) {
+						return Hit{Rule: r, Service: svc, Text: body}, true
+					}
+				}
+			}
+		}
+	case telemetry.KindTraces:
+		if r.field != "span.status" && r.field != "name" {
+			return Hit{}, false
+		}
+		rss := msg.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			svc := serviceName(rss.At(i).Resource().Attributes())
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					s := spans.At(k)
+					if r.field == "span.status" && s.Status().Code() == r.status ||
+						r.field == "name" && r.re.MatchString(s.Name()) {
+						return Hit{Rule: r, Service: svc, Text: s.Name()}, true
+					}
+				}
+			}
+		}
+	case telemetry.KindMetrics:
+		if r.field != "name" {
+			return Hit{}, false
+		}
+		rms := msg.Metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			svc := serviceName(rms.At(i).Resource().Attributes())
+			sms := rms.At(i).ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					if name := ms.At(k).Name(); r.re.MatchString(name) {
+						return Hit{Rule: r, Service: svc, Text: name}, true
+					}
+				}
+			}
+		}
+	}
+	return Hit{}, false
+}
+
+func (r Rule) logOK(sev telemetry.Severity, body string) bool {
+	switch {
+	case r.field == "body":
+		return r.re.MatchString(body)
+	case r.op == ">=":
+		return sev >= r.severity
+	default:
+		return sev == r.severity
+	}
+}
+
+func serviceName(attrs pcommon.Map) string {
+	if v, ok := attrs.Get("service.name"); ok {
+		return v.AsString()
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"severity",
+		"=ERROR",
+		"severity=loud",
+		"severity=~ERROR",
+		"span.status>=ERROR",
+		"span.status=BROKEN",
+		"body=timeout",
+		"name=~(",
+		"duration>=1s",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", s)
+		}
+	}
+	if _, err := ParseAll([]string{"severity>=ERROR", "body=timeout"}); err == nil {
+		t.Error("ParseAll with a bad rule succeeded")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	declined := telemetry.Parse(testutil.Log("payments", "error", "card declined: gateway timeout"))
+	placed := telemetry.Parse(testutil.Log("checkout", "info", "order placed"))
+	span := telemetry.Parse(testutil.Span("checkout", "GET /cart", time.Millisecond))
+	gauge := telemetry.Parse(testutil.Gauge("checkout", "queue.depth", 7))
+
+	for _, tc := range []struct {
+		rule string
+		msg  telemetry.Message
+		want string // the hit, or "" for none
+	}{
+		{"severity>=ERROR", declined, "severity>=ERROR: [payments] card declined: gateway timeout"},
+		{"severity >= warn", declined, "severity >= warn: [payments] card declined: gateway timeout"},
+		{"severity>=ERROR", placed, ""},
+		{"severity=INFO", placed, "severity=INFO: [checkout] order placed"},
+		{"severity=INFO", declined, ""},
+		{"body=~timeout|refused", declined, "body=~timeout|refused: [payments] card declined: gateway timeout"},
+		{"body=~timeout|refused", placed, ""},
+		{"body=~a=b", placed, ""},
+		{"severity>=TRACE", span, ""},
+		{"span.status=UNSET", span, "span.status=UNSET: [checkout] GET /cart"},
+		{"span.status=error", span, ""},
+		{"span.status=ERROR", declined, ""},
+		{"name=~^GET ", span, "name=~^GET : [checkout] GET /cart"},
+		{"name=~queue", gauge, "name=~queue: [checkout] queue.depth"},
+		{"name=~queue", placed, ""},
+		{"body=~queue", gauge, ""},
+	} {
+		r, err := Parse(tc.rule)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.rule, err)
+			continue
+		}
+		h, ok := r.Match(tc.msg)
+		got := ""
+		if ok {
+			got = h.String()
+		}
+		if got != tc.want {
+			t.Errorf("%s on %v = %q, want %q", tc.rule, tc.msg.Kind, got, tc.want)
+		}
+	}
+}
+
+func TestMatchFirstRule(t *testing.T) {
+	rules, err := ParseAll([]string{"name=~charge", "severity>=WARN", "body=~declined"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, ok := Match(rules, telemetry.Parse(testutil.Log("payments", "error", "card declined")))
+	if !ok || h.Rule.String() != "severity>=WARN" {
+		t.Errorf("Match = %v, %v; want the severity rule", h, ok)
+	}
+	if _, ok := Match(rules, telemetry.Parse(testutil.Log("checkout", "info", "order placed"))); ok {
+		t.Error("Match hit a message no rule matches")
+	}
+}
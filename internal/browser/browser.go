@@ -0,0 +1,60 @@
+// Package browser opens URLs in the user's web browser: the command in
+// $BROWSER if it is set, otherwise the platform's own opener.
+package browser
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// open is what Open calls; Use replaces it.
+var open = start
+
+// Open shows url in a browser without waiting for it to close.
+func Open(url string) error {
+	return open(url)
+}
+
+// Use replaces how URLs are opened with fn, or restores the default if fn
+// is nil. Tests use it to see what was opened.
+func Use(fn func(url string) error) {
+	if fn == nil {
+		fn = start
+	}
+	open = fn
+}
+
+func start(url string) error {
+	argv := command()
+	if len(argv) == 0 {
+		return errors.New("no browser found; set $BROWSER")
+	}
+	cmd := exec.Command(argv[0], append(argv[1:], url)...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait() // reap it; the opener's exit status says nothing useful
+	return nil
+}
+
+// command returns the opener to run with the URL appended.
+func command() []string {
+	if argv := strings.Fields(os.Getenv("BROWSER")); len(argv) > 0 {
+		return argv
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open"}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler"}
+	}
+	for _, name := range []string{"xdg-open", "wslview", "sensible-browser"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return []string{name}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Package clip copies text to the clipboard. It tries the system clipboard
+// first, then the platform's clipboard commands, and finally an OSC 52
+// escape sequence, which Windows Terminal, iTerm2, kitty, WezTerm, and tmux
+// turn into a clipboard write even over SSH.
+package clip
+
+import (
+	"errors"
+	"os"
+
+	"github.com/muesli/termenv"
+	"golang.design/x/clipboard"
+)
+
+// backend is one way of reaching the clipboard.
+type backend struct {
+	name  string
+	write func(text []byte) error
+}
+
+// backends are tried in order by Write; Init fills them in.
+var backends = []backend{osc52}
+
+// Init works out which backends are available here. It cannot fail: OSC 52
+// is always there as a last resort, even if nothing handles it.
+func Init() {
+	backends = nil
+	if clipboard.Init() == nil {
+		backends = append(backends, backend{"system clipboard", func(text []byte) error {
+			clipboard.Write(clipboard.FmtText, text)
+			return nil
+		}})
+	}
+	backends = append(backends, commands()...)
+	backends = append(backends, osc52)
+}
+
+// Use replaces the backends with write alone, named name. Tests use it to
+// see what was copied.
+func Use(name string, write func(text []byte) error) {
+	backends = []backend{{name, write}}
+}
+
+// Write copies text to the clipboard with the first backend that takes it,
+// and returns the backend's name.
+func Write(text []byte) (string, error) {
+	var errs []error
+	for _, b := range backends {
+		err := b.write(text)
+		if err == nil {
+			return b.name, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", errors.Join(errs...)
+}
+
+// osc52 asks the terminal on stdout to set its clipboard.
+var osc52 = backend{"OSC 52", func(text []byte) error {
+	termenv.NewOutput(os.Stdout).Copy(string(text))
+	return nil
+}}
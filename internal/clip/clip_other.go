@@ -0,0 +1,41 @@
+//go:build !windows
+
+package clip
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+)
+
+// commands returns the clipboard commands found on PATH, for builds where
+// the system clipboard is unavailable, such as those without cgo, or
+// Wayland sessions without X.
+func commands() []backend {
+	var out []backend
+	for _, c := range []struct {
+		name string
+		args []string
+		env  string // only when this is set, if not empty
+	}{
+		{"pbcopy", nil, ""},
+		{"wl-copy", nil, "WAYLAND_DISPLAY"},
+		{"xclip", []string{"-selection", "clipboard"}, "DISPLAY"},
+		{"xsel", []string{"--clipboard", "--input"}, "DISPLAY"},
+	} {
+		if c.env != "" && os.Getenv(c.env) == "" {
+			continue
+		}
+		path, err := exec.LookPath(c.name)
+		if err != nil {
+			continue
+		}
+		args := c.args
+		out = append(out, backend{c.name, func(text []byte) error {
+			cmd := exec.Command(path, args...)
+			cmd.Stdin = bytes.NewReader(text)
+			return cmd.Run()
+		}})
+	}
+	return out
+}
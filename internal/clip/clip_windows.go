@@ -0,0 +1,24 @@
+//go:build windows
+
+package clip
+
+import (
+	"bytes"
+	"os/exec"
+)
+
+// commands returns PowerShell's Set-Clipboard, for when the system
+// clipboard cannot be opened. clip.exe is not used: it reads the console
+// code page, not UTF-8, and mangles anything outside ASCII.
+func commands() []backend {
+	ps, err := exec.LookPath("powershell.exe")
+	if err != nil {
+		return nil
+	}
+	return []backend{{"PowerShell", func(text []byte) error {
+		cmd := exec.Command(ps, "-NoProfile", "-NonInteractive", "-Command",
+			"[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())")
+		cmd.Stdin = bytes.NewReader(text)
+		return cmd.Run()
+	}}}
+}
@@ -0,0 +1,499 @@
+// Package filter selects log records, metrics, and spans by service,
+// severity, attribute values, text, duration, and time. The same Filter is
+// used wherever otail narrows a stream, so a query means the same thing
+// everywhere. The query package parses the language users type; Parse here
+// reads the query-string form Filter.String writes.
+package filter
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	plog "go.opentelemetry.io/collector/pdata/plog"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Filter is a conjunction of conditions; the zero value matches everything.
+type Filter struct {
+	Service  string             // service.name, case-insensitive
+	Severity telemetry.Severity // minimum log severity; spans at ERROR and above must have error status
+	Attrs    map[string]string  // attribute key → value, on the item or its resource
+	Texts    []string           // case-insensitive substrings of the body, name, or summary; all must be there
+	TraceID  string             // hex trace ID of log records and spans, or of a metric's exemplars
+
+	Kinds       []telemetry.Kind // signals kept; empty = all
+	MinDuration time.Duration    // spans lasting at least this long; 0 = no minimum
+	MaxDuration time.Duration    // spans lasting at most this long; 0 = no maximum
+	Last        time.Duration    // items stamped no longer ago than this; 0 = any time
+}
+
+// now is the clock Last is measured against.
+var now = time.Now
+
+// attrPrefix marks attribute conditions in query form.
+const attrPrefix = "attr."
+
+// Parse reads a filter from query-string syntax:
+//
+//	service=checkout&severity=error&attr.http.status_code=500&q=timeout
+//
+// service.name is accepted for service, and trace selects one trace ID.
+// kind takes a comma-separated list of signals, min_duration and
+// max_duration bound span durations, and last keeps items stamped within
+// that long, each a Go duration such as 250ms. q may be repeated; each
+// value must be found.
+func Parse(query string) (Filter, error) {
+	v, err := url.ParseQuery(query)
+	if err != nil {
+		return Filter{}, err
+	}
+	return FromValues(v)
+}
+
+// FromValues reads a filter from parsed query parameters, ignoring keys it
+// does not know.
+func FromValues(v url.Values) (Filter, error) {
+	f := Filter{Service: v.Get("service"), TraceID: strings.ToLower(v.Get("trace"))}
+	for _, q := range v["q"] {
+		if q != "" {
+			f.Texts = append(f.Texts, q)
+		}
+	}
+	if f.Service == "" {
+		f.Service = v.Get("service.name")
+	}
+	if s := v.Get("severity"); s != "" {
+		sev, err := ParseSeverity(s)
+		if err != nil {
+			return Filter{}, err
+		}
+		f.Severity = sev
+	}
+	if s := v.Get("kind"); s != "" {
+		for _, name := range strings.Split(s, ",") {
+			k, err := telemetry.ParseKind(name)
+			if err != nil {
+				return Filter{}, err
+			}
+			if !slices.Contains(f.Kinds, k) {
+				f.Kinds = append(f.Kinds, k)
+			}
+		}
+		slices.Sort(f.Kinds)
+	}
+	for _, d := range []struct {
+		key string
+		to  *time.Duration
+	}{{"min_duration", &f.MinDuration}, {"max_duration", &f.MaxDuration}, {"last", &f.Last}} {
+		if s := v.Get(d.key); s != "" {
+			dur, err := time.ParseDuration(s)
+			if err != nil || dur <= 0 {
+				return Filter{}, fmt.Errorf("%s %q: want a positive duration such as 250ms", d.key, s)
+			}
+			*d.to = dur
+		}
+	}
+	for key, vals := range v {
+		if name, ok := strings.CutPrefix(key, attrPrefix); ok && name != "" && len(vals) > 0 {
+			if f.Attrs == nil {
+				f.Attrs = map[string]string{}
+			}
+			f.Attrs[name] = vals[0]
+		}
+	}
+	return f, nil
+}
+
+// ParseSeverity maps a severity name such as "warn" to its range.
+func ParseSeverity(s string) (telemetry.Severity, error) {
+	for sev := telemetry.SeverityTrace; sev <= telemetry.SeverityFatal; sev++ {
+		if strings.EqualFold(s, sev.String()) {
+			return sev, nil
+		}
+	}
+	if strings.EqualFold(s, "warning") {
+		return telemetry.SeverityWarn, nil
+	}
+	return 0, fmt.Errorf("unknown severity %q", s)
+}
+
+// Preset is a filter saved under a name.
+type Preset struct {
+	Name   string
+	Filter Filter
+}
+
+// Equal reports whether f and g match the same things.
+func (f Filter) Equal(g Filter) bool {
+	return f.String() == g.String()
+}
+
+// IsZero reports whether f matches everything.
+func (f Filter) IsZero() bool {
+	return f.Service == "" && f.Severity == telemetry.SeverityUnset && len(f.Attrs) == 0 && len(f.Texts) == 0 && f.TraceID == "" &&
+		len(f.Kinds) == 0 && f.MinDuration == 0 && f.MaxDuration == 0 && f.Last == 0
+}
+
+// String renders f in the query syntax accepted by Parse.
+func (f Filter) String() string {
+	v := url.Values{}
+	if f.Service != "" {
+		v.Set("service", f.Service)
+	}
+	if f.Severity != telemetry.SeverityUnset {
+		v.Set("severity", strings.ToLower(f.Severity.String()))
+	}
+	for k, val := range f.Attrs {
+		v.Set(attrPrefix+k, val)
+	}
+	if len(f.Texts) > 0 {
+		v["q"] = slices.Clone(f.Texts)
+	}
+	if f.TraceID != "" {
+		v.Set("trace", f.TraceID)
+	}
+	if len(f.Kinds) > 0 {
+		names := make([]string, len(f.Kinds))
+		for i, k := range slices.Sorted(slices.Values(f.Kinds)) {
+			names[i] = k.String()
+		}
+		v.Set("kind", strings.Join(names, ","))
+	}
+	if f.MinDuration > 0 {
+		v.Set("min_duration", f.MinDuration.String())
+	}
+	if f.MaxDuration > 0 {
+		v.Set("max_duration", f.MaxDuration.String())
+	}
+	if f.Last > 0 {
+		v.Set("last", f.Last.String())
+	}
+	return v.Encode()
+}
+
+// Apply returns msg narrowed to the items f matches, and false when none
+// do. A zero filter returns msg unchanged; otherwise the result is a copy
+// and msg is not modified. Metrics are kept or dropped whole. Unknown
+// frames only honour Kinds and Texts, matched against the raw bytes.
+func (f Filter) Apply(msg telemetry.Message) (telemetry.Message, bool) {
+	if f.IsZero() {
+		return msg, true
+	}
+	if !f.kindOK(msg.Kind) {
+		return msg, false
+	}
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		logs := plog.NewLogs()
+		msg.Logs.CopyTo(logs)
+		n := f.pruneLogs(logs)
+		return telemetry.FromLogs(logs), n > 0
+	case telemetry.KindMetrics:
+		metrics := pmetric.NewMetrics()
+		msg.Metrics.CopyTo(metrics)
+		n := f.pruneMetrics(metrics)
+		return telemetry.FromMetrics(metrics), n > 0
+	case telemetry.KindTraces:
+		traces := ptrace.NewTraces()
+		msg.Traces.CopyTo(traces)
+		n := f.pruneSpans(traces)
+		return telemetry.FromTraces(traces), n > 0
+	default:
+		ok := f.Service == "" && f.Severity == telemetry.SeverityUnset && len(f.Attrs) == 0 && f.TraceID == "" &&
+			!f.durationSet() && f.Last == 0 && f.textOK(string(msg.Raw))
+		return msg, ok
+	}
+}
+
+// Match reports whether f matches at least one item of msg, without
+// copying it; it agrees with the second result of Apply.
+func (f Filter) Match(msg telemetry.Message) bool {
+	if f.IsZero() {
+		return true
+	}
+	if !f.kindOK(msg.Kind) {
+		return false
+	}
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		rls := msg.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			res := rls.At(i).Resource().Attributes()
+			if !f.serviceOK(res) {
+				continue
+			}
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					if f.logOK(lrs.At(k), res) {
+						return true
+					}
+				}
+			}
+		}
+	case telemetry.KindMetrics:
+		rms := msg.Metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			res := rms.At(i).Resource().Attributes()
+			if !f.serviceOK(res) {
+				continue
+			}
+			sms := rms.At(i).ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					if f.metricOK(ms.At(k), res) {
+						return true
+					}
+				}
+			}
+		}
+	case telemetry.KindTraces:
+		rss := msg.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			res := rss.At(i).Resource().Attributes()
+			if !f.serviceOK(res) {
+				continue
+			}
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					if f.spanOK(spans.At(k), res) {
+						return true
+					}
+				}
+			}
+		}
+	default:
+		_, ok := f.Apply(msg)
+		return ok
+	}
+	return false
+}
+
+func (f Filter) pruneLogs(logs plog.Logs) int {
+	kept := 0
+	logs.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		res := rl.Resource().Attributes()
+		if !f.serviceOK(res) {
+			return true
+		}
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+				if !f.logOK(lr, res) {
+					return true
+				}
+				kept++
+				return false
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+	return kept
+}
+
+func (f Filter) pruneMetrics(metrics pmetric.Metrics) int {
+	kept := 0
+	metrics.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		res := rm.Resource().Attributes()
+		if !f.serviceOK(res) {
+			return true
+		}
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				if !f.metricOK(m, res) {
+					return true
+				}
+				kept++
+				return false
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+	return kept
+}
+
+func (f Filter) pruneSpans(traces ptrace.Traces) int {
+	kept := 0
+	traces.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		res := rs.Resource().Attributes()
+		if !f.serviceOK(res) {
+			return true
+		}
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(s ptrace.Span) bool {
+				if !f.spanOK(s, res) {
+					return true
+				}
+				kept++
+				return false
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	return kept
+}
+
+func (f Filter) logOK(lr plog.LogRecord, res pcommon.Map) bool {
+	if f.Severity != telemetry.SeverityUnset && telemetry.SeverityOf(lr.SeverityNumber()) < f.Severity {
+		return false
+	}
+	if f.TraceID != "" && lr.TraceID().String() != f.TraceID {
+		return false
+	}
+	if f.durationSet() {
+		return false
+	}
+	if ts := lr.Timestamp(); f.Last > 0 && !f.recent(cmp.Or(ts, lr.ObservedTimestamp())) {
+		return false
+	}
+	return f.attrsOK(lr.Attributes(), res) && f.textOK(lr.Body().AsString())
+}
+
+func (f Filter) metricOK(m pmetric.Metric, res pcommon.Map) bool {
+	if f.durationSet() {
+		return false
+	}
+	if !f.textOK(m.Name(), m.Description()) {
+		return false
+	}
+	pts := points(m)
+	if f.TraceID != "" && !slices.ContainsFunc(pts, f.exemplarOK) {
+		return false
+	}
+	if f.Last > 0 && !slices.ContainsFunc(pts, func(p point) bool { return f.recent(p.time) }) {
+		return false
+	}
+	return f.attrsOK(pcommon.NewMap(), res) || slices.ContainsFunc(pts, func(p point) bool { return f.attrsOK(p.attrs, res) })
+}
+
+func (f Filter) spanOK(s ptrace.Span, res pcommon.Map) bool {
+	if f.Severity >= telemetry.SeverityError && s.Status().Code() != ptrace.StatusCodeError {
+		return false
+	}
+	if f.TraceID != "" && s.TraceID().String() != f.TraceID {
+		return false
+	}
+	if d := s.EndTimestamp().AsTime().Sub(s.StartTimestamp().AsTime()); f.MinDuration > 0 && d < f.MinDuration || f.MaxDuration > 0 && d > f.MaxDuration {
+		return false
+	}
+	if f.Last > 0 && !f.recent(s.StartTimestamp()) {
+		return false
+	}
+	return f.attrsOK(s.Attributes(), res) && f.textOK(s.Name())
+}
+
+// textOK reports whether every text term is in one of ss, ignoring case.
+func (f Filter) textOK(ss ...string) bool {
+	for _, t := range f.Texts {
+		if !slices.ContainsFunc(ss, func(s string) bool { return containsFold(s, t) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) kindOK(k telemetry.Kind) bool {
+	return len(f.Kinds) == 0 || slices.Contains(f.Kinds, k)
+}
+
+// durationSet reports whether f keeps only spans of some duration, which
+// nothing else has.
+func (f Filter) durationSet() bool {
+	return f.MinDuration > 0 || f.MaxDuration > 0
+}
+
+// recent reports whether ts is no longer ago than Last.
+func (f Filter) recent(ts pcommon.Timestamp) bool {
+	return ts != 0 && now().Sub(ts.AsTime()) <= f.Last
+}
+
+// point is what a filter looks at in a metric data point.
+type point struct {
+	attrs     pcommon.Map
+	time      pcommon.Timestamp
+	exemplars pmetric.ExemplarSlice // empty for summaries, which have none
+}
+
+// points returns the data points of m.
+func points(m pmetric.Metric) []point {
+	var out []point
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < m.Gauge().DataPoints().Len(); i++ {
+			dp := m.Gauge().DataPoints().At(i)
+			out = append(out, point{dp.Attributes(), dp.Timestamp(), dp.Exemplars()})
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < m.Sum().DataPoints().Len(); i++ {
+			dp := m.Sum().DataPoints().At(i)
+			out = append(out, point{dp.Attributes(), dp.Timestamp(), dp.Exemplars()})
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < m.Histogram().DataPoints().Len(); i++ {
+			dp := m.Histogram().DataPoints().At(i)
+			out = append(out, point{dp.Attributes(), dp.Timestamp(), dp.Exemplars()})
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < m.ExponentialHistogram().DataPoints().Len(); i++ {
+			dp := m.ExponentialHistogram().DataPoints().At(i)
+			out = append(out, point{dp.Attributes(), dp.Timestamp(), dp.Exemplars()})
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < m.Summary().DataPoints().Len(); i++ {
+			dp := m.Summary().DataPoints().At(i)
+			out = append(out, point{dp.Attributes(), dp.Timestamp(), pmetric.NewExemplarSlice()})
+		}
+	}
+	return out
+}
+
+// exemplarOK reports whether any exemplar of p belongs to TraceID.
+func (f Filter) exemplarOK(p point) bool {
+	for i := 0; i < p.exemplars.Len(); i++ {
+		if p.exemplars.At(i).TraceID().String() == f.TraceID {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) serviceOK(res pcommon.Map) bool {
+	if f.Service == "" {
+		return true
+	}
+	v, ok := res.Get("service.name")
+	return ok && strings.EqualFold(v.AsString(), f.Service)
+}
+
+// attrsOK reports whether every attribute condition holds on item or,
+// failing that, on the resource.
+func (f Filter) attrsOK(item, res pcommon.Map) bool {
+	for k, want := range f.Attrs {
+		v, ok := item.Get(k)
+		if !ok {
+			v, ok = res.Get(k)
+		}
+		if !ok || v.AsString() != want {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(s, sub string) bool {
+	return sub == "" || strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
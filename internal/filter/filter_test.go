@@ -0,0 +1,266 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// traceID is the trace the payments items belong to.
+const traceID = "5b8efff798038103d269b633813fc60c"
+
+// epoch is when the recent items were stamped; the others have no time.
+var epoch = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+var tid = pcommon.TraceID{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c}
+
+// logs returns three records: an info and a debug one from checkout, and
+// an error from payments in trace traceID stamped at epoch.
+func logs() telemetry.Message {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	rl.Resource().Attributes().PutStr("region", "eu")
+	lrs := rl.ScopeLogs().AppendEmpty().LogRecords()
+	lr := lrs.AppendEmpty()
+	lr.SetSeverityNumber(plog.SeverityNumberInfo)
+	lr.Body().SetStr("Order placed")
+	lr.Attributes().PutInt("http.status_code", 200)
+	lr = lrs.AppendEmpty()
+	lr.SetSeverityNumber(plog.SeverityNumberDebug4)
+	lr.Body().SetStr("cache warm")
+
+	rl = ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "payments")
+	lr = rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetSeverityNumber(plog.SeverityNumberError2)
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(epoch))
+	lr.SetTraceID(tid)
+	lr.Body().SetStr("card declined: timeout")
+	lr.Attributes().PutInt("http.status_code", 500)
+	return telemetry.FromLogs(ld)
+}
+
+// metrics returns a gauge from checkout with an attribute on its point,
+// stamped at epoch, and a sum from payments with an exemplar in trace
+// traceID.
+func metrics() telemetry.Message {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("queue.depth")
+	dp := m.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetIntValue(7)
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(epoch))
+	dp.Attributes().PutStr("queue", "orders")
+
+	rm = md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "payments")
+	m = rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("charges")
+	m.SetDescription("Card charges attempted")
+	sp := m.SetEmptySum().DataPoints().AppendEmpty()
+	sp.SetIntValue(3)
+	ex := sp.Exemplars().AppendEmpty()
+	ex.SetTraceID(tid)
+	ex.SetIntValue(1)
+	return telemetry.FromMetrics(md)
+}
+
+// traces returns an OK span from checkout, 120ms long and started at
+// epoch, and a 2s error span from payments in trace traceID.
+func traces() telemetry.Message {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	s := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	s.SetName("GET /cart")
+	s.SetTraceID(pcommon.TraceID{1})
+	s.SetStartTimestamp(pcommon.NewTimestampFromTime(epoch))
+	s.SetEndTimestamp(pcommon.NewTimestampFromTime(epoch.Add(120 * time.Millisecond)))
+	s.Status().SetCode(ptrace.StatusCodeOk)
+	s.Attributes().PutStr("http.route", "/cart")
+
+	rs = td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "payments")
+	s = rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	s.SetName("POST /charge")
+	s.SetTraceID(tid)
+	s.SetEndTimestamp(pcommon.Timestamp(2 * time.Second))
+	s.Status().SetCode(ptrace.StatusCodeError)
+	return telemetry.FromTraces(td)
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  Filter
+	}{
+		{"", Filter{}},
+		{"service=checkout", Filter{Service: "checkout"}},
+		{"service.name=checkout", Filter{Service: "checkout"}},
+		{"service=checkout&service.name=payments", Filter{Service: "checkout"}},
+		{"severity=warning", Filter{Severity: telemetry.SeverityWarn}},
+		{"severity=ERROR", Filter{Severity: telemetry.SeverityError}},
+		{"attr.http.status_code=500&q=Timeout", Filter{Attrs: map[string]string{"http.status_code": "500"}, Texts: []string{"Timeout"}}},
+		{"q=gateway&q=&q=timeout", Filter{Texts: []string{"gateway", "timeout"}}},
+		{"trace=5B8EFFF798038103D269B633813FC60C", Filter{TraceID: traceID}},
+		{"attr.=x&limit=10", Filter{}},
+		{"kind=traces,logs,trace", Filter{Kinds: []telemetry.Kind{telemetry.KindLogs, telemetry.KindTraces}}},
+		{"min_duration=200ms&max_duration=1s&last=5m", Filter{MinDuration: 200 * time.Millisecond, MaxDuration: time.Second, Last: 5 * time.Minute}},
+	} {
+		got, err := Parse(tc.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.query, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("Parse(%q) = %q, want %q", tc.query, got, tc.want)
+		}
+		if again, err := Parse(got.String()); err != nil || !again.Equal(got) {
+			t.Errorf("Parse(%q).String() = %q does not parse back (%v)", tc.query, got, err)
+		}
+	}
+
+	for _, query := range []string{"severity=loud", "q=%zz", "kind=spams", "last=soon", "min_duration=-1s"} {
+		if _, err := Parse(query); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", query)
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want telemetry.Severity
+	}{
+		{"trace", telemetry.SeverityTrace},
+		{"DEBUG", telemetry.SeverityDebug},
+		{"Info", telemetry.SeverityInfo},
+		{"warn", telemetry.SeverityWarn},
+		{"Warning", telemetry.SeverityWarn},
+		{"error", telemetry.SeverityError},
+		{"fatal", telemetry.SeverityFatal},
+	} {
+		got, err := ParseSeverity(tc.name)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseSeverity(%q) = %v, %v; want %v", tc.name, got, err, tc.want)
+		}
+	}
+	for _, name := range []string{"", "-", "err", "critical"} {
+		if _, err := ParseSeverity(name); err == nil {
+			t.Errorf("ParseSeverity(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+// count returns how many log records, metrics, or spans msg holds.
+func count(msg telemetry.Message) int {
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		return msg.Logs.LogRecordCount()
+	case telemetry.KindMetrics:
+		return msg.Metrics.MetricCount()
+	case telemetry.KindTraces:
+		return msg.Traces.SpanCount()
+	}
+	return 0
+}
+
+func TestApply(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return epoch.Add(30 * time.Second) }
+	unknown := telemetry.Parse([]byte("plain text: Checkout started"))
+	for _, tc := range []struct {
+		query string
+		msg   telemetry.Message
+		want  int // items kept
+	}{
+		{"", logs(), 3},
+		{"service=CHECKOUT", logs(), 2},
+		{"severity=info", logs(), 2},
+		{"severity=error", logs(), 1},
+		{"severity=fatal", logs(), 0},
+		{"q=TIMEOUT", logs(), 1},
+		{"q=timeout&q=card", logs(), 1}, // every term, in any order
+		{"q=timeout&q=refund", logs(), 0},
+		{"attr.http.status_code=500", logs(), 1},
+		{"attr.region=eu", logs(), 2},
+		{"attr.region=eu&attr.http.status_code=200", logs(), 1},
+		{"trace=" + traceID, logs(), 1},
+		{"service=checkout&trace=" + traceID, logs(), 0},
+		{"kind=logs", logs(), 3},
+		{"kind=traces", logs(), 0},
+		{"min_duration=1ms", logs(), 0},
+		{"last=1m", logs(), 1},
+		{"last=10s", logs(), 0},
+
+		{"q=charges", metrics(), 1},
+		{"q=attempted", metrics(), 1},
+		{"attr.queue=orders", metrics(), 1},
+		{"attr.queue=refunds", metrics(), 0},
+		{"trace=" + traceID, metrics(), 1},
+		{"service=checkout&trace=" + traceID, metrics(), 0},
+		{"last=1m", metrics(), 1},
+		{"max_duration=1s", metrics(), 0},
+
+		{"severity=error", traces(), 1},
+		{"severity=warn", traces(), 2},
+		{"attr.http.route=/cart", traces(), 1},
+		{"q=post", traces(), 1},
+		{"trace=" + traceID, traces(), 1},
+		{"min_duration=1s", traces(), 1},
+		{"max_duration=120ms", traces(), 1},
+		{"min_duration=100ms&max_duration=1s", traces(), 1},
+		{"min_duration=3s", traces(), 0},
+		{"last=1m&kind=traces,logs", traces(), 1},
+
+		{"q=checkout", unknown, 1},
+		{"q=refund", unknown, 0},
+		{"service=checkout", unknown, 0},
+		{"kind=unknown&q=checkout", unknown, 1},
+		{"kind=logs", unknown, 0},
+		{"last=1m", unknown, 0},
+	} {
+		f, err := Parse(tc.query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.query, err)
+		}
+		orig := count(tc.msg)
+		got, ok := f.Apply(tc.msg)
+		n := 0
+		switch {
+		case ok && tc.msg.Kind == telemetry.KindUnknown:
+			n = 1
+		case ok:
+			n = count(got)
+		}
+		if n != tc.want || ok != (tc.want > 0) {
+			t.Errorf("%s on %v: kept %d (ok %v), want %d", tc.query, tc.msg.Kind, n, ok, tc.want)
+		}
+		if match := f.Match(tc.msg); match != ok {
+			t.Errorf("%s on %v: Match = %v, Apply = %v", tc.query, tc.msg.Kind, match, ok)
+		}
+		if count(tc.msg) != orig {
+			t.Errorf("%s on %v: Apply modified the message", tc.query, tc.msg.Kind)
+		}
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	if !(Filter{}).IsZero() || !(Filter{Attrs: map[string]string{}}).IsZero() {
+		t.Error("empty filter is not zero")
+	}
+	for _, f := range []Filter{{TraceID: traceID}, {Kinds: []telemetry.Kind{telemetry.KindLogs}}, {MaxDuration: time.Second}, {Last: time.Minute}} {
+		if f.IsZero() {
+			t.Errorf("filter %q is zero", f)
+		}
+	}
+}
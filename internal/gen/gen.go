@@ -0,0 +1,253 @@
+// Package gen makes synthetic OTLP frames, encoded as the remotetap
+// processor sends them, for load-testing otail without a collector.
+package gen
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"golang.org/x/net/websocket"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Config tweaks behaviour; zero-value is sane.
+type Config struct {
+	Rate     int              // frames per second to each client, default 100
+	Kinds    []telemetry.Kind // signals to cycle through, default logs, metrics, and traces
+	Records  int              // log records, spans, or data points per frame, default 10
+	Services int              // distinct service.name values, default 5
+}
+
+func (c *Config) withDefaults() Config {
+	out := Config{}
+	if c != nil {
+		out = *c
+	}
+	if out.Rate <= 0 {
+		out.Rate = 100
+	}
+	if len(out.Kinds) == 0 {
+		out.Kinds = []telemetry.Kind{telemetry.KindLogs, telemetry.KindMetrics, telemetry.KindTraces}
+	}
+	if out.Records <= 0 {
+		out.Records = 10
+	}
+	if out.Services <= 0 {
+		out.Services = 5
+	}
+	return out
+}
+
+// Generator makes frames. It is not safe for concurrent use.
+type Generator struct {
+	cfg Config
+	rnd *rand.Rand
+	n   int // frames made so far
+}
+
+// New returns a Generator for cfg, seeded from seed so runs can be repeated.
+func New(cfg *Config, seed uint64) *Generator {
+	return &Generator{cfg: cfg.withDefaults(), rnd: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// Next returns the next frame, cycling through the configured signals.
+func (g *Generator) Next() []byte {
+	kind := g.cfg.Kinds[g.n%len(g.cfg.Kinds)]
+	g.n++
+	return g.Frame(kind)
+}
+
+// Frame returns one frame of kind as OTLP JSON. Unknown kinds get a frame
+// that no decoder accepts.
+func (g *Generator) Frame(kind telemetry.Kind) []byte {
+	var (
+		b   []byte
+		err error
+	)
+	switch kind {
+	case telemetry.KindLogs:
+		b, err = (&plog.JSONMarshaler{}).MarshalLogs(g.logs())
+	case telemetry.KindMetrics:
+		b, err = (&pmetric.JSONMarshaler{}).MarshalMetrics(g.metrics())
+	case telemetry.KindTraces:
+		b, err = (&ptrace.JSONMarshaler{}).MarshalTraces(g.traces())
+	default:
+		b = fmt.Appendf(nil, "not otlp #%d", g.n)
+	}
+	if err != nil {
+		panic(err) // pdata built here always marshals
+	}
+	return b
+}
+
+var (
+	routes   = []string{"/api/cart", "/api/checkout", "/api/items/{id}", "/healthz", "/login"}
+	statuses = []int64{200, 200, 200, 200, 201, 204, 302, 400, 404, 500, 503}
+	sevs     = []plog.SeverityNumber{
+		plog.SeverityNumberDebug, plog.SeverityNumberInfo, plog.SeverityNumberInfo,
+		plog.SeverityNumberInfo, plog.SeverityNumberInfo, plog.SeverityNumberWarn,
+		plog.SeverityNumberError,
+	}
+)
+
+func (g *Generator) service() string {
+	return fmt.Sprintf("svc-%d", g.rnd.IntN(g.cfg.Services))
+}
+
+func (g *Generator) resource(r pcommon.Resource, service string) {
+	r.Attributes().PutStr("service.name", service)
+	r.Attributes().PutStr("host.name", fmt.Sprintf("host-%d", g.rnd.IntN(3)))
+}
+
+func (g *Generator) logs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	g.resource(rl.Resource(), g.service())
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("otail-gen")
+	now := time.Now()
+	for range g.cfg.Records {
+		lr := sl.LogRecords().AppendEmpty()
+		lr.SetTimestamp(pcommon.NewTimestampFromTime(now))
+		sev := sevs[g.rnd.IntN(len(sevs))]
+		lr.SetSeverityNumber(sev)
+		lr.SetSeverityText(sev.String())
+		route, status := routes[g.rnd.IntN(len(routes))], statuses[g.rnd.IntN(len(statuses))]
+		lr.Body().SetStr(fmt.Sprintf("GET %s %d in %dms", route, status, g.rnd.IntN(500)))
+		lr.Attributes().PutStr("http.route", route)
+		lr.Attributes().PutInt("http.status_code", status)
+	}
+	return ld
+}
+
+func (g *Generator) metrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	g.resource(rm.Resource(), g.service())
+	ms := rm.ScopeMetrics().AppendEmpty().Metrics()
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	cpu := ms.AppendEmpty()
+	cpu.SetName("system.cpu.utilization")
+	cpu.SetUnit("1")
+	requests := ms.AppendEmpty()
+	requests.SetName("http.server.requests")
+	sum := requests.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	duration := ms.AppendEmpty()
+	duration.SetName("http.server.duration")
+	duration.SetUnit("ms")
+	hist := duration.SetEmptyHistogram()
+	hist.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+
+	gauge := cpu.SetEmptyGauge()
+	for i := range g.cfg.Records {
+		switch i % 3 {
+		case 0:
+			dp := gauge.DataPoints().AppendEmpty()
+			dp.SetTimestamp(now)
+			dp.SetDoubleValue(g.rnd.Float64())
+			dp.Attributes().PutInt("cpu", int64(i))
+		case 1:
+			dp := sum.DataPoints().AppendEmpty()
+			dp.SetTimestamp(now)
+			dp.SetIntValue(int64(g.n*10 + g.rnd.IntN(10)))
+			dp.Attributes().PutStr("http.route", routes[g.rnd.IntN(len(routes))])
+		default:
+			dp := hist.DataPoints().AppendEmpty()
+			dp.SetTimestamp(now)
+			dp.ExplicitBounds().FromRaw([]float64{5, 10, 25, 50, 100, 250, 500})
+			counts := make([]uint64, 8)
+			var total uint64
+			for j := range counts {
+				counts[j] = uint64(g.rnd.IntN(20))
+				total += counts[j]
+			}
+			dp.BucketCounts().FromRaw(counts)
+			dp.SetCount(total)
+			dp.SetSum(float64(total) * 40)
+		}
+	}
+	return md
+}
+
+// traces returns one trace of Records spans: a root span and children in
+// other services, so the service map has edges to show. Durations are
+// log-normal around 20ms and about one span in twenty fails.
+func (g *Generator) traces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	var traceID pcommon.TraceID
+	for i := range traceID {
+		traceID[i] = byte(g.rnd.UintN(256))
+	}
+	start := time.Now().Add(-time.Second)
+	var root pcommon.SpanID
+	for i := range g.cfg.Records {
+		service := g.service()
+		if i == 0 {
+			service = "svc-0"
+		}
+		rs := td.ResourceSpans().AppendEmpty()
+		g.resource(rs.Resource(), service)
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(traceID)
+		var id pcommon.SpanID
+		for j := range id {
+			id[j] = byte(g.rnd.UintN(256))
+		}
+		span.SetSpanID(id)
+		route := routes[g.rnd.IntN(len(routes))]
+		if i == 0 {
+			root = id
+			span.SetKind(ptrace.SpanKindServer)
+			span.SetName("GET " + route)
+		} else {
+			span.SetParentSpanID(root)
+			span.SetKind(ptrace.SpanKindClient)
+			span.SetName("call " + route)
+		}
+		d := time.Duration(20 * math.Exp(g.rnd.NormFloat64()) * float64(time.Millisecond))
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(d)))
+		span.Attributes().PutStr("http.route", route)
+		if g.rnd.IntN(20) == 0 {
+			span.Status().SetCode(ptrace.StatusCodeError)
+			span.Status().SetMessage("upstream timed out")
+		}
+	}
+	return td
+}
+
+// Handler serves frames over a websocket to every client that connects,
+// each at cfg.Rate frames per second until it disconnects.
+func Handler(cfg *Config) http.Handler {
+	c := cfg.withDefaults()
+	return websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		g := New(&c, uint64(time.Now().UnixNano()))
+		// Frames are sent in small bursts every tick to reach high rates
+		// without a timer per frame.
+		const tick = 10 * time.Millisecond
+		t := time.NewTicker(tick)
+		defer t.Stop()
+		began, sent := time.Now(), 0
+		for now := range t.C {
+			due := int(now.Sub(began).Seconds()*float64(c.Rate)) - sent
+			for range due {
+				if err := websocket.Message.Send(ws, string(g.Next())); err != nil {
+					return
+				}
+				sent++
+			}
+		}
+	})
+}
@@ -0,0 +1,189 @@
+// Package headless streams parsed telemetry to a writer without the TUI,
+// for piping into grep, jq, or a file.
+package headless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/transport"
+	"github.com/jwafle/otail/internal/ui/theme"
+)
+
+// Format selects how frames are written.
+type Format int
+
+const (
+	FormatCompact Format = iota // one summary line per record
+	FormatJSON                  // one OTLP JSON document per frame
+)
+
+// ParseFormat accepts "compact" or "json".
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "compact":
+		return FormatCompact, nil
+	case "json", "jsonl":
+		return FormatJSON, nil
+	default:
+		return 0, fmt.Errorf("unknown format %q (want compact or json)", s)
+	}
+}
+
+// Color selects whether compact output is colorized.
+type Color int
+
+const (
+	ColorAuto   Color = iota // colorize when the output is a terminal
+	ColorAlways              // always emit ANSI colors
+	ColorNever               // never emit ANSI colors
+)
+
+// ParseColor accepts "auto", "always", or "never".
+func ParseColor(s string) (Color, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return ColorAuto, nil
+	case "always":
+		return ColorAlways, nil
+	case "never":
+		return ColorNever, nil
+	default:
+		return 0, fmt.Errorf("unknown color mode %q (want auto, always, or never)", s)
+	}
+}
+
+// Config tweaks output; zero-value is sane.
+type Config struct {
+	Format  Format
+	Color   Color
+	Signals []telemetry.Kind // nil = every signal, including unknown frames
+	Filter  filter.Filter    // zero = every frame
+	Theme   theme.Theme      // zero = theme.Default
+	Out     io.Writer        // nil = os.Stdout
+	Limit   int              // stop after this many written frames; 0 = no limit
+
+	Temporality telemetry.Temporality // convert sums before printing; zero = as received
+}
+
+// Run opens src and writes every frame to cfg.Out until ctx is cancelled,
+// the stream ends, cfg.Limit is reached, or the stream fails.
+func Run(ctx context.Context, src transport.Source, cfg *Config) error {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	out := cfg.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	th := cfg.Theme
+	if th.Name == "" {
+		th = theme.Default
+	}
+
+	r := lipgloss.NewRenderer(out)
+	switch cfg.Color {
+	case ColorAlways:
+		r.SetColorProfile(termenv.ANSI256)
+	case ColorNever:
+		r.SetColorProfile(termenv.Ascii)
+	}
+	p := &printer{out: out, format: cfg.Format, styles: th.StylesFor(r)}
+	normalizer := telemetry.NewNormalizer(cfg.Temporality)
+
+	stream, err := src(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	written := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case b, ok := <-stream.Messages():
+			if !ok {
+				return nil
+			}
+			msg := telemetry.Parse(b)
+			if !wanted(cfg.Signals, msg.Kind) {
+				continue
+			}
+			normalizer.Apply(&msg)
+			if msg, ok = cfg.Filter.Apply(msg); !ok {
+				continue
+			}
+			ok, err := p.print(msg)
+			if err != nil {
+				return err
+			}
+			if ok {
+				written++
+			}
+			if cfg.Limit > 0 && written >= cfg.Limit {
+				return nil
+			}
+		case err, ok := <-stream.Errors():
+			if ok && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
+
+func wanted(signals []telemetry.Kind, k telemetry.Kind) bool {
+	if len(signals) == 0 {
+		return true
+	}
+	for _, s := range signals {
+		if s == k {
+			return true
+		}
+	}
+	return false
+}
+
+type printer struct {
+	out    io.Writer
+	format Format
+	styles theme.Styles
+}
+
+// print writes msg and reports whether anything was written.
+func (p *printer) print(msg telemetry.Message) (bool, error) {
+	if p.format == FormatJSON {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, msg.Raw); err != nil {
+			// Not JSON at all; there is nothing jq could do with it.
+			log.Printf("skipping %d byte non-JSON frame", len(msg.Raw))
+			return false, nil
+		}
+		buf.WriteByte('\n')
+		_, err := p.out.Write(buf.Bytes())
+		return err == nil, err
+	}
+
+	for _, rec := range msg.Records() {
+		tag := p.styles.Status.Render(fmt.Sprintf("%-7s", rec.Kind))
+		line := rec.String()
+		if rec.Kind == telemetry.KindLogs {
+			line = p.styles.SeverityStyle(rec.Severity).Render(line)
+		}
+		if _, err := fmt.Fprintln(p.out, tag, line); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
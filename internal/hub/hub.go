@@ -0,0 +1,378 @@
+// Package hub fans a single telemetry stream out to any number of
+// subscribers. Each frame is read from the collector once, parsed once, and
+// delivered to every subscriber, so the TUI and web clients can watch the
+// same stream without stealing frames from each other.
+package hub
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/transport"
+)
+
+// Config tweaks behaviour; zero-value is sane.
+type Config struct {
+	Buffer  int // per-subscriber message buffer, default 1024
+	History int // recent messages replayed to new subscribers, default 256
+	Retain  int // messages kept for Snapshot and resume, default 10000; at least History
+}
+
+// Entry is a message together with its position in the hub's stream.
+// Sequence numbers start at 1 and increase by one per message.
+type Entry struct {
+	Seq uint64
+	telemetry.Message
+}
+
+// Hub owns the upstream stream and the set of subscribers.
+type Hub struct {
+	buffer int
+
+	replay int
+
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	history []Entry // ring of the last len(history) messages
+	next    int     // ring write position
+	filled  bool    // ring has wrapped at least once
+	seq     uint64  // sequence number of the latest message
+	state   transport.State
+	closed  bool
+}
+
+// New returns a Hub with no upstream; call Run to start ingesting.
+func New(cfg *Config) *Hub {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	buf := cfg.Buffer
+	if buf <= 0 {
+		buf = 1024
+	}
+	hist := cfg.History
+	if hist <= 0 {
+		hist = 256
+	}
+	retain := cfg.Retain
+	if retain <= 0 {
+		retain = 10000
+	}
+	return &Hub{
+		buffer:  buf,
+		replay:  hist,
+		subs:    map[*Subscription]struct{}{},
+		history: make([]Entry, max(retain, hist)),
+	}
+}
+
+// Run opens a stream from src and broadcasts its frames until ctx is
+// cancelled or the stream ends. All subscriptions are closed when it
+// returns.
+func (h *Hub) Run(ctx context.Context, src transport.Source) error {
+	stream, err := src(ctx)
+	if err != nil {
+		h.shutdown()
+		return err
+	}
+	return h.RunStream(ctx, stream)
+}
+
+// RunStream is Run on a stream that is already open, for callers that also
+// pass it to Source. It closes stream when it returns.
+func (h *Hub) RunStream(ctx context.Context, stream *transport.Stream) error {
+	defer h.shutdown()
+	defer stream.Close()
+
+	states := stream.States()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case st, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			h.setState(st)
+		case b, ok := <-stream.Messages():
+			if !ok {
+				return nil
+			}
+			h.publish(telemetry.Parse(b))
+		case err, ok := <-stream.Errors():
+			if ok && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
+
+// SubscribeOptions controls a single subscription; zero-value is sane.
+type SubscribeOptions struct {
+	Replay    bool   // start with the hub's recent history
+	After     uint64 // start with every retained message after this sequence number; overrides Replay
+	EvictSlow bool   // close the subscription instead of dropping when its buffer fills
+	Block     bool   // wait for room instead of dropping, holding up every subscriber; for consumers that must see every message
+}
+
+// Subscribe registers a new subscriber. It observes the current connection
+// state immediately and every message published after it joined; history
+// requested through opts is available from Backlog. Callers must Close the
+// subscription when done.
+func (h *Hub) Subscribe(opts *SubscribeOptions) *Subscription {
+	if opts == nil {
+		opts = &SubscribeOptions{}
+	}
+	s := &Subscription{
+		hub:    h,
+		evict:  opts.EvictSlow,
+		block:  opts.Block,
+		done:   make(chan struct{}),
+		msgCh:  make(chan Entry, h.buffer),
+		stateC: make(chan transport.State, 8),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(s.msgCh)
+		close(s.stateC)
+		return s
+	}
+	h.subs[s] = struct{}{}
+	s.setState(h.state)
+	switch {
+	case opts.After > 0:
+		s.backlog = h.recent(len(h.history))
+		i := 0
+		for i < len(s.backlog) && s.backlog[i].Seq <= opts.After {
+			i++
+		}
+		s.backlog = s.backlog[i:]
+	case opts.Replay:
+		s.backlog = h.recent(h.replay)
+	}
+	return s
+}
+
+// Source returns a transport.Source whose streams carry the raw frames of
+// a subscription to h, so a consumer of frames such as the TUI can share
+// h's upstream with every other subscriber. upstream is the stream h runs
+// on: the streams report its stats and sources and switch them on and
+// off, but closing them leaves it open.
+func (h *Hub) Source(upstream *transport.Stream) transport.Source {
+	return func(ctx context.Context) (*transport.Stream, error) {
+		sub := h.Subscribe(nil)
+		frames := make(chan []byte)
+		stopped := make(chan struct{})
+		go func() {
+			defer close(frames)
+			for e := range sub.Messages() {
+				select {
+				case frames <- e.Raw:
+				case <-stopped:
+					return
+				}
+			}
+		}()
+		return transport.Relay(ctx, transport.Relayed{
+			Frames: frames,
+			States: sub.States(),
+			Shared: upstream,
+			Lost:   func() uint64 { return sub.Stats().Dropped },
+			Stop: func() {
+				close(stopped)
+				sub.Close()
+			},
+		}), nil
+	}
+}
+
+// Snapshot returns the retained messages, oldest first. The slice is a
+// copy; the messages themselves are shared and must not be modified.
+func (h *Hub) Snapshot() []telemetry.Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := h.recent(len(h.history))
+	out := make([]telemetry.Message, len(entries))
+	for i, e := range entries {
+		out[i] = e.Message
+	}
+	return out
+}
+
+// recent returns up to the last n retained messages, oldest first, as a
+// fresh slice. Called with mu held.
+func (h *Hub) recent(n int) []Entry {
+	all := h.history[:h.next]
+	if h.filled {
+		all = append(h.history[h.next:len(h.history):len(h.history)], h.history[:h.next]...)
+	}
+	if len(all) > n {
+		all = all[len(all)-n:]
+	}
+	return append([]Entry(nil), all...)
+}
+
+// State returns the latest upstream connection state.
+func (h *Hub) State() transport.State {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state
+}
+
+// Seed fills the history with entries kept from an earlier run, oldest
+// first, and numbers the messages published after them from the last
+// one's sequence number. Call it before Run.
+func (h *Hub) Seed(entries []Entry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(entries) > len(h.history) {
+		entries = entries[len(entries)-len(h.history):]
+	}
+	for _, e := range entries {
+		h.retain(e)
+	}
+	if len(entries) > 0 {
+		h.seq = entries[len(entries)-1].Seq
+	}
+}
+
+// retain writes e to the history ring. Called with mu held.
+func (h *Hub) retain(e Entry) {
+	h.history[h.next] = e
+	h.next++
+	if h.next == len(h.history) {
+		h.next, h.filled = 0, true
+	}
+}
+
+func (h *Hub) publish(msg telemetry.Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	e := Entry{Seq: h.seq, Message: msg}
+	h.retain(e)
+	for s := range h.subs {
+		if s.block {
+			// Close signals done before taking mu, so a consumer that
+			// gives up cannot leave the hub waiting here.
+			select {
+			case s.msgCh <- e:
+			case <-s.done:
+			}
+			continue
+		}
+		select {
+		case s.msgCh <- e:
+		default:
+			s.dropped.Add(1)
+			if s.evict {
+				s.evicted.Store(true)
+				delete(h.subs, s)
+				s.close()
+			}
+		}
+	}
+}
+
+func (h *Hub) setState(st transport.State) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.state = st
+	for s := range h.subs {
+		s.setState(st)
+	}
+}
+
+func (h *Hub) shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		s.close()
+	}
+	h.subs = nil
+}
+
+func (h *Hub) unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		s.close()
+	}
+}
+
+// --------------------------------------------------------------------
+
+// Subscription is one consumer's view of the hub.
+type Subscription struct {
+	hub     *Hub
+	evict   bool
+	evicted atomic.Bool
+	block   bool
+	done    chan struct{} // closed by Close
+	closing sync.Once
+	backlog []Entry
+	msgCh   chan Entry
+	stateC  chan transport.State
+	dropped atomic.Uint64 // messages discarded because msgCh was full
+}
+
+// Backlog returns the history requested at Subscribe, oldest first.
+// Consumers deliver it before anything from Messages, which picks up
+// exactly where the backlog ends.
+func (s *Subscription) Backlog() []Entry { return s.backlog }
+
+// Messages returns the channel of parsed messages. It is closed when the
+// subscription or the hub shuts down.
+func (s *Subscription) Messages() <-chan Entry { return s.msgCh }
+
+// States returns the upstream connection state stream. Like
+// transport.Stream.States, slow readers only miss intermediate transitions.
+func (s *Subscription) States() <-chan transport.State { return s.stateC }
+
+// Stats reports the subscription's buffer usage.
+func (s *Subscription) Stats() transport.Stats {
+	return transport.Stats{
+		Buffered: len(s.msgCh),
+		Capacity: cap(s.msgCh),
+		Dropped:  s.dropped.Load(),
+	}
+}
+
+// Evicted reports whether the hub closed the subscription because it fell
+// behind. Only subscriptions created with EvictSlow are evicted.
+func (s *Subscription) Evicted() bool { return s.evicted.Load() }
+
+// Close detaches the subscription from the hub. It is safe to call more
+// than once.
+func (s *Subscription) Close() {
+	s.closing.Do(func() { close(s.done) })
+	s.hub.unsubscribe(s)
+}
+
+// setState publishes st without blocking, dropping the oldest pending
+// transition when the buffer is full. Called with hub.mu held.
+func (s *Subscription) setState(st transport.State) {
+	for {
+		select {
+		case s.stateC <- st:
+			return
+		default:
+		}
+		select {
+		case <-s.stateC:
+		default:
+		}
+	}
+}
+
+// close shuts the channels. Called with hub.mu held.
+func (s *Subscription) close() {
+	close(s.msgCh)
+	close(s.stateC)
+}
@@ -0,0 +1,190 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+	"github.com/jwafle/otail/internal/transport"
+)
+
+// publishN publishes n log messages with the bodies a, b, c, and so on.
+func publishN(h *Hub, n int) {
+	for i := range n {
+		h.publish(telemetry.Parse(testutil.Log("checkout", "info", string(rune('a'+i)))))
+	}
+}
+
+func seqs(entries []Entry) []uint64 {
+	var out []uint64
+	for _, e := range entries {
+		out = append(out, e.Seq)
+	}
+	return out
+}
+
+// receive reads n entries from sub, failing the test if they take too long.
+func receive(t *testing.T, sub *Subscription, n int) []Entry {
+	t.Helper()
+	var out []Entry
+	for len(out) < n {
+		select {
+		case e, ok := <-sub.Messages():
+			if !ok {
+				t.Fatalf("subscription closed after %d of %d messages", len(out), n)
+			}
+			out = append(out, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d messages", len(out), n)
+		}
+	}
+	return out
+}
+
+func TestSubscribeHistory(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts *SubscribeOptions
+		want []uint64
+	}{
+		{"live", nil, nil},
+		{"replay", &SubscribeOptions{Replay: true}, []uint64{5, 6, 7}},
+		{"after", &SubscribeOptions{After: 4}, []uint64{5, 6, 7}},
+		{"after-overrides-replay", &SubscribeOptions{Replay: true, After: 5}, []uint64{6, 7}},
+		{"after-beyond-retain", &SubscribeOptions{After: 1}, []uint64{3, 4, 5, 6, 7}},
+		{"after-latest", &SubscribeOptions{After: 7}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(&Config{History: 3, Retain: 5})
+			publishN(h, 7)
+			sub := h.Subscribe(tc.opts)
+			defer sub.Close()
+			if got := seqs(sub.Backlog()); !slices.Equal(got, tc.want) {
+				t.Errorf("backlog = %v, want %v", got, tc.want)
+			}
+			// Messages picks up exactly where the backlog ends.
+			publishN(h, 1)
+			if e := receive(t, sub, 1)[0]; e.Seq != h.seq {
+				t.Errorf("next message is %d, want %d", e.Seq, h.seq)
+			}
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	h := New(&Config{History: 2, Retain: 3})
+	publishN(h, 4)
+	var bodies []string
+	for _, msg := range h.Snapshot() {
+		bodies = append(bodies, msg.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	}
+	if want := []string{"b", "c", "d"}; !slices.Equal(bodies, want) {
+		t.Errorf("snapshot = %q, want %q", bodies, want)
+	}
+}
+
+func TestSlowSubscribers(t *testing.T) {
+	h := New(&Config{Buffer: 2})
+	dropping := h.Subscribe(nil)
+	defer dropping.Close()
+	evicted := h.Subscribe(&SubscribeOptions{EvictSlow: true})
+	defer evicted.Close()
+	publishN(h, 3)
+
+	if st := dropping.Stats(); st.Dropped != 1 || st.Buffered != 2 || st.Capacity != 2 {
+		t.Errorf("dropping subscriber stats = %+v, want 1 dropped of 2 buffered", st)
+	}
+	if dropping.Evicted() {
+		t.Error("subscriber without EvictSlow was evicted")
+	}
+	if !evicted.Evicted() {
+		t.Fatal("slow subscriber with EvictSlow was not evicted")
+	}
+	// What was buffered before the eviction is still delivered.
+	if got := seqs(receive(t, evicted, 2)); !slices.Equal(got, []uint64{1, 2}) {
+		t.Errorf("evicted subscriber received %v, want [1 2]", got)
+	}
+	if _, ok := <-evicted.Messages(); ok {
+		t.Error("evicted subscription is still open")
+	}
+}
+
+func TestBlockingSubscriber(t *testing.T) {
+	h := New(&Config{Buffer: 1})
+	sub := h.Subscribe(&SubscribeOptions{Block: true})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		publishN(h, 4)
+	}()
+	if got := seqs(receive(t, sub, 4)); !slices.Equal(got, []uint64{1, 2, 3, 4}) {
+		t.Errorf("received %v, want every message in order", got)
+	}
+	<-done
+	if st := sub.Stats(); st.Dropped != 0 {
+		t.Errorf("blocking subscriber dropped %d", st.Dropped)
+	}
+
+	// A publish waiting on a subscriber that gives up is let go.
+	publishN(h, 1)
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		publishN(h, 1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sub.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish still blocked after Close")
+	}
+}
+
+func TestSeed(t *testing.T) {
+	h := New(&Config{History: 2, Retain: 3})
+	var entries []Entry
+	for seq := uint64(10); seq <= 13; seq++ {
+		entries = append(entries, Entry{Seq: seq, Message: telemetry.Parse(testutil.Log("checkout", "info", "old"))})
+	}
+	h.Seed(entries)
+	publishN(h, 1)
+	sub := h.Subscribe(&SubscribeOptions{After: 10})
+	defer sub.Close()
+	if got := seqs(sub.Backlog()); !slices.Equal(got, []uint64{12, 13, 14}) {
+		t.Errorf("backlog after seeding = %v, want [12 13 14]", got)
+	}
+}
+
+func TestSource(t *testing.T) {
+	frames := [][]byte{
+		testutil.Log("checkout", "info", "order placed"),
+		testutil.Span("checkout", "GET /cart", time.Millisecond),
+		testutil.Gauge("checkout", "queue.depth", 7),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	upstream := transport.Replay(ctx, bytes.NewReader(append(bytes.Join(frames, []byte("\n")), '\n')), 0)
+	h := New(nil)
+	stream, err := h.Source(upstream)(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := h.Subscribe(nil)
+	go h.RunStream(ctx, upstream)
+
+	var got [][]byte
+	for frame := range stream.Messages() {
+		got = append(got, frame)
+	}
+	if !slices.EqualFunc(got, frames, bytes.Equal) {
+		t.Errorf("relayed %d frames, want the %d replayed", len(got), len(frames))
+	}
+	if n := len(receive(t, other, len(frames))); n != len(frames) {
+		t.Errorf("other subscriber received %d frames", n)
+	}
+}
@@ -0,0 +1,199 @@
+// Package jaeger writes OTLP traces in the JSON format of Jaeger's query
+// API, which Jaeger UI opens with its JSON file upload. Spans are mapped
+// the way Jaeger's own OTLP receiver maps them: the kind, status, and scope
+// become tags, events become logs, and the parent and links become
+// references.
+package jaeger
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// file is what Jaeger UI reads: the response of GET /api/traces.
+type file struct {
+	Data []trace `json:"data"`
+}
+
+type trace struct {
+	TraceID   string             `json:"traceID"`
+	Spans     []span             `json:"spans"`
+	Processes map[string]process `json:"processes"`
+}
+
+type span struct {
+	TraceID       string      `json:"traceID"`
+	SpanID        string      `json:"spanID"`
+	OperationName string      `json:"operationName"`
+	References    []reference `json:"references"`
+	Flags         uint32      `json:"flags"`
+	StartTime     int64       `json:"startTime"` // µs since the epoch
+	Duration      int64       `json:"duration"`  // µs
+	Tags          []tag       `json:"tags"`
+	Logs          []log       `json:"logs"`
+	ProcessID     string      `json:"processID"`
+}
+
+type reference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type log struct {
+	Timestamp int64 `json:"timestamp"`
+	Fields    []tag `json:"fields"`
+}
+
+type process struct {
+	ServiceName string `json:"serviceName"`
+	Tags        []tag  `json:"tags"`
+}
+
+type tag struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// Marshal returns td as Jaeger JSON, one trace per trace ID in the order
+// they first appear. Each resource is a process.
+func Marshal(td ptrace.Traces) ([]byte, error) {
+	var f file
+	byID := map[pcommon.TraceID]int{} // index into f.Data
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		pid := fmt.Sprintf("p%d", i+1)
+		proc := newProcess(rs.Resource())
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			scope := sss.At(j).Scope()
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				s := spans.At(k)
+				n, ok := byID[s.TraceID()]
+				if !ok {
+					n = len(f.Data)
+					byID[s.TraceID()] = n
+					f.Data = append(f.Data, trace{TraceID: traceID(s.TraceID()), Processes: map[string]process{}})
+				}
+				t := &f.Data[n]
+				t.Processes[pid] = proc
+				t.Spans = append(t.Spans, newSpan(s, scope, pid))
+			}
+		}
+	}
+	if f.Data == nil {
+		f.Data = []trace{}
+	}
+	return json.Marshal(f)
+}
+
+func newProcess(r pcommon.Resource) process {
+	p := process{ServiceName: "unknown", Tags: []tag{}}
+	r.Attributes().Range(func(k string, v pcommon.Value) bool {
+		if k == "service.name" {
+			p.ServiceName = v.AsString()
+		} else {
+			p.Tags = append(p.Tags, newTag(k, v))
+		}
+		return true
+	})
+	return p
+}
+
+func newSpan(s ptrace.Span, scope pcommon.InstrumentationScope, pid string) span {
+	out := span{
+		TraceID:       traceID(s.TraceID()),
+		SpanID:        s.SpanID().String(),
+		OperationName: s.Name(),
+		References:    []reference{},
+		Flags:         s.Flags(),
+		StartTime:     int64(s.StartTimestamp()) / 1e3,
+		Duration:      max(int64(s.EndTimestamp())-int64(s.StartTimestamp()), 0) / 1e3,
+		Tags:          []tag{},
+		Logs:          []log{},
+		ProcessID:     pid,
+	}
+	if !s.ParentSpanID().IsEmpty() {
+		out.References = append(out.References, reference{"CHILD_OF", traceID(s.TraceID()), s.ParentSpanID().String()})
+	}
+	for i := 0; i < s.Links().Len(); i++ {
+		l := s.Links().At(i)
+		out.References = append(out.References, reference{"FOLLOWS_FROM", traceID(l.TraceID()), l.SpanID().String()})
+	}
+
+	s.Attributes().Range(func(k string, v pcommon.Value) bool {
+		out.Tags = append(out.Tags, newTag(k, v))
+		return true
+	})
+	if kind := spanKinds[s.Kind()]; kind != "" {
+		out.Tags = append(out.Tags, tag{"span.kind", "string", kind})
+	}
+	switch s.Status().Code() {
+	case ptrace.StatusCodeError:
+		out.Tags = append(out.Tags, tag{"error", "bool", true}, tag{"otel.status_code", "string", "ERROR"})
+	case ptrace.StatusCodeOk:
+		out.Tags = append(out.Tags, tag{"otel.status_code", "string", "OK"})
+	}
+	if msg := s.Status().Message(); msg != "" {
+		out.Tags = append(out.Tags, tag{"otel.status_description", "string", msg})
+	}
+	if scope.Name() != "" {
+		out.Tags = append(out.Tags, tag{"otel.scope.name", "string", scope.Name()})
+	}
+	if scope.Version() != "" {
+		out.Tags = append(out.Tags, tag{"otel.scope.version", "string", scope.Version()})
+	}
+
+	for i := 0; i < s.Events().Len(); i++ {
+		e := s.Events().At(i)
+		l := log{Timestamp: int64(e.Timestamp()) / 1e3, Fields: []tag{{"event", "string", e.Name()}}}
+		e.Attributes().Range(func(k string, v pcommon.Value) bool {
+			l.Fields = append(l.Fields, newTag(k, v))
+			return true
+		})
+		out.Logs = append(out.Logs, l)
+	}
+	return out
+}
+
+// spanKinds are the span.kind tags Jaeger uses; internal spans get none.
+var spanKinds = map[ptrace.SpanKind]string{
+	ptrace.SpanKindServer:   "server",
+	ptrace.SpanKindClient:   "client",
+	ptrace.SpanKindProducer: "producer",
+	ptrace.SpanKindConsumer: "consumer",
+}
+
+// newTag converts an attribute. Jaeger tags have no arrays or maps, so
+// those become their JSON text, as in Jaeger's OTLP receiver.
+func newTag(k string, v pcommon.Value) tag {
+	switch v.Type() {
+	case pcommon.ValueTypeBool:
+		return tag{k, "bool", v.Bool()}
+	case pcommon.ValueTypeInt:
+		return tag{k, "int64", v.Int()}
+	case pcommon.ValueTypeDouble:
+		return tag{k, "float64", v.Double()}
+	case pcommon.ValueTypeBytes:
+		return tag{k, "binary", base64.StdEncoding.EncodeToString(v.Bytes().AsRaw())}
+	}
+	return tag{k, "string", v.AsString()}
+}
+
+// traceID spells id as Jaeger does: a trace ID whose high 64 bits are
+// zero, as 64-bit IDs padded to 128 bits are, has only its low 16 digits.
+func traceID(id pcommon.TraceID) string {
+	s := hex.EncodeToString(id[:])
+	if s[:16] != "0000000000000000" {
+		return s
+	}
+	return s[16:]
+}
@@ -0,0 +1,102 @@
+package jaeger
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestMarshal(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	rs.Resource().Attributes().PutStr("host.name", "web-1")
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("otelhttp")
+
+	root := ss.Spans().AppendEmpty()
+	root.SetTraceID(pcommon.TraceID{15: 1})
+	root.SetSpanID(pcommon.SpanID{7: 1})
+	root.SetName("GET /cart")
+	root.SetKind(ptrace.SpanKindServer)
+	root.SetStartTimestamp(pcommon.Timestamp(1_000_000_000))
+	root.SetEndTimestamp(pcommon.Timestamp(1_250_000_000))
+	root.Attributes().PutInt("http.response.status_code", 500)
+	root.Attributes().PutEmptySlice("tags").AppendEmpty().SetStr("a")
+	root.Status().SetCode(ptrace.StatusCodeError)
+	root.Status().SetMessage("boom")
+	e := root.Events().AppendEmpty()
+	e.SetName("exception")
+	e.SetTimestamp(pcommon.Timestamp(1_100_000_000))
+	e.Attributes().PutBool("exception.escaped", true)
+
+	child := ss.Spans().AppendEmpty()
+	child.SetTraceID(pcommon.TraceID{0: 2, 15: 1})
+	child.SetSpanID(pcommon.SpanID{7: 2})
+	child.SetParentSpanID(root.SpanID())
+	child.SetName("SELECT")
+	l := child.Links().AppendEmpty()
+	l.SetTraceID(root.TraceID())
+	l.SetSpanID(root.SpanID())
+
+	b, err := Marshal(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got file
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Data) != 2 {
+		t.Fatalf("got %d traces, want one per trace ID:\n%s", len(got.Data), b)
+	}
+	if got.Data[0].TraceID != "0000000000000001" || got.Data[1].TraceID != "02000000000000000000000000000001" {
+		t.Errorf("trace IDs = %s, %s", got.Data[0].TraceID, got.Data[1].TraceID)
+	}
+	if p := got.Data[0].Processes["p1"]; p.ServiceName != "checkout" || len(p.Tags) != 1 || p.Tags[0].Key != "host.name" {
+		t.Errorf("process = %+v", p)
+	}
+
+	s := got.Data[0].Spans[0]
+	if s.SpanID != "0000000000000001" || s.OperationName != "GET /cart" || s.StartTime != 1_000_000 || s.Duration != 250_000 || s.ProcessID != "p1" {
+		t.Errorf("span = %+v", s)
+	}
+	tags := map[string]any{}
+	for _, tg := range s.Tags {
+		tags[tg.Key+":"+tg.Type] = tg.Value
+	}
+	want := map[string]any{
+		"http.response.status_code:int64": float64(500),
+		"tags:string":                     `["a"]`,
+		"span.kind:string":                "server",
+		"error:bool":                      true,
+		"otel.status_code:string":         "ERROR",
+		"otel.status_description:string":  "boom",
+		"otel.scope.name:string":          "otelhttp",
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+	if len(s.Logs) != 1 || s.Logs[0].Timestamp != 1_100_000 || len(s.Logs[0].Fields) != 2 || s.Logs[0].Fields[0].Value != "exception" {
+		t.Errorf("logs = %+v", s.Logs)
+	}
+
+	refs := got.Data[1].Spans[0].References
+	wantRefs := []reference{
+		{"CHILD_OF", "02000000000000000000000000000001", "0000000000000001"},
+		{"FOLLOWS_FROM", "0000000000000001", "0000000000000001"},
+	}
+	if !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("references = %+v, want %+v", refs, wantRefs)
+	}
+}
+
+func TestMarshalEmpty(t *testing.T) {
+	b, err := Marshal(ptrace.NewTraces())
+	if err != nil || string(b) != `{"data":[]}` {
+		t.Errorf("Marshal(empty) = %s, %v", b, err)
+	}
+}
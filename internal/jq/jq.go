@@ -0,0 +1,92 @@
+// Package jq evaluates jq expressions against decoded JSON, to pluck values
+// out of OTLP payloads. It wraps github.com/itchyny/gojq, so the whole jq
+// language is available.
+package jq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// Limits on one evaluation, so a runaway expression such as repeat(.)
+// cannot hang the caller.
+const (
+	maxResults = 10000
+	timeout    = 2 * time.Second
+)
+
+// Query is a compiled expression.
+type Query struct {
+	src  string
+	code *gojq.Code
+}
+
+// Parse compiles expr.
+func Parse(expr string) (*Query, error) {
+	q, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("jq %q: %w", expr, err)
+	}
+	code, err := gojq.Compile(q)
+	if err != nil {
+		return nil, fmt.Errorf("jq %q: %w", expr, err)
+	}
+	return &Query{src: expr, code: code}, nil
+}
+
+func (q *Query) String() string { return q.src }
+
+// Run evaluates q against the JSON document doc and returns every result.
+// Numbers keep their precision.
+func (q *Query) Run(doc []byte) ([]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	d := json.NewDecoder(bytes.NewReader(doc))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	var out []any
+	iter := q.code.RunWithContext(ctx, v)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return out, nil
+		}
+		if err, ok := v.(error); ok {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, fmt.Errorf("jq %q: gave up after %v", q.src, timeout)
+			}
+			return nil, err
+		}
+		if len(out) == maxResults {
+			return nil, fmt.Errorf("jq %q: more than %d results", q.src, maxResults)
+		}
+		out = append(out, v)
+	}
+}
+
+// Lines evaluates q against doc and renders each result as indented JSON.
+func (q *Query) Lines(doc []byte) ([]string, error) {
+	vals, err := q.Run(doc)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, v := range vals {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, strings.Split(string(b), "\n")...)
+	}
+	return lines, nil
+}
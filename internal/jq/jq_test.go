@@ -0,0 +1,95 @@
+package jq
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// doc is a trimmed OTLP logs payload.
+const doc = `{"resourceLogs":[{"resource":{"attributes":[
+	{"key":"service.name","value":{"stringValue":"checkout"}},
+	{"key":"host.name","value":{"stringValue":"web-1"}}]},
+ "scopeLogs":[{"logRecords":[
+	{"timeUnixNano":"1704207845000000000","severityNumber":9,"body":{"stringValue":"order placed"}},
+	{"timeUnixNano":"1704207846000000000","severityNumber":17,"body":{"stringValue":"card declined"}}]}]}]}`
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want string // results as compact JSON, one per line
+	}{
+		{`.resourceLogs | length`, `1`},
+		{`.resourceLogs[0].resource.attributes[] | select(.key == "service.name") | .value.stringValue`, `"checkout"`},
+		{`.resourceLogs[0].resource.attributes[] | select(.key != "service.name") | .key`, `"host.name"`},
+		{`.resourceLogs[].scopeLogs[].logRecords[].body.stringValue`, "\"order placed\"\n\"card declined\""},
+		{`.resourceLogs[].scopeLogs[].logRecords[-1].severityNumber`, `17`},
+		{`[.. | .severityNumber? // empty] | add`, `26`},
+		{`.resourceLogs[0] | keys`, `["resource","scopeLogs"]`},
+		{`.resourceLogs[0]."resource".attributes | map(.key)`, `["service.name","host.name"]`},
+		{`.missing.deeper`, `null`},
+		{`.resourceLogs[].scopeLogs[].logRecords[] | select(.severityNumber >= 17) | .timeUnixNano`, `"1704207846000000000"`},
+		{`.resourceLogs[].scopeLogs[].logRecords[] | select(.severityNumber > 99)`, ``},
+	} {
+		q, err := Parse(tc.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.expr, err)
+			continue
+		}
+		vals, err := q.Run([]byte(doc))
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		var got []string
+		for _, v := range vals {
+			b, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(b))
+		}
+		if g := strings.Join(got, "\n"); g != tc.want {
+			t.Errorf("%s = %s, want %s", tc.expr, g, tc.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, expr := range []string{`.[`, `select(`, `nosuchfunction`} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+	for _, expr := range []string{`.resourceLogs.foo`, `.resourceLogs[0] | length | keys`, `error("boom")`, `repeat(.)`} {
+		q, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", expr, err)
+		}
+		if _, err := q.Run([]byte(doc)); err == nil {
+			t.Errorf("%s succeeded", expr)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	q, err := Parse(`.resourceLogs[0].resource.attributes[0]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := q.Lines([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{`,
+		`  "key": "service.name",`,
+		`  "value": {`,
+		`    "stringValue": "checkout"`,
+		`  }`,
+		`}`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Lines =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
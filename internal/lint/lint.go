@@ -0,0 +1,334 @@
+// Package lint checks telemetry against the OpenTelemetry semantic
+// conventions and the OTLP data model: resources without service.name,
+// attribute names that are not lowercase dotted words or are deprecated,
+// invalid span status codes and IDs, and the like. The TUI shows what it
+// finds in a panel, and otail lint reports it for a recorded file.
+package lint
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	plog "go.opentelemetry.io/collector/pdata/plog"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Rule is one check.
+type Rule struct {
+	Name        string
+	Description string
+}
+
+// Rules lists every check, in the order reports list them when their
+// counts tie.
+var Rules = []Rule{
+	{"missing-service-name", "resource has no service.name"},
+	{"attribute-name", "attribute key is not lowercase words separated by dots"},
+	{"deprecated-attribute", "attribute key was renamed by the semantic conventions"},
+	{"metric-name", "metric name is not lowercase words separated by dots"},
+	{"invalid-status-code", "span status code is not UNSET, OK, or ERROR"},
+	{"status-message-without-error", "span status has a message but is not ERROR"},
+	{"invalid-trace-id", "span has an empty trace or span ID"},
+	{"empty-span-name", "span has no name"},
+	{"span-ends-before-start", "span ends before it starts"},
+	{"missing-timestamp", "log record has neither a time nor an observed time"},
+	{"invalid-severity", "log severity number is outside 1-24"},
+	{"empty-metric", "metric has no data points"},
+}
+
+// Violation is one thing a check found.
+type Violation struct {
+	Rule    string
+	Kind    telemetry.Kind
+	Service string
+	Detail  string // what was wrong, as in `attribute "HTTP.Method"`
+}
+
+// namePattern is how the semantic conventions spell attribute and metric
+// names: lowercase words joined by dots, with underscores inside words.
+// Later words may hold hyphens too, as HTTP header names do in
+// http.request.header.content-type.
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z0-9_][a-z0-9_-]*)*$`)
+
+// deprecated maps attribute keys the semantic conventions renamed to their
+// replacements.
+var deprecated = map[string]string{
+	"http.method":                  "http.request.method",
+	"http.status_code":             "http.response.status_code",
+	"http.url":                     "url.full",
+	"http.target":                  "url.path",
+	"http.scheme":                  "url.scheme",
+	"http.flavor":                  "network.protocol.version",
+	"http.user_agent":              "user_agent.original",
+	"http.client_ip":               "client.address",
+	"http.request_content_length":  "http.request.body.size",
+	"http.response_content_length": "http.response.body.size",
+	"net.peer.name":                "server.address",
+	"net.peer.port":                "server.port",
+	"net.host.name":                "server.address",
+	"net.host.port":                "server.port",
+	"net.sock.peer.addr":           "network.peer.address",
+	"net.sock.peer.port":           "network.peer.port",
+	"net.protocol.name":            "network.protocol.name",
+	"net.protocol.version":         "network.protocol.version",
+	"db.statement":                 "db.query.text",
+	"db.operation":                 "db.operation.name",
+	"db.name":                      "db.namespace",
+	"db.system":                    "db.system.name",
+	"deployment.environment":       "deployment.environment.name",
+	"message.type":                 "rpc.message.type",
+	"messaging.message_id":         "messaging.message.id",
+}
+
+// checker collects the violations in one message.
+type checker struct {
+	kind    telemetry.Kind
+	service string
+	out     []Violation
+}
+
+func (c *checker) add(rule, format string, args ...any) {
+	c.out = append(c.out, Violation{Rule: rule, Kind: c.kind, Service: c.service, Detail: fmt.Sprintf(format, args...)})
+}
+
+// Check returns what every check finds in msg. Frames that are not OTLP
+// have nothing to check.
+func Check(msg telemetry.Message) []Violation {
+	c := &checker{kind: msg.Kind}
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		rls := msg.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			c.resource(rls.At(i).Resource())
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				c.attributes(sls.At(j).Scope().Attributes())
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					c.log(lrs.At(k))
+				}
+			}
+		}
+	case telemetry.KindTraces:
+		rss := msg.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			c.resource(rss.At(i).Resource())
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				c.attributes(sss.At(j).Scope().Attributes())
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					c.span(spans.At(k))
+				}
+			}
+		}
+	case telemetry.KindMetrics:
+		rms := msg.Metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			c.resource(rms.At(i).Resource())
+			sms := rms.At(i).ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				c.attributes(sms.At(j).Scope().Attributes())
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					c.metric(ms.At(k))
+				}
+			}
+		}
+	}
+	return c.out
+}
+
+func (c *checker) resource(r pcommon.Resource) {
+	c.service = ""
+	if v, ok := r.Attributes().Get("service.name"); ok {
+		c.service = v.AsString()
+	}
+	if c.service == "" {
+		c.add("missing-service-name", "resource without service.name")
+	}
+	c.attributes(r.Attributes())
+}
+
+func (c *checker) attributes(attrs pcommon.Map) {
+	attrs.Range(func(k string, _ pcommon.Value) bool {
+		if to, ok := deprecated[k]; ok {
+			c.add("deprecated-attribute", "attribute %q, now %q", k, to)
+		} else if !namePattern.MatchString(k) {
+			c.add("attribute-name", "attribute %q", k)
+		}
+		return true
+	})
+}
+
+func (c *checker) log(lr plog.LogRecord) {
+	c.attributes(lr.Attributes())
+	if lr.Timestamp() == 0 && lr.ObservedTimestamp() == 0 {
+		c.add("missing-timestamp", "log record %q", shorten(lr.Body().AsString()))
+	}
+	if n := lr.SeverityNumber(); n < 0 || n > 24 {
+		c.add("invalid-severity", "severity number %d", n)
+	}
+}
+
+func (c *checker) span(s ptrace.Span) {
+	c.attributes(s.Attributes())
+	name := s.Name()
+	if name == "" {
+		c.add("empty-span-name", "span %s", s.SpanID())
+		name = s.SpanID().String()
+	}
+	switch code := s.Status().Code(); code {
+	case ptrace.StatusCodeUnset, ptrace.StatusCodeOk:
+		if s.Status().Message() != "" {
+			c.add("status-message-without-error", "span %q is %s with message %q", name, code, shorten(s.Status().Message()))
+		}
+	case ptrace.StatusCodeError:
+	default:
+		c.add("invalid-status-code", "span %q has status code %d", name, int32(code))
+	}
+	if s.TraceID().IsEmpty() || s.SpanID().IsEmpty() {
+		c.add("invalid-trace-id", "span %q", name)
+	}
+	if s.EndTimestamp() < s.StartTimestamp() {
+		c.add("span-ends-before-start", "span %q", name)
+	}
+	events := s.Events()
+	for i := 0; i < events.Len(); i++ {
+		c.attributes(events.At(i).Attributes())
+	}
+	links := s.Links()
+	for i := 0; i < links.Len(); i++ {
+		c.attributes(links.At(i).Attributes())
+	}
+}
+
+func (c *checker) metric(m pmetric.Metric) {
+	if !namePattern.MatchString(m.Name()) {
+		c.add("metric-name", "metric %q", m.Name())
+	}
+	var n int
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		n = dps.Len()
+		for i := 0; i < n; i++ {
+			c.attributes(dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		n = dps.Len()
+		for i := 0; i < n; i++ {
+			c.attributes(dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		n = dps.Len()
+		for i := 0; i < n; i++ {
+			c.attributes(dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		n = dps.Len()
+		for i := 0; i < n; i++ {
+			c.attributes(dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		n = dps.Len()
+		for i := 0; i < n; i++ {
+			c.attributes(dps.At(i).Attributes())
+		}
+	}
+	if n == 0 {
+		c.add("empty-metric", "metric %q", m.Name())
+	}
+}
+
+// shorten cuts s to a length that fits in a report line.
+func shorten(s string) string {
+	const maxLen = 40
+	if r := []rune(s); len(r) > maxLen {
+		return string(r[:maxLen-1]) + "…"
+	}
+	return s
+}
+
+// maxExamples is how many distinct details a Finding keeps.
+const maxExamples = 3
+
+// Finding is how often one rule was broken.
+type Finding struct {
+	Rule     Rule
+	Count    int
+	Services []string // sorted; "" is a resource without service.name
+	Examples []string // the first few distinct details
+}
+
+// Report tallies the violations in every message added to it.
+type Report struct {
+	Messages int // checked
+	findings map[string]*Finding
+}
+
+// Add checks msg and counts what it finds, returning that.
+func (r *Report) Add(msg telemetry.Message) []Violation {
+	if r.findings == nil {
+		r.findings = map[string]*Finding{}
+	}
+	r.Messages++
+	vs := Check(msg)
+	for _, v := range vs {
+		f := r.findings[v.Rule]
+		if f == nil {
+			f = &Finding{Rule: ruleNamed(v.Rule)}
+			r.findings[v.Rule] = f
+		}
+		f.Count++
+		if i, found := slices.BinarySearch(f.Services, v.Service); !found {
+			f.Services = slices.Insert(f.Services, i, v.Service)
+		}
+		if len(f.Examples) < maxExamples && !slices.Contains(f.Examples, v.Detail) {
+			f.Examples = append(f.Examples, v.Detail)
+		}
+	}
+	return vs
+}
+
+// Total is how many violations were found.
+func (r *Report) Total() int {
+	n := 0
+	for _, f := range r.findings {
+		n += f.Count
+	}
+	return n
+}
+
+// Findings returns every rule that was broken, most often first.
+func (r *Report) Findings() []Finding {
+	out := make([]Finding, 0, len(r.findings))
+	for _, f := range r.findings {
+		out = append(out, *f)
+	}
+	slices.SortFunc(out, func(a, b Finding) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(ruleIndex(a.Rule.Name), ruleIndex(b.Rule.Name)))
+	})
+	return out
+}
+
+func ruleIndex(name string) int {
+	return slices.IndexFunc(Rules, func(r Rule) bool { return r.Name == name })
+}
+
+func ruleNamed(name string) Rule {
+	if i := ruleIndex(name); i >= 0 {
+		return Rules[i]
+	}
+	return Rule{Name: name}
+}
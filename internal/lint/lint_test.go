@@ -0,0 +1,115 @@
+package lint
+
+import (
+	"slices"
+	"testing"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	plog "go.opentelemetry.io/collector/pdata/plog"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+)
+
+// rules returns the rule of each violation, in order.
+func rules(vs []Violation) []string {
+	var out []string
+	for _, v := range vs {
+		out = append(out, v.Rule)
+	}
+	return out
+}
+
+func TestCheckClean(t *testing.T) {
+	for _, frame := range [][]byte{
+		testutil.Log("checkout", "info", "order placed"),
+		testutil.Span("checkout", "GET /cart", 0),
+		testutil.Gauge("checkout", "queue.depth", 3),
+		[]byte("not OTLP"),
+	} {
+		if vs := Check(telemetry.Parse(frame)); len(vs) > 0 {
+			t.Errorf("Check(%s) = %v, want nothing", frame, vs)
+		}
+	}
+}
+
+func TestCheckSpans(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("HostName", "web-1")
+	s := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	s.Attributes().PutStr("http.method", "GET")
+	s.Attributes().PutStr("http.request.header.content-type", "text/plain")
+	s.Status().SetCode(ptrace.StatusCodeOk)
+	s.Status().SetMessage("fine")
+	s.SetStartTimestamp(pcommon.Timestamp(2))
+	s.SetEndTimestamp(pcommon.Timestamp(1))
+
+	vs := Check(telemetry.Message{Kind: telemetry.KindTraces, Traces: td})
+	want := []string{"missing-service-name", "attribute-name", "deprecated-attribute", "empty-span-name", "status-message-without-error", "invalid-trace-id", "span-ends-before-start"}
+	if got := rules(vs); !slices.Equal(got, want) {
+		t.Errorf("rules = %q, want %q", got, want)
+	}
+	if got := vs[2].Detail; got != `attribute "http.method", now "http.request.method"` {
+		t.Errorf("deprecated-attribute detail = %q", got)
+	}
+
+	s.Status().SetCode(ptrace.StatusCode(7))
+	if got := rules(Check(telemetry.Message{Kind: telemetry.KindTraces, Traces: td})); !slices.Contains(got, "invalid-status-code") {
+		t.Errorf("rules = %q, want invalid-status-code", got)
+	}
+}
+
+func TestCheckLogsAndMetrics(t *testing.T) {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	lr := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetSeverityNumber(plog.SeverityNumber(30))
+	if got, want := rules(Check(telemetry.Message{Kind: telemetry.KindLogs, Logs: ld})), []string{"missing-timestamp", "invalid-severity"}; !slices.Equal(got, want) {
+		t.Errorf("log rules = %q, want %q", got, want)
+	}
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("Requests_Total")
+	m.SetEmptyGauge()
+	if got, want := rules(Check(telemetry.Message{Kind: telemetry.KindMetrics, Metrics: md})), []string{"metric-name", "empty-metric"}; !slices.Equal(got, want) {
+		t.Errorf("metric rules = %q, want %q", got, want)
+	}
+}
+
+func TestReport(t *testing.T) {
+	var r Report
+	bad := func(service, key string) telemetry.Message {
+		ld := plog.NewLogs()
+		rl := ld.ResourceLogs().AppendEmpty()
+		if service != "" {
+			rl.Resource().Attributes().PutStr("service.name", service)
+		}
+		lr := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+		lr.SetTimestamp(1)
+		lr.Attributes().PutStr(key, "x")
+		return telemetry.Message{Kind: telemetry.KindLogs, Logs: ld}
+	}
+	r.Add(bad("checkout", "A"))
+	r.Add(bad("payments", "B"))
+	r.Add(bad("", "C"))
+	r.Add(bad("checkout", "A"))
+	r.Add(telemetry.Parse(testutil.Log("checkout", "info", "order placed")))
+
+	if r.Messages != 5 || r.Total() != 5 {
+		t.Errorf("Messages, Total = %d, %d; want 5, 5", r.Messages, r.Total())
+	}
+	fs := r.Findings()
+	if len(fs) != 2 || fs[0].Rule.Name != "attribute-name" || fs[1].Rule.Name != "missing-service-name" {
+		t.Fatalf("Findings = %+v, want attribute-name then missing-service-name", fs)
+	}
+	if f := fs[0]; f.Count != 4 || !slices.Equal(f.Services, []string{"", "checkout", "payments"}) || len(f.Examples) != 3 {
+		t.Errorf("attribute-name finding = %+v", f)
+	}
+}
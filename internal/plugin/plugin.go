@@ -0,0 +1,311 @@
+// Package plugin runs external commands that annotate the frames otail
+// receives, so they can be enriched, re-leveled, or dropped without
+// changing otail. A plugin reads every frame from stdin as one line of
+// OTLP JSON and answers each with one line of JSON, an Annotation:
+//
+//	{"drop": true}
+//	{"tags": ["pci"], "attributes": {"team": "payments"}, "severity": "error"}
+//	{}
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	plog "go.opentelemetry.io/collector/pdata/plog"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// answerTimeout is how long a plugin has to answer for a frame before it
+// is given up on.
+const answerTimeout = 5 * time.Second
+
+// TagsKey is the resource attribute that tags are added to.
+const TagsKey = "otail.tags"
+
+// Annotation is a plugin's answer for one frame. The zero Annotation keeps
+// the frame as it is.
+type Annotation struct {
+	Drop       bool              `json:"drop,omitempty"`       // leave the frame out
+	Tags       []string          `json:"tags,omitempty"`       // appended to every resource's otail.tags
+	Attributes map[string]string `json:"attributes,omitempty"` // set on every resource
+	Severity   string            `json:"severity,omitempty"`   // given to every log record, as in severity>=LEVEL
+}
+
+// Plugin is a running plugin command. Once it fails, by exiting, answering
+// with something other than an Annotation, or not answering in time, it is
+// stopped and frames pass it by unchanged.
+type Plugin struct {
+	command string
+	logger  *log.Logger
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	answers chan []byte   // one per line the plugin writes; closed at EOF
+	done    chan struct{} // closed once the plugin is stopped
+	failed  bool
+}
+
+// Start runs command with sh -c. logger receives what the plugin writes
+// to stderr and why it failed; nil discards them.
+func Start(command string, logger *log.Logger) (*Plugin, error) {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	cmd := exec.Command("sh", "-c", command)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = logWriter{logger, command}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %q: %w", command, err)
+	}
+	p := &Plugin{command: command, logger: logger, cmd: cmd, stdin: stdin, answers: make(chan []byte, 1), done: make(chan struct{})}
+	go p.read(stdout)
+	return p, nil
+}
+
+// read passes on each line the plugin writes.
+func (p *Plugin) read(stdout io.Reader) {
+	defer close(p.answers)
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		select {
+		case p.answers <- bytes.Clone(sc.Bytes()):
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Annotate sends frame to the plugin and returns its answer.
+func (p *Plugin) Annotate(frame []byte) (Annotation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failed {
+		return Annotation{}, errors.New("plugin stopped")
+	}
+	a, err := p.annotate(frame)
+	if err != nil {
+		p.failed = true
+		p.logger.Printf("plugin %q: %v; passing frames through", p.command, err)
+		p.stop()
+	}
+	return a, err
+}
+
+func (p *Plugin) annotate(frame []byte) (Annotation, error) {
+	var line bytes.Buffer
+	if err := json.Compact(&line, frame); err != nil {
+		return Annotation{}, nil // not JSON, so not for plugins
+	}
+	line.WriteByte('\n')
+	// A plugin that stops reading blocks the write, so it is timed too.
+	timeout := time.After(answerTimeout)
+	written := make(chan error, 1)
+	go func() {
+		_, err := p.stdin.Write(line.Bytes())
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			return Annotation{}, err
+		}
+	case <-timeout:
+		return Annotation{}, fmt.Errorf("not reading stdin within %s", answerTimeout)
+	}
+	var answer []byte
+	select {
+	case b, ok := <-p.answers:
+		if !ok {
+			return Annotation{}, errors.New("exited")
+		}
+		answer = b
+	case <-timeout:
+		return Annotation{}, fmt.Errorf("no answer within %s", answerTimeout)
+	}
+	var a Annotation
+	if err := json.Unmarshal(answer, &a); err != nil {
+		return Annotation{}, fmt.Errorf("answer %q: %w", answer, err)
+	}
+	if a.Severity != "" {
+		if _, err := filter.ParseSeverity(a.Severity); err != nil {
+			return Annotation{}, fmt.Errorf("answer %q: %w", answer, err)
+		}
+	}
+	return a, nil
+}
+
+// Close stops the plugin.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failed = true
+	return p.stop()
+}
+
+// stop closes the plugin's stdin, which asks it to exit, and kills it if
+// it has not shortly after.
+func (p *Plugin) stop() error {
+	if p.cmd == nil {
+		return nil
+	}
+	cmd := p.cmd
+	p.cmd = nil
+	close(p.done)
+	p.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return nil // it has had its say on stderr
+		}
+		return err
+	case <-time.After(time.Second):
+		cmd.Process.Signal(os.Kill)
+		return <-done
+	}
+}
+
+// logWriter logs each write from a plugin's stderr.
+type logWriter struct {
+	logger  *log.Logger
+	command string
+}
+
+func (w logWriter) Write(b []byte) (int, error) {
+	w.logger.Printf("plugin %q: %s", w.command, bytes.TrimRight(b, "\n"))
+	return len(b), nil
+}
+
+// Chain runs every frame through each plugin in turn.
+type Chain []*Plugin
+
+// Process returns frame as the plugins left it, and false if one dropped
+// it. A plugin that fails lets frames by unchanged.
+func (c Chain) Process(frame []byte) ([]byte, bool) {
+	for _, p := range c {
+		a, err := p.Annotate(frame)
+		if err != nil {
+			continue
+		}
+		var keep bool
+		if frame, keep = Apply(frame, a); !keep {
+			return nil, false
+		}
+	}
+	return frame, true
+}
+
+// Close stops every plugin.
+func (c Chain) Close() error {
+	var errs []error
+	for _, p := range c {
+		errs = append(errs, p.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Apply returns frame with a's tags, attributes, and severity applied, and
+// false if a drops it. A frame that is not OTLP JSON, or an annotation
+// with nothing to change, leaves frame as it is.
+func Apply(frame []byte, a Annotation) ([]byte, bool) {
+	if a.Drop {
+		return nil, false
+	}
+	if len(a.Tags) == 0 && len(a.Attributes) == 0 && a.Severity == "" {
+		return frame, true
+	}
+	msg := telemetry.Parse(frame)
+	var (
+		out []byte
+		err error
+	)
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		rls := msg.Logs.ResourceLogs()
+		for i := range rls.Len() {
+			annotate(rls.At(i).Resource().Attributes(), a)
+			setSeverity(rls.At(i).ScopeLogs(), a.Severity)
+		}
+		out, err = (&plog.JSONMarshaler{}).MarshalLogs(msg.Logs)
+	case telemetry.KindMetrics:
+		rms := msg.Metrics.ResourceMetrics()
+		for i := range rms.Len() {
+			annotate(rms.At(i).Resource().Attributes(), a)
+		}
+		out, err = (&pmetric.JSONMarshaler{}).MarshalMetrics(msg.Metrics)
+	case telemetry.KindTraces:
+		rss := msg.Traces.ResourceSpans()
+		for i := range rss.Len() {
+			annotate(rss.At(i).Resource().Attributes(), a)
+		}
+		out, err = (&ptrace.JSONMarshaler{}).MarshalTraces(msg.Traces)
+	default:
+		return frame, true
+	}
+	if err != nil {
+		return frame, true
+	}
+	return out, true
+}
+
+// annotate adds a's tags and attributes to the resource attributes attrs.
+func annotate(attrs pcommon.Map, a Annotation) {
+	for k, v := range a.Attributes {
+		attrs.PutStr(k, v)
+	}
+	if len(a.Tags) == 0 {
+		return
+	}
+	var tags pcommon.Slice
+	if v, ok := attrs.Get(TagsKey); ok && v.Type() == pcommon.ValueTypeSlice {
+		tags = v.Slice()
+	} else {
+		tags = attrs.PutEmptySlice(TagsKey)
+	}
+	for _, t := range a.Tags {
+		tags.AppendEmpty().SetStr(t)
+	}
+}
+
+// setSeverity gives every log record in sls the severity named by level,
+// at the start of its OTLP range; "" leaves them alone.
+func setSeverity(sls plog.ScopeLogsSlice, level string) {
+	sev, err := filter.ParseSeverity(level)
+	if err != nil {
+		return
+	}
+	n := plog.SeverityNumber((int(sev)-1)*4 + 1)
+	for i := range sls.Len() {
+		lrs := sls.At(i).LogRecords()
+		for j := range lrs.Len() {
+			lrs.At(j).SetSeverityNumber(n)
+			lrs.At(j).SetSeverityText(sev.String())
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+)
+
+func start(t *testing.T, command string) *Plugin {
+	t.Helper()
+	p, err := Start(command, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestApply(t *testing.T) {
+	frame := testutil.Log("checkout", "info", "order placed")
+	if _, keep := Apply(frame, Annotation{Drop: true}); keep {
+		t.Error("Apply kept a dropped frame")
+	}
+	if out, keep := Apply(frame, Annotation{}); !keep || string(out) != string(frame) {
+		t.Errorf("Apply of an empty annotation = %s, %v; want the frame unchanged", out, keep)
+	}
+
+	out, keep := Apply(frame, Annotation{Tags: []string{"pci", "eu"}, Attributes: map[string]string{"team": "payments"}, Severity: "warn"})
+	msg := telemetry.Parse(out)
+	if !keep || msg.Kind != telemetry.KindLogs {
+		t.Fatalf("Apply = %s, %v; want annotated logs", out, keep)
+	}
+	rl := msg.Logs.ResourceLogs().At(0)
+	attrs := rl.Resource().Attributes().AsRaw()
+	if tags, _ := attrs[TagsKey].([]any); len(tags) != 2 || tags[0] != "pci" || tags[1] != "eu" || attrs["team"] != "payments" {
+		t.Errorf("resource attributes = %v, want the tags and team", attrs)
+	}
+	lr := rl.ScopeLogs().At(0).LogRecords().At(0)
+	if got := telemetry.SeverityOf(lr.SeverityNumber()); got != telemetry.SeverityWarn || lr.SeverityText() != "WARN" {
+		t.Errorf("severity = %v %q, want WARN", got, lr.SeverityText())
+	}
+
+	// Tags add to those an earlier plugin gave.
+	again, _ := Apply(out, Annotation{Tags: []string{"late"}})
+	tags := telemetry.Parse(again).Logs.ResourceLogs().At(0).Resource().Attributes().AsRaw()[TagsKey]
+	if got, _ := tags.([]any); len(got) != 3 {
+		t.Errorf("tags after a second Apply = %v, want three", tags)
+	}
+
+	if out, keep := Apply([]byte("not OTLP"), Annotation{Tags: []string{"x"}}); !keep || string(out) != "not OTLP" {
+		t.Errorf("Apply of a non-OTLP frame = %q, %v; want it unchanged", out, keep)
+	}
+}
+
+func TestChain(t *testing.T) {
+	c := Chain{start(t, `while read -r line; do
+		case "$line" in
+		*debug*) echo '{"drop": true}' ;;
+		*) echo '{"tags": ["seen"]}' ;;
+		esac
+	done`)}
+	if _, keep := c.Process(testutil.Log("checkout", "debug", "cache warm")); keep {
+		t.Error("a frame the plugin dropped was kept")
+	}
+	out, keep := c.Process(testutil.Log("checkout", "info", "order placed"))
+	if !keep || !strings.Contains(string(out), `"seen"`) {
+		t.Errorf("Process = %s, %v; want the frame tagged", out, keep)
+	}
+}
+
+func TestFailedPlugin(t *testing.T) {
+	for _, command := range []string{
+		"exit 0",
+		`read -r line; echo 'not json'; cat >/dev/null`,
+		`read -r line; echo '{"severity": "loud"}'; cat >/dev/null`,
+	} {
+		p := start(t, command)
+		frame := testutil.Log("checkout", "info", "order placed")
+		if _, err := p.Annotate(frame); err == nil {
+			t.Errorf("%q: Annotate succeeded", command)
+		}
+		// From then on, frames pass by without waiting on the plugin.
+		begin := time.Now()
+		if out, keep := (Chain{p}).Process(frame); !keep || string(out) != string(frame) || time.Since(begin) > time.Second {
+			t.Errorf("%q: Process after a failure = %s, %v; want the frame unchanged at once", command, out, keep)
+		}
+	}
+}
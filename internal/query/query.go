@@ -0,0 +1,382 @@
+// Package query parses otail's query language, which combines text,
+// attributes, and time in one line:
+//
+//	"gateway timeout" service=api dur>200ms last:5m kind:traces
+//
+// Terms are separated by spaces (or &, so the older service=api&q=x form
+// still reads the same) and must all hold. Bare words and "quoted phrases"
+// are searched for as text, each on its own; FIELD:VALUE and FIELD=VALUE set a condition.
+// The TUI search bar, --filter, :filter, and the web server's query
+// parameters all go through Parse, so a query means the same everywhere.
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Error is a syntax error at a column of the query.
+type Error struct {
+	Col int // 1-based, in characters
+	Msg string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("col %d: %s", e.Col, e.Msg) }
+
+// term is one space-separated piece of a query.
+type term struct {
+	pos    int    // byte offset in the query
+	key    string // field name; "" for text
+	op     string // ":", "=", ">", ">=", "<", or "<="; "" for text
+	val    string // unquoted
+	valPos int    // byte offset of the value
+}
+
+// fields are the names Parse knows, besides attr.NAME.
+var fields = map[string]bool{
+	"q": true, "text": true, "service": true, "service.name": true,
+	"severity": true, "sev": true, "level": true, "trace": true, "trace_id": true,
+	"kind": true, "signal": true, "dur": true, "duration": true, "last": true, "since": true,
+}
+
+var traceRE = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Parse reads a query. The zero Filter, from an empty query, matches
+// everything. Errors are *Error.
+func Parse(s string) (filter.Filter, error) {
+	var f filter.Filter
+	terms, err := split(s)
+	if err != nil {
+		return filter.Filter{}, err
+	}
+	var text []string
+	for _, t := range terms {
+		fail := func(pos int, format string, args ...any) error {
+			return &Error{Col: utf8.RuneCountInString(s[:pos]) + 1, Msg: fmt.Sprintf(format, args...)}
+		}
+		if t.key == "" {
+			if t.val != "" {
+				text = append(text, t.val)
+			}
+			continue
+		}
+		key := strings.ToLower(t.key)
+		if t.op == "!=" {
+			return filter.Filter{}, fail(t.pos, "%s!=: negation is not supported", t.key)
+		}
+		if t.val == "" && (fields[key] || t.op != ":") {
+			return filter.Filter{}, fail(t.valPos, "%s%s needs a value", t.key, t.op)
+		}
+		switch {
+		case key == "q" || key == "text":
+			if err := want(t, ":", "="); err != nil {
+				return filter.Filter{}, fail(t.pos, "%v", err)
+			}
+			text = append(text, t.val)
+		case key == "service" || key == "service.name":
+			if err := want(t, ":", "="); err != nil {
+				return filter.Filter{}, fail(t.pos, "%v", err)
+			}
+			f.Service = t.val
+		case key == "severity" || key == "sev" || key == "level":
+			if err := want(t, ":", "=", ">="); err != nil {
+				return filter.Filter{}, fail(t.pos, "%v", err)
+			}
+			sev, err := filter.ParseSeverity(t.val)
+			if err != nil {
+				return filter.Filter{}, fail(t.valPos, "%v; want trace, debug, info, warn, error, or fatal", err)
+			}
+			f.Severity = sev
+		case key == "trace" || key == "trace_id":
+			if err := want(t, ":", "="); err != nil {
+				return filter.Filter{}, fail(t.pos, "%v", err)
+			}
+			id := strings.ToLower(t.val)
+			if !traceRE.MatchString(id) {
+				return filter.Filter{}, fail(t.valPos, "trace %q: want 32 hex digits", t.val)
+			}
+			f.TraceID = id
+		case key == "kind" || key == "signal":
+			if err := want(t, ":", "="); err != nil {
+				return filter.Filter{}, fail(t.pos, "%v", err)
+			}
+			for _, name := range strings.Split(t.val, ",") {
+				k, err := telemetry.ParseKind(name)
+				if err != nil {
+					return filter.Filter{}, fail(t.valPos, "%v; want logs, metrics, traces, or unknown", err)
+				}
+				if !slices.Contains(f.Kinds, k) {
+					f.Kinds = append(f.Kinds, k)
+				}
+			}
+			slices.Sort(f.Kinds)
+		case key == "dur" || key == "duration":
+			if err := want(t, ">", ">=", "<", "<="); err != nil {
+				return filter.Filter{}, fail(t.pos, "%v", err)
+			}
+			d, err := duration(t.val)
+			if err != nil {
+				return filter.Filter{}, fail(t.valPos, "%v", err)
+			}
+			switch t.op {
+			case ">":
+				f.MinDuration = d + 1
+			case ">=":
+				f.MinDuration = d
+			case "<":
+				if d <= 1 {
+					return filter.Filter{}, fail(t.valPos, "no duration is shorter than %v", d)
+				}
+				f.MaxDuration = d - 1
+			case "<=":
+				f.MaxDuration = d
+			}
+		case key == "last" || key == "since":
+			if err := want(t, ":", "="); err != nil {
+				return filter.Filter{}, fail(t.pos, "%v", err)
+			}
+			d, err := duration(t.val)
+			if err != nil {
+				return filter.Filter{}, fail(t.valPos, "%v", err)
+			}
+			f.Last = d
+		case strings.HasPrefix(key, "attr.") || t.op == "=":
+			if err := want(t, ":", "="); err != nil {
+				return filter.Filter{}, fail(t.pos, "%v", err)
+			}
+			name := t.key
+			if strings.HasPrefix(key, "attr.") {
+				name = t.key[len("attr."):]
+			}
+			if name == "" {
+				return filter.Filter{}, fail(t.pos, "attr. needs an attribute name, as in attr.http.route=/cart")
+			}
+			if f.Attrs == nil {
+				f.Attrs = map[string]string{}
+			}
+			f.Attrs[name] = t.val
+		default:
+			return filter.Filter{}, fail(t.pos, "unknown field %q; write %s=VALUE to match an attribute, or quote the text", t.key, t.key)
+		}
+	}
+	if f.MinDuration > 0 && f.MaxDuration > 0 && f.MinDuration > f.MaxDuration {
+		return filter.Filter{}, &Error{Col: 1, Msg: fmt.Sprintf("no duration is both at least %v and at most %v", f.MinDuration, f.MaxDuration)}
+	}
+	f.Texts = text
+	return f, nil
+}
+
+// want checks that t uses one of ops.
+func want(t term, ops ...string) error {
+	if slices.Contains(ops, t.op) {
+		return nil
+	}
+	alts := make([]string, len(ops))
+	for i, op := range ops {
+		alts[i] = t.key + op
+	}
+	return fmt.Errorf("%s%s: want %s", t.key, t.op, strings.Join(alts, " or "))
+}
+
+// duration reads a positive Go duration such as 200ms or 5m.
+func duration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%q is not a duration; want one such as 200ms or 5m", s)
+	}
+	return d, nil
+}
+
+// split cuts s into terms at spaces and & outside quotes.
+func split(s string) ([]term, error) {
+	var terms []term
+	for i := 0; i < len(s); {
+		if isSep(s[i]) {
+			i++
+			continue
+		}
+		t := term{pos: i}
+		var val strings.Builder
+		quoted := false
+		for i < len(s) && !isSep(s[i]) {
+			c := s[i]
+			switch {
+			case c == '"':
+				quoted = true
+				open := i
+				i++
+				for ; i < len(s) && s[i] != '"'; i++ {
+					if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+						i++
+					}
+					val.WriteByte(s[i])
+				}
+				if i == len(s) {
+					return nil, &Error{Col: utf8.RuneCountInString(s[:open]) + 1, Msg: "unterminated quote"}
+				}
+				i++
+			case t.op == "" && !quoted && (strings.IndexByte(":=<>", c) >= 0 || strings.HasPrefix(s[i:], "!=")):
+				t.key = val.String()
+				val.Reset()
+				t.op = string(c)
+				if i+1 < len(s) && s[i+1] == '=' && c != ':' && c != '=' {
+					t.op += "="
+				}
+				if t.key == "" {
+					return nil, &Error{Col: utf8.RuneCountInString(s[:i]) + 1, Msg: fmt.Sprintf("%s needs a field name before it", t.op)}
+				}
+				i += len(t.op)
+				t.valPos = i
+			default:
+				val.WriteByte(c)
+				i++
+			}
+		}
+		t.val = val.String()
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+func isSep(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '&' }
+
+// Format renders f as a query Parse reads back to an equal filter.
+func Format(f filter.Filter) string {
+	var terms []string
+	if len(f.Kinds) > 0 {
+		names := make([]string, len(f.Kinds))
+		for i, k := range slices.Sorted(slices.Values(f.Kinds)) {
+			names[i] = k.String()
+		}
+		terms = append(terms, "kind:"+strings.Join(names, ","))
+	}
+	if f.Service != "" {
+		terms = append(terms, "service:"+quote(f.Service))
+	}
+	if f.Severity != telemetry.SeverityUnset {
+		terms = append(terms, "severity>="+strings.ToLower(f.Severity.String()))
+	}
+	if f.TraceID != "" {
+		terms = append(terms, "trace:"+f.TraceID)
+	}
+	// dur>200ms reads better than dur>=200.000001ms, so use the strict
+	// form when it is shorter.
+	if d := f.MinDuration; d > 0 {
+		if len((d - 1).String()) < len(d.String()) {
+			terms = append(terms, "dur>"+(d-1).String())
+		} else {
+			terms = append(terms, "dur>="+d.String())
+		}
+	}
+	if d := f.MaxDuration; d > 0 {
+		if len((d + 1).String()) < len(d.String()) {
+			terms = append(terms, "dur<"+(d+1).String())
+		} else {
+			terms = append(terms, "dur<="+d.String())
+		}
+	}
+	if f.Last > 0 {
+		terms = append(terms, "last:"+f.Last.String())
+	}
+	keys := make([]string, 0, len(f.Attrs))
+	for k := range f.Attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		terms = append(terms, "attr."+quote(k)+"="+quote(f.Attrs[k]))
+	}
+	for _, t := range f.Texts {
+		terms = append(terms, "q:"+quote(t))
+	}
+	return strings.Join(terms, " ")
+}
+
+// quote wraps s in quotes when it would otherwise not read back as one
+// value.
+func quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n&\":=<>!\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// FromValues reads a filter from URL query parameters: the ones
+// filter.FromValues reads, plus query, a query in this language whose
+// conditions win over theirs.
+func FromValues(v url.Values) (filter.Filter, error) {
+	f, err := filter.FromValues(v)
+	if err != nil {
+		return filter.Filter{}, err
+	}
+	if s := v.Get("query"); s != "" {
+		g, err := Parse(s)
+		if err != nil {
+			return filter.Filter{}, fmt.Errorf("query: %w", err)
+		}
+		f = merge(f, g)
+	}
+	return f, nil
+}
+
+// merge returns f with the conditions g sets replacing f's.
+func merge(f, g filter.Filter) filter.Filter {
+	if g.Service != "" {
+		f.Service = g.Service
+	}
+	if g.Severity != telemetry.SeverityUnset {
+		f.Severity = g.Severity
+	}
+	if len(g.Texts) > 0 {
+		f.Texts = g.Texts
+	}
+	if g.TraceID != "" {
+		f.TraceID = g.TraceID
+	}
+	if len(g.Kinds) > 0 {
+		f.Kinds = g.Kinds
+	}
+	if g.MinDuration > 0 {
+		f.MinDuration = g.MinDuration
+	}
+	if g.MaxDuration > 0 {
+		f.MaxDuration = g.MaxDuration
+	}
+	if g.Last > 0 {
+		f.Last = g.Last
+	}
+	if len(g.Attrs) > 0 {
+		attrs := make(map[string]string, len(f.Attrs)+len(g.Attrs))
+		for k, v := range f.Attrs {
+			attrs[k] = v
+		}
+		for k, v := range g.Attrs {
+			attrs[k] = v
+		}
+		f.Attrs = attrs
+	}
+	return f
+}
+
+// ParsePreset reads a preset written as NAME=QUERY, for example
+// "errors=severity>=error service:payments".
+func ParsePreset(s string) (filter.Preset, error) {
+	name, q, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return filter.Preset{}, fmt.Errorf("preset %q: want NAME=QUERY", s)
+	}
+	f, err := Parse(q)
+	if err != nil {
+		return filter.Preset{}, fmt.Errorf("preset %s: %w", name, err)
+	}
+	return filter.Preset{Name: name, Filter: f}, nil
+}
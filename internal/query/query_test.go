@@ -0,0 +1,160 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+)
+
+const traceID = "5b8efff798038103d269b633813fc60c"
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  filter.Filter
+	}{
+		{"", filter.Filter{}},
+		{"  ", filter.Filter{}},
+		{"timeout", filter.Filter{Texts: []string{"timeout"}}},
+		{`card "gateway timeout"`, filter.Filter{Texts: []string{"card", "gateway timeout"}}},
+		{`q:"timeout" service=api dur>200ms last:5m kind:traces`, filter.Filter{
+			Texts: []string{"timeout"}, Service: "api", MinDuration: 200*time.Millisecond + 1, Last: 5 * time.Minute,
+			Kinds: []telemetry.Kind{telemetry.KindTraces},
+		}},
+		{"service:checkout", filter.Filter{Service: "checkout"}},
+		{"service.name=checkout", filter.Filter{Service: "checkout"}},
+		{"severity>=warn", filter.Filter{Severity: telemetry.SeverityWarn}},
+		{"level:ERROR", filter.Filter{Severity: telemetry.SeverityError}},
+		{"trace:" + "5B8EFFF798038103D269B633813FC60C", filter.Filter{TraceID: traceID}},
+		{"kind:logs,traces,logs", filter.Filter{Kinds: []telemetry.Kind{telemetry.KindLogs, telemetry.KindTraces}}},
+		{"dur>=1s dur<2s", filter.Filter{MinDuration: time.Second, MaxDuration: 2*time.Second - 1}},
+		{"duration<=50ms", filter.Filter{MaxDuration: 50 * time.Millisecond}},
+		{"attr.http.route=/cart http.status_code=500", filter.Filter{Attrs: map[string]string{"http.route": "/cart", "http.status_code": "500"}}},
+		{`attr.msg:"a b"`, filter.Filter{Attrs: map[string]string{"msg": "a b"}}},
+		{"service=api&q=timeout&severity=error", filter.Filter{Service: "api", Texts: []string{"timeout"}, Severity: telemetry.SeverityError}},
+		{`"level:error" "say \"hi\""`, filter.Filter{Texts: []string{"level:error", `say "hi"`}}},
+	} {
+		got, err := Parse(tc.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.query, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("Parse(%q) = %q, want %q", tc.query, got, tc.want)
+		}
+		if again, err := Parse(Format(got)); err != nil || !again.Equal(got) {
+			t.Errorf("Parse(%q): Format = %q does not parse back (%v)", tc.query, Format(got), err)
+		}
+	}
+}
+
+// TestTextTerms checks that each word must be found on its own, so word
+// order does not matter, as in the TUI search bar.
+func TestTextTerms(t *testing.T) {
+	msg := telemetry.Parse(testutil.Log("payments", "error", "card declined: gateway timeout"))
+	for q, want := range map[string]bool{
+		"timeout card":            true,
+		`timeout "card declined"`: true,
+		`"timeout gateway"`:       false,
+		"timeout refund":          false,
+	} {
+		f, err := Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.Match(msg); got != want {
+			t.Errorf("Parse(%q).Match = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		col   int
+	}{
+		{`timeout "gateway`, 9},
+		{"service=", 9},
+		{"severity>=loud", 11},
+		{"severity<warn", 1},
+		{"trace:abc", 7},
+		{"kind:logs,spams", 6},
+		{"dur=200ms", 1},
+		{"dur>fast", 5},
+		{"dur>0s", 5},
+		{"dur<1ns", 5},
+		{"dur<=0", 6},
+		{"last:-5m", 6},
+		{"ok http://x", 4},
+		{"service!=api", 1},
+		{"=api", 1},
+		{"attr.=x", 1},
+		{"dur>2s dur<1s", 1},
+		{"façade level:x", 14},
+	} {
+		_, err := Parse(tc.query)
+		var qe *Error
+		if !errors.As(err, &qe) {
+			t.Errorf("Parse(%q) = %v, want a *Error", tc.query, err)
+			continue
+		}
+		if qe.Col != tc.col {
+			t.Errorf("Parse(%q) = %v, want the error at col %d", tc.query, err, tc.col)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		f    filter.Filter
+		want string
+	}{
+		{filter.Filter{}, ""},
+		{filter.Filter{Service: "api", Severity: telemetry.SeverityError, Texts: []string{"timeout"}}, "service:api severity>=error q:timeout"},
+		{filter.Filter{MinDuration: 200*time.Millisecond + 1, MaxDuration: time.Second}, "dur>200ms dur<=1s"},
+		{filter.Filter{Texts: []string{"card", "gateway timeout"}, Attrs: map[string]string{"k": `a "b"`}}, `attr.k="a \"b\"" q:card q:"gateway timeout"`},
+		{filter.Filter{Kinds: []telemetry.Kind{telemetry.KindTraces, telemetry.KindLogs}, Last: time.Hour}, "kind:logs,traces last:1h0m0s"},
+	} {
+		if got := Format(tc.f); got != tc.want {
+			t.Errorf("Format(%q) = %q, want %q", tc.f, got, tc.want)
+		}
+	}
+}
+
+func TestFromValues(t *testing.T) {
+	v := url.Values{"service": {"checkout"}, "severity": {"warn"}, "attr.region": {"eu"}, "query": {"service:payments dur>1s attr.tier=gold"}}
+	got, err := FromValues(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filter.Filter{
+		Service: "payments", Severity: telemetry.SeverityWarn, MinDuration: time.Second + 1,
+		Attrs: map[string]string{"region": "eu", "tier": "gold"},
+	}
+	if !got.Equal(want) {
+		t.Errorf("FromValues = %q, want %q", got, want)
+	}
+	if _, err := FromValues(url.Values{"query": {"dur=1s"}}); err == nil {
+		t.Error("FromValues with a bad query succeeded")
+	}
+}
+
+func TestParsePreset(t *testing.T) {
+	p, err := ParsePreset(" errors =severity>=error service:payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (filter.Filter{Service: "payments", Severity: telemetry.SeverityError}); p.Name != "errors" || !p.Filter.Equal(want) {
+		t.Errorf("ParsePreset = %s %q, want errors %q", p.Name, p.Filter, want)
+	}
+	for _, s := range []string{"errors", "=severity=error", "loud=severity=loud"} {
+		if _, err := ParsePreset(s); err == nil {
+			t.Errorf("ParsePreset(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package redact masks sensitive data in the frames otail receives, before
+// they are shown, yanked, exported, or served. A rule masks attributes by
+// key, as in "key=*password*", or text in string values, as in
+// "value=~[0-9]{3}-[0-9]{2}-[0-9]{4}"; emails, cards, tokens, and secrets
+// name built-in rules.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces what a rule matches.
+const Mask = "[REDACTED]"
+
+// Rule is one parsed rule. Its String is the text it was parsed from.
+type Rule struct {
+	text string
+
+	keys   []string       // globs masking the whole value of attributes they match
+	values *regexp.Regexp // masks what it matches in string values
+	valid  func(string) bool
+}
+
+// builtin are the rules known by name.
+var builtin = map[string]Rule{
+	"emails": {values: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	"cards":  {values: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhn},
+	"tokens": {values: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*` +
+		`|\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+` + // JWTs
+		`|\b(?:AKIA|ASIA)[0-9A-Z]{16}\b` + // AWS access keys
+		`|\bgh[pousr]_[A-Za-z0-9]{36,}` + // GitHub tokens
+		`|\bxox[abpors]-[A-Za-z0-9-]{10,}` + // Slack tokens
+		`|\bsk_(?:live|test)_[A-Za-z0-9]{16,}`)}, // Stripe keys
+	"secrets": {keys: []string{"*password*", "*passwd*", "*secret*", "*token*", "*api_key*", "*apikey*", "*api-key*", "*authorization*", "*cookie*", "*credential*"}},
+}
+
+// Names lists the built-in rules.
+var Names = []string{"emails", "cards", "tokens", "secrets"}
+
+// Parse reads a rule:
+//
+//	key=GLOB       attributes whose key matches GLOB, ignoring case
+//	value=~REGEX   what REGEX matches in attribute values and log bodies
+//	NAME           a built-in rule: emails, cards, tokens, or secrets
+func Parse(s string) (Rule, error) {
+	text := strings.TrimSpace(s)
+	if r, ok := builtin[strings.ToLower(text)]; ok {
+		r.text = text
+		return r, nil
+	}
+	r := Rule{text: text}
+	if re, ok := strings.CutPrefix(text, "value=~"); ok {
+		var err error
+		if r.values, err = regexp.Compile(re); err != nil {
+			return Rule{}, fmt.Errorf("redact rule %q: %w", text, err)
+		}
+		if r.values.MatchString("") {
+			return Rule{}, fmt.Errorf("redact rule %q: the pattern matches nothing at all", text)
+		}
+		return r, nil
+	}
+	if glob, ok := strings.CutPrefix(text, "key="); ok && glob != "" {
+		glob = strings.ToLower(glob)
+		if _, err := path.Match(glob, ""); err != nil {
+			return Rule{}, fmt.Errorf("redact rule %q: %w", text, err)
+		}
+		r.keys = []string{glob}
+		return r, nil
+	}
+	return Rule{}, fmt.Errorf("redact rule %q: want key=GLOB, value=~REGEX, or one of %s", text, strings.Join(Names, ", "))
+}
+
+// ParseAll parses each of specs.
+func ParseAll(specs []string) ([]Rule, error) {
+	var rules []Rule
+	for _, s := range specs {
+		r, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (r Rule) String() string { return r.text }
+
+// Redactor applies rules to frames.
+type Redactor struct {
+	rules []Rule
+}
+
+// New returns a Redactor applying rules, or nil if there are none.
+func New(rules []Rule) *Redactor {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &Redactor{rules: rules}
+}
+
+// otlpKeys are the top-level keys of OTLP JSON, in both spellings pdata
+// reads.
+var otlpKeys = []string{"resourceLogs", "resource_logs", "resourceSpans", "resource_spans", "resourceMetrics", "resource_metrics"}
+
+// Transform returns frame with what the rules match masked; it never drops
+// a frame. In OTLP JSON only attributes and string values are touched, so
+// IDs and timestamps stay as they are; anything else is masked as text.
+func (r *Redactor) Transform(frame []byte) ([]byte, bool) {
+	d := json.NewDecoder(bytes.NewReader(frame))
+	d.UseNumber()
+	var doc map[string]any
+	if err := d.Decode(&doc); err != nil || !hasAny(doc, otlpKeys) {
+		return r.text(frame), true
+	}
+	if !r.walk(doc) {
+		return frame, true
+	}
+	var out bytes.Buffer
+	e := json.NewEncoder(&out)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(doc); err != nil {
+		return frame, true
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), true
+}
+
+func hasAny(doc map[string]any, keys []string) bool {
+	for _, k := range keys {
+		if _, ok := doc[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// text masks what the value rules match anywhere in b.
+func (r *Redactor) text(b []byte) []byte {
+	for _, rule := range r.rules {
+		if rule.values != nil {
+			b = rule.values.ReplaceAllFunc(b, func(m []byte) []byte {
+				if rule.valid != nil && !rule.valid(string(m)) {
+					return m
+				}
+				return []byte(Mask)
+			})
+		}
+	}
+	return b
+}
+
+// walk masks the attributes and string values in v, reporting whether it
+// changed any.
+func (r *Redactor) walk(v any) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		if key, ok := v["key"].(string); ok && r.maskKey(key) {
+			if _, ok := v["value"]; ok {
+				v["value"] = map[string]any{"stringValue": Mask}
+				return true
+			}
+		}
+		for k, child := range v {
+			if s, ok := child.(string); ok && (k == "stringValue" || k == "string_value") {
+				if masked := string(r.text([]byte(s))); masked != s {
+					v[k], changed = masked, true
+				}
+				continue
+			}
+			changed = r.walk(child) || changed
+		}
+	case []any:
+		for _, child := range v {
+			changed = r.walk(child) || changed
+		}
+	}
+	return changed
+}
+
+// maskKey reports whether a key rule matches the attribute key.
+func (r *Redactor) maskKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, rule := range r.rules {
+		for _, glob := range rule.keys {
+			if ok, _ := path.Match(glob, key); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// luhn reports whether the digits in s pass the Luhn check card numbers
+// carry, so other long numbers are left alone.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
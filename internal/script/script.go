@@ -0,0 +1,332 @@
+// Package script runs Starlark transform scripts over the frames otail
+// receives. A script defines either or both of
+//
+//	def transform(msg): ...  # msg is the frame's OTLP JSON as dicts and lists
+//	def format(record): ...  # returns the line compact mode shows for record
+//
+// transform returns the message to pass on, changed in place or rebuilt,
+// or None to drop it. format returns a string, or None to show the message
+// as usual. Scripts can use json and three helpers: records(msg) lists
+// every log record, span, and metric in msg; attr(obj, key) returns one of
+// their attributes; set_attr(obj, key, value) sets one.
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	starjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// maxSteps bounds the work of one call, so a script that loops forever
+// fails instead of stalling the stream.
+const maxSteps = 10_000_000
+
+// fileOptions lets scripts use the whole language.
+var fileOptions = &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true, Recursion: true}
+
+// prelude defines the helpers every script can use.
+const prelude = `
+_signals = [
+    ("resourceLogs", "scopeLogs", "logRecords"),
+    ("resourceSpans", "scopeSpans", "spans"),
+    ("resourceMetrics", "scopeMetrics", "metrics"),
+]
+
+def records(msg):
+    out = []
+    for resources, scopes, items in _signals:
+        for r in msg.get(resources, []):
+            for s in r.get(scopes, []):
+                out.extend(s.get(items, []))
+    return out
+
+def attr(obj, key):
+    for a in obj.get("attributes", []):
+        if a.get("key") == key:
+            v = a.get("value", {})
+            for t in ("stringValue", "intValue", "doubleValue", "boolValue"):
+                if t in v:
+                    return v[t]
+    return None
+
+def set_attr(obj, key, value):
+    t = {"string": "stringValue", "int": "intValue", "float": "doubleValue", "bool": "boolValue"}[type(value)]
+    if t == "intValue":
+        value = str(value)  # as OTLP JSON writes 64-bit integers
+    attrs = obj.setdefault("attributes", [])
+    for a in attrs:
+        if a.get("key") == key:
+            a["value"] = {t: value}
+            return
+    attrs.append({"key": key, "value": {t: value}})
+`
+
+// predeclared holds what scripts can use without loading it.
+var predeclared = func() starlark.StringDict {
+	globals, err := starlark.ExecFileOptions(fileOptions, &starlark.Thread{Name: "prelude"}, "prelude.star", prelude, starlark.StringDict{"json": starjson.Module})
+	if err != nil {
+		panic(err)
+	}
+	globals.Freeze()
+	return starlark.StringDict{
+		"json":     starjson.Module,
+		"records":  globals["records"],
+		"attr":     globals["attr"],
+		"set_attr": globals["set_attr"],
+	}
+}()
+
+// Script is one loaded script.
+type Script struct {
+	Name      string
+	transform starlark.Callable // nil = none
+	format    starlark.Callable // nil = none
+	enabled   atomic.Bool
+}
+
+// Set is every script from the config, run in the order given.
+type Set struct {
+	scripts []*Script
+	logger  *log.Logger
+}
+
+// Load reads each script given as NAME=PATH, leaving those named in off
+// turned off. logger receives what scripts print and why one was turned
+// off; nil discards them.
+func Load(specs, off []string, logger *log.Logger) (*Set, error) {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	s := &Set{logger: logger}
+	for _, spec := range specs {
+		name, path, ok := strings.Cut(spec, "=")
+		if name = strings.TrimSpace(name); !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("script %q: want NAME=PATH", spec)
+		}
+		if s.lookup(name) != nil {
+			return nil, fmt.Errorf("script %s: named twice", name)
+		}
+		sc, err := s.load(name, path)
+		if err != nil {
+			return nil, err
+		}
+		sc.enabled.Store(!slices.Contains(off, name))
+		s.scripts = append(s.scripts, sc)
+	}
+	for _, name := range off {
+		if s.lookup(name) == nil {
+			return nil, fmt.Errorf("script-off %s: no such script", name)
+		}
+	}
+	return s, nil
+}
+
+func (s *Set) load(name, path string) (*Script, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("script %s: %w", name, err)
+	}
+	globals, err := starlark.ExecFileOptions(fileOptions, s.thread(name), path, src, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("script %s: %w", name, explain(err))
+	}
+	globals.Freeze()
+	sc := &Script{Name: name}
+	for fn, dst := range map[string]*starlark.Callable{"transform": &sc.transform, "format": &sc.format} {
+		v, ok := globals[fn]
+		if !ok {
+			continue
+		}
+		if *dst, ok = v.(starlark.Callable); !ok {
+			return nil, fmt.Errorf("script %s: %s is a %s, not a function", name, fn, v.Type())
+		}
+	}
+	if sc.transform == nil && sc.format == nil {
+		return nil, fmt.Errorf("script %s: defines neither transform nor format", name)
+	}
+	return sc, nil
+}
+
+// thread returns a fresh thread to run the script called name on, which
+// logs what it prints.
+func (s *Set) thread(name string) *starlark.Thread {
+	th := &starlark.Thread{
+		Name:  name,
+		Print: func(_ *starlark.Thread, msg string) { s.logger.Printf("script %s: %s", name, msg) },
+	}
+	th.SetMaxExecutionSteps(maxSteps)
+	return th
+}
+
+// explain adds the Starlark backtrace to errors raised by a script.
+func explain(err error) error {
+	var ee *starlark.EvalError
+	if errors.As(err, &ee) {
+		return errors.New(ee.Backtrace())
+	}
+	return err
+}
+
+// fail turns sc off after an error, so one bad script does not stall or
+// flood the stream.
+func (s *Set) fail(sc *Script, err error) {
+	sc.enabled.Store(false)
+	s.logger.Printf("script %s: %v; turned off (:script %s on to retry)", sc.Name, explain(err), sc.Name)
+}
+
+func (s *Set) lookup(name string) *Script {
+	for _, sc := range s.scripts {
+		if sc.Name == name {
+			return sc
+		}
+	}
+	return nil
+}
+
+// Scripts returns the scripts in the order they run.
+func (s *Set) Scripts() []*Script {
+	if s == nil {
+		return nil
+	}
+	return s.scripts
+}
+
+// Enabled reports whether sc is turned on.
+func (sc *Script) Enabled() bool {
+	return sc.enabled.Load()
+}
+
+// Enable turns the script called name on or off.
+func (s *Set) Enable(name string, on bool) error {
+	if s == nil {
+		return errors.New("script: no scripts loaded; add them with --script NAME=PATH")
+	}
+	sc := s.lookup(name)
+	if sc == nil {
+		return fmt.Errorf("script %s: no such script", name)
+	}
+	sc.enabled.Store(on)
+	return nil
+}
+
+// HasFormat reports whether any script defines format.
+func (s *Set) HasFormat() bool {
+	return s != nil && slices.ContainsFunc(s.scripts, func(sc *Script) bool { return sc.format != nil })
+}
+
+// Transform runs frame through the transform of every script turned on,
+// and returns false if one drops it. Frames that are not JSON pass by
+// unchanged, as does every frame a failing script was given.
+func (s *Set) Transform(frame []byte) ([]byte, bool) {
+	if !json.Valid(frame) {
+		return frame, true
+	}
+	for _, sc := range s.scripts {
+		if sc.transform == nil || !sc.Enabled() {
+			continue
+		}
+		out, keep, err := s.run(sc, frame)
+		if err != nil {
+			s.fail(sc, err)
+			continue
+		}
+		if !keep {
+			return nil, false
+		}
+		frame = out
+	}
+	return frame, true
+}
+
+func (s *Set) run(sc *Script, frame []byte) ([]byte, bool, error) {
+	th := s.thread(sc.Name)
+	msg, err := starlark.Call(th, starjson.Module.Members["decode"], starlark.Tuple{starlark.String(frame)}, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	v, err := starlark.Call(th, sc.transform, starlark.Tuple{msg}, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if v == starlark.None {
+		return nil, false, nil
+	}
+	out, err := starlark.Call(th, starjson.Module.Members["encode"], starlark.Tuple{v}, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("transform returned %s: %w", v.Type(), err)
+	}
+	return []byte(out.(starlark.String)), true, nil
+}
+
+// Line returns the line the first format script turned on gives r, and
+// false if none gives one.
+func (s *Set) Line(r telemetry.Record) (string, bool) {
+	for _, sc := range s.scripts {
+		if sc.format == nil || !sc.Enabled() {
+			continue
+		}
+		line, ok, err := s.line(sc, r)
+		if err != nil {
+			s.fail(sc, err)
+			continue
+		}
+		if ok {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+func (s *Set) line(sc *Script, r telemetry.Record) (string, bool, error) {
+	th := s.thread(sc.Name)
+	rec, err := record(th, r)
+	if err != nil {
+		return "", false, err
+	}
+	v, err := starlark.Call(th, sc.format, starlark.Tuple{rec}, nil)
+	if err != nil {
+		return "", false, err
+	}
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return "", false, nil
+	case starlark.String:
+		return strings.ReplaceAll(string(v), "\n", " "), true, nil
+	default:
+		return "", false, fmt.Errorf("format returned %s, want a string or None", v.Type())
+	}
+}
+
+// record gives r to a script as a dict of kind, time, severity, service,
+// text, and resource attributes.
+func record(th *starlark.Thread, r telemetry.Record) (starlark.Value, error) {
+	d := map[string]any{
+		"kind":     r.Kind.String(),
+		"severity": r.Severity.String(),
+		"service":  r.Service,
+		"text":     r.Text,
+		"resource": r.Resource.AsRaw(),
+		"time":     nil,
+	}
+	if !r.Time.IsZero() {
+		d["time"] = r.Time.UTC().Format(time.RFC3339Nano)
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Call(th, starjson.Module.Members["decode"], starlark.Tuple{starlark.String(b)}, nil)
+}
@@ -0,0 +1,161 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+)
+
+// load writes each script's source to a file and loads them, in order.
+func load(t *testing.T, off []string, sources ...string) *Set {
+	t.Helper()
+	var specs []string
+	for i, src := range sources {
+		path := filepath.Join(t.TempDir(), "s.star")
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		specs = append(specs, string(rune('a'+i))+"="+path)
+	}
+	s, err := Load(specs, off, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	ok := write("ok.star", "def transform(msg):\n    return msg\n")
+	for _, tc := range []struct {
+		specs, off []string
+		want       string
+	}{
+		{specs: []string{ok}, want: "want NAME=PATH"},
+		{specs: []string{"a=" + ok, "a=" + ok}, want: "named twice"},
+		{specs: []string{"a=" + filepath.Join(dir, "missing.star")}, want: "no such file"},
+		{specs: []string{"a=" + write("syntax.star", "def transform(msg)\n")}, want: "got newline"},
+		{specs: []string{"a=" + write("empty.star", "x = 1\n")}, want: "neither transform nor format"},
+		{specs: []string{"a=" + write("notfn.star", "format = 1\n")}, want: "not a function"},
+		{specs: []string{"a=" + ok}, off: []string{"b"}, want: "no such script"},
+	} {
+		if _, err := Load(tc.specs, tc.off, nil); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Load(%q, %q) = %v, want an error containing %q", tc.specs, tc.off, err, tc.want)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	s := load(t, nil, `
+def transform(msg):
+    for r in records(msg):
+        set_attr(r, "user.email", "ann@example.com")
+    return msg
+`, `
+def transform(msg):
+    for r in records(msg):
+        if attr(r, "user.email") != None:
+            set_attr(r, "user.email", "<redacted>")
+        set_attr(r, "retries", 3)
+        if r.get("body", {}).get("stringValue") == "drop me":
+            return None
+    return msg
+`)
+	frame := testutil.Log("checkout", "info", "order placed")
+	out, keep := s.Transform(frame)
+	if !keep {
+		t.Fatal("Transform dropped the frame")
+	}
+	msg := telemetry.Parse(out)
+	if msg.Kind != telemetry.KindLogs {
+		t.Fatalf("Transform gave %s, not logs", out)
+	}
+	attrs := msg.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	if attrs["user.email"] != "<redacted>" || attrs["retries"] != int64(3) {
+		t.Errorf("attributes = %v, want the email redacted and retries 3", attrs)
+	}
+
+	if _, keep := s.Transform(testutil.Log("checkout", "info", "drop me")); keep {
+		t.Error("Transform kept a frame the script dropped")
+	}
+	if out, keep := s.Transform([]byte("not json")); !keep || string(out) != "not json" {
+		t.Errorf("Transform of a non-JSON frame = %q, %v; want it unchanged", out, keep)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	s := load(t, nil, `
+def format(r):
+    if r["kind"] != "logs":
+        return None
+    return "%s %s: %s" % (r["severity"], r["service"], r["text"])
+`)
+	if !s.HasFormat() {
+		t.Fatal("HasFormat = false")
+	}
+	msg := telemetry.Parse(testutil.Log("checkout", "warn", "slow\nquery"))
+	line, ok := s.Line(msg.Records()[0])
+	if want := "WARN checkout: slow query"; !ok || line != want {
+		t.Errorf("Line = %q, %v; want %q", line, ok, want)
+	}
+	if _, ok := s.Line(telemetry.Parse(testutil.Span("checkout", "GET /cart", 0)).Records()[0]); ok {
+		t.Error("Line gave a line for a span the script returned None for")
+	}
+
+	if err := s.Enable("a", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Line(msg.Records()[0]); ok {
+		t.Error("Line gave a line with the script turned off")
+	}
+}
+
+func TestFailingScript(t *testing.T) {
+	s := load(t, []string{"b"}, `
+def transform(msg):
+    return msg["no such key"]
+`, `
+def transform(msg):
+    return None
+`)
+	frame := testutil.Log("checkout", "info", "order placed")
+	if out, keep := s.Transform(frame); !keep || string(out) != string(frame) {
+		t.Errorf("Transform with a failing script = %s, %v; want the frame unchanged", out, keep)
+	}
+	if sc := s.Scripts()[0]; sc.Enabled() {
+		t.Error("the failing script was left on")
+	}
+
+	if err := s.Enable("b", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, keep := s.Transform(frame); keep {
+		t.Error("Transform kept a frame the script turned on drops")
+	}
+	if err := s.Enable("c", true); err == nil {
+		t.Error("Enable of an unknown script succeeded")
+	}
+}
+
+func TestEndlessScript(t *testing.T) {
+	s := load(t, nil, `
+def transform(msg):
+    while True:
+        pass
+`)
+	frame := testutil.Log("checkout", "info", "order placed")
+	if _, keep := s.Transform(frame); !keep || s.Scripts()[0].Enabled() {
+		t.Error("a script that never returns was not stopped and turned off")
+	}
+}
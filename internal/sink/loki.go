@@ -0,0 +1,233 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	plog "go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+const (
+	lokiQueue    = 4096            // log records waiting to be pushed
+	lokiBatch    = 1000            // most log records per push
+	lokiInterval = time.Second     // longest a record waits for a push
+	lokiTimeout  = 5 * time.Second // per push, and for the final push on Close
+)
+
+// Loki pushes log records to a Loki server's push API, one stream per
+// distinct set of resource attributes. Metrics and traces are ignored.
+type Loki struct {
+	url    string
+	client *http.Client
+	logger *log.Logger
+
+	queue   chan lokiEntry
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// lokiEntry is one log line with the labels of its stream.
+type lokiEntry struct {
+	labels string // canonical JSON of the label set, used to group streams
+	ts     time.Time
+	line   string
+}
+
+// NewLoki returns a sink that pushes to the Loki server at base, e.g.
+// http://loki:3100. The push path is added unless base already has one.
+func NewLoki(base string, logger *log.Logger) (*Loki, error) {
+	u, err := url.Parse(base)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid loki url %q", base)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/loki/api/v1/push"
+	}
+	l := &Loki{
+		url:    u.String(),
+		client: &http.Client{Timeout: lokiTimeout},
+		logger: logger,
+		queue:  make(chan lokiEntry, lokiQueue),
+		done:   make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+// Write queues the log records in frame; other frames are ignored.
+func (l *Loki) Write(frame []byte) {
+	logs, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs(frame)
+	if err != nil {
+		return
+	}
+	rls := logs.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		labels := lokiLabels(rls.At(i).Resource().Attributes())
+		sls := rls.At(i).ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			lrs := sls.At(j).LogRecords()
+			for k := 0; k < lrs.Len(); k++ {
+				lr := lrs.At(k)
+				e := lokiEntry{ts: lokiTime(lr), line: lr.Body().AsString()}
+				e.labels = withLevel(labels, telemetry.SeverityOf(lr.SeverityNumber()))
+				select {
+				case l.queue <- e:
+				default:
+					l.dropped.Add(1)
+				}
+			}
+		}
+	}
+}
+
+// Close pushes what is queued, waiting at most lokiTimeout.
+func (l *Loki) Close() error {
+	l.once.Do(func() { close(l.queue) })
+	select {
+	case <-l.done:
+	case <-time.After(lokiTimeout):
+		return fmt.Errorf("loki: gave up flushing after %s", lokiTimeout)
+	}
+	if n := l.dropped.Load(); n > 0 {
+		return fmt.Errorf("loki: dropped %d log records because the queue was full", n)
+	}
+	return nil
+}
+
+// run batches queued entries and pushes them every lokiInterval or
+// lokiBatch entries, whichever comes first.
+func (l *Loki) run() {
+	defer close(l.done)
+	tick := time.NewTicker(lokiInterval)
+	defer tick.Stop()
+	var batch []lokiEntry
+	for {
+		select {
+		case e, ok := <-l.queue:
+			if !ok {
+				l.push(batch)
+				return
+			}
+			if batch = append(batch, e); len(batch) >= lokiBatch {
+				l.push(batch)
+				batch = nil
+			}
+		case <-tick.C:
+			l.push(batch)
+			batch = nil
+		}
+	}
+}
+
+// push sends batch as a single push request.
+func (l *Loki) push(batch []lokiEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	byLabels := map[string]*stream{}
+	var streams []*stream
+	for _, e := range batch {
+		s, ok := byLabels[e.labels]
+		if !ok {
+			s = &stream{}
+			json.Unmarshal([]byte(e.labels), &s.Stream)
+			byLabels[e.labels] = s
+			streams = append(streams, s)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.ts.UnixNano(), 10), e.line})
+	}
+	body, err := json.Marshal(map[string]any{"streams": streams})
+	if err != nil {
+		l.logger.Printf("loki: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lokiTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		l.logger.Printf("loki: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := l.client.Do(req)
+	if err != nil {
+		l.logger.Printf("loki: push %d lines: %v", len(batch), err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		l.logger.Printf("loki: push %d lines: %s: %s", len(batch), resp.Status, bytes.TrimSpace(msg))
+	}
+}
+
+// lokiLabels turns resource attributes into Loki labels. Label names may
+// only contain letters, digits, and underscores, so service.name becomes
+// service_name.
+func lokiLabels(attrs pcommon.Map) map[string]string {
+	labels := map[string]string{}
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		if v.Type() == pcommon.ValueTypeMap || v.Type() == pcommon.ValueTypeSlice || v.Type() == pcommon.ValueTypeBytes {
+			return true // not useful as a label
+		}
+		labels[labelName(k)] = v.AsString()
+		return true
+	})
+	if len(labels) == 0 {
+		labels["job"] = "otail"
+	}
+	return labels
+}
+
+// withLevel adds the record's severity as the level label and returns the
+// label set as JSON, which sorts the keys so equal sets encode alike.
+func withLevel(labels map[string]string, sev telemetry.Severity) string {
+	if _, ok := labels["level"]; !ok && sev != telemetry.SeverityUnset {
+		labels = maps.Clone(labels)
+		labels["level"] = strings.ToLower(sev.String())
+	}
+	b, _ := json.Marshal(labels)
+	return string(b)
+}
+
+func labelName(k string) string {
+	b := []byte(k)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// lokiTime is the record's timestamp, falling back to when it was observed
+// and then to now.
+func lokiTime(lr plog.LogRecord) time.Time {
+	if ts := lr.Timestamp(); ts != 0 {
+		return ts.AsTime()
+	}
+	if ts := lr.ObservedTimestamp(); ts != 0 {
+		return ts.AsTime()
+	}
+	return time.Now()
+}
@@ -0,0 +1,178 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+const (
+	otlpQueue   = 1024            // messages waiting to be exported
+	otlpTimeout = 5 * time.Second // per export, and for the final exports on Close
+)
+
+// OTLP re-exports every logs, metrics, and traces message to a collector
+// over OTLP/gRPC or OTLP/HTTP, making otail a tee with a UI. Frames that do
+// not parse as OTLP are not forwarded.
+type OTLP struct {
+	target string
+	export func(ctx context.Context, msg telemetry.Message) error
+	close  func() error
+	logger *log.Logger
+
+	queue   chan telemetry.Message
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// NewOTLP returns a sink that exports to target, one of
+//
+//	otlp-grpc://host:4317        OTLP/gRPC without TLS
+//	otlp-http://host:4318        OTLP/HTTP protobuf to /v1/logs, /v1/metrics, /v1/traces
+//	otlp-https://host:4318       the same over TLS
+func NewOTLP(target string, logger *log.Logger) (*OTLP, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid otlp target %q", target)
+	}
+	o := &OTLP{
+		target: target,
+		logger: logger,
+		queue:  make(chan telemetry.Message, otlpQueue),
+		done:   make(chan struct{}),
+	}
+	switch u.Scheme {
+	case "otlp-grpc", "grpc":
+		conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("otlp %s: %w", target, err)
+		}
+		o.export, o.close = grpcExporter(conn), conn.Close
+	case "otlp-http", "otlp-https", "http", "https":
+		u.Scheme = strings.TrimPrefix(u.Scheme, "otlp-")
+		o.export, o.close = httpExporter(u), func() error { return nil }
+	default:
+		return nil, fmt.Errorf("otlp %s: unknown scheme %q (want otlp-grpc, otlp-http, or otlp-https)", target, u.Scheme)
+	}
+	go o.run()
+	return o, nil
+}
+
+// Write queues frame for export if it is OTLP.
+func (o *OTLP) Write(frame []byte) {
+	msg := telemetry.Parse(frame)
+	if msg.Kind == telemetry.KindUnknown {
+		return
+	}
+	select {
+	case o.queue <- msg:
+	default:
+		o.dropped.Add(1)
+	}
+}
+
+// Close exports what is queued, waiting at most otlpTimeout.
+func (o *OTLP) Close() error {
+	o.once.Do(func() { close(o.queue) })
+	select {
+	case <-o.done:
+	case <-time.After(otlpTimeout):
+		return fmt.Errorf("otlp %s: gave up flushing after %s", o.target, otlpTimeout)
+	}
+	err := o.close()
+	if n := o.dropped.Load(); n > 0 {
+		return fmt.Errorf("otlp %s: dropped %d messages because the queue was full", o.target, n)
+	}
+	return err
+}
+
+func (o *OTLP) run() {
+	defer close(o.done)
+	for msg := range o.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), otlpTimeout)
+		if err := o.export(ctx, msg); err != nil {
+			o.logger.Printf("otlp %s: export %s: %v", o.target, msg.Kind, err)
+		}
+		cancel()
+	}
+}
+
+func grpcExporter(conn *grpc.ClientConn) func(context.Context, telemetry.Message) error {
+	logs := plogotlp.NewGRPCClient(conn)
+	metrics := pmetricotlp.NewGRPCClient(conn)
+	traces := ptraceotlp.NewGRPCClient(conn)
+	return func(ctx context.Context, msg telemetry.Message) error {
+		var err error
+		switch msg.Kind {
+		case telemetry.KindLogs:
+			_, err = logs.Export(ctx, plogotlp.NewExportRequestFromLogs(msg.Logs))
+		case telemetry.KindMetrics:
+			_, err = metrics.Export(ctx, pmetricotlp.NewExportRequestFromMetrics(msg.Metrics))
+		case telemetry.KindTraces:
+			_, err = traces.Export(ctx, ptraceotlp.NewExportRequestFromTraces(msg.Traces))
+		}
+		return err
+	}
+}
+
+func httpExporter(base *url.URL) func(context.Context, telemetry.Message) error {
+	client := &http.Client{Timeout: otlpTimeout}
+	post := func(ctx context.Context, path string, body []byte) error {
+		u := *base
+		u.Path = strings.TrimSuffix(u.Path, "/") + path
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+		}
+		return nil
+	}
+	return func(ctx context.Context, msg telemetry.Message) error {
+		var (
+			body []byte
+			path string
+			err  error
+		)
+		switch msg.Kind {
+		case telemetry.KindLogs:
+			body, err = plogotlp.NewExportRequestFromLogs(msg.Logs).MarshalProto()
+			path = "/v1/logs"
+		case telemetry.KindMetrics:
+			body, err = pmetricotlp.NewExportRequestFromMetrics(msg.Metrics).MarshalProto()
+			path = "/v1/metrics"
+		case telemetry.KindTraces:
+			body, err = ptraceotlp.NewExportRequestFromTraces(msg.Traces).MarshalProto()
+			path = "/v1/traces"
+		}
+		if err != nil {
+			return err
+		}
+		return post(ctx, path, body)
+	}
+}
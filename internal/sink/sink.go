@@ -0,0 +1,58 @@
+// Package sink forwards the frames otail receives to other systems while
+// they are also being shown, so otail can double as a bridge.
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// Sink receives a copy of every frame. Write must not block: sinks queue
+// frames and send them from their own goroutine, dropping what does not
+// fit.
+type Sink interface {
+	Write(frame []byte)
+	// Close sends what is queued and stops the sink.
+	Close() error
+}
+
+// Parse builds a sink from a KIND=URL spec such as loki=http://loki:3100 or
+// otlp=otlp-grpc://collector:4317.
+// logger receives delivery errors; nil discards them.
+func Parse(spec string, logger *log.Logger) (Sink, error) {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	kind, target, ok := strings.Cut(spec, "=")
+	if !ok || target == "" {
+		return nil, fmt.Errorf("sink %q: want KIND=URL", spec)
+	}
+	switch strings.ToLower(kind) {
+	case "loki":
+		return NewLoki(target, logger)
+	case "otlp":
+		return NewOTLP(target, logger)
+	default:
+		return nil, fmt.Errorf("sink %q: unknown kind %q (want loki or otlp)", spec, kind)
+	}
+}
+
+// Tee writes every frame to each of sinks.
+type Tee []Sink
+
+func (t Tee) Write(frame []byte) {
+	for _, s := range t {
+		s.Write(frame)
+	}
+}
+
+func (t Tee) Close() error {
+	var errs []error
+	for _, s := range t {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,411 @@
+// Package store keeps the telemetry a hub receives in a SQLite database, so
+// history outlives the process and can be searched without holding it all
+// in memory.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/jwafle/otail/internal/hub"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// driver is the database/sql driver the store opens.
+const driver = "sqlite3"
+
+// maxBatch caps how many messages Record writes in one transaction.
+const maxBatch = 512
+
+const schema = `
+CREATE TABLE IF NOT EXISTS frames (
+	id       INTEGER PRIMARY KEY,
+	kind     TEXT    NOT NULL,
+	received INTEGER NOT NULL, -- Unix nanoseconds
+	raw      BLOB    NOT NULL
+);
+CREATE TABLE IF NOT EXISTS logs (
+	id         INTEGER PRIMARY KEY,
+	frame      INTEGER NOT NULL REFERENCES frames (id),
+	time       INTEGER NOT NULL, -- Unix nanoseconds
+	severity   TEXT    NOT NULL,
+	service    TEXT    NOT NULL,
+	body       TEXT    NOT NULL,
+	trace_id   TEXT    NOT NULL,
+	span_id    TEXT    NOT NULL,
+	attributes TEXT    NOT NULL -- JSON object
+);
+CREATE INDEX IF NOT EXISTS logs_time ON logs (time);
+CREATE TABLE IF NOT EXISTS spans (
+	trace_id TEXT    NOT NULL,
+	frame    INTEGER NOT NULL REFERENCES frames (id),
+	PRIMARY KEY (trace_id, frame)
+) WITHOUT ROWID;
+`
+
+// ftsSchema adds a full-text index of log records. FTS5 is only compiled
+// into the driver with the sqlite_fts5 build tag; without it the store
+// searches by scanning.
+const ftsSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS logs_fts USING fts5 (
+	body, attributes, content = 'logs', content_rowid = 'id', tokenize = 'trigram'
+);
+CREATE TRIGGER IF NOT EXISTS logs_fts_insert AFTER INSERT ON logs BEGIN
+	INSERT INTO logs_fts (rowid, body, attributes) VALUES (new.id, new.body, new.attributes);
+END;
+`
+
+// Store is a SQLite database of received messages.
+type Store struct {
+	db  *sql.DB
+	fts bool // logs_fts indexes every log record
+}
+
+// Open opens the store spec names: "sqlite:PATH" for a database at PATH,
+// created if need be. It returns nil for "memory" or an empty spec, which
+// keep history in memory only.
+func Open(spec string) (*Store, error) {
+	if spec == "" || spec == "memory" {
+		return nil, nil
+	}
+	path, ok := strings.CutPrefix(spec, "sqlite:")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("store %q: want memory or sqlite:PATH", spec)
+	}
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db}
+	if err := s.init(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// init creates the schema, and the full-text index if the driver has FTS5.
+func (s *Store) init() error {
+	for _, stmt := range []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 5000", schema} {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if err := s.db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&s.fts); err != nil {
+		return err
+	}
+	if !s.fts {
+		// An index left by a build with FTS5 cannot be written without it.
+		_, err := s.db.Exec(`DROP TRIGGER IF EXISTS logs_fts_insert`)
+		return err
+	}
+	// A database last written without FTS5 has no trigger, and an index
+	// missing the records added since; rebuild it from logs.
+	var triggers int
+	if err := s.db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'logs_fts_insert'`).Scan(&triggers); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(ftsSchema); err != nil {
+		return err
+	}
+	if triggers == 0 {
+		_, err := s.db.Exec(`INSERT INTO logs_fts (logs_fts) VALUES ('rebuild')`)
+		return err
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record writes each message from sub to the store, a batch of whatever
+// has arrived per transaction, until sub is closed or a write fails. A
+// message is stored under its sequence number, so sub should be blocking
+// (hub.SubscribeOptions.Block) for the store to miss nothing, and the hub
+// seeded with Recent so numbering carries on across restarts.
+func (s *Store) Record(sub *hub.Subscription) error {
+	for e := range sub.Messages() {
+		batch := []hub.Entry{e}
+	drain:
+		for len(batch) < maxBatch {
+			select {
+			case e, ok := <-sub.Messages():
+				if !ok {
+					break drain
+				}
+				batch = append(batch, e)
+			default:
+				break drain
+			}
+		}
+		if err := s.add(batch, time.Now()); err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+	}
+	return nil
+}
+
+// add writes entries, received at now, in one transaction.
+func (s *Store) add(entries []hub.Entry, now time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, e := range entries {
+		msg, frame := e.Message, int64(e.Seq)
+		if _, err := tx.Exec(`INSERT INTO frames (id, kind, received, raw) VALUES (?, ?, ?, ?)`, frame, msg.Kind.String(), now.UnixNano(), msg.Raw); err != nil {
+			return err
+		}
+		switch msg.Kind {
+		case telemetry.KindLogs:
+			err = addLogs(tx, frame, msg)
+		case telemetry.KindTraces:
+			err = addSpans(tx, frame, msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func addLogs(tx *sql.Tx, frame int64, msg telemetry.Message) error {
+	rls := msg.Logs.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		svc := serviceName(rl.Resource())
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			lrs := sls.At(j).LogRecords()
+			for k := 0; k < lrs.Len(); k++ {
+				lr := lrs.At(k)
+				ts := lr.Timestamp()
+				if ts == 0 {
+					ts = lr.ObservedTimestamp()
+				}
+				attrs, err := json.Marshal(lr.Attributes().AsRaw())
+				if err != nil {
+					return err
+				}
+				_, err = tx.Exec(`INSERT INTO logs (frame, time, severity, service, body, trace_id, span_id, attributes) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+					frame, int64(ts), telemetry.SeverityOf(lr.SeverityNumber()).String(), svc, lr.Body().AsString(),
+					traceID(lr.TraceID()), spanID(lr.SpanID()), string(attrs))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func addSpans(tx *sql.Tx, frame int64, msg telemetry.Message) error {
+	seen := map[pcommon.TraceID]bool{}
+	rss := msg.Traces.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		sss := rss.At(i).ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				id := spans.At(k).TraceID()
+				if id.IsEmpty() || seen[id] {
+					continue
+				}
+				seen[id] = true
+				if _, err := tx.Exec(`INSERT OR IGNORE INTO spans (trace_id, frame) VALUES (?, ?)`, traceID(id), frame); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Recent returns up to the last n stored messages, oldest first, numbered
+// as they were recorded, for seeding a hub with hub.Seed.
+func (s *Store) Recent(ctx context.Context, n int) ([]hub.Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, raw FROM (SELECT id, raw FROM frames ORDER BY id DESC LIMIT ?) ORDER BY id`, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []hub.Entry
+	for rows.Next() {
+		var (
+			id  int64
+			raw []byte
+		)
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		out = append(out, hub.Entry{Seq: uint64(id), Message: telemetry.Parse(raw)})
+	}
+	return out, rows.Err()
+}
+
+// Log is a stored log record.
+type Log struct {
+	Time       time.Time
+	Severity   string
+	Service    string
+	Body       string
+	TraceID    string // hex; empty if none
+	SpanID     string // hex; empty if none
+	Attributes map[string]any
+}
+
+// LogQuery selects stored log records; zero-value matches all of them.
+type LogQuery struct {
+	Since time.Time // records at or after this time; zero = all
+	Texts []string  // case-insensitive substrings of the body, attributes, or service, all of which must be there; nil = all
+	Limit int       // most recent records to return; 0 = all
+
+	Service    string            // service name, case-insensitive; empty = all
+	Severities []string          // severity names kept, such as "ERROR"; nil = all
+	TraceID    string            // hex trace ID; empty = all
+	Attrs      map[string]string // record attribute key → value, compared as text
+}
+
+// Logs returns the most recent log records matching q, oldest first.
+// Texts of three or more characters is looked up in the full-text index if
+// there is one; shorter text is matched by scanning.
+func (s *Store) Logs(ctx context.Context, q LogQuery) ([]Log, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if !q.Since.IsZero() {
+		where = append(where, "time >= ?")
+		args = append(args, q.Since.UnixNano())
+	}
+	for _, text := range q.Texts {
+		like := "%" + escapeLike(text) + "%"
+		if s.fts && len([]rune(text)) >= 3 {
+			where = append(where, `(id IN (SELECT rowid FROM logs_fts WHERE logs_fts MATCH ?) OR service LIKE ? ESCAPE '\')`)
+			args = append(args, phrase(text), like)
+		} else {
+			where = append(where, `(body LIKE ? ESCAPE '\' OR attributes LIKE ? ESCAPE '\' OR service LIKE ? ESCAPE '\')`)
+			args = append(args, like, like, like)
+		}
+	}
+	if q.Service != "" {
+		where = append(where, "service = ? COLLATE NOCASE")
+		args = append(args, q.Service)
+	}
+	if len(q.Severities) > 0 {
+		where = append(where, "severity IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(q.Severities)), ", ")+")")
+		for _, sev := range q.Severities {
+			args = append(args, sev)
+		}
+	}
+	if q.TraceID != "" {
+		where = append(where, "trace_id = ?")
+		args = append(args, strings.ToLower(q.TraceID))
+	}
+	for _, k := range slices.Sorted(maps.Keys(q.Attrs)) {
+		where = append(where, "CAST(json_extract(attributes, ?) AS TEXT) = ?")
+		args = append(args, `$."`+strings.ReplaceAll(k, `"`, `\"`)+`"`, q.Attrs[k])
+	}
+	query := `SELECT time, severity, service, body, trace_id, span_id, attributes FROM logs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Log
+	for rows.Next() {
+		var (
+			l     Log
+			ts    int64
+			attrs string
+		)
+		if err := rows.Scan(&ts, &l.Severity, &l.Service, &l.Body, &l.TraceID, &l.SpanID, &attrs); err != nil {
+			return nil, err
+		}
+		l.Time = time.Unix(0, ts).UTC()
+		if err := json.Unmarshal([]byte(attrs), &l.Attributes); err != nil {
+			return nil, err
+		}
+		if len(l.Attributes) == 0 {
+			l.Attributes = nil
+		}
+		out = append(out, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Reverse(out)
+	return out, nil
+}
+
+// Trace returns every stored message holding a span of trace id, in the
+// order they were received.
+func (s *Store) Trace(ctx context.Context, id pcommon.TraceID) ([]telemetry.Message, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT f.raw FROM spans s JOIN frames f ON f.id = s.frame WHERE s.trace_id = ? ORDER BY f.id`, traceID(id))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []telemetry.Message
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		out = append(out, telemetry.Parse(raw))
+	}
+	return out, rows.Err()
+}
+
+// phrase quotes s as an FTS5 string, which the trigram tokenizer matches
+// as a case-insensitive substring.
+func phrase(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func traceID(id pcommon.TraceID) string {
+	if id.IsEmpty() {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
+
+func spanID(id pcommon.SpanID) string {
+	if id.IsEmpty() {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
+
+func serviceName(r pcommon.Resource) string {
+	if v, ok := r.Attributes().Get("service.name"); ok {
+		return v.AsString()
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jwafle/otail/internal/hub"
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+	"github.com/jwafle/otail/internal/transport"
+)
+
+func openTemp(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := Open("sqlite:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestOpenSpecs(t *testing.T) {
+	for _, spec := range []string{"", "memory"} {
+		if s, err := Open(spec); s != nil || err != nil {
+			t.Errorf("Open(%q) = %v, %v; want no store", spec, s, err)
+		}
+	}
+	for _, spec := range []string{"sqlite:", "postgres:db", "otail.db"} {
+		if _, err := Open(spec); err == nil {
+			t.Errorf("Open(%q) succeeded", spec)
+		}
+	}
+}
+
+// TestRoundTrip records a stream through a hub, reopens the database, and
+// reads it back.
+func TestRoundTrip(t *testing.T) {
+	frames := [][]byte{
+		testutil.Log("checkout", "info", "order placed"),
+		testutil.Span("checkout", "GET /cart", 120*time.Millisecond),
+		testutil.Log("payments", "error", "card declined"),
+		testutil.Gauge("checkout", "queue.depth", 7),
+		testutil.Span("payments", "charge", 80*time.Millisecond),
+	}
+	path := filepath.Join(t.TempDir(), "otail.db")
+	s := openTemp(t, path)
+
+	c := testutil.NewCollector(t, frames...)
+	h := hub.New(nil)
+	sub := h.Subscribe(&hub.SubscribeOptions{Block: true})
+	done := make(chan error, 1)
+	go func() { done <- s.Record(sub) }()
+	ctx, cancel := context.WithCancel(context.Background())
+	go h.Run(ctx, transport.DialSource(c.URL, c.Origin, nil))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		recent, err := s.Recent(context.Background(), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) == len(frames) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("recorded %d of %d frames", len(recent), len(frames))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s = openTemp(t, path)
+	ctx = context.Background()
+	recent, err := s.Recent(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []telemetry.Kind
+	for i, e := range recent {
+		if want := uint64(i + 3); e.Seq != want {
+			t.Errorf("recent[%d].Seq = %d, want %d", i, e.Seq, want)
+		}
+		kinds = append(kinds, e.Kind)
+	}
+	if want := []telemetry.Kind{telemetry.KindLogs, telemetry.KindMetrics, telemetry.KindTraces}; !slices.Equal(kinds, want) {
+		t.Errorf("recent kinds = %v, want %v", kinds, want)
+	}
+
+	for _, tc := range []struct {
+		q    LogQuery
+		want []string
+	}{
+		{LogQuery{}, []string{"order placed", "card declined"}},
+		{LogQuery{Texts: []string{"DECLINED"}}, []string{"card declined"}},
+		{LogQuery{Texts: []string{"declined", "CARD"}}, []string{"card declined"}}, // every term, in any order
+		{LogQuery{Texts: []string{"ch"}}, []string{"order placed"}},                // the service, by scanning
+		{LogQuery{Texts: []string{"refund"}}, nil},
+		{LogQuery{Limit: 1}, []string{"card declined"}},
+		{LogQuery{Since: testutil.Epoch.Add(time.Second)}, nil},
+		{LogQuery{Service: "PAYMENTS"}, []string{"card declined"}},
+		{LogQuery{Severities: []string{"WARN", "ERROR", "FATAL"}}, []string{"card declined"}},
+		{LogQuery{TraceID: testutil.TraceID.String()}, nil},
+		{LogQuery{Attrs: map[string]string{"http.route": "/cart"}}, nil},
+	} {
+		logs, err := s.Logs(ctx, tc.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var bodies []string
+		for _, l := range logs {
+			bodies = append(bodies, l.Body)
+		}
+		if !slices.Equal(bodies, tc.want) {
+			t.Errorf("Logs(%+v) = %q, want %q", tc.q, bodies, tc.want)
+		}
+	}
+	logs, err := s.Logs(ctx, LogQuery{Texts: []string{"card"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := logs[0]; l.Service != "payments" || l.Severity != "ERROR" || !l.Time.Equal(testutil.Epoch) {
+		t.Errorf("stored record = %+v", l)
+	}
+
+	trace, err := s.Trace(ctx, testutil.TraceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trace) != 2 {
+		t.Fatalf("Trace returned %d frames, want 2", len(trace))
+	}
+}
@@ -0,0 +1,95 @@
+package telemetry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ANSI is what rendering does with ANSI escape sequences in string values,
+// which services that color their own log output leave in their bodies.
+type ANSI int
+
+const (
+	ANSIEscape ANSI = iota // show them escaped, as \u001b[31m
+	ANSIStrip              // remove them
+	ANSIColor              // color the text as the service did; sequences other than colors are removed
+)
+
+// ANSIModes names the modes ParseANSI accepts, in order.
+var ANSIModes = []string{"escape", "strip", "color"}
+
+// ParseANSI maps "escape", "strip", or "color" to an ANSI mode; "" is
+// escape.
+func ParseANSI(s string) (ANSI, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "escape":
+		return ANSIEscape, nil
+	case "strip":
+		return ANSIStrip, nil
+	case "color", "colour":
+		return ANSIColor, nil
+	}
+	return 0, fmt.Errorf("unknown ANSI mode %q (want escape, strip, or color)", s)
+}
+
+func (a ANSI) String() string {
+	if int(a) < len(ANSIModes) {
+		return ANSIModes[a]
+	}
+	return fmt.Sprintf("ANSI(%d)", int(a))
+}
+
+var (
+	// escapedCSI is a control sequence as encoding/json escapes it inside
+	// a string literal, and rawCSI the same in decoded text.
+	escapedCSI = regexp.MustCompile(`\\u001b\[([0-9;:]*)([ -/]*[@-~])`)
+	rawCSI     = regexp.MustCompile("\x1b\\[([0-9;:]*)([ -/]*[@-~])")
+)
+
+// sgrReset ends a colored value so its colors don't run into the rest of
+// the line.
+const sgrReset = "\x1b[0m"
+
+// literal applies a to the control sequences in a JSON string literal.
+func (a ANSI) literal(lit string) string {
+	if a == ANSIEscape || !strings.Contains(lit, `\u001b[`) {
+		return lit
+	}
+	colored := false
+	lit = escapedCSI.ReplaceAllStringFunc(lit, func(seq string) string {
+		m := escapedCSI.FindStringSubmatch(seq)
+		if a != ANSIColor || m[2] != "m" {
+			return ""
+		}
+		colored = true
+		return "\x1b[" + m[1] + "m"
+	})
+	if colored {
+		lit = strings.TrimSuffix(lit, `"`) + sgrReset + `"`
+	}
+	return lit
+}
+
+// Text applies a to the control sequences in decoded text, and replaces
+// any other control characters with '.', so s is safe to print.
+func (a ANSI) Text(s string) string {
+	if a == ANSIEscape || !strings.Contains(s, "\x1b[") {
+		return printable(s)
+	}
+	var b strings.Builder
+	colored, last := false, 0
+	for _, m := range rawCSI.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(printable(s[last:m[0]]))
+		last = m[1]
+		if a == ANSIColor && s[m[4]:m[5]] == "m" {
+			b.WriteString(s[m[0]:m[1]])
+			colored = true
+		}
+	}
+	b.WriteString(printable(s[last:]))
+	if colored {
+		b.WriteString(sgrReset)
+	}
+	return b.String()
+}
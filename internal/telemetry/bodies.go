@@ -0,0 +1,49 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// noTime stands in for the time of a record that carries none, so bodies
+// stay lined up.
+const noTime = "--:--:--.---"
+
+// bodyLines renders each log record in m on a line of its own as its time,
+// severity, and body, as in a plain log file, leaving out the OTLP envelope.
+// Bodies that span lines carry on under the first, past the time and
+// severity.
+func (m Message) bodyLines(f Format) rendering {
+	var lines []string
+	for _, r := range m.Records() {
+		ts := noTime
+		if !r.Time.IsZero() {
+			ts = r.Time.Format("15:04:05.000")
+		}
+		prefix := fmt.Sprintf("%s %-5s ", ts, r.Severity)
+		for i, l := range strings.Split(strings.TrimRight(r.Text, "\r\n"), "\n") {
+			if i == 1 {
+				prefix = strings.Repeat(" ", len(prefix))
+			}
+			lines = append(lines, prefix+f.ANSI.Text(strings.ReplaceAll(l, "\t", "    ")))
+		}
+	}
+	if len(lines) == 0 {
+		lines = []string{"(no log records)"}
+	}
+	return rendering{lines: lines}
+}
+
+// recordLines renders each record as the line f.Line gives it, and false
+// if it gives none for one of them.
+func (m Message) recordLines(f Format) (rendering, bool) {
+	var lines []string
+	for _, r := range m.Records() {
+		l, ok := f.Line(r)
+		if !ok {
+			return rendering{}, false
+		}
+		lines = append(lines, f.ANSI.Text(l))
+	}
+	return rendering{lines: lines}, len(lines) > 0
+}
@@ -0,0 +1,226 @@
+package telemetry
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// barWidth is the width in cells of the longest bucket bar.
+const barWidth = 24
+
+// chartBuckets replaces the bucket arrays of a histogram or exponential
+// histogram data point with a "buckets" list of bar chart rows, one per
+// bucket with its bounds and count. Anything else, or buckets that don't
+// add up, is returned unchanged.
+func chartBuckets(n *node) *node {
+	if n.kind != '{' {
+		return n
+	}
+	var rows []string
+	var replaced []string
+	if counts, ok := n.field("bucketCounts"); ok {
+		if _, ok := n.field("offset"); ok {
+			return n // one side of an exponential histogram, charted with its data point
+		}
+		bounds, _ := n.field("explicitBounds")
+		rows, ok = explicitRows(bounds, counts)
+		if !ok {
+			return n
+		}
+		replaced = []string{"bucketCounts", "explicitBounds"}
+	} else if _, ok := n.field("scale"); ok {
+		rows, ok = exponentialRows(n)
+		if !ok {
+			return n
+		}
+		replaced = []string{"negative", "zeroCount", "positive"}
+	} else {
+		return n
+	}
+
+	out := &node{kind: '{'}
+	done := false
+	for i, k := range n.keys {
+		if !slices.Contains(replaced, k) {
+			out.keys = append(out.keys, k)
+			out.vals = append(out.vals, n.vals[i])
+			continue
+		}
+		if !done {
+			list := &node{kind: '['}
+			for _, r := range rows {
+				list.vals = append(list.vals, &node{lit: quote(r)})
+			}
+			out.keys = append(out.keys, "buckets")
+			out.vals = append(out.vals, list)
+			done = true
+		}
+	}
+	return out
+}
+
+// explicitRows labels a histogram's buckets from its explicit bounds: the
+// first is (-∞, b0], the last (bn, +∞).
+func explicitRows(bounds, counts *node) ([]string, bool) {
+	cs, ok := uints(counts)
+	if !ok || len(cs) == 0 {
+		return nil, false
+	}
+	var bs []string
+	if bounds != nil {
+		for _, b := range bounds.vals {
+			if b.kind != 0 {
+				return nil, false
+			}
+			bs = append(bs, b.lit)
+		}
+	}
+	if len(bs)+1 != len(cs) {
+		return nil, false
+	}
+	labels := make([]string, len(cs))
+	for i := range cs {
+		lo, hi := "-∞", "+∞)"
+		if i > 0 {
+			lo = bs[i-1]
+		}
+		if i < len(bs) {
+			hi = bs[i] + "]"
+		}
+		labels[i] = "(" + lo + ", " + hi
+	}
+	return barRows(labels, cs), true
+}
+
+// exponentialRows labels an exponential histogram's buckets: bucket i of
+// the positive range covers (base^i, base^(i+1)] where base = 2^(2^-scale),
+// mirrored for the negative range, which is listed first, most negative at
+// the top, followed by the zero bucket.
+func exponentialRows(n *node) ([]string, bool) {
+	scale, ok := intField(n, "scale")
+	if !ok {
+		return nil, false
+	}
+	bound := func(i int64) string {
+		return strconv.FormatFloat(math.Exp2(float64(i)*math.Exp2(float64(-scale))), 'g', 4, 64)
+	}
+	var labels []string
+	var counts []uint64
+	if neg, ok := n.field("negative"); ok {
+		offset, cs, ok := expBuckets(neg)
+		if !ok {
+			return nil, false
+		}
+		for k := len(cs) - 1; k >= 0; k-- {
+			i := offset + int64(k)
+			labels = append(labels, "[-"+bound(i+1)+", -"+bound(i)+")")
+			counts = append(counts, cs[k])
+		}
+	}
+	zero, ok := intField(n, "zeroCount")
+	if !ok {
+		return nil, false
+	}
+	labels = append(labels, "zero")
+	counts = append(counts, uint64(zero))
+	if pos, ok := n.field("positive"); ok {
+		offset, cs, ok := expBuckets(pos)
+		if !ok {
+			return nil, false
+		}
+		for k, c := range cs {
+			i := offset + int64(k)
+			labels = append(labels, "("+bound(i)+", "+bound(i+1)+"]")
+			counts = append(counts, c)
+		}
+	}
+	return barRows(labels, counts), true
+}
+
+// expBuckets reads an exponential histogram's {"offset", "bucketCounts"}.
+func expBuckets(n *node) (int64, []uint64, bool) {
+	offset, ok := intField(n, "offset")
+	if !ok {
+		return 0, nil, false
+	}
+	counts, ok := n.field("bucketCounts")
+	if !ok {
+		return offset, nil, true
+	}
+	cs, ok := uints(counts)
+	return offset, cs, ok
+}
+
+// intField reads an integer field, which OTLP JSON omits when zero and
+// quotes when 64 bits wide.
+func intField(n *node, key string) (int64, bool) {
+	v, ok := n.field(key)
+	if !ok {
+		return 0, true
+	}
+	if v.kind != 0 {
+		return 0, false
+	}
+	s := v.lit
+	if u, err := strconv.Unquote(s); err == nil {
+		s = u
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	return i, err == nil
+}
+
+func uints(n *node) ([]uint64, bool) {
+	if n.kind != '[' {
+		return nil, false
+	}
+	out := make([]uint64, len(n.vals))
+	for i, v := range n.vals {
+		if v.kind != 0 {
+			return nil, false
+		}
+		s := v.lit
+		if u, err := strconv.Unquote(s); err == nil {
+			s = u
+		}
+		c, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		out[i] = c
+	}
+	return out, true
+}
+
+// barRows lays out one row per bucket: label, a bar scaled to the fullest
+// bucket in eighths of a cell, and the count.
+func barRows(labels []string, counts []uint64) []string {
+	labelWidth, countWidth := 0, 0
+	var most uint64
+	for i, l := range labels {
+		labelWidth = max(labelWidth, len([]rune(l)))
+		countWidth = max(countWidth, len(strconv.FormatUint(counts[i], 10)))
+		most = max(most, counts[i])
+	}
+	rows := make([]string, len(labels))
+	for i, l := range labels {
+		rows[i] = fmt.Sprintf("%s%s %s %*d", l, strings.Repeat(" ", labelWidth-len([]rune(l))), bar(counts[i], most), countWidth, counts[i])
+	}
+	return rows
+}
+
+var eighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+func bar(count, most uint64) string {
+	if most == 0 {
+		return strings.Repeat(" ", barWidth)
+	}
+	n := int(float64(count) / float64(most) * barWidth * 8)
+	if n == 0 && count > 0 {
+		n = 1 // a non-empty bucket is never invisible
+	}
+	b := strings.Repeat("█", n/8) + eighths[n%8]
+	return b + strings.Repeat(" ", barWidth-len([]rune(b)))
+}
@@ -0,0 +1,447 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Format controls how Lines renders a message; the zero value indents the
+// JSON with keys sorted and attribute lists flattened.
+type Format struct {
+	ReceivedOrder bool // keep object keys in the order received instead of sorting them
+	MaxDepth      int  // collapse objects and arrays nested deeper than this to {…} and […]; 0 = no limit
+	RawAttributes bool // show attribute lists and histogram buckets as OTLP sends them instead of {"key": value} objects and bar charts
+	Hex           bool // show unknown frames as a hexdump even when they are text
+	Compact       bool // render each message on a single line
+	MaxValue      int  // cut string values longer than this many characters short with …; 0 = no limit
+	FlatStacks    bool // show stack traces in string values as one escaped line instead of a frame per line
+	ANSI          ANSI // what to do with ANSI escape sequences in string values
+	Bodies        bool // show log records as time, severity, and body lines instead of OTLP JSON
+	FullIDs       bool // show trace and span IDs in full instead of their first few characters
+
+	// Line, when set, gives the line Compact shows for a record. A message
+	// it gives a line for every record of is shown as those lines instead
+	// of as JSON.
+	Line func(Record) (string, bool)
+}
+
+var (
+	formatMu  sync.RWMutex
+	format    Format
+	formatGen atomic.Uint64 // bumped by SetFormat so cached lines are re-rendered
+)
+
+// SetFormat changes how every message renders from now on.
+func SetFormat(f Format) {
+	formatMu.Lock()
+	format = f
+	formatMu.Unlock()
+	formatGen.Add(1)
+}
+
+// CurrentFormat returns the format Lines renders with.
+func CurrentFormat() Format {
+	formatMu.RLock()
+	defer formatMu.RUnlock()
+	return format
+}
+
+// node is a decoded JSON value that remembers the order of object keys.
+type node struct {
+	kind byte // '{', '[', or 0 for a scalar
+	keys []string
+	vals []*node
+	lit  string // scalar as JSON text
+}
+
+// parseNode decodes b, returning false if it is not a single JSON value.
+func parseNode(b []byte) (*node, bool) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	n, err := decodeNode(d)
+	if err != nil {
+		return nil, false
+	}
+	if _, err := d.Token(); err == nil {
+		return nil, false // trailing data
+	}
+	return n, true
+}
+
+func decodeNode(d *json.Decoder) (*node, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &node{kind: byte(t)}
+		for d.More() {
+			if n.kind == '{' {
+				k, err := d.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, k.(string))
+			}
+			v, err := decodeNode(d)
+			if err != nil {
+				return nil, err
+			}
+			n.vals = append(n.vals, v)
+		}
+		if _, err := d.Token(); err != nil { // closing delimiter
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &node{lit: quote(t)}, nil
+	case json.Number:
+		return &node{lit: t.String()}, nil
+	case bool:
+		return &node{lit: strconv.FormatBool(t)}, nil
+	case nil:
+		return &node{lit: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unexpected token %v", tok)
+	}
+}
+
+// field returns the value of key in an object node.
+func (n *node) field(key string) (*node, bool) {
+	if n.kind != '{' {
+		return nil, false
+	}
+	if i := slices.Index(n.keys, key); i >= 0 {
+		return n.vals[i], true
+	}
+	return nil, false
+}
+
+// rendering is a message rendered to lines, with what the UI needs to
+// know about them.
+type rendering struct {
+	lines   []string
+	values  map[int]int  // lines holding a value that can be toggled, to the value's ordinal
+	starts  map[int]int  // ordinal of each such value to its first line
+	library map[int]bool // lines in a library frame of a stack trace
+}
+
+// render writes n as indented lines under f, numbering them from base.
+// Values longer than f.MaxValue are cut short, and string values holding a
+// stack trace are shown a frame per line, unless their ordinal is toggled.
+func render(n *node, f Format, base int, toggled map[int]bool) rendering {
+	if !f.RawAttributes {
+		n = flatten(n)
+	}
+	if !f.FullIDs {
+		n = shortenIDs(n)
+	}
+	r := renderer{f: f, base: base, toggled: toggled}
+	if f.Compact {
+		b := getBuffer()
+		r.inline(b, n, 0)
+		r.lines = []string{b.String()}
+		putBuffer(b)
+		return r.rendering
+	}
+	r.write(n, 0, "", "")
+	return r.rendering
+}
+
+type renderer struct {
+	rendering
+	f Format
+
+	base    int          // number of the first line
+	ord     int          // ordinal of the next scalar
+	toggled map[int]bool // values, by ordinal, shown the other way
+}
+
+// mark records that line shows part of the value with ordinal ord.
+func (r *renderer) mark(line, ord int) {
+	if r.values == nil {
+		r.values, r.starts = make(map[int]int), make(map[int]int)
+	}
+	r.values[line] = ord
+	if _, ok := r.starts[ord]; !ok {
+		r.starts[ord] = line
+	}
+}
+
+// value returns a scalar as shown on the next line: cut short if it is a
+// string longer than f.MaxValue, unless it is toggled, and with its escape
+// sequences shown as f.ANSI says. In compact form the whole message is one
+// line, so every value there shares ordinal 0.
+func (r *renderer) value(lit string, ord int) string {
+	return r.f.ANSI.literal(r.cut(lit, ord))
+}
+
+// cut returns lit cut short if it is a string longer than f.MaxValue and
+// not toggled.
+func (r *renderer) cut(lit string, ord int) string {
+	line := r.base + len(r.lines)
+	if r.f.MaxValue <= 0 || lit == "" || lit[0] != '"' || utf8.RuneCountInString(lit)-2 <= r.f.MaxValue {
+		return lit
+	}
+	r.mark(line, ord)
+	if r.toggled[ord] {
+		return lit
+	}
+	body, n := lit[1:len(lit)-1], 0
+	for i := range body {
+		if n == r.f.MaxValue {
+			body = body[:i]
+			break
+		}
+		n++
+	}
+	// Don't leave half an escape sequence before the ellipsis.
+	return `"` + strings.TrimSuffix(body, `\`) + `…"`
+}
+
+// scalar writes a scalar on its own line, or a string holding a stack
+// trace over as many lines as it has, unless f.FlatStacks.
+func (r *renderer) scalar(lit, prefix, suffix string) {
+	ord := r.ord
+	r.ord++
+	if !r.f.FlatStacks && strings.Contains(lit, `\n`) {
+		var s string
+		if json.Unmarshal([]byte(lit), &s) == nil {
+			if st, ok := parseStack(s); ok {
+				r.stack(st, ord, prefix, suffix)
+				return
+			}
+		}
+	}
+	r.lines = append(r.lines, prefix+r.value(lit, ord)+suffix)
+}
+
+// stack writes st inside the quotes of a string value, indenting each
+// line by its depth under the key; a toggled trace is collapsed to its
+// first frame.
+func (r *renderer) stack(st stack, ord int, prefix, suffix string) {
+	lines := st.lines
+	if r.toggled[ord] {
+		lines = st.collapsed()
+	}
+	indent := prefix[:len(prefix)-len(strings.TrimLeft(prefix, " "))] + "  "
+	for i, l := range lines {
+		line := r.base + len(r.lines)
+		text := r.f.ANSI.Text(l.text)
+		s := indent + indentation(l.depth) + text
+		if i == 0 {
+			s = prefix + `"` + text
+		}
+		if i == len(lines)-1 {
+			s += `"` + suffix
+		}
+		r.mark(line, ord)
+		if l.library {
+			if r.library == nil {
+				r.library = make(map[int]bool)
+			}
+			r.library[line] = true
+		}
+		r.lines = append(r.lines, s)
+	}
+}
+
+// write renders n at depth, with prefix (indent and key) before it and
+// suffix (a trailing comma) after it.
+func (r *renderer) write(n *node, depth int, prefix, suffix string) {
+	if n.kind == 0 {
+		r.scalar(n.lit, prefix, suffix)
+		return
+	}
+	open, close := string(n.kind), "}"
+	if n.kind == '[' {
+		close = "]"
+	}
+	if len(n.vals) == 0 {
+		r.lines = append(r.lines, prefix+open+close+suffix)
+		return
+	}
+	if r.f.MaxDepth > 0 && depth > r.f.MaxDepth {
+		r.lines = append(r.lines, prefix+open+"…"+close+suffix)
+		return
+	}
+	r.lines = append(r.lines, prefix+open)
+	indent := indentation(depth + 1)
+	order := r.order(n)
+	for j, i := range order {
+		p := indent
+		if n.kind == '{' {
+			p += quote(n.keys[i]) + ": "
+		}
+		s := ","
+		if j == len(order)-1 {
+			s = ""
+		}
+		r.write(n.vals[i], depth+1, p, s)
+	}
+	r.lines = append(r.lines, indentation(depth)+close+suffix)
+}
+
+// inline renders n on one line, as compact JSON, honouring the same key
+// order and depth limit as write.
+func (r *renderer) inline(b *bytes.Buffer, n *node, depth int) {
+	if n.kind == 0 {
+		b.WriteString(r.value(n.lit, 0))
+		return
+	}
+	open, close := string(n.kind), "}"
+	if n.kind == '[' {
+		close = "]"
+	}
+	b.WriteString(open)
+	if len(n.vals) > 0 && r.f.MaxDepth > 0 && depth > r.f.MaxDepth {
+		b.WriteString("…")
+	} else {
+		for j, i := range r.order(n) {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			if n.kind == '{' {
+				b.WriteString(quote(n.keys[i]))
+				b.WriteByte(':')
+			}
+			r.inline(b, n.vals[i], depth+1)
+		}
+	}
+	b.WriteString(close)
+}
+
+// order returns the indexes of n's children in display order.
+func (r *renderer) order(n *node) []int {
+	order := make([]int, len(n.vals))
+	for i := range order {
+		order[i] = i
+	}
+	if n.kind == '{' && !r.f.ReceivedOrder {
+		slices.SortStableFunc(order, func(a, b int) int { return strings.Compare(n.keys[a], n.keys[b]) })
+	}
+	return order
+}
+
+// flatten rewrites every OTLP attribute list, [{"key": k, "value": {"stringValue": v}}, ...]
+// under an "attributes" key, into an object {k: v}, and the buckets of every
+// histogram data point into a bar chart.
+func flatten(n *node) *node {
+	if n.kind == 0 {
+		return n
+	}
+	n = chartBuckets(n)
+	out := &node{kind: n.kind, keys: n.keys, vals: make([]*node, len(n.vals))}
+	for i, v := range n.vals {
+		if n.kind == '{' && n.keys[i] == "attributes" {
+			if kv, ok := keyValues(v); ok {
+				out.vals[i] = kv
+				continue
+			}
+		}
+		out.vals[i] = flatten(v)
+	}
+	return out
+}
+
+// idKeys are the OTLP fields holding trace and span IDs.
+var idKeys = []string{"traceId", "spanId", "parentSpanId"}
+
+// shortID is how many characters of an ID are shown unless Format.FullIDs;
+// eight hex digits tell the IDs in a tail apart.
+const shortID = 8
+
+// shortenIDs cuts every trace and span ID in n to its first shortID
+// characters and an ellipsis.
+func shortenIDs(n *node) *node {
+	if n.kind == 0 {
+		return n
+	}
+	out := &node{kind: n.kind, keys: n.keys, vals: make([]*node, len(n.vals))}
+	for i, v := range n.vals {
+		if n.kind == '{' && v.kind == 0 && strings.HasPrefix(v.lit, `"`) && len(v.lit) > shortID+2 && slices.Contains(idKeys, n.keys[i]) {
+			out.vals[i] = &node{lit: v.lit[:shortID+1] + `…"`}
+			continue
+		}
+		out.vals[i] = shortenIDs(v)
+	}
+	return out
+}
+
+// keyValues converts a list of OTLP KeyValue objects into an object node.
+func keyValues(n *node) (*node, bool) {
+	if n.kind != '[' {
+		return nil, false
+	}
+	out := &node{kind: '{'}
+	for _, kv := range n.vals {
+		k, ok := kv.field("key")
+		if !ok || k.kind != 0 || len(kv.keys) > 2 {
+			return nil, false
+		}
+		key, err := strconv.Unquote(k.lit)
+		if err != nil {
+			return nil, false
+		}
+		val := &node{lit: "null"}
+		if v, ok := kv.field("value"); ok {
+			if val, ok = anyValue(v); !ok {
+				return nil, false
+			}
+		}
+		out.keys = append(out.keys, key)
+		out.vals = append(out.vals, val)
+	}
+	return out, true
+}
+
+// anyValue unwraps an OTLP AnyValue such as {"intValue": "3"} into a plain
+// JSON value.
+func anyValue(n *node) (*node, bool) {
+	if n.kind != '{' || len(n.keys) > 1 {
+		return nil, false
+	}
+	if len(n.keys) == 0 {
+		return &node{lit: "null"}, true
+	}
+	v := n.vals[0]
+	switch n.keys[0] {
+	case "stringValue", "boolValue", "doubleValue", "bytesValue":
+		return v, v.kind == 0
+	case "intValue":
+		// int64 values are strings in OTLP JSON.
+		if s, err := strconv.Unquote(v.lit); err == nil {
+			if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return &node{lit: s}, true
+			}
+		}
+		return v, v.kind == 0
+	case "arrayValue":
+		out := &node{kind: '['}
+		if vals, ok := v.field("values"); ok && vals.kind == '[' {
+			for _, e := range vals.vals {
+				ev, ok := anyValue(e)
+				if !ok {
+					return nil, false
+				}
+				out.vals = append(out.vals, ev)
+			}
+		}
+		return out, true
+	case "kvlistValue":
+		if vals, ok := v.field("values"); ok {
+			return keyValues(vals)
+		}
+		return &node{kind: '{'}, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,202 @@
+package telemetry
+
+import (
+	pcommon "go.opentelemetry.io/collector/pdata/pcommon"
+	plog "go.opentelemetry.io/collector/pdata/plog"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Each decoded frame holds its own copy of every string in it, though
+// resource attributes, scope names, attribute keys, span and metric names,
+// and most attribute values are the same in nearly every message of a
+// session. An Interner swaps those for one shared copy.
+
+const (
+	// maxInterned bounds the strings an Interner remembers, so a value that
+	// never repeats, like a request ID, can't grow it forever; past it,
+	// only strings already known are shared.
+	maxInterned = 1 << 17
+	// maxInternLen is the longest attribute value worth interning; longer
+	// ones are rarely repeated.
+	maxInternLen = 128
+)
+
+// Interner makes repeated strings in messages share memory. It is not safe
+// for concurrent use.
+type Interner struct {
+	strs  map[string]string
+	stats InternStats
+}
+
+// InternStats counts what an Interner has done.
+type InternStats struct {
+	Strings int   // distinct strings remembered
+	Shared  int   // strings replaced with a copy already held
+	Saved   int64 // bytes those replaced strings took
+}
+
+func NewInterner() *Interner {
+	return &Interner{strs: make(map[string]string)}
+}
+
+// Stats returns the counts so far.
+func (in *Interner) Stats() InternStats {
+	return in.stats
+}
+
+func (in *Interner) str(s string) string {
+	if s == "" {
+		return s
+	}
+	if c, ok := in.strs[s]; ok {
+		in.stats.Shared++
+		in.stats.Saved += int64(len(s))
+		return c
+	}
+	if len(in.strs) < maxInterned {
+		in.strs[s] = s
+		in.stats.Strings++
+	}
+	return s
+}
+
+// Intern replaces the repeated strings in m's decoded data with shared
+// copies. Log bodies and span events' own text are left alone.
+func (in *Interner) Intern(m Message) {
+	switch m.Kind {
+	case KindLogs:
+		in.logs(m.Logs)
+	case KindMetrics:
+		in.metrics(m.Metrics)
+	case KindTraces:
+		in.traces(m.Traces)
+	}
+}
+
+func (in *Interner) logs(ld plog.Logs) {
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		in.attrs(rl.Resource().Attributes())
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			sl := sls.At(j)
+			in.scope(sl.Scope())
+			lrs := sl.LogRecords()
+			for k := 0; k < lrs.Len(); k++ {
+				lr := lrs.At(k)
+				lr.SetSeverityText(in.str(lr.SeverityText()))
+				in.attrs(lr.Attributes())
+			}
+		}
+	}
+}
+
+func (in *Interner) traces(td ptrace.Traces) {
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		in.attrs(rs.Resource().Attributes())
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			ss := sss.At(j)
+			in.scope(ss.Scope())
+			spans := ss.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				s := spans.At(k)
+				s.SetName(in.str(s.Name()))
+				in.attrs(s.Attributes())
+				events := s.Events()
+				for e := 0; e < events.Len(); e++ {
+					events.At(e).SetName(in.str(events.At(e).Name()))
+					in.attrs(events.At(e).Attributes())
+				}
+				links := s.Links()
+				for l := 0; l < links.Len(); l++ {
+					in.attrs(links.At(l).Attributes())
+				}
+			}
+		}
+	}
+}
+
+func (in *Interner) metrics(md pmetric.Metrics) {
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		in.attrs(rm.Resource().Attributes())
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sm := sms.At(j)
+			in.scope(sm.Scope())
+			ms := sm.Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				m := ms.At(k)
+				m.SetName(in.str(m.Name()))
+				m.SetDescription(in.str(m.Description()))
+				m.SetUnit(in.str(m.Unit()))
+				in.dataPoints(m)
+			}
+		}
+	}
+}
+
+func (in *Interner) dataPoints(m pmetric.Metric) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < m.Gauge().DataPoints().Len(); i++ {
+			in.attrs(m.Gauge().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < m.Sum().DataPoints().Len(); i++ {
+			in.attrs(m.Sum().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < m.Histogram().DataPoints().Len(); i++ {
+			in.attrs(m.Histogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < m.ExponentialHistogram().DataPoints().Len(); i++ {
+			in.attrs(m.ExponentialHistogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < m.Summary().DataPoints().Len(); i++ {
+			in.attrs(m.Summary().DataPoints().At(i).Attributes())
+		}
+	}
+}
+
+func (in *Interner) scope(s pcommon.InstrumentationScope) {
+	s.SetName(in.str(s.Name()))
+	s.SetVersion(in.str(s.Version()))
+	in.attrs(s.Attributes())
+}
+
+// attrs rebuilds m with interned keys and short string values; pcommon
+// has no way to change a key in place.
+func (in *Interner) attrs(m pcommon.Map) {
+	if m.Len() == 0 {
+		return
+	}
+	out := pcommon.NewMap()
+	out.EnsureCapacity(m.Len())
+	m.Range(func(k string, v pcommon.Value) bool {
+		nv := out.PutEmpty(in.str(k))
+		switch v.Type() {
+		case pcommon.ValueTypeStr:
+			if s := v.Str(); len(s) <= maxInternLen {
+				nv.SetStr(in.str(s))
+			} else {
+				nv.SetStr(s)
+			}
+		case pcommon.ValueTypeMap:
+			v.CopyTo(nv)
+			in.attrs(nv.Map())
+		default:
+			v.CopyTo(nv)
+		}
+		return true
+	})
+	out.MoveTo(m)
+}
@@ -0,0 +1,243 @@
+package telemetry
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	plog "go.opentelemetry.io/collector/pdata/plog"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Message is the canonical form that UI and transport layers consume. It
+// carries the decoded pdata for its kind and a Summary; render forms are
+// computed lazily and cached, so copies of a Message share the work.
+type Message struct {
+	Kind Kind   // logs, metrics, traces, or unknown
+	Raw  []byte // the frame as received
+
+	Logs    plog.Logs       // set when Kind == KindLogs
+	Metrics pmetric.Metrics // set when Kind == KindMetrics
+	Traces  ptrace.Traces   // set when Kind == KindTraces
+
+	Diagnostics []string // unknown only: why each unmarshaler rejected the frame
+
+	Received time.Time // when the UI took the frame in; zero if not set
+	Warning  string    // why the UI found it out of order or repeated; empty = neither
+
+	summary *Summary
+	render  *renderCache
+}
+
+type renderCache struct {
+	mu  sync.Mutex
+	gen uint64 // formatGen the lines were rendered under
+	rendering
+	toggled map[int]bool // values, by ordinal, shown the other way: long ones in full, stack traces collapsed
+}
+
+func newMessage(m Message) Message {
+	s := summarize(m)
+	m.summary = &s
+	m.render = &renderCache{}
+	return m
+}
+
+// Lines returns the message as indented JSON in the current Format, one
+// entry per line.
+func (m Message) Lines() []string {
+	if m.render == nil {
+		return m.indentedLines(CurrentFormat(), nil).lines
+	}
+	c := m.render
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.update(m)
+	return c.lines
+}
+
+// update re-renders the lines if they are missing or the format changed.
+// A new format can render values differently, so it untoggles them all.
+func (c *renderCache) update(m Message) {
+	if gen := formatGen.Load(); c.lines == nil || c.gen != gen {
+		if c.gen != gen {
+			c.toggled = nil
+		}
+		c.rendering = m.indentedLines(CurrentFormat(), c.toggled)
+		c.gen = gen
+	}
+}
+
+// ToggleValue shows the value on line j in full if it is cut short, or
+// cuts it short again; a stack trace on line j is collapsed to its first
+// frame, or expanded again. It returns the value's first line, and false
+// if line j has nothing to toggle. Copies of the message share the change.
+func (m Message) ToggleValue(j int) (start int, ok bool) {
+	c := m.render
+	if c == nil {
+		return 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.update(m)
+	ord, ok := c.values[j]
+	if !ok {
+		return 0, false
+	}
+	if c.toggled[ord] {
+		delete(c.toggled, ord)
+	} else {
+		if c.toggled == nil {
+			c.toggled = make(map[int]bool)
+		}
+		c.toggled[ord] = true
+	}
+	c.lines = nil
+	c.update(m)
+	return c.starts[ord], true
+}
+
+// DropRendered forgets the message's rendered lines to free memory, and
+// returns about how many bytes they took. Lines renders them again when
+// next asked; values toggled stay toggled.
+func (m Message) DropRendered() int {
+	c := m.render
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, l := range c.lines {
+		n += len(l) + 16 // and the string header
+	}
+	c.rendering = rendering{}
+	return n
+}
+
+// FullLines returns the message as Lines does, but with nothing shortened:
+// values and IDs in full and stack traces as the escaped strings they
+// are. It is what copying a message takes.
+func (m Message) FullLines() []string {
+	return m.indentedLines(CurrentFormat().full(), nil).lines
+}
+
+// JSONLines returns FullLines, but always as JSON and with escape
+// sequences escaped, so the lines parse.
+func (m Message) JSONLines() []string {
+	f := CurrentFormat().full()
+	f.Bodies, f.ANSI = false, ANSIEscape
+	return m.indentedLines(f, nil).lines
+}
+
+// full returns f with everything that shortens what is shown turned off.
+func (f Format) full() Format {
+	f.MaxValue, f.FlatStacks, f.FullIDs = 0, true, true
+	return f
+}
+
+// LibraryFrame reports whether line j is part of a stack frame outside the
+// application, such as the runtime or a framework.
+func (m Message) LibraryFrame(j int) bool {
+	c := m.render
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.update(m)
+	return c.library[j]
+}
+
+// indentedLines renders the message in f with the toggled values shown
+// the other way.
+func (m Message) indentedLines(f Format, toggled map[int]bool) rendering {
+	var (
+		out []byte
+		err error
+	)
+	if f.Compact && f.Line != nil && m.Kind != KindUnknown {
+		if r, ok := m.recordLines(f); ok {
+			return r
+		}
+	}
+	switch m.Kind {
+	case KindLogs:
+		if f.Bodies {
+			return m.bodyLines(f)
+		}
+		out, err = (&plog.JSONMarshaler{}).MarshalLogs(m.Logs)
+	case KindMetrics:
+		out, err = (&pmetric.JSONMarshaler{}).MarshalMetrics(m.Metrics)
+	case KindTraces:
+		out, err = (&ptrace.JSONMarshaler{}).MarshalTraces(m.Traces)
+	default:
+		lines := make([]string, 0, len(m.Diagnostics))
+		for _, d := range m.Diagnostics {
+			lines = append(lines, "// "+printable(d))
+		}
+		if f.Hex || !utf8.Valid(m.Raw) {
+			return rendering{lines: append(lines, hexdump(m.Raw)...)}
+		}
+		body := pretty(m.Raw, f, len(lines), toggled)
+		body.lines = append(lines, body.lines...)
+		return body
+	}
+	if err != nil {
+		// Fallback: just show the incoming bytes.
+		return pretty(m.Raw, f, 0, toggled)
+	}
+	return pretty(out, f, 0, toggled)
+}
+
+// pretty re-indents JSON in f, numbering lines from base, falling back to
+// the input as a single line.
+func pretty(b []byte, f Format, base int, toggled map[int]bool) rendering {
+	if n, ok := parseNode(b); ok {
+		return render(n, f, base, toggled)
+	}
+	return rendering{lines: []string{string(b)}}
+}
+
+// hexdump renders b as offset, hex, and ASCII columns, like hexdump -C.
+// Binary frames go through here so they can't garble the terminal.
+func hexdump(b []byte) []string {
+	if len(b) == 0 {
+		return []string{"(empty frame)"}
+	}
+	return strings.Split(strings.TrimSuffix(hex.Dump(b), "\n"), "\n")
+}
+
+// printable replaces control characters and invalid UTF-8, which the
+// unmarshalers quote from the frame in their errors, with '.'.
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '.'
+		}
+		return r
+	}, s)
+}
+
+// FromLogs wraps logs built in-process, such as a filtered copy of a
+// received frame, with Raw re-encoded as OTLP JSON.
+func FromLogs(l plog.Logs) Message {
+	raw, _ := (&plog.JSONMarshaler{}).MarshalLogs(l)
+	return newMessage(Message{Kind: KindLogs, Raw: raw, Logs: l})
+}
+
+// FromMetrics is FromLogs for metrics.
+func FromMetrics(m pmetric.Metrics) Message {
+	raw, _ := (&pmetric.JSONMarshaler{}).MarshalMetrics(m)
+	return newMessage(Message{Kind: KindMetrics, Raw: raw, Metrics: m})
+}
+
+// FromTraces is FromLogs for traces.
+func FromTraces(t ptrace.Traces) Message {
+	raw, _ := (&ptrace.JSONMarshaler{}).MarshalTraces(t)
+	return newMessage(Message{Kind: KindTraces, Raw: raw, Traces: t})
+}
@@ -0,0 +1,28 @@
+package telemetry_test
+
+import (
+	"testing"
+
+	"github.com/jwafle/otail/internal/gen"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+func BenchmarkParse(b *testing.B) {
+	for _, kind := range []telemetry.Kind{telemetry.KindLogs, telemetry.KindMetrics, telemetry.KindTraces, telemetry.KindUnknown} {
+		frame := gen.New(nil, 1).Frame(kind)
+		b.Run(kind.String(), func(b *testing.B) {
+			b.SetBytes(int64(len(frame)))
+			for b.Loop() {
+				telemetry.Parse(frame)
+			}
+		})
+	}
+}
+
+func BenchmarkLines(b *testing.B) {
+	frame := gen.New(nil, 1).Frame(telemetry.KindTraces)
+	for b.Loop() {
+		// Parse afresh so the render cache does not answer.
+		telemetry.Parse(frame).Lines()
+	}
+}
@@ -2,7 +2,8 @@
 package telemetry
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -34,69 +35,111 @@ func (k Kind) String() string {
 	}
 }
 
-// Message is the canonical form that UI and transport layers consume.
-type Message struct {
-	Kind          Kind     // logs, metrics, traces, or unknown
-	IndentedLines []string // indented, parsed JSON for ui
+// ParseKind maps a signal name ("logs", "metrics", "traces", or "unknown",
+// also accepted as "other") to its Kind.
+func ParseKind(s string) (Kind, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "logs", "log":
+		return KindLogs, nil
+	case "metrics", "metric":
+		return KindMetrics, nil
+	case "traces", "trace", "spans":
+		return KindTraces, nil
+	case "unknown", "other":
+		return KindUnknown, nil
+	default:
+		return 0, fmt.Errorf("unknown signal %q", s)
+	}
 }
 
 // Parse inspects a raw websocket frame and classifies it.
-// It never returns an error; unknown data are flagged as KindUnknown.
+// It never returns an error; unknown data are flagged as KindUnknown and
+// carry the rejection reason from each unmarshaler in Diagnostics, which are
+// also prepended to the rendered lines as comments.
+//
+// Only the decoding happens here, once, into pdata, along with the small
+// Summary the UI needs for every message; the JSON lines are rendered
+// when the message is first shown.
 func Parse(data []byte) Message {
-	// Helpers -------------------------------------------------------------
-
-	pretty := func(b []byte) []string {
-		var v interface{}
-		// If we can re-indent nicely, do so; otherwise fall back.
-		if json.Unmarshal(b, &v) == nil {
-			if pb, err := json.MarshalIndent(v, "", "  "); err == nil {
-				return strings.Split(string(pb), "\n")
-			}
+	// The unmarshalers read the whole frame even to find it holds none of
+	// their signal, so try the one its first key names before the rest.
+	if k, ok := sniffKind(data); ok {
+		if m, err := decode(k, data); err == nil {
+			return m
 		}
-		return []string{string(b)}
 	}
-
-	asMsg := func(kind Kind, raw []byte, marshal func() ([]byte, error)) Message {
-		out, err := marshal()
-		if err != nil {
-			// Fallback: just show the incoming bytes.
-			return Message{Kind: kind, IndentedLines: pretty(raw)}
+	var diags []string
+	for _, k := range []Kind{KindLogs, KindMetrics, KindTraces} {
+		m, err := decode(k, data)
+		if err == nil {
+			return m
 		}
-		return Message{Kind: kind, IndentedLines: pretty(out)}
+		diags = append(diags, fmt.Sprintf("%s: %v", k, err))
 	}
 
-	// Logs ----------------------------------------------------------------
-	if logs, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs(data); err == nil &&
-		logs.ResourceLogs().Len() > 0 {
+	// Unknown or malformed payload ---------------------------------------
+	return newMessage(Message{Kind: KindUnknown, Raw: data, Diagnostics: diags})
+}
 
-		return asMsg(KindLogs, data, func() ([]byte, error) {
-			return (&plog.JSONMarshaler{}).MarshalLogs(logs)
-		})
+// decode unmarshals data as signal k, failing if it holds no resources.
+func decode(k Kind, data []byte) (Message, error) {
+	switch k {
+	case KindLogs:
+		logs, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs(data)
+		if err == nil && logs.ResourceLogs().Len() == 0 {
+			err = errors.New("no resourceLogs")
+		}
+		if err != nil {
+			return Message{}, err
+		}
+		return newMessage(Message{Kind: KindLogs, Raw: data, Logs: logs}), nil
+	case KindMetrics:
+		metrics, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(data)
+		if err == nil && metrics.ResourceMetrics().Len() == 0 {
+			err = errors.New("no resourceMetrics")
+		}
+		if err != nil {
+			return Message{}, err
+		}
+		return newMessage(Message{Kind: KindMetrics, Raw: data, Metrics: metrics}), nil
+	case KindTraces:
+		traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(data)
+		if err == nil && traces.ResourceSpans().Len() == 0 {
+			err = errors.New("no resourceSpans")
+		}
+		if err != nil {
+			return Message{}, err
+		}
+		return newMessage(Message{Kind: KindTraces, Raw: data, Traces: traces}), nil
 	}
+	return Message{}, ErrUnsupportedKind
+}
 
-	// Metrics -------------------------------------------------------------
-	if metrics, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(data); err == nil &&
-		metrics.ResourceMetrics().Len() > 0 {
-
-		return asMsg(KindMetrics, data, func() ([]byte, error) {
-			return (&pmetric.JSONMarshaler{}).MarshalMetrics(metrics)
-		})
+// sniffKind guesses the signal of an OTLP JSON frame from its first key,
+// without decoding the rest.
+func sniffKind(data []byte) (Kind, bool) {
+	rest := bytes.TrimLeft(data, " \t\r\n")
+	rest, ok := bytes.CutPrefix(rest, []byte("{"))
+	if !ok {
+		return 0, false
 	}
-
-	// Traces --------------------------------------------------------------
-	if traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(data); err == nil &&
-		traces.ResourceSpans().Len() > 0 {
-
-		return asMsg(KindTraces, data, func() ([]byte, error) {
-			return (&ptrace.JSONMarshaler{}).MarshalTraces(traces)
-		})
+	rest, ok = bytes.CutPrefix(bytes.TrimLeft(rest, " \t\r\n"), []byte(`"`))
+	if !ok {
+		return 0, false
 	}
-
-	// Unknown or malformed payload ---------------------------------------
-	return Message{
-		Kind:          KindUnknown,
-		IndentedLines: pretty(data),
+	key, _, ok := bytes.Cut(rest, []byte(`"`))
+	if !ok {
+		return 0, false
+	}
+	switch string(key) {
+	case "resourceLogs", "resource_logs":
+		return KindLogs, true
+	case "resourceMetrics", "resource_metrics":
+		return KindMetrics, true
+	case "resourceSpans", "resource_spans":
+		return KindTraces, true
 	}
+	return 0, false
 }
 
 // ErrUnsupportedKind can be returned by callers that need to reject unknown kinds.
@@ -0,0 +1,84 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// Rendering a message goes through many short-lived buffers: one to quote
+// each key and string value, one for each compact line. They are pooled so
+// that drawing a screenful of messages does not churn the garbage
+// collector.
+
+// maxPooled caps the buffers put back in a pool, so one huge frame does
+// not pin its buffer for the rest of the session.
+const maxPooled = 64 << 10
+
+var buffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+// putBuffer empties b and returns it to the pool.
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooled {
+		return
+	}
+	b.Reset()
+	buffers.Put(b)
+}
+
+// A quoter is a JSON encoder together with the buffer it writes to.
+type quoter struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var quoters = sync.Pool{New: func() any {
+	q := &quoter{}
+	q.enc = json.NewEncoder(&q.buf)
+	q.enc.SetEscapeHTML(false)
+	return q
+}}
+
+// quote returns s as a JSON string.
+func quote(s string) string {
+	if plain(s) {
+		return `"` + s + `"`
+	}
+	q := quoters.Get().(*quoter)
+	q.enc.Encode(s)
+	out := string(bytes.TrimSuffix(q.buf.Bytes(), []byte("\n")))
+	if q.buf.Cap() <= maxPooled {
+		q.buf.Reset()
+		quoters.Put(q)
+	}
+	return out
+}
+
+// plain reports whether s is printable ASCII that needs no escaping in a
+// JSON string, which most keys and values are.
+func plain(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= 0x7f || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// spaces backs indentation up to len(spaces)/2 levels deep.
+const spaces = "                                                                "
+
+// indentation returns the leading spaces for a line at depth, without
+// allocating for any but the deepest.
+func indentation(depth int) string {
+	if n := 2 * depth; n <= len(spaces) {
+		return spaces[:n]
+	}
+	return strings.Repeat("  ", depth)
+}
@@ -0,0 +1,260 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	plog "go.opentelemetry.io/collector/pdata/plog"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Severity buckets OTLP log severity numbers into their six ranges.
+type Severity int
+
+const (
+	SeverityUnset Severity = iota
+	SeverityTrace
+	SeverityDebug
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+	SeverityFatal
+)
+
+// SeverityOf maps an OTLP severity number (1-24) onto its range.
+func SeverityOf(n plog.SeverityNumber) Severity {
+	if n <= 0 || n > 24 {
+		return SeverityUnset
+	}
+	return Severity((int(n)-1)/4 + 1)
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityTrace:
+		return "TRACE"
+	case SeverityDebug:
+		return "DEBUG"
+	case SeverityInfo:
+		return "INFO"
+	case SeverityWarn:
+		return "WARN"
+	case SeverityError:
+		return "ERROR"
+	case SeverityFatal:
+		return "FATAL"
+	default:
+		return "-"
+	}
+}
+
+// Record is a one-line summary of a single log record, metric, or span.
+type Record struct {
+	Kind     Kind
+	Time     time.Time   // zero when the payload carries no timestamp
+	Severity Severity    // logs only
+	Service  string      // service.name resource attribute, if any
+	Resource pcommon.Map // every resource attribute; empty for unknown frames
+	Text     string
+}
+
+// String renders the record as "time SEVERITY [service] text".
+func (r Record) String() string {
+	var b strings.Builder
+	if !r.Time.IsZero() {
+		b.WriteString(r.Time.Format("15:04:05.000 "))
+	}
+	if r.Kind == KindLogs {
+		fmt.Fprintf(&b, "%-5s ", r.Severity)
+	}
+	if r.Service != "" {
+		fmt.Fprintf(&b, "[%s] ", r.Service)
+	}
+	b.WriteString(r.Text)
+	return b.String()
+}
+
+// Records flattens the message into one Record per log record, metric, or
+// span. Unknown frames yield a single record describing the frame.
+func (m Message) Records() []Record {
+	var out []Record
+	switch m.Kind {
+	case KindLogs:
+		rls := m.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			rl := rls.At(i)
+			svc := serviceName(rl.Resource())
+			sls := rl.ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					lr := lrs.At(k)
+					ts := lr.Timestamp()
+					if ts == 0 {
+						ts = lr.ObservedTimestamp()
+					}
+					out = append(out, Record{
+						Kind:     KindLogs,
+						Time:     timeOf(ts),
+						Severity: SeverityOf(lr.SeverityNumber()),
+						Service:  svc,
+						Resource: rl.Resource().Attributes(),
+						Text:     lr.Body().AsString(),
+					})
+				}
+			}
+		}
+	case KindMetrics:
+		rms := m.Metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			rm := rms.At(i)
+			svc := serviceName(rm.Resource())
+			sms := rm.ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					ts, text := summarizeMetric(ms.At(k))
+					out = append(out, Record{Kind: KindMetrics, Time: ts, Service: svc, Resource: rm.Resource().Attributes(), Text: text})
+				}
+			}
+		}
+	case KindTraces:
+		rss := m.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			rs := rss.At(i)
+			svc := serviceName(rs.Resource())
+			sss := rs.ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					out = append(out, Record{
+						Kind:     KindTraces,
+						Time:     timeOf(spans.At(k).StartTimestamp()),
+						Service:  svc,
+						Resource: rs.Resource().Attributes(),
+						Text:     summarizeSpan(spans.At(k)),
+					})
+				}
+			}
+		}
+	default:
+		text := fmt.Sprintf("%d bytes", len(m.Raw))
+		if len(m.Diagnostics) > 0 {
+			text += ": " + m.Diagnostics[0]
+		}
+		out = append(out, Record{Kind: KindUnknown, Resource: pcommon.NewMap(), Text: text})
+	}
+	return out
+}
+
+// Services returns the service.name of each resource in the message that
+// has one, once per resource.
+func (m Message) Services() []string {
+	var out []string
+	add := func(r pcommon.Resource) {
+		if svc := serviceName(r); svc != "" {
+			out = append(out, svc)
+		}
+	}
+	switch m.Kind {
+	case KindLogs:
+		for i := 0; i < m.Logs.ResourceLogs().Len(); i++ {
+			add(m.Logs.ResourceLogs().At(i).Resource())
+		}
+	case KindMetrics:
+		for i := 0; i < m.Metrics.ResourceMetrics().Len(); i++ {
+			add(m.Metrics.ResourceMetrics().At(i).Resource())
+		}
+	case KindTraces:
+		for i := 0; i < m.Traces.ResourceSpans().Len(); i++ {
+			add(m.Traces.ResourceSpans().At(i).Resource())
+		}
+	}
+	return out
+}
+
+func serviceName(r pcommon.Resource) string {
+	if v, ok := r.Attributes().Get("service.name"); ok {
+		return v.AsString()
+	}
+	return ""
+}
+
+func timeOf(ts pcommon.Timestamp) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+	return ts.AsTime().Local()
+}
+
+func summarizeMetric(m pmetric.Metric) (time.Time, string) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		return summarizeNumbers(m.Name(), "gauge", m.Gauge().DataPoints())
+	case pmetric.MetricTypeSum:
+		return summarizeNumbers(m.Name(), "sum", m.Sum().DataPoints())
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		if dps.Len() == 0 {
+			return time.Time{}, m.Name() + " histogram"
+		}
+		dp := dps.At(dps.Len() - 1)
+		return timeOf(dp.Timestamp()), fmt.Sprintf("%s histogram count=%d sum=%g (%d points)", m.Name(), dp.Count(), dp.Sum(), dps.Len())
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		if dps.Len() == 0 {
+			return time.Time{}, m.Name() + " exphistogram"
+		}
+		dp := dps.At(dps.Len() - 1)
+		return timeOf(dp.Timestamp()), fmt.Sprintf("%s exphistogram count=%d sum=%g (%d points)", m.Name(), dp.Count(), dp.Sum(), dps.Len())
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		if dps.Len() == 0 {
+			return time.Time{}, m.Name() + " summary"
+		}
+		dp := dps.At(dps.Len() - 1)
+		return timeOf(dp.Timestamp()), fmt.Sprintf("%s summary count=%d sum=%g (%d points)", m.Name(), dp.Count(), dp.Sum(), dps.Len())
+	default:
+		return time.Time{}, m.Name()
+	}
+}
+
+func summarizeNumbers(name, typ string, dps pmetric.NumberDataPointSlice) (time.Time, string) {
+	if dps.Len() == 0 {
+		return time.Time{}, name + " " + typ
+	}
+	dp := dps.At(dps.Len() - 1)
+	var v string
+	if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+		v = fmt.Sprint(dp.IntValue())
+	} else {
+		v = fmt.Sprint(dp.DoubleValue())
+	}
+	return timeOf(dp.Timestamp()), fmt.Sprintf("%s %s=%s (%d points)", name, typ, v, dps.Len())
+}
+
+func summarizeSpan(s ptrace.Span) string {
+	text := fmt.Sprintf("%s %s", s.Name(), s.EndTimestamp().AsTime().Sub(s.StartTimestamp().AsTime()))
+	if s.Status().Code() == ptrace.StatusCodeError {
+		text += " ERROR"
+		if msg := s.Status().Message(); msg != "" {
+			text += ": " + msg
+		}
+	}
+	return text + " trace=" + s.TraceID().String()
+}
+
+// PipelineLatency is how long lr took to be observed, by a collector or an
+// SDK's exporter, after it happened: its observed time less its time. It is
+// false when either is missing, or when clock skew puts the observation
+// first.
+func PipelineLatency(lr plog.LogRecord) (time.Duration, bool) {
+	ts, observed := lr.Timestamp(), lr.ObservedTimestamp()
+	if ts == 0 || observed == 0 || observed < ts {
+		return 0, false
+	}
+	return observed.AsTime().Sub(ts.AsTime()), true
+}
@@ -0,0 +1,178 @@
+package telemetry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Stack traces logged as a single string are shown one line per frame,
+// indented by what each line is, instead of as one string full of \n and
+// \t. Lines from the runtime and third-party libraries are marked so they
+// can be dimmed, leaving the application's own frames to stand out.
+
+// stackLine is one line of a stack trace as it is shown.
+type stackLine struct {
+	text    string // without its original indentation; may hold control characters
+	depth   int    // indentation level: 0 for headers such as the exception, more for frames
+	frame   bool   // the line starts a frame
+	library bool   // the line belongs to a frame outside the application
+}
+
+// stack is a parsed stack trace.
+type stack struct {
+	lines  []stackLine
+	frames int
+}
+
+var (
+	javaFrame = regexp.MustCompile(`^\s*at\s+(\S+)`)
+	javaMore  = regexp.MustCompile(`^\s*\.\.\. \d+ (more|common frames omitted)`)
+	goFile    = regexp.MustCompile(`^\s+(\S+\.go):\d+`)
+	pyFile    = regexp.MustCompile(`^\s*File "([^"]+)", line \d+`)
+)
+
+// javaLibraries are package prefixes of the JDK and common frameworks.
+var javaLibraries = []string{
+	"java.", "javax.", "jdk.", "sun.", "com.sun.", "kotlin.", "kotlinx.", "scala.",
+	"org.springframework.", "org.apache.", "org.hibernate.", "org.eclipse.", "org.junit.",
+	"io.netty.", "io.grpc.", "reactor.", "okhttp3.", "com.google.",
+}
+
+// parseStack splits s into a stack trace if it looks like one: a Python
+// traceback, a Go panic or goroutine dump, or a Java exception with "at"
+// frames. It needs at least two frames.
+func parseStack(s string) (stack, bool) {
+	if !strings.Contains(s, "\n") {
+		return stack{}, false
+	}
+	raw := strings.Split(strings.TrimRight(s, " \t\r\n"), "\n")
+	var py, golang, java int
+	for _, l := range raw {
+		switch {
+		case pyFile.MatchString(l):
+			py++
+		case goFile.MatchString(l):
+			golang++
+		case javaFrame.MatchString(l):
+			java++
+		}
+	}
+	var st stack
+	switch {
+	case py >= 2:
+		st = parsePython(raw)
+	case golang >= 2:
+		st = parseGo(raw)
+	case java >= 2:
+		st = parseJava(raw)
+	default:
+		return stack{}, false
+	}
+	for i, l := range st.lines {
+		st.lines[i].text = strings.TrimSpace(strings.ReplaceAll(l.text, "\t", "    "))
+		if l.frame {
+			st.frames++
+		}
+	}
+	return st, true
+}
+
+func parsePython(raw []string) stack {
+	var st stack
+	inFrame, library := false, false
+	for _, l := range raw {
+		switch m := pyFile.FindStringSubmatch(l); {
+		case m != nil:
+			path := m[1]
+			library = strings.Contains(path, "site-packages") || strings.Contains(path, "dist-packages") ||
+				strings.Contains(path, "/lib/python") || strings.HasPrefix(path, "<frozen")
+			inFrame = true
+			st.lines = append(st.lines, stackLine{text: l, depth: 1, frame: true, library: library})
+		case inFrame && strings.HasPrefix(l, " "):
+			// The source line of the frame above.
+			st.lines = append(st.lines, stackLine{text: l, depth: 2, library: library})
+		default:
+			inFrame = false
+			st.lines = append(st.lines, stackLine{text: l})
+		}
+	}
+	return st
+}
+
+func parseGo(raw []string) stack {
+	st := stack{lines: make([]stackLine, len(raw))}
+	for i, l := range raw {
+		st.lines[i] = stackLine{text: l}
+	}
+	// Each frame is a function line followed by its file line.
+	for i, l := range raw {
+		m := goFile.FindStringSubmatch(l)
+		if m == nil || i == 0 {
+			continue
+		}
+		library := goLibraryFunc(raw[i-1]) || goLibraryFile(m[1])
+		st.lines[i-1] = stackLine{text: raw[i-1], depth: 1, frame: true, library: library}
+		st.lines[i] = stackLine{text: l, depth: 2, library: library}
+	}
+	return st
+}
+
+// goLibraryFunc reports whether a function line such as
+// "net/http.(*conn).serve(...)" is in the standard library, whose import
+// paths have no dot in their first element.
+func goLibraryFunc(l string) bool {
+	l = strings.TrimPrefix(strings.TrimSpace(l), "created by ")
+	first, _, _ := strings.Cut(l, "/")
+	if !strings.Contains(l, "/") {
+		first, _, _ = strings.Cut(l, ".")
+	}
+	return first != "main" && !strings.Contains(first, ".")
+}
+
+// goLibraryFile reports whether path is in GOROOT or the module cache.
+func goLibraryFile(path string) bool {
+	return strings.Contains(path, "/pkg/mod/") || strings.Contains(path, "/go/src/") ||
+		strings.HasPrefix(path, "/usr/local/go/") || strings.HasPrefix(path, "/usr/lib/go")
+}
+
+func parseJava(raw []string) stack {
+	var st stack
+	for _, l := range raw {
+		switch m := javaFrame.FindStringSubmatch(l); {
+		case m != nil:
+			sym := m[1]
+			if _, after, ok := strings.Cut(sym, "/"); ok {
+				sym = after // java.base/java.lang.Thread.run
+			}
+			library := false
+			for _, p := range javaLibraries {
+				if strings.HasPrefix(sym, p) {
+					library = true
+					break
+				}
+			}
+			st.lines = append(st.lines, stackLine{text: l, depth: 1, frame: true, library: library})
+		case javaMore.MatchString(l):
+			st.lines = append(st.lines, stackLine{text: l, depth: 1, library: true})
+		default:
+			st.lines = append(st.lines, stackLine{text: l})
+		}
+	}
+	return st
+}
+
+// collapsed returns the lines up to the end of the first frame, followed by
+// a count of the frames left out.
+func (st stack) collapsed() []stackLine {
+	seen := 0
+	for i, l := range st.lines {
+		if l.frame {
+			seen++
+		}
+		if seen == 2 {
+			return append(st.lines[:i:i], stackLine{text: fmt.Sprintf("… %d more frames", st.frames-1), depth: 1})
+		}
+	}
+	return st.lines
+}
@@ -0,0 +1,79 @@
+package telemetry
+
+import (
+	"slices"
+
+	pcommon "go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Summary is what the UI shows about a message on every redraw, worked out
+// once when the message is made, off the UI goroutine, instead of walking
+// its pdata each time.
+type Summary struct {
+	Records  int             // log records, metrics, or spans; 1 for an unknown frame
+	Services []string        // distinct service.name values, sorted
+	TraceID  pcommon.TraceID // of the first log record or span that has one
+	SpanID   pcommon.SpanID  // of that same record or span
+}
+
+// Summary returns the message's summary.
+func (m Message) Summary() Summary {
+	if m.summary == nil {
+		return summarize(m)
+	}
+	return *m.summary
+}
+
+func summarize(m Message) Summary {
+	s := Summary{Records: recordCount(m)}
+	s.Services = slices.Compact(slices.Sorted(slices.Values(m.Services())))
+	s.TraceID, s.SpanID = firstIDs(m)
+	return s
+}
+
+// recordCount returns how many Records m yields, without making them.
+func recordCount(m Message) int {
+	switch m.Kind {
+	case KindLogs:
+		return m.Logs.LogRecordCount()
+	case KindMetrics:
+		return m.Metrics.MetricCount()
+	case KindTraces:
+		return m.Traces.SpanCount()
+	}
+	return 1
+}
+
+// firstIDs returns the trace and span IDs of the first log record or span
+// in m that has a trace ID.
+func firstIDs(m Message) (pcommon.TraceID, pcommon.SpanID) {
+	switch m.Kind {
+	case KindLogs:
+		rls := m.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					if lr := lrs.At(k); !lr.TraceID().IsEmpty() {
+						return lr.TraceID(), lr.SpanID()
+					}
+				}
+			}
+		}
+	case KindTraces:
+		rss := m.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					if s := spans.At(k); !s.TraceID().IsEmpty() {
+						return s.TraceID(), s.SpanID()
+					}
+				}
+			}
+		}
+	}
+	return pcommon.TraceID{}, pcommon.SpanID{}
+}
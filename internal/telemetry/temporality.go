@@ -0,0 +1,150 @@
+package telemetry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Temporality is how sums are shown: as received, or all converted to
+// per-interval deltas or running totals.
+type Temporality int
+
+const (
+	TemporalityAsReceived Temporality = iota
+	TemporalityDelta
+	TemporalityCumulative
+)
+
+// ParseTemporality maps "delta", "cumulative", or "" (as received) to a
+// Temporality.
+func ParseTemporality(s string) (Temporality, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "received", "none":
+		return TemporalityAsReceived, nil
+	case "delta":
+		return TemporalityDelta, nil
+	case "cumulative":
+		return TemporalityCumulative, nil
+	}
+	return 0, fmt.Errorf("unknown temporality %q (want delta or cumulative)", s)
+}
+
+// Normalizer rewrites the sums in metrics messages to one temporality. It
+// remembers the last point of every series, so messages must be passed in
+// the order they arrived. It is not safe for concurrent use.
+type Normalizer struct {
+	to     Temporality
+	series map[string]*seriesState
+}
+
+type seriesState struct {
+	start, last pcommon.Timestamp
+	value       float64 // cumulative: the last total; delta: the running total
+}
+
+// NewNormalizer returns a Normalizer converting to to. A nil Normalizer, or
+// one converting to TemporalityAsReceived, leaves messages alone.
+func NewNormalizer(to Temporality) *Normalizer {
+	return &Normalizer{to: to, series: map[string]*seriesState{}}
+}
+
+// Apply converts msg's sums in place.
+//
+// Cumulative to delta: each point becomes the change since the series'
+// previous point, starting then; the first point of a series, and the first
+// after a counter reset, is its whole value since its start time.
+// Delta to cumulative: each point becomes the running total since the
+// series was first seen.
+func (n *Normalizer) Apply(msg *Message) {
+	if n == nil || n.to == TemporalityAsReceived || msg.Kind != KindMetrics {
+		return
+	}
+	rms := msg.Metrics.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sm := sms.At(j)
+			ms := sm.Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				m := ms.At(k)
+				if m.Type() != pmetric.MetricTypeSum {
+					continue
+				}
+				from := m.Sum().AggregationTemporality()
+				want := pmetric.AggregationTemporalityDelta
+				if n.to == TemporalityCumulative {
+					want = pmetric.AggregationTemporalityCumulative
+				}
+				if from == want || from == pmetric.AggregationTemporalityUnspecified {
+					continue
+				}
+				prefix := seriesPrefix(rm.Resource().Attributes(), sm.Scope().Name(), m.Name())
+				dps := m.Sum().DataPoints()
+				for d := 0; d < dps.Len(); d++ {
+					dp := dps.At(d)
+					n.convert(prefix+"|"+canonicalAttrs(dp.Attributes()), dp, want)
+				}
+				m.Sum().SetAggregationTemporality(want)
+			}
+		}
+	}
+}
+
+func (n *Normalizer) convert(key string, dp pmetric.NumberDataPoint, want pmetric.AggregationTemporality) {
+	v := pointValue(dp)
+	st, seen := n.series[key]
+	if !seen {
+		st = &seriesState{start: dp.StartTimestamp()}
+		n.series[key] = st
+	}
+	if want == pmetric.AggregationTemporalityDelta {
+		delta, start := v-st.value, st.last
+		if !seen || v < st.value || dp.StartTimestamp() > st.last {
+			delta, start = v, dp.StartTimestamp() // first point, or the counter reset
+		}
+		st.value, st.last = v, dp.Timestamp()
+		setPointValue(dp, delta)
+		dp.SetStartTimestamp(start)
+		return
+	}
+	st.value += v
+	st.last = dp.Timestamp()
+	setPointValue(dp, st.value)
+	dp.SetStartTimestamp(st.start)
+}
+
+func pointValue(dp pmetric.NumberDataPoint) float64 {
+	if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+		return float64(dp.IntValue())
+	}
+	return dp.DoubleValue()
+}
+
+// setPointValue keeps integer points integers.
+func setPointValue(dp pmetric.NumberDataPoint, v float64) {
+	if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+		dp.SetIntValue(int64(v))
+		return
+	}
+	dp.SetDoubleValue(v)
+}
+
+func seriesPrefix(res pcommon.Map, scope, metric string) string {
+	return canonicalAttrs(res) + "|" + scope + "|" + metric
+}
+
+// canonicalAttrs encodes attrs with sorted keys, so equal sets encode alike.
+func canonicalAttrs(attrs pcommon.Map) string {
+	parts := make([]string, 0, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		parts = append(parts, k+"="+v.AsString())
+		return true
+	})
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
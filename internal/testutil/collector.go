@@ -0,0 +1,95 @@
+package testutil
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+// Collector is a fake collector: a websocket server that sends every client
+// the frames it was started with, then whatever Send is given, and holds
+// the connection open until the test ends.
+type Collector struct {
+	URL    string // ws:// address to dial
+	Origin string // Origin header to dial with
+
+	srv  *httptest.Server
+	done chan struct{}
+
+	mu      sync.Mutex
+	sent    [][]byte             // every frame so far, replayed to late clients
+	clients map[chan []byte]bool // one per open connection
+}
+
+// NewCollector starts a Collector that sends frames to each client as it
+// connects. It is shut down when t ends.
+func NewCollector(t testing.TB, frames ...[]byte) *Collector {
+	t.Helper()
+	c := &Collector{
+		done:    make(chan struct{}),
+		sent:    frames,
+		clients: make(map[chan []byte]bool),
+	}
+	c.srv = httptest.NewServer(websocket.Handler(c.serve))
+	c.URL = "ws" + strings.TrimPrefix(c.srv.URL, "http")
+	c.Origin = c.srv.URL
+	t.Cleanup(c.Close)
+	return c
+}
+
+// Send sends frames to every connected client, and to clients that
+// connect later.
+func (c *Collector) Send(frames ...[]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, frames...)
+	for ch := range c.clients {
+		for _, f := range frames {
+			ch <- f
+		}
+	}
+}
+
+// Close disconnects every client and stops the server.
+func (c *Collector) Close() {
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+	close(c.done)
+	c.srv.CloseClientConnections()
+	c.srv.Close()
+}
+
+func (c *Collector) serve(ws *websocket.Conn) {
+	defer ws.Close()
+	c.mu.Lock()
+	// Buffered so Send never waits on a slow client; tests send a handful
+	// of frames.
+	ch := make(chan []byte, 1024)
+	for _, f := range c.sent {
+		ch <- f
+	}
+	c.clients[ch] = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.clients, ch)
+		c.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case f := <-ch:
+			if err := websocket.Message.Send(ws, string(f)); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
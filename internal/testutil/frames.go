@@ -0,0 +1,112 @@
+// Package testutil has fakes for end-to-end tests: a collector that serves
+// canned OTLP frames over a websocket, and a driver that runs a Bubble Tea
+// model without a terminal and records what it renders.
+package testutil
+
+import (
+	"hash/fnv"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Epoch is the timestamp of every canned frame, so views that show times
+// render the same on every run.
+var Epoch = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// severities maps the names Log accepts to their OTLP severity numbers.
+var severities = map[string]plog.SeverityNumber{
+	"trace": plog.SeverityNumberTrace,
+	"debug": plog.SeverityNumberDebug,
+	"info":  plog.SeverityNumberInfo,
+	"warn":  plog.SeverityNumberWarn,
+	"error": plog.SeverityNumberError,
+	"fatal": plog.SeverityNumberFatal,
+}
+
+// Log returns a frame holding one log record from service. severity is a
+// name such as "info" or "error".
+func Log(service, severity, body string) []byte {
+	return LogAt(service, severity, body, Epoch)
+}
+
+// LogAt is Log with the record's time set to t.
+func LogAt(service, severity, body string, t time.Time) []byte {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	resource(rl.Resource(), service)
+	lr := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(t))
+	lr.SetSeverityNumber(severities[severity])
+	lr.SetSeverityText(severity)
+	lr.Body().SetStr(body)
+	return must((&plog.JSONMarshaler{}).MarshalLogs(ld))
+}
+
+// ObservedLog returns a frame holding one info log record from service,
+// observed lag after its time.
+func ObservedLog(service, body string, lag time.Duration) []byte {
+	ld, _ := (&plog.JSONUnmarshaler{}).UnmarshalLogs(Log(service, "info", body))
+	lr := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(Epoch.Add(lag)))
+	return must((&plog.JSONMarshaler{}).MarshalLogs(ld))
+}
+
+// TraceID is the trace every span from Span belongs to.
+var TraceID = pcommon.TraceID{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c}
+
+// Span returns a frame holding one span named name from service, lasting
+// d. Spans share a trace, and each name gets its own span ID.
+func Span(service, name string, d time.Duration) []byte {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	resource(rs.Resource(), service)
+	s := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	s.SetTraceID(TraceID)
+	s.SetSpanID(spanID(name))
+	s.SetName(name)
+	s.SetKind(ptrace.SpanKindServer)
+	s.SetStartTimestamp(pcommon.NewTimestampFromTime(Epoch))
+	s.SetEndTimestamp(pcommon.NewTimestampFromTime(Epoch.Add(d)))
+	return must((&ptrace.JSONMarshaler{}).MarshalTraces(td))
+}
+
+// Gauge returns a frame holding one gauge data point from service.
+func Gauge(service, name string, value float64) []byte {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	resource(rm.Resource(), service)
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName(name)
+	dp := m.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(Epoch))
+	dp.SetDoubleValue(value)
+	return must((&pmetric.JSONMarshaler{}).MarshalMetrics(md))
+}
+
+func resource(r pcommon.Resource, service string) {
+	r.Attributes().PutStr("service.name", service)
+}
+
+// spanID derives a stable span ID from name.
+func spanID(name string) pcommon.SpanID {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	var id pcommon.SpanID
+	for i, v := 0, h.Sum64(); i < len(id); i, v = i+1, v>>8 {
+		id[i] = byte(v)
+	}
+	return id
+}
+
+// must panics if a frame could not be marshalled; the inputs are fixed, so
+// it can only mean a bug here.
+func must(b []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
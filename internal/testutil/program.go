@@ -0,0 +1,195 @@
+package testutil
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// WaitTimeout is how long WaitFor waits for a frame before failing the test.
+var WaitTimeout = 5 * time.Second
+
+// Program runs a Bubble Tea model as tea.NewProgram would, minus the
+// terminal: the test sends it keys and messages, and every frame the model
+// would render is recorded, without styling, for WaitFor to check.
+type Program struct {
+	t    testing.TB
+	p    *tea.Program
+	done chan struct{}
+
+	mu      sync.Mutex
+	frame   string        // latest frame, ANSI stripped
+	changed chan struct{} // closed and replaced whenever frame changes
+	final   tea.Model     // the model once Run returned
+	err     error
+}
+
+// Start runs m on a width×height screen until Quit or the end of t.
+func Start(t testing.TB, m tea.Model, width, height int) *Program {
+	t.Helper()
+	tp := &Program{t: t, done: make(chan struct{}), changed: make(chan struct{})}
+	tp.p = tea.NewProgram(recorder{m, tp},
+		tea.WithInput(nil),
+		tea.WithOutput(io.Discard),
+		tea.WithoutRenderer(),
+		tea.WithoutSignalHandler(),
+	)
+	go func() {
+		final, err := tp.p.Run()
+		tp.mu.Lock()
+		if r, ok := final.(recorder); ok {
+			tp.final = r.Model
+		}
+		tp.err = err
+		tp.mu.Unlock()
+		close(tp.done)
+	}()
+	tp.p.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	t.Cleanup(func() {
+		tp.p.Kill()
+		<-tp.done
+	})
+	return tp
+}
+
+// Send delivers msgs to the model in order.
+func (tp *Program) Send(msgs ...tea.Msg) {
+	for _, msg := range msgs {
+		tp.p.Send(msg)
+	}
+}
+
+// Press sends one key press per name. Names are as tea.KeyMsg.String
+// prints them: "p", "G", "enter", "esc", "down", "ctrl+c", and so on.
+func (tp *Program) Press(names ...string) {
+	for _, name := range names {
+		tp.p.Send(Key(name))
+	}
+}
+
+// Type sends text one rune at a time, as if typed.
+func (tp *Program) Type(text string) {
+	for _, r := range text {
+		tp.p.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// Key returns the key press named name. An "alt+" prefix holds alt down
+// as well, as in "alt+ctrl+d".
+func Key(name string) tea.KeyMsg {
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		k := Key(rest)
+		k.Alt = true
+		return k
+	}
+	if name == " " || name == "space" {
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	}
+	if k, ok := keyNames[name]; ok {
+		return tea.KeyMsg{Type: k}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+// keyNames maps the names of special keys to their types. Bubble Tea
+// numbers control characters from 0 to 127 and other keys below KeyRunes.
+var keyNames = func() map[string]tea.KeyType {
+	names := make(map[string]tea.KeyType)
+	for k := tea.KeyType(-200); k <= 127; k++ {
+		if s := k.String(); s != "" && k != tea.KeyRunes {
+			if _, dup := names[s]; !dup {
+				names[s] = k
+			}
+		}
+	}
+	return names
+}()
+
+// Frame returns the latest frame.
+func (tp *Program) Frame() string {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return tp.frame
+}
+
+// WaitFor waits until the latest frame satisfies cond and returns it. It
+// fails the test, showing the last frame, if none does within WaitTimeout.
+func (tp *Program) WaitFor(cond func(frame string) bool) string {
+	tp.t.Helper()
+	deadline := time.After(WaitTimeout)
+	for {
+		tp.mu.Lock()
+		frame, changed := tp.frame, tp.changed
+		tp.mu.Unlock()
+		if cond(frame) {
+			return frame
+		}
+		select {
+		case <-changed:
+		case <-tp.done:
+			tp.t.Fatalf("program exited while waiting; last frame:\n%s", frame)
+		case <-deadline:
+			tp.t.Fatalf("timed out after %s; last frame:\n%s", WaitTimeout, frame)
+		}
+	}
+}
+
+// WaitForText waits until the latest frame contains every one of texts.
+func (tp *Program) WaitForText(texts ...string) string {
+	tp.t.Helper()
+	return tp.WaitFor(func(frame string) bool {
+		for _, s := range texts {
+			if !strings.Contains(frame, s) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Quit asks the program to quit and returns the model it ended with.
+func (tp *Program) Quit() tea.Model {
+	tp.t.Helper()
+	tp.p.Quit()
+	select {
+	case <-tp.done:
+	case <-time.After(WaitTimeout):
+		tp.t.Fatalf("program did not quit within %s", WaitTimeout)
+	}
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if tp.err != nil && tp.err != tea.ErrProgramKilled {
+		tp.t.Fatalf("program failed: %v", tp.err)
+	}
+	return tp.final
+}
+
+// recorder renders its model after every update and hands the frame to
+// the Program.
+type recorder struct {
+	tea.Model
+	tp *Program
+}
+
+func (r recorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	r.Model, cmd = r.Model.Update(msg)
+	r.tp.record(ansi.Strip(r.Model.View()))
+	return r, cmd
+}
+
+func (tp *Program) record(frame string) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if frame == tp.frame {
+		return
+	}
+	tp.frame = frame
+	close(tp.changed)
+	tp.changed = make(chan struct{})
+}
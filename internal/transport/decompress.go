@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+)
+
+// maxInflated caps how large a decompressed frame may grow, so a small
+// hostile frame cannot exhaust memory.
+const maxInflated = 64 << 20
+
+// decompress undoes gzip, zlib, or raw deflate compression that a proxy
+// applied to frame. It reports whether frame looked compressed; when it
+// did but could not be inflated, the error says why and the caller keeps
+// the frame as received.
+//
+// Frames that start out as text are never touched. Otherwise gzip and
+// zlib are recognised by their headers; raw deflate, which is what
+// websocket permessage-deflate produces, has none, so it is only accepted
+// if it inflates to JSON.
+func decompress(frame []byte) ([]byte, bool, error) {
+	switch {
+	case looksLikeText(frame):
+		return nil, false, nil
+	case isGzip(frame):
+		r, err := gzip.NewReader(bytes.NewReader(frame))
+		if err != nil {
+			return nil, true, err
+		}
+		out, err := inflate(r)
+		return out, true, err
+	case isZlib(frame):
+		r, err := zlib.NewReader(bytes.NewReader(frame))
+		if err != nil {
+			return nil, true, err
+		}
+		out, err := inflate(r)
+		return out, true, err
+	default:
+		// permessage-deflate strips the final empty block, so the stream
+		// ends early; whatever came out before that is the message.
+		out, err := inflate(flate.NewReader(bytes.NewReader(frame)))
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, false, nil
+		}
+		if t := bytes.TrimSpace(out); len(t) == 0 || (t[0] != '{' && t[0] != '[') {
+			return nil, false, nil // not deflate after all, e.g. protobuf
+		}
+		return out, true, nil
+	}
+}
+
+func inflate(r io.Reader) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, maxInflated+1))
+	if err == nil && len(out) > maxInflated {
+		err = errors.New("decompressed frame exceeds 64 MiB")
+	}
+	return out, err
+}
+
+func isGzip(b []byte) bool {
+	return len(b) >= 3 && b[0] == 0x1f && b[1] == 0x8b && b[2] == 8
+}
+
+// isZlib checks the two-byte zlib header: deflate method and a header
+// checksum that is a multiple of 31.
+func isZlib(b []byte) bool {
+	return len(b) >= 2 && b[0]&0x0f == 8 && b[0]>>4 <= 7 && (uint(b[0])<<8|uint(b[1]))%31 == 0
+}
+
+// looksLikeText reports whether frame starts like the JSON (or at least
+// the printable text) the collector normally sends.
+func looksLikeText(b []byte) bool {
+	for _, c := range b[:min(len(b), 64)] {
+		if c < 0x20 && c != '\n' && c != '\r' && c != '\t' {
+			return false
+		}
+	}
+	return true
+}
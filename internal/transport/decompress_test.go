@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+)
+
+const frameJSON = `{"resourceLogs":[{"resource":{},"scopeLogs":[]}]}`
+
+func compress(t *testing.T, newWriter func(io.Writer) io.WriteCloser, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipped(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+func zlibbed(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+func deflated(w io.Writer) io.WriteCloser {
+	fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+	return fw
+}
+
+// permessageDeflate compresses data as a websocket extension does: raw
+// deflate, flushed, with the trailing empty block's marker cut off.
+func permessageDeflate(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	io.WriteString(fw, data)
+	if err := fw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{0, 0, 0xff, 0xff})
+}
+
+func TestDecompress(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		frame      []byte
+		want       string // inflated frame; "" when it is kept as is
+		compressed bool
+		fails      bool
+	}{
+		{"json", []byte(frameJSON), "", false, false},
+		{"text", []byte("not OTLP at all"), "", false, false},
+		{"gzip", compress(t, gzipped, frameJSON), frameJSON, true, false},
+		{"zlib", compress(t, zlibbed, frameJSON), frameJSON, true, false},
+		{"deflate", compress(t, deflated, frameJSON), frameJSON, true, false},
+		{"permessage-deflate", permessageDeflate(t, frameJSON), frameJSON, true, false},
+		{"deflated text", compress(t, deflated, "not OTLP at all"), "", false, false},
+		{"protobuf", []byte{0x0a, 0x02, 0x08, 0x01}, "", false, false},
+		{"truncated gzip", compress(t, gzipped, frameJSON)[:12], "", true, true},
+		{"corrupt zlib", []byte{0x78, 0x9c, 0x07, 0x00}, "", true, true}, // reserved block type
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, compressed, err := decompress(tc.frame)
+			if compressed != tc.compressed || (err != nil) != tc.fails {
+				t.Fatalf("decompress = compressed %v, err %v; want compressed %v, error %v", compressed, err, tc.compressed, tc.fails)
+			}
+			if string(out) != tc.want {
+				t.Errorf("decompress = %q, want %q", out, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	big := compress(t, gzipped, string(bytes.Repeat([]byte{' '}, maxInflated+1)))
+	if _, compressed, err := decompress(big); !compressed || err == nil {
+		t.Fatalf("decompress of a frame inflating past the limit = compressed %v, err %v; want an error", compressed, err)
+	}
+}
@@ -0,0 +1,168 @@
+package transport
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// NamedSource is a Source with the label the source switcher shows for it.
+type NamedSource struct {
+	Name   string
+	Source Source
+}
+
+// SourceStatus describes one source of a merged Stream.
+type SourceStatus struct {
+	Name    string
+	State   State
+	Enabled bool   // frames are passed on; disabled sources stay connected
+	Frames  uint64 // frames received since the stream opened, enabled or not
+}
+
+// member is one source feeding a merged Stream.
+type member struct {
+	name    string
+	stream  *Stream
+	enabled atomic.Bool
+	frames  atomic.Uint64
+
+	mu    sync.Mutex
+	state State
+}
+
+// Merge returns a Source that opens every source in srcs and interleaves
+// their frames on a single Stream. Each source reconnects on its own; the
+// merged stream reports Connected while any of them is, and closes once
+// all of them have. Sources can be switched off and on at runtime with
+// Stream.SetEnabled.
+func Merge(srcs []NamedSource) Source {
+	return func(ctx context.Context) (*Stream, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		s := &Stream{
+			msgCh:   make(chan []byte, 1024),
+			errCh:   make(chan error, 1),
+			stateCh: make(chan State, 8),
+			cancel:  cancel,
+		}
+		for _, src := range srcs {
+			child, err := src.Source(ctx)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			mb := &member{name: src.Name, stream: child}
+			mb.enabled.Store(true)
+			s.sources = append(s.sources, mb)
+		}
+
+		var wg sync.WaitGroup
+		for _, mb := range s.sources {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.forward(ctx, mb)
+			}()
+		}
+		go func() {
+			wg.Wait()
+			cancel()
+			s.setState(State{Kind: Disconnected, Err: ctx.Err()})
+			close(s.msgCh)
+			close(s.errCh)
+			close(s.stateCh)
+		}()
+		return s, nil
+	}
+}
+
+// forward copies mb's frames to s until mb's stream closes or ctx is done,
+// tracking its connection state.
+func (s *Stream) forward(ctx context.Context, mb *member) {
+	states := mb.stream.States()
+	for {
+		select {
+		case st, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			mb.mu.Lock()
+			mb.state = st
+			mb.mu.Unlock()
+			s.setState(s.mergedState())
+		case frame, ok := <-mb.stream.Messages():
+			if !ok {
+				return
+			}
+			mb.frames.Add(1)
+			if !mb.enabled.Load() {
+				continue
+			}
+			// Blocking, so a burst backs up into the source's own buffer,
+			// where it is spilled or dropped and counted.
+			select {
+			case s.msgCh <- frame:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// mergedState summarises the sources: connected if any is, otherwise
+// reconnecting if any is (with the soonest retry), otherwise connecting if
+// any is, otherwise disconnected.
+func (s *Stream) mergedState() State {
+	var out State
+	out.Kind = Disconnected
+	rank := map[StateKind]int{Disconnected: 0, Connecting: 1, Reconnecting: 2, Connected: 3}
+	for _, mb := range s.sources {
+		mb.mu.Lock()
+		st := mb.state
+		mb.mu.Unlock()
+		switch {
+		case rank[st.Kind] > rank[out.Kind]:
+			out = st
+		case st.Kind == Reconnecting && out.Kind == Reconnecting && st.NextRetry.Before(out.NextRetry):
+			out = st
+		}
+	}
+	return out
+}
+
+// Sources reports each source of a merged stream in the order given to
+// Merge, or nil for a stream with a single connection.
+func (s *Stream) Sources() []SourceStatus {
+	if s.inner != nil {
+		return s.inner.Sources()
+	}
+	out := make([]SourceStatus, 0, len(s.sources))
+	for _, mb := range s.sources {
+		mb.mu.Lock()
+		st := mb.state
+		mb.mu.Unlock()
+		out = append(out, SourceStatus{
+			Name:    mb.name,
+			State:   st,
+			Enabled: mb.enabled.Load(),
+			Frames:  mb.frames.Load(),
+		})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// SetEnabled switches source i of a merged stream on or off. A disabled
+// source stays connected, but its frames are discarded.
+func (s *Stream) SetEnabled(i int, on bool) {
+	if s.inner != nil {
+		s.inner.SetEnabled(i, on)
+		return
+	}
+	if i >= 0 && i < len(s.sources) {
+		s.sources[i].enabled.Store(on)
+	}
+}
@@ -0,0 +1,131 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Handshake describes each step of establishing a websocket connection, for
+// diagnosing endpoints that connect but never deliver anything.
+type Handshake struct {
+	Endpoint string
+	Addrs    []string      // resolved addresses of the host
+	Resolve  time.Duration // DNS lookup time
+	Remote   string        // address the TCP connection reached
+	Connect  time.Duration // TCP connect time
+	TLS      *tls.ConnectionState
+	TLSTime  time.Duration
+	Upgrade  time.Duration // websocket upgrade round trip
+	Status   string        // HTTP status line the server answered the upgrade with
+}
+
+// Probe dials endpoint exactly once, recording the handshake as it goes.
+// On success the returned Stream delivers frames from that single
+// connection and closes when it drops; it never reconnects. On failure the
+// Handshake still reports how far the dial got.
+func Probe(ctx context.Context, endpoint, origin string) (*Handshake, *Stream, error) {
+	hs := &Handshake{Endpoint: endpoint}
+
+	wsCfg, err := websocket.NewConfig(endpoint, origin)
+	if err != nil {
+		return hs, nil, err
+	}
+	u := wsCfg.Location
+	host, port := u.Hostname(), u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+
+	start := time.Now()
+	hs.Addrs, err = net.DefaultResolver.LookupHost(ctx, host)
+	hs.Resolve = time.Since(start)
+	if err != nil {
+		return hs, nil, fmt.Errorf("resolve %s: %w", host, err)
+	}
+
+	start = time.Now()
+	var d net.Dialer
+	raw, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	hs.Connect = time.Since(start)
+	if err != nil {
+		return hs, nil, fmt.Errorf("connect: %w", err)
+	}
+	hs.Remote = raw.RemoteAddr().String()
+	conn := raw
+
+	if u.Scheme == "wss" {
+		start = time.Now()
+		tc := tls.Client(raw, &tls.Config{ServerName: host})
+		err = tc.HandshakeContext(ctx)
+		hs.TLSTime = time.Since(start)
+		if err != nil {
+			raw.Close()
+			return hs, nil, fmt.Errorf("tls handshake: %w", err)
+		}
+		st := tc.ConnectionState()
+		hs.TLS = &st
+		conn = tc
+	}
+
+	rec := &statusRecorder{Conn: conn}
+	start = time.Now()
+	c, err := websocket.NewClient(wsCfg, rec)
+	hs.Upgrade = time.Since(start)
+	hs.Status = rec.statusLine()
+	if err != nil {
+		conn.Close()
+		return hs, nil, fmt.Errorf("websocket upgrade: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Stream{
+		msgCh:   make(chan []byte, 1024),
+		errCh:   make(chan error, 1),
+		stateCh: make(chan State, 8),
+		cancel:  cancel,
+	}
+	s.setState(State{Kind: Connected})
+	go func() {
+		// readLoop only notices cancellation between frames.
+		go func() {
+			<-ctx.Done()
+			c.Close()
+		}()
+		err := readLoop(ctx, c, s, nil)
+		cancel()
+		s.setState(State{Kind: Disconnected, Err: err})
+		close(s.msgCh)
+		close(s.errCh)
+		close(s.stateCh)
+	}()
+	return hs, s, nil
+}
+
+// statusRecorder keeps the first line the server sends, which is the HTTP
+// status of the upgrade response.
+type statusRecorder struct {
+	net.Conn
+	head []byte
+}
+
+func (r *statusRecorder) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	if len(r.head) < 256 && !bytes.Contains(r.head, []byte("\r\n")) {
+		r.head = append(r.head, p[:n]...)
+	}
+	return n, err
+}
+
+func (r *statusRecorder) statusLine() string {
+	line, _, _ := bytes.Cut(r.head, []byte("\r\n"))
+	return string(line)
+}
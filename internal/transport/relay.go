@@ -0,0 +1,72 @@
+package transport
+
+import "context"
+
+// Relayed is what a Relay stream passes on.
+type Relayed struct {
+	Frames <-chan []byte // closed when the relay should end
+	States <-chan State  // nil = none
+
+	// Shared is the stream the frames were read from by someone else, such
+	// as a hub fanning it out; Stats, Sources, and SetEnabled report on and
+	// act on it. Closing the relay leaves it open. nil = none.
+	Shared *Stream
+
+	// Lost counts frames that never reached Frames because this consumer
+	// fell behind; nil = none.
+	Lost func() uint64
+
+	// Stop is called once the relay has ended, so whatever feeds Frames can
+	// stop; nil = none.
+	Stop func()
+}
+
+// Relay returns a Stream that passes on the frames and states in r, for a
+// consumer that shares a stream with others. It closes when r.Frames does
+// or ctx is done.
+func Relay(ctx context.Context, r Relayed) *Stream {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Stream{
+		msgCh:   make(chan []byte, 1024),
+		errCh:   make(chan error, 1),
+		stateCh: make(chan State, 8),
+		cancel:  cancel,
+		inner:   r.Shared,
+		lost:    r.Lost,
+	}
+	go func() {
+		defer func() {
+			cancel()
+			if r.Stop != nil {
+				r.Stop()
+			}
+			s.setState(State{Kind: Disconnected, Err: ctx.Err()})
+			close(s.msgCh)
+			close(s.errCh)
+			close(s.stateCh)
+		}()
+		states := r.States
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case st, ok := <-states:
+				if !ok {
+					states = nil
+					continue
+				}
+				s.setState(st)
+			case frame, ok := <-r.Frames:
+				if !ok {
+					return
+				}
+				select {
+				case s.msgCh <- frame:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return s
+}
@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// maxReplayFrame bounds a single recorded frame.
+const maxReplayFrame = 64 << 20
+
+// Replay returns a Stream that emits the frames recorded in r, one per line
+// (the format written by `otail export`), waiting interval between frames.
+// Unlike Dial it never drops frames: it blocks until the reader catches up.
+// The stream reports Connected while replaying and closes at end of input;
+// r is closed at that point if it is an io.Closer.
+func Replay(ctx context.Context, r io.Reader, interval time.Duration) *Stream {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Stream{
+		msgCh:   make(chan []byte, 1024),
+		errCh:   make(chan error, 1),
+		stateCh: make(chan State, 8),
+		cancel:  cancel,
+	}
+
+	go func() {
+		defer func() {
+			cancel()
+			if c, ok := r.(io.Closer); ok {
+				c.Close()
+			}
+			s.setState(State{Kind: Disconnected, Err: io.EOF})
+			close(s.msgCh)
+			close(s.errCh)
+			close(s.stateCh)
+		}()
+
+		s.setState(State{Kind: Connected})
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64<<10), maxReplayFrame)
+		for sc.Scan() {
+			if len(sc.Bytes()) == 0 {
+				continue
+			}
+			frame := append([]byte(nil), sc.Bytes()...)
+			select {
+			case s.msgCh <- frame:
+			case <-ctx.Done():
+				return
+			}
+			if interval > 0 {
+				select {
+				case <-time.After(interval):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		if err := sc.Err(); err != nil {
+			s.errCh <- err
+		}
+	}()
+
+	return s
+}
+
+// ReplayFile returns a Source that replays the recording at path, reopening
+// it from the start on every call.
+func ReplayFile(path string, interval time.Duration) Source {
+	return func(ctx context.Context) (*Stream, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return Replay(ctx, f, interval), nil
+	}
+}
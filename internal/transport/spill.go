@@ -0,0 +1,156 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"sync"
+)
+
+// spill is an on-disk FIFO of frames that did not fit in a stream's message
+// channel. Frames are stored length-prefixed in a temp file that is
+// truncated whenever the queue empties, so it only grows during a burst.
+type spill struct {
+	mu      sync.Mutex
+	f       *os.File
+	r, w    int64 // read and write offsets
+	frames  int   // frames between r and w
+	limit   int64 // most bytes the file may hold
+	pending chan struct{}
+}
+
+func newSpill(dir string, limit int64) (*spill, error) {
+	f, err := os.CreateTemp(dir, "otail-spill-*")
+	if err != nil {
+		return nil, err
+	}
+	return &spill{f: f, limit: limit, pending: make(chan struct{}, 1)}, nil
+}
+
+// push appends frame, reporting false if the file is full or unwritable.
+func (sp *spill) push(frame []byte) bool {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	n := int64(4 + len(frame))
+	if sp.w+n > sp.limit {
+		return false
+	}
+	buf := binary.BigEndian.AppendUint32(make([]byte, 0, n), uint32(len(frame)))
+	if _, err := sp.f.WriteAt(append(buf, frame...), sp.w); err != nil {
+		return false
+	}
+	sp.w += n
+	sp.frames++
+	select {
+	case sp.pending <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// peek returns the oldest frame without removing it. The frame stays queued
+// until next is called, so frames arriving meanwhile queue up behind it.
+func (sp *spill) peek() ([]byte, bool) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.frames == 0 {
+		return nil, false
+	}
+	var hdr [4]byte
+	if _, err := sp.f.ReadAt(hdr[:], sp.r); err != nil {
+		sp.reset()
+		return nil, false
+	}
+	frame := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+	if _, err := sp.f.ReadAt(frame, sp.r+4); err != nil {
+		sp.reset()
+		return nil, false
+	}
+	return frame, true
+}
+
+// next removes the frame returned by peek.
+func (sp *spill) next() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	var hdr [4]byte
+	if _, err := sp.f.ReadAt(hdr[:], sp.r); err != nil {
+		sp.reset()
+		return
+	}
+	sp.r += 4 + int64(binary.BigEndian.Uint32(hdr[:]))
+	if sp.frames--; sp.frames == 0 {
+		sp.reset()
+	}
+}
+
+// reset empties the queue and gives the disk space back. Callers hold mu.
+func (sp *spill) reset() {
+	sp.r, sp.w, sp.frames = 0, 0, 0
+	sp.f.Truncate(0)
+}
+
+// len returns how many frames are waiting on disk.
+func (sp *spill) len() int {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.frames
+}
+
+// drain moves spilled frames into msgCh as the reader makes room, until ctx
+// is done.
+func (sp *spill) drain(ctx context.Context, msgCh chan<- []byte) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sp.pending:
+		}
+		for {
+			frame, ok := sp.peek()
+			if !ok {
+				break
+			}
+			select {
+			case msgCh <- frame:
+				sp.next()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// close removes the spill file.
+func (sp *spill) close() {
+	sp.f.Close()
+	os.Remove(sp.f.Name())
+}
+
+// deliver hands frame to the reader. While anything is spilled, frames go
+// to the spill file behind it so order is kept; otherwise they go straight
+// to msgCh, spilling only when it is full. Frames that fit nowhere are
+// counted in s.dropped.
+func (s *Stream) deliver(frame []byte) {
+	if s.spill != nil && s.spill.len() > 0 {
+		if !s.spill.push(frame) {
+			s.drop()
+		}
+		return
+	}
+	select {
+	case s.msgCh <- frame:
+	default:
+		if s.spill == nil || !s.spill.push(frame) {
+			s.drop()
+		}
+	}
+}
+
+// drop counts a frame that fit nowhere. The debug log hears of the first
+// and then of every doubling, so a stalled reader cannot flood it.
+func (s *Stream) drop() {
+	if n := s.dropped.Add(1); n&(n-1) == 0 {
+		s.debugLog().Warn("frames dropped", "total", n)
+	}
+}
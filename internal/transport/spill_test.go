@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestSpill(t *testing.T, limit int64) *spill {
+	t.Helper()
+	sp, err := newSpill(t.TempDir(), limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sp.close)
+	return sp
+}
+
+func TestSpillOrder(t *testing.T) {
+	sp := newTestSpill(t, 1<<20)
+	for _, f := range []string{"a", "bb", "", "ccc"} {
+		if !sp.push([]byte(f)) {
+			t.Fatalf("push(%q) failed", f)
+		}
+	}
+	if n := sp.len(); n != 4 {
+		t.Fatalf("len = %d, want 4", n)
+	}
+	for _, want := range []string{"a", "bb", ""} {
+		got, ok := sp.peek()
+		if !ok || string(got) != want {
+			t.Fatalf("peek = %q, %v; want %q", got, ok, want)
+		}
+		if again, _ := sp.peek(); string(again) != want {
+			t.Fatalf("second peek = %q, want %q again", again, want)
+		}
+		sp.next()
+	}
+	// A frame pushed while others wait queues up behind them.
+	sp.push([]byte("dddd"))
+	for _, want := range []string{"ccc", "dddd"} {
+		got, ok := sp.peek()
+		if !ok || string(got) != want {
+			t.Fatalf("peek = %q, %v; want %q", got, ok, want)
+		}
+		sp.next()
+	}
+	if _, ok := sp.peek(); ok {
+		t.Fatal("peek found a frame in an empty spill")
+	}
+	if fi, err := sp.f.Stat(); err != nil || fi.Size() != 0 {
+		t.Fatalf("spill file not truncated once empty: %v, %v", fi.Size(), err)
+	}
+}
+
+func TestSpillLimit(t *testing.T) {
+	sp := newTestSpill(t, 20) // two 6-byte frames and their headers
+	if !sp.push([]byte("frame1")) || !sp.push([]byte("frame2")) {
+		t.Fatal("push within the limit failed")
+	}
+	if sp.push([]byte("frame3")) {
+		t.Fatal("push past the limit succeeded")
+	}
+	sp.peek()
+	sp.next()
+	if sp.push([]byte("frame3")) {
+		t.Fatal("space was reused before the spill emptied")
+	}
+	sp.peek()
+	sp.next()
+	if !sp.push([]byte("frame3")) {
+		t.Fatal("push after the spill emptied failed")
+	}
+}
+
+func TestDeliverKeepsOrder(t *testing.T) {
+	s := &Stream{msgCh: make(chan []byte, 2), spill: newTestSpill(t, 1<<20)}
+	const n = 10
+	for i := range n {
+		s.deliver(fmt.Appendf(nil, "%d", i))
+	}
+	if got := s.Stats(); got.Buffered != 2 || got.Spilled != n-2 || got.Dropped != 0 {
+		t.Fatalf("Stats = %+v, want 2 buffered and %d spilled", got, n-2)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.spill.drain(ctx, s.msgCh)
+	for i := range n {
+		select {
+		case got := <-s.msgCh:
+			if want := fmt.Sprint(i); string(got) != want {
+				t.Fatalf("frame %d = %q, want %q", i, got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("frame %d never arrived", i)
+		}
+	}
+}
+
+func TestDeliverDrops(t *testing.T) {
+	s := &Stream{msgCh: make(chan []byte, 1)}
+	s.deliver([]byte("kept"))
+	s.deliver([]byte("dropped"))
+	if got := s.Stats(); got.Buffered != 1 || got.Dropped != 1 {
+		t.Fatalf("without a spill: Stats = %+v, want 1 buffered and 1 dropped", got)
+	}
+
+	s = &Stream{msgCh: make(chan []byte, 1), spill: newTestSpill(t, 8)}
+	for _, f := range []string{"kept", "disk", "dropped"} {
+		s.deliver([]byte(f))
+	}
+	if got := s.Stats(); got.Buffered != 1 || got.Spilled != 1 || got.Dropped != 1 {
+		t.Fatalf("with a full spill: Stats = %+v, want 1 buffered, 1 spilled, and 1 dropped", got)
+	}
+}
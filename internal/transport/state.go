@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"fmt"
+	"time"
+)
+
+// StateKind enumerates the connection states a Stream moves through.
+type StateKind int
+
+const (
+	Connecting   StateKind = iota // initial dial in progress
+	Connected                     // websocket open, frames flowing
+	Disconnected                  // connection lost or stream closed
+	Reconnecting                  // waiting to redial after a failure
+)
+
+func (k StateKind) String() string {
+	switch k {
+	case Connected:
+		return "connected"
+	case Disconnected:
+		return "disconnected"
+	case Reconnecting:
+		return "reconnecting"
+	default:
+		return "connecting"
+	}
+}
+
+// State is a connection state transition emitted on Stream.States.
+type State struct {
+	Kind      StateKind
+	Attempt   int       // Reconnecting: consecutive failed dials
+	NextRetry time.Time // Reconnecting: when the next dial starts
+	Err       error     // Disconnected/Reconnecting: cause, if any
+}
+
+func (s State) String() string {
+	if s.Kind == Reconnecting {
+		return fmt.Sprintf("%s (attempt %d)", s.Kind, s.Attempt)
+	}
+	return s.Kind.String()
+}
@@ -0,0 +1,80 @@
+package transport
+
+import "context"
+
+// Tap returns a Source that opens src and calls tap with every frame before
+// passing it on, so frames can be copied elsewhere while still being shown.
+// tap runs on the stream's goroutine and should not block.
+func Tap(src Source, tap func(frame []byte)) Source {
+	return Transform(src, func(frame []byte) ([]byte, bool) {
+		tap(frame)
+		return frame, true
+	})
+}
+
+// Transform returns a Source that opens src and passes on each frame as fn
+// returns it, leaving out those it returns false for. fn runs on the
+// stream's goroutine; while it works, frames wait in the inner stream's
+// buffer.
+func Transform(src Source, fn func(frame []byte) ([]byte, bool)) Source {
+	return func(ctx context.Context) (*Stream, error) {
+		inner, err := src(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		s := &Stream{
+			msgCh:   make(chan []byte, 1024),
+			errCh:   make(chan error, 1),
+			stateCh: make(chan State, 8),
+			cancel:  func() { cancel(); inner.Close() },
+			inner:   inner,
+		}
+		go func() {
+			states, errs := inner.States(), inner.Errors()
+			defer func() {
+				if states != nil {
+					for st := range states { // the final Disconnected
+						s.setState(st)
+					}
+				}
+				close(s.msgCh)
+				close(s.errCh)
+				close(s.stateCh)
+			}()
+			for {
+				select {
+				case st, ok := <-states:
+					if !ok {
+						states = nil
+						continue
+					}
+					s.setState(st)
+				case err, ok := <-errs:
+					if !ok {
+						errs = nil
+						continue
+					}
+					s.errCh <- err
+					return
+				case frame, ok := <-inner.Messages():
+					if !ok {
+						return
+					}
+					if frame, ok = fn(frame); !ok {
+						continue
+					}
+					// Blocking, like the inner stream's reader was: a live
+					// stream still drops on its own buffer, and a replay
+					// never drops.
+					select {
+					case s.msgCh <- frame:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+		return s, nil
+	}
+}
@@ -3,9 +3,12 @@ package transport
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/url"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/websocket"
@@ -13,9 +16,31 @@ import (
 
 // Stream exposes a read-only frame channel plus an error stream.
 type Stream struct {
-	msgCh  chan []byte // never closed by user code
-	errCh  chan error  // unrecoverable faults
-	cancel context.CancelFunc
+	msgCh   chan []byte // never closed by user code
+	errCh   chan error  // unrecoverable faults
+	stateCh chan State  // connection state transitions
+	cancel  context.CancelFunc
+
+	dropped     atomic.Uint64 // frames discarded because msgCh was full
+	undecodable atomic.Uint64 // compressed frames that failed to decompress
+	spill       *spill        // overflow queue on disk; nil = drop instead
+	debug       *slog.Logger  // connection events and drops; nil = none
+
+	sources []*member     // set by Merge; nil for a single connection
+	inner   *Stream       // set by Tap and Relay: the stream whose frames it copies
+	lost    func() uint64 // set by Relay: frames dropped before reaching it
+}
+
+// Stats is a point-in-time snapshot of the stream's buffer usage.
+type Stats struct {
+	Buffered int    // frames waiting in the message channel
+	Capacity int    // size of the message channel
+	Dropped  uint64 // frames discarded since Dial
+	Spilled  int    // frames waiting on disk for room in the channel
+
+	// DecompressFailed counts frames that looked gzip or zlib compressed
+	// but did not inflate; they are passed on as received.
+	DecompressFailed uint64
 }
 
 // Messages returns the channel on which callers receive raw frames.
@@ -25,9 +50,46 @@ func (s *Stream) Messages() <-chan []byte { return s.msgCh }
 // closing msgCh, so callers should select on both.
 func (s *Stream) Errors() <-chan error { return s.errCh }
 
+// States returns the connection state stream. Slow readers may miss
+// intermediate transitions but always observe the latest one. The channel
+// is closed when the stream shuts down.
+func (s *Stream) States() <-chan State { return s.stateCh }
+
 // Close cancels the underlying context and shuts the channels.
 func (s *Stream) Close() { s.cancel() }
 
+// Stats reports the current buffer usage. It is safe to call from any
+// goroutine.
+func (s *Stream) Stats() Stats {
+	st := Stats{
+		Buffered: len(s.msgCh),
+		Capacity: cap(s.msgCh),
+		Dropped:  s.dropped.Load(),
+
+		DecompressFailed: s.undecodable.Load(),
+	}
+	if s.lost != nil {
+		st.Dropped += s.lost()
+	}
+	if s.spill != nil {
+		st.Spilled = s.spill.len()
+	}
+	children := make([]*Stream, 0, len(s.sources)+1)
+	for _, mb := range s.sources {
+		children = append(children, mb.stream)
+	}
+	if s.inner != nil {
+		children = append(children, s.inner)
+	}
+	for _, c := range children {
+		child := c.Stats()
+		st.Dropped += child.Dropped
+		st.Spilled += child.Spilled
+		st.DecompressFailed += child.DecompressFailed
+	}
+	return st
+}
+
 // --------------------------------------------------------------------
 
 // Config tweaks behaviour; zero-value is sane.
@@ -36,12 +98,20 @@ type Config struct {
 	BaseBackoff  time.Duration // default 500 ms
 	MaxBackoff   time.Duration // default 30 s
 	Logger       *log.Logger   // nil = discard
+	Debug        *slog.Logger  // structured events for --debug-log; nil = none
+
+	// SpillLimit is how many bytes of frames may be kept in a temp file in
+	// SpillDir (default os.TempDir) when the reader falls behind, to be
+	// delivered once it catches up. 0 = drop those frames instead.
+	SpillLimit int64
+	SpillDir   string
 }
 
 // Dial starts a background goroutine that
 //   - dials endpoint (with Origin header)
 //   - pipes frames into Stream.msgCh
 //   - auto-reconnects with exponential back-off
+//   - reports connection state transitions on Stream.States
 func Dial(ctx context.Context, endpoint, origin string, cfg *Config) (*Stream, error) {
 	if cfg == nil {
 		cfg = &Config{}
@@ -65,16 +135,39 @@ func Dial(ctx context.Context, endpoint, origin string, cfg *Config) (*Stream, e
 
 	ctx, cancel := context.WithCancel(ctx)
 	s := &Stream{
-		msgCh:  make(chan []byte, 1024),
-		errCh:  make(chan error, 1), // buffer so goroutine can exit
-		cancel: cancel,
+		msgCh:   make(chan []byte, 1024),
+		errCh:   make(chan error, 1), // buffer so goroutine can exit
+		stateCh: make(chan State, 8),
+		cancel:  cancel,
+	}
+	if cfg.Debug != nil {
+		s.debug = cfg.Debug.With("endpoint", endpoint)
+	}
+	drained := make(chan struct{})
+	if cfg.SpillLimit > 0 {
+		if s.spill, err = newSpill(cfg.SpillDir, cfg.SpillLimit); err != nil {
+			cancel()
+			return nil, fmt.Errorf("transport: spill file: %w", err)
+		}
+		go func() {
+			defer close(drained)
+			s.spill.drain(ctx, s.msgCh)
+		}()
+	} else {
+		close(drained)
 	}
 
 	go func() {
 		defer func() {
 			cancel()
+			<-drained // the drainer sends on msgCh
+			if s.spill != nil {
+				s.spill.close()
+			}
+			s.setState(State{Kind: Disconnected, Err: ctx.Err()})
 			close(s.msgCh)
 			close(s.errCh)
+			close(s.stateCh)
 		}()
 
 		backoffAttempt := 0
@@ -85,20 +178,33 @@ func Dial(ctx context.Context, endpoint, origin string, cfg *Config) (*Stream, e
 			default:
 			}
 
+			s.debugLog().Debug("dialing", "attempt", backoffAttempt+1)
 			c, err := websocket.Dial(endpoint, "", origin)
 			if err != nil {
 				delay := backoff(backoffAttempt, cfg.BaseBackoff, cfg.MaxBackoff)
 				logger.Printf("dial error: %v (retry in %s)", err, delay)
-				time.Sleep(delay)
 				backoffAttempt++
+				s.setState(State{
+					Kind:      Reconnecting,
+					Attempt:   backoffAttempt,
+					NextRetry: time.Now().Add(delay),
+					Err:       err,
+				})
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(delay):
+				}
 				continue
 			}
 			backoffAttempt = 0 // successful dial → reset
+			s.setState(State{Kind: Connected})
 
-			if err = readLoop(ctx, c, s.msgCh); err != nil {
+			if err = readLoop(ctx, c, s, logger); err != nil {
 				// Connection dropped – try again unless context cancelled.
 				if ctx.Err() == nil {
 					logger.Printf("read loop ended: %v", err)
+					s.setState(State{Kind: Disconnected, Err: err})
 					// next iteration will redial
 				} else {
 					s.errCh <- err
@@ -111,11 +217,52 @@ func Dial(ctx context.Context, endpoint, origin string, cfg *Config) (*Stream, e
 	return s, nil
 }
 
+// Source opens a Stream. Callers that want to reconnect call it again.
+type Source func(ctx context.Context) (*Stream, error)
+
+// DialSource returns a Source that dials endpoint with cfg.
+func DialSource(endpoint, origin string, cfg *Config) Source {
+	return func(ctx context.Context) (*Stream, error) {
+		return Dial(ctx, endpoint, origin, cfg)
+	}
+}
+
 // --------------------------------------------------------------------
 // Internal helpers
 
-// readLoop blocks, copying frames to out until EOF or ctx.Done().
-func readLoop(ctx context.Context, c *websocket.Conn, out chan<- []byte) error {
+// debugLog returns where s logs debug events.
+func (s *Stream) debugLog() *slog.Logger {
+	if s.debug == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return s.debug
+}
+
+// setState publishes st without blocking. When the buffer is full the
+// oldest pending transition is discarded so the latest state always lands.
+func (s *Stream) setState(st State) {
+	if st.Err != nil {
+		s.debugLog().Info("connection", "state", st.Kind.String(), "attempt", st.Attempt, "err", st.Err)
+	} else {
+		s.debugLog().Info("connection", "state", st.Kind.String())
+	}
+	for {
+		select {
+		case s.stateCh <- st:
+			return
+		default:
+		}
+		select {
+		case <-s.stateCh:
+		default:
+		}
+	}
+}
+
+// readLoop blocks, copying frames to s.msgCh until EOF or ctx.Done().
+// Compressed frames are inflated first. Frames that do not fit in msgCh
+// are spilled to disk if configured, otherwise counted in s.dropped.
+func readLoop(ctx context.Context, c *websocket.Conn, s *Stream, logger *log.Logger) error {
 	defer c.Close()
 
 	for {
@@ -129,10 +276,16 @@ func readLoop(ctx context.Context, c *websocket.Conn, out chan<- []byte) error {
 		if err := websocket.Message.Receive(c, &frame); err != nil {
 			return err // includes io.EOF on clean close
 		}
-		// Non-blocking send; drop frame if no reader (paused UI).
-		select {
-		case out <- frame:
-		default:
+		if out, compressed, err := decompress(frame); err != nil {
+			s.undecodable.Add(1)
+			s.debugLog().Warn("decompress failed", "bytes", len(frame), "err", err)
+			if logger != nil {
+				logger.Printf("decompress frame: %v", err)
+			}
+		} else if compressed {
+			frame = out
 		}
+		// Never blocks: a paused or slow UI must not stall the socket.
+		s.deliver(frame)
 	}
 }
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/alert"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// alertFlash is how long the status bar shows the latest alert.
+const alertFlash = 3 * time.Second
+
+// alertCooldown is the minimum time between bells and alert commands, so a
+// burst of matching messages alerts once.
+const alertCooldown = 2 * time.Second
+
+// alerts holds the notification rules and when they last fired.
+type alerts struct {
+	rules   []alert.Rule
+	command string // run with sh -c on each alert; empty = none
+
+	last    alert.Hit
+	matched time.Time // when last matched; drives the status bar flash
+	fired   time.Time // when the bell last rang
+}
+
+// checkAlerts evaluates the rules against msg as it arrives, whether or not
+// its tab is paused, and returns a command that rings the bell and runs the
+// alert command when one matches outside the cooldown.
+func (m *Model) checkAlerts(msg telemetry.Message) tea.Cmd {
+	a := &m.alerts
+	if len(a.rules) == 0 {
+		return nil
+	}
+	hit, ok := alert.Match(a.rules, msg)
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	a.last, a.matched = hit, now
+	if now.Sub(a.fired) < alertCooldown {
+		return nil
+	}
+	a.fired = now
+	command := a.command
+	return func() tea.Msg {
+		// The renderer owns stdout; the bell goes to the same terminal.
+		os.Stderr.WriteString("\a")
+		if command != "" {
+			runAlertCommand(command, hit)
+		}
+		return nil
+	}
+}
+
+// runAlertCommand runs command with the hit described in its environment.
+// Its output is discarded, since it would corrupt the screen.
+func runAlertCommand(command string, hit alert.Hit) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		"OTAIL_ALERT="+hit.String(),
+		"OTAIL_ALERT_RULE="+hit.Rule.String(),
+		"OTAIL_ALERT_SERVICE="+hit.Service,
+		"OTAIL_ALERT_TEXT="+hit.Text,
+	)
+	cmd.Run()
+}
+
+// alerting reports whether the status bar is flashing an alert.
+func (m Model) alerting() bool {
+	return !m.alerts.matched.IsZero() && time.Since(m.alerts.matched) < alertFlash
+}
+
+func (m Model) renderAlert() string {
+	return styles.Banner.Render("ALERT " + m.alerts.last.String())
+}
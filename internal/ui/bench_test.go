@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/jwafle/otail/internal/gen"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// benchMessages returns n parsed log messages, rendered once so benchmarks
+// measure the store and view rather than JSON rendering.
+func benchMessages(n int) []telemetry.Message {
+	g := gen.New(&gen.Config{Kinds: []telemetry.Kind{telemetry.KindLogs}}, 1)
+	msgs := make([]telemetry.Message, n)
+	for i := range msgs {
+		msgs[i] = telemetry.Parse(g.Next())
+		msgs[i].Lines()
+	}
+	return msgs
+}
+
+func BenchmarkStoreAdd(b *testing.B) {
+	msgs := benchMessages(1000)
+	for b.Loop() {
+		s := &messageStore{}
+		for _, m := range msgs {
+			s.Add(m)
+		}
+	}
+}
+
+func BenchmarkSyncViewport(b *testing.B) {
+	m := newModel(nil, func() {}, nil, telemetry.KindLogs)
+	m.width, m.height = 120, 40
+	m.viewport = newViewport(m.width, m.height-m.chromeRows())
+	m.ready = true
+	for _, msg := range benchMessages(10000) {
+		m.live.Add(msg)
+	}
+	for _, paused := range []bool{false, true} {
+		name := "streaming"
+		if paused {
+			name = "paused"
+		}
+		b.Run(name, func(b *testing.B) {
+			m.paused = paused
+			m.viewport.SetTotal(m.totalLines())
+			m.viewport.SetYOffset(m.totalLines() / 2)
+			m.cur.line = m.viewport.YOffset
+			for b.Loop() {
+				m.syncViewport()
+			}
+		})
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	x := &wordIndex{}
+	for i, m := range benchMessages(10000) {
+		x.add(i, m)
+	}
+	for _, query := range []string{"card declined", "checkout", "c"} {
+		b.Run(query, func(b *testing.B) {
+			for b.Loop() {
+				x.find(query)
+			}
+		})
+	}
+}
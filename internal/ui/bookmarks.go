@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// bookmark remembers a message by its arrival number rather than its line,
+// so it stays on the same message whatever the filter, and goes stale
+// rather than moving when its message is cleared.
+type bookmark struct {
+	kind    telemetry.Kind
+	seq     int    // -1 for a bookmark restored from a previous run
+	summary string // the message's first record, for the list
+}
+
+// bookmarkState is the Model's side of bookmarks; the marks themselves live
+// in the store they point into, so snapshots keep their own.
+type bookmarkState struct {
+	pending string // "`" or "'" while waiting for the bookmark's letter
+	note    string // why the last jump went nowhere, until the next key
+	list    []rune // letters in the list overlay, in order
+	sel     int
+}
+
+// Seq returns the arrival number of message i of kind k.
+func (s *messageStore) Seq(k telemetry.Kind, i int) int {
+	return s.seqs[k][i]
+}
+
+// FindSeq returns the index of the message of kind k that arrived as seq,
+// and false once it has been cleared.
+func (s *messageStore) FindSeq(k telemetry.Kind, seq int) (int, bool) {
+	seqs := s.seqs[k]
+	i := sort.SearchInts(seqs, seq)
+	return i, i < len(seqs) && seqs[i] == seq
+}
+
+// bookmarkLetter finishes a ` or ' key with the letter that follows it.
+func (m *Model) bookmarkLetter(msg tea.KeyMsg) {
+	op := m.bookmarks.pending
+	m.bookmarks.pending = ""
+	if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 || msg.Runes[0] < 'a' || msg.Runes[0] > 'z' {
+		return // anything else cancels
+	}
+	r := msg.Runes[0]
+	if op == "`" {
+		m.setBookmark(r)
+	} else {
+		m.jumpToBookmark(r)
+	}
+}
+
+// setBookmark marks the message under the cursor with r.
+func (m *Model) setBookmark(r rune) {
+	if m.cur.msg == nil {
+		return
+	}
+	i := m.cursorMsgIndex()
+	summary := ""
+	if recs := m.cur.msg.Records(); len(recs) > 0 {
+		summary = recs[0].String()
+	}
+	if m.store.marks == nil {
+		m.store.marks = make(map[rune]bookmark)
+	}
+	m.store.marks[r] = bookmark{kind: m.Active, seq: m.store.Seq(m.Active, i), summary: summary}
+	m.bookmarks.note = fmt.Sprintf("bookmark %c set", r)
+}
+
+// jumpToBookmark pauses on the message marked r, switching to its tab.
+func (m *Model) jumpToBookmark(r rune) {
+	b, ok := m.store.marks[r]
+	if !ok {
+		m.bookmarks.note = fmt.Sprintf("no bookmark %c", r)
+		return
+	}
+	i, ok := m.store.FindSeq(b.kind, b.seq)
+	switch {
+	case b.seq < 0:
+		m.bookmarks.note = fmt.Sprintf("bookmark %c is from a previous run", r)
+		return
+	case !ok:
+		m.bookmarks.note = fmt.Sprintf("bookmark %c was cleared", r)
+		return
+	}
+	if m.overlay != overlayNone {
+		m.closeOverlay()
+	}
+	m.switchTab(b.kind)
+	if m.tableMode() {
+		m.bookmarks.note = "bookmarks need the JSON view (v)"
+		return
+	}
+	line, ok := m.store.MessageStart(b.kind, i)
+	if !ok {
+		m.bookmarks.note = fmt.Sprintf("bookmark %c is hidden by the filter", r)
+		return
+	}
+	m.paused = true
+	m.cur.line = line
+	m.viewport.SetTotal(m.totalLines())
+	m.ensureCursorVisible()
+	m.syncViewport()
+}
+
+// openBookmarks lists the bookmarks by letter.
+func (m *Model) openBookmarks() {
+	m.bookmarks.list = m.bookmarks.list[:0]
+	for r := range m.store.marks {
+		m.bookmarks.list = append(m.bookmarks.list, r)
+	}
+	slices.Sort(m.bookmarks.list)
+	m.bookmarks.sel = min(m.bookmarks.sel, max(len(m.bookmarks.list)-1, 0))
+	m.renderBookmarks()
+}
+
+func (m *Model) renderBookmarks() {
+	lines := []string{styles.Status.Render("bookmarks · enter jump · d delete · esc close")}
+	if len(m.bookmarks.list) == 0 {
+		lines = append(lines, "", "no bookmarks; ` then a letter marks the message under the cursor (paused)")
+	}
+	for n, r := range m.bookmarks.list {
+		b := m.store.marks[r]
+		state := ""
+		i, ok := m.store.FindSeq(b.kind, b.seq)
+		if b.seq < 0 {
+			state = " (previous run)"
+		} else if !ok {
+			state = " (cleared)"
+		} else if _, shown := m.store.MessageStart(b.kind, i); !shown {
+			state = " (filtered out)"
+		}
+		line := truncateValue(fmt.Sprintf("  %c  %-7s %s%s", r, b.kind, b.summary, state), max(m.viewport.Width, 8))
+		switch {
+		case n == m.bookmarks.sel:
+			line = styles.Cursor.Render(line)
+		case !ok:
+			line = styles.Context.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+// bookmarksKey handles keys while the list is open and reports whether it
+// consumed msg.
+func (m *Model) bookmarksKey(msg tea.KeyMsg) bool {
+	p := &m.bookmarks
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case msg.String() == "enter" && len(p.list) > 0:
+		m.jumpToBookmark(p.list[p.sel])
+		return true
+	case msg.String() == "d" && len(p.list) > 0:
+		delete(m.store.marks, p.list[p.sel])
+		m.openBookmarks()
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		p.sel = max(p.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		p.sel = max(min(p.sel+1, len(p.list)-1), 0)
+	default:
+		return false
+	}
+	m.renderBookmarks()
+	m.scrollToSelection(p.sel + 1)
+	return true
+}
+
+func bookmarkSegment(m Model) string {
+	switch {
+	case m.bookmarks.pending == "`":
+		return "bookmark: press a-z"
+	case m.bookmarks.pending == "'":
+		return "jump to bookmark: press a-z"
+	}
+	return m.bookmarks.note
+}
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"runtime/metrics"
+	"sort"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// With a memory limit (--memory), otail still keeps every message, but as
+// the heap nears the limit it forgets the rendered lines and styled rows of
+// the oldest messages, keeping their frames and decoded data, and renders
+// them again if they are scrolled back to. That trades CPU for a much
+// longer history than the rendered form would leave room for.
+
+const (
+	budgetHigh = 0.9  // share of the limit at which old messages are downgraded
+	budgetLow  = 0.75 // share of the limit downgrading aims to get the heap under
+)
+
+// The live heap as marked by the last GC, and the number of completed GC
+// cycles. Unlike the heap's object bytes, the live heap never counts
+// garbage still waiting to be swept, so rendered lines already dropped are
+// not counted again; it only changes when a cycle completes, so the budget
+// is checked again only after one has.
+const (
+	liveMetric   = "/gc/heap/live:bytes"
+	cyclesMetric = "/gc/cycles/total:gc-cycles"
+)
+
+type memoryBudget struct {
+	limit      int64  // bytes; 0 = no limit
+	done       int    // arrival number of the last message downgraded
+	downgraded int    // messages downgraded in all
+	freed      int64  // bytes of rendered lines dropped in all
+	cycle      uint64 // GC cycles completed at the last downgrade
+	read       func() (live int64, cycles uint64)
+}
+
+func newMemoryBudget(limit int64) memoryBudget {
+	return memoryBudget{limit: limit, done: -1, read: readHeap()}
+}
+
+// readHeap returns a reader of the live heap and completed GC cycles.
+func readHeap() func() (int64, uint64) {
+	sample := []metrics.Sample{{Name: liveMetric}, {Name: cyclesMetric}}
+	return func() (int64, uint64) {
+		metrics.Read(sample)
+		var live int64
+		var cycles uint64
+		if sample[0].Value.Kind() == metrics.KindUint64 {
+			live = int64(sample[0].Value.Uint64())
+		}
+		if sample[1].Value.Kind() == metrics.KindUint64 {
+			cycles = sample[1].Value.Uint64()
+		}
+		return live, cycles
+	}
+}
+
+// enforceBudget downgrades the oldest messages not yet downgraded, in
+// arrival order across kinds, while the heap is near the limit.
+func (m *Model) enforceBudget() {
+	b := &m.budget
+	if b.limit <= 0 {
+		return
+	}
+	heap, cycles := b.read()
+	if b.downgraded > 0 && cycles == b.cycle {
+		return // what was dropped last time is not reflected yet
+	}
+	if float64(heap) < budgetHigh*float64(b.limit) {
+		return
+	}
+	b.cycle = cycles
+	need := heap - int64(budgetLow*float64(b.limit))
+	s := m.live
+	next := make(map[telemetry.Kind]int)
+	for _, t := range tabs {
+		next[t.kind] = sort.SearchInts(s.seqs[t.kind], b.done+1)
+	}
+	for freed := int64(0); freed < need; {
+		// The kind whose next message arrived first.
+		oldest, ok := telemetry.Kind(0), false
+		for _, t := range tabs {
+			if next[t.kind] < len(s.seqs[t.kind]) && (!ok || s.seqs[t.kind][next[t.kind]] < s.seqs[oldest][next[oldest]]) {
+				oldest, ok = t.kind, true
+			}
+		}
+		if !ok {
+			return // nothing left to downgrade; the frames themselves fill the budget
+		}
+		i := next[oldest]
+		next[oldest]++
+		n := int64(s.dropRendered(oldest, i))
+		m.styled.drop(oldest, i)
+		freed += n
+		b.freed += n
+		b.downgraded++
+		b.done = s.seqs[oldest][i]
+	}
+}
+
+// dropRendered forgets the rendered lines of message i of kind k, and of
+// the view of it shown while a view narrows k, returning about how many
+// bytes that frees.
+func (s *messageStore) dropRendered(k telemetry.Kind, i int) int {
+	n := s.Messages(k)[i].DropRendered()
+	if v, ok := s.views[k][i]; ok {
+		n += v.DropRendered()
+	}
+	return n
+}
+
+// drop forgets the styled rows of message i of kind k.
+func (c *styleCache) drop(k telemetry.Kind, i int) {
+	delete(c.entries, styleKey{k, i})
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+func TestEnforceBudget(t *testing.T) {
+	m := newModel(nil, func() {}, nil, telemetry.KindLogs)
+	msgs := benchMessages(10)
+	smallest := int64(-1)
+	for _, msg := range msgs {
+		if n := int64(msg.DropRendered()); smallest < 0 || n < smallest {
+			smallest = n
+		}
+		msg.Lines()
+		m.live.Add(msg)
+	}
+
+	// At this limit, downgrading one message of any size frees enough to
+	// get under 75% of it.
+	limit := 4 * smallest
+	var live int64
+	var cycles uint64
+	m.budget = newMemoryBudget(limit)
+	m.budget.read = func() (int64, uint64) { return live, cycles }
+
+	steps := []struct {
+		name       string
+		live       int64
+		cycles     uint64
+		downgraded int
+	}{
+		{"under the limit", limit / 2, 1, 0},
+		{"at the limit", limit, 1, 1},
+		{"no GC since", limit, 1, 1},
+		{"after a GC, still at the limit", limit, 2, 2},
+		{"after a GC, under", limit / 2, 3, 2},
+		{"at the limit again", limit, 3, 3},
+	}
+	for _, s := range steps {
+		live, cycles = s.live, s.cycles
+		m.enforceBudget()
+		if got := m.budget.downgraded; got != s.downgraded {
+			t.Errorf("%s: downgraded = %d, want %d", s.name, got, s.downgraded)
+		}
+	}
+	if m.budget.done != m.live.seqs[telemetry.KindLogs][2] {
+		t.Errorf("done = %d, want the third message's arrival", m.budget.done)
+	}
+}
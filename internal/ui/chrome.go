@@ -0,0 +1,68 @@
+package ui
+
+import "strings"
+
+// The chrome is everything around the viewport: the tabs, and the table
+// header in table mode, above it; the detail footer, latency histogram,
+// status bar, and short help below it. Zen mode (Z) hides the tabs, footer,
+// status bar, and help so messages get every row they can; a prompt,
+// alert, or disconnect banner then takes the bottom row while it lasts.
+
+// helpHeight is the number of rows the short help takes.
+const helpHeight = 1
+
+// viewportTop returns the screen row the viewport starts at.
+func (m *Model) viewportTop() int {
+	top := 0
+	if !m.zen {
+		top += tabHeight
+	}
+	if m.tableMode() {
+		top++ // column header
+	}
+	return top
+}
+
+// chromeRows returns how many rows the chrome takes.
+func (m *Model) chromeRows() int {
+	rows := m.viewportTop()
+	if m.showDetail() {
+		rows++
+	}
+	if m.showHistogram() {
+		rows += histogramHeight
+	}
+	if !m.zen {
+		rows += 1 + helpHeight // status bar
+	}
+	return rows
+}
+
+// toggleZen hides or restores the chrome.
+func (m *Model) toggleZen() {
+	m.zen = !m.zen
+	m.syncViewport()
+}
+
+// notice returns what takes the status bar's place, if anything: the
+// banner once the stream has closed, the prompt, or an alert.
+func (m Model) notice() string {
+	switch {
+	case m.closed:
+		return m.renderBanner()
+	case m.prompt.active:
+		return m.renderPrompt()
+	case m.alerting():
+		return m.renderAlert()
+	}
+	return ""
+}
+
+// overBottomRow puts line over the last row of view, for zen mode, which
+// keeps no row free for it.
+func overBottomRow(view, line string) string {
+	if i := strings.LastIndexByte(view, '\n'); i >= 0 {
+		return view[:i+1] + line
+	}
+	return line
+}
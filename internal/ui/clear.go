@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// clearBuffers drops the received messages of kinds, resetting each tab to
+// an empty, streaming-from-the-bottom view. u puts them back. Snapshots
+// never change, so nothing is cleared while viewing one.
+func (m *Model) clearBuffers(kinds ...telemetry.Kind) {
+	if m.viewingSnapshot() {
+		return
+	}
+	var removed []clearedKind
+	for _, k := range kinds {
+		removed = append(removed, m.live.Clear(k))
+		if st, ok := m.tabStates[k]; ok {
+			m.tabStates[k] = tabState{paused: st.paused, received: st.received}
+		}
+		m.order.Reset(k)
+		switch k {
+		case telemetry.KindTraces:
+			m.latency = aggregate.Latency{}
+		case telemetry.KindLogs:
+			m.pipeline = aggregate.Pipeline{}
+		}
+	}
+	offset, line := m.viewport.YOffset, m.cur.line
+	what := "clear"
+	if len(kinds) > 1 {
+		what = "clear all"
+	}
+	m.pushUndo(what, func(m *Model) {
+		for _, c := range removed {
+			m.live.Restore(c)
+			if c.kind == telemetry.KindTraces || c.kind == telemetry.KindLogs {
+				m.rebuildLatency()
+			}
+		}
+		m.afterClear()
+		if m.paused {
+			m.viewport.SetYOffset(offset)
+			m.cur.line = line
+			m.ensureCursorVisible()
+			m.syncViewport()
+		}
+	})
+	m.cur.reset()
+	m.afterClear()
+}
+
+// afterClear re-syncs the view after the active tab's messages were replaced.
+// Cached styling is keyed by message position, so it is dropped too.
+func (m *Model) afterClear() {
+	m.styled.reset(m.textWidth())
+	m.viewport.SetTotal(m.totalLines())
+	if m.paused {
+		m.viewport.SetYOffset(0)
+	} else {
+		m.viewport.GotoBottom()
+	}
+	m.syncViewport()
+}
+
+// rebuildLatency recomputes the latency histograms from the stored traces
+// and logs.
+func (m *Model) rebuildLatency() {
+	m.latency, m.pipeline = aggregate.Latency{}, aggregate.Pipeline{}
+	for _, msg := range m.live.Messages(telemetry.KindTraces) {
+		m.latency.AddTraces(msg.Traces)
+	}
+	for _, msg := range m.live.Messages(telemetry.KindLogs) {
+		m.pipeline.AddLogs(msg.Logs)
+	}
+}
+
+// allKinds lists every tab's kind, for clearing them all.
+func allKinds() []telemetry.Kind {
+	kinds := make([]telemetry.Kind, len(tabs))
+	for i, t := range tabs {
+		kinds[i] = t.kind
+	}
+	return kinds
+}
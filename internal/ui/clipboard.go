@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jwafle/otail/internal/clip"
+)
+
+// copyLines puts lines on the clipboard and notes how, until the next key.
+func (m *Model) copyLines(lines []string) {
+	how, err := clip.Write([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		m.clipNote = "copy failed: " + err.Error()
+		return
+	}
+	m.clipNote = fmt.Sprintf("copied %s via %s", plural(len(lines), "line"), how)
+}
+
+func clipSegment(m Model) string {
+	return m.clipNote
+}
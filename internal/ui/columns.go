@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// builtinColumns are offered by the column picker ahead of attribute keys.
+var builtinColumns = []string{"time", "severity", "service", "body", "pipeline"}
+
+// columnPicker toggles log table columns on and off.
+type columnPicker struct {
+	options []string
+	sel     int
+}
+
+// openColumnPicker offers the built-in columns, the current ones, and every
+// attribute key seen on logs.
+func (m *Model) openColumnPicker() {
+	opts := append([]string(nil), builtinColumns...)
+	for _, c := range m.currentColumns() {
+		if !slices.Contains(opts, c) {
+			opts = append(opts, c)
+		}
+	}
+	for _, ks := range m.store.AttributesFor(telemetry.KindLogs).Top(0) {
+		if ks.Key != "service.name" && !slices.Contains(opts, ks.Key) {
+			opts = append(opts, ks.Key)
+		}
+	}
+	m.columns = columnPicker{options: opts}
+	m.renderColumnPicker()
+}
+
+// currentColumns returns the table's columns, or those a new table would
+// start with.
+func (m *Model) currentColumns() []string {
+	switch {
+	case m.table != nil:
+		return m.table.columns
+	case len(m.tableColumns) > 0:
+		return m.tableColumns
+	default:
+		return DefaultTableColumns
+	}
+}
+
+func (m *Model) renderColumnPicker() {
+	cur := m.currentColumns()
+	lines := []string{styles.Status.Render("log table columns · enter toggle · esc close")}
+	for i, opt := range m.columns.options {
+		mark := "[ ]"
+		if n := slices.Index(cur, opt); n >= 0 {
+			mark = fmt.Sprintf("[%d]", n+1)
+		}
+		line := fmt.Sprintf("  %s %s", mark, opt)
+		if i == m.columns.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+// columnsKey handles keys while the picker is open and reports whether it
+// consumed msg.
+func (m *Model) columnsKey(msg tea.KeyMsg) bool {
+	p := &m.columns
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		p.sel = max(p.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		p.sel = min(p.sel+1, len(p.options)-1)
+	case msg.String() == "enter" || msg.String() == " ":
+		cols := slices.Clone(m.currentColumns())
+		opt := p.options[p.sel]
+		if n := slices.Index(cols, opt); n >= 0 {
+			if len(cols) == 1 {
+				return true // keep at least one column
+			}
+			cols = slices.Delete(cols, n, n+1)
+		} else {
+			cols = append(cols, opt)
+		}
+		m.tableColumns = cols
+		if m.table != nil {
+			m.table.columns = cols
+			m.table.clearSort()
+		}
+	default:
+		return false
+	}
+	m.renderColumnPicker()
+	line := p.sel + 1 // below the title
+	if p.sel == 0 {
+		line = 0
+	}
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+	m.syncViewport()
+	return true
+}
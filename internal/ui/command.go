@@ -0,0 +1,283 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/query"
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/ui/theme"
+)
+
+// maxHistory is how many : commands up and down step back through.
+const maxHistory = 100
+
+// commandNames lists the : commands, for tab completion.
+var commandNames = []string{"ansi", "clear", "context", "endpoint", "export", "export-trace", "filter", "group", "repro", "sample", "save", "script", "set", "theme", "trace", "unsave"}
+
+// startCommand opens the : prompt for commands that have no key of their
+// own, starting from value.
+func (m *Model) startCommand(value string) tea.Cmd {
+	cmd := m.openPrompt(":", value, runCommand)
+	m.prompt.history, m.prompt.histPos = m.history, len(m.history)
+	m.prompt.complete = completeCommand
+	m.suggest()
+	return cmd
+}
+
+// runCommand remembers line for the history, then runs it.
+func runCommand(m *Model, line string) tea.Cmd {
+	if line = strings.TrimSpace(line); line != "" {
+		m.history = slices.DeleteFunc(m.history, func(h string) bool { return h == line })
+		m.history = append(m.history, line)
+		if len(m.history) > maxHistory {
+			m.history = slices.Clone(m.history[len(m.history)-maxHistory:])
+		}
+	}
+	return submitCommand(m, line)
+}
+
+// submitCommand runs a : command. Unknown commands are reported in the
+// status bar until the next key.
+func submitCommand(m *Model, line string) tea.Cmd {
+	m.commandErr = nil
+	switch args := strings.Fields(line); {
+	case len(args) == 0:
+	case args[0] == "clear" && len(args) == 1:
+		m.clearBuffers(m.Active)
+	case args[0] == "clear" && len(args) == 2 && args[1] == "all":
+		m.clearBuffers(allKinds()...)
+	case args[0] == "sample":
+		p, err := ParseTailPolicy(strings.Join(args[1:], ","))
+		if err != nil {
+			m.commandErr = err
+			break
+		}
+		m.setTailPolicy(p)
+	case args[0] == "context" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			m.commandErr = fmt.Errorf("context %q: want a number of messages", args[1])
+			break
+		}
+		m.setContext(n)
+	case args[0] == "group" && len(args) <= 2:
+		by := ""
+		if len(args) == 2 {
+			by = args[1]
+		}
+		return m.setGroupBy(by)
+	case args[0] == "filter":
+		q, _ := strings.CutPrefix(strings.TrimSpace(line), "filter")
+		f, err := query.Parse(strings.TrimSpace(q))
+		if err != nil {
+			m.commandErr = fmt.Errorf("filter: %w", err)
+			break
+		}
+		m.setFilter(f)
+	case args[0] == "save" && len(args) >= 2:
+		q := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "save"))
+		m.commandErr = m.save(args[1], strings.TrimSpace(strings.TrimPrefix(q, args[1])))
+	case args[0] == "unsave" && len(args) == 2:
+		m.commandErr = m.unsave(args[1])
+	case args[0] == "export" && len(args) == 2:
+		msgs := m.store.Shown(m.Active) // the whole tab, or the selection if any
+		if m.visual.active {
+			msgs = m.takeSelection()
+		}
+		m.export(msgs, args[1])
+	case args[0] == "export-trace" && len(args) == 3:
+		m.exportTrace(args[1], args[2])
+	case args[0] == "ansi" && len(args) == 2:
+		a, err := telemetry.ParseANSI(args[1])
+		if err != nil {
+			m.commandErr = err
+			break
+		}
+		f := telemetry.CurrentFormat()
+		f.ANSI = a
+		m.setFormat(f)
+	case args[0] == "trace" && len(args) >= 2:
+		ref, _ := strings.CutPrefix(strings.TrimSpace(line), "trace")
+		id, err := parseTraceRef(ref)
+		if err != nil {
+			m.commandErr = err
+			break
+		}
+		m.filterToTrace(id)
+	case args[0] == "theme" && len(args) == 2:
+		th, err := theme.Lookup(args[1])
+		if err != nil {
+			m.commandErr = err
+			break
+		}
+		styles, m.theme = th.Styles(), th.Name
+		m.styled.reset(m.textWidth())
+		m.syncViewport()
+	case args[0] == "endpoint" && len(args) == 2:
+		return m.switchEndpoint(args[1])
+	case args[0] == "set" && len(args) >= 2 && len(args) <= 3:
+		return m.set(args[1:])
+	case args[0] == "repro" && len(args) <= 2:
+		format := "curl"
+		if len(args) == 2 {
+			format = args[1]
+		}
+		m.yankRepro(format)
+	case args[0] == "script" && len(args) == 3 && (args[2] == "on" || args[2] == "off"):
+		if m.commandErr = m.scripts.Enable(args[1], args[2] == "on"); m.commandErr == nil {
+			m.setFormat(telemetry.CurrentFormat()) // redo the lines format scripts gave
+		}
+	default:
+		m.commandErr = fmt.Errorf("unknown command %q", strings.TrimSpace(line))
+	}
+	return nil
+}
+
+// switchEndpoint reconnects to the collector named by spec, an --endpoint
+// value, keeping what has been received so far.
+func (m *Model) switchEndpoint(spec string) tea.Cmd {
+	if m.newSource == nil {
+		m.commandErr = fmt.Errorf("endpoint: not available for this source")
+		return nil
+	}
+	src, err := m.newSource(spec)
+	if err != nil {
+		m.commandErr = err
+		return nil
+	}
+	m.dial = dialer(src)
+	return m.reconnect()
+}
+
+// setting is an on/off option for :set.
+type setting struct {
+	name string
+	set  func(m *Model, on bool) tea.Cmd
+}
+
+// settings lists the options :set knows, by name.
+var settings = []setting{
+	{"wrap", func(m *Model, on bool) tea.Cmd {
+		if on == !m.noWrap {
+			return nil
+		}
+		return m.toggleWrap()
+	}},
+	{"zen", func(m *Model, on bool) tea.Cmd {
+		if on != m.zen {
+			m.toggleZen()
+		}
+		return nil
+	}},
+	formatSetting("bodies", func(f *telemetry.Format, on bool) { f.Bodies = on }),
+	formatSetting("compact", func(f *telemetry.Format, on bool) { f.Compact = on }),
+	formatSetting("fullids", func(f *telemetry.Format, on bool) { f.FullIDs = on }),
+	formatSetting("hex", func(f *telemetry.Format, on bool) { f.Hex = on }),
+	formatSetting("rawattrs", func(f *telemetry.Format, on bool) { f.RawAttributes = on }),
+	formatSetting("sortkeys", func(f *telemetry.Format, on bool) { f.ReceivedOrder = !on }),
+	formatSetting("stacks", func(f *telemetry.Format, on bool) { f.FlatStacks = !on }),
+}
+
+// formatSetting makes a setting that changes the render format with apply.
+func formatSetting(name string, apply func(f *telemetry.Format, on bool)) setting {
+	return setting{name, func(m *Model, on bool) tea.Cmd {
+		f := telemetry.CurrentFormat()
+		apply(&f, on)
+		m.setFormat(f)
+		return nil
+	}}
+}
+
+// set runs :set NAME [on|off], where NAME alone means on and noNAME off,
+// as in vi.
+func (m *Model) set(args []string) tea.Cmd {
+	name, on := args[0], true
+	if len(args) == 2 {
+		switch args[1] {
+		case "on", "true":
+		case "off", "false":
+			on = false
+		default:
+			m.commandErr = fmt.Errorf("set %s: want on or off, not %q", name, args[1])
+			return nil
+		}
+	} else if n, ok := strings.CutPrefix(name, "no"); ok && settingIndex(n) >= 0 {
+		name, on = n, false
+	}
+	i := settingIndex(name)
+	if i < 0 {
+		m.commandErr = fmt.Errorf("set: unknown option %q", name)
+		return nil
+	}
+	return settings[i].set(m, on)
+}
+
+func settingIndex(name string) int {
+	return slices.IndexFunc(settings, func(s setting) bool { return s.name == name })
+}
+
+// completeCommand returns the whole command lines that line could be
+// completed to, completing its last word: a command name, then the
+// command's arguments. Words that take another after them end in a space.
+func completeCommand(m *Model, line string) []string {
+	cut := strings.LastIndexAny(line, " &") + 1
+	base := line[:cut]
+	var words []string
+	switch args := strings.Fields(base); {
+	case len(args) == 0:
+		for _, c := range commandNames {
+			words = append(words, c+" ")
+		}
+	case args[0] == "clear" && len(args) == 1:
+		words = []string{"all"}
+	case args[0] == "ansi" && len(args) == 1:
+		words = telemetry.ANSIModes
+	case args[0] == "theme" && len(args) == 1:
+		words = theme.Names()
+	case args[0] == "unsave" && len(args) == 1:
+		for _, pr := range m.presets.list {
+			if m.presets.saved[pr.Name] {
+				words = append(words, pr.Name)
+			}
+		}
+	case args[0] == "filter" || args[0] == "save" && len(args) >= 2:
+		words = []string{"service:", "severity>=", "kind:", "dur>", "last:", "trace:", "attr.", "q:"}
+	case args[0] == "group" && len(args) == 1:
+		for _, k := range m.store.AttributesFor(m.Active).Top(50) {
+			words = append(words, k.Key)
+		}
+	case args[0] == "set" && len(args) == 1:
+		for _, s := range settings {
+			words = append(words, s.name+" ", "no"+s.name)
+		}
+	case args[0] == "set" && len(args) == 2, args[0] == "script" && len(args) == 2:
+		words = []string{"on", "off"}
+	case args[0] == "repro" && len(args) == 1:
+		words = reproFormats
+	case args[0] == "export-trace" && len(args) == 1:
+		for _, f := range traceFormats {
+			words = append(words, f+" ")
+		}
+	case args[0] == "script" && len(args) == 1:
+		for _, sc := range m.scripts.Scripts() {
+			words = append(words, sc.Name+" ")
+		}
+	}
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = base + w
+	}
+	return out
+}
+
+func commandSegment(m Model) string {
+	if m.commandErr == nil {
+		return ""
+	}
+	return ":" + m.commandErr.Error()
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Context mode shows the messages around each filter match, like grep -C,
+// dimmed so the matches stand out.
+
+// place indexes message i of kind k, shown as m, if the filter matches
+// it, or as context if it is within s.context messages of one that does.
+func (s *messageStore) place(k telemetry.Kind, i int, m telemetry.Message) {
+	x := s.indexFor(k)
+	f := s.filters[k]
+	n := s.context
+	if f.IsZero() {
+		n = 0
+	}
+	switch {
+	case f.Match(m):
+		for _, j := range x.before {
+			x.addContext(j, s.rowsOf(*s.Display(k, j)))
+		}
+		x.before = x.before[:0]
+		x.add(i, s.rowsOf(m))
+		x.after = n
+	case x.after > 0:
+		x.addContext(i, s.rowsOf(m))
+		x.after--
+	case n > 0:
+		x.before = append(x.before, i)
+		if len(x.before) > n {
+			x.before = x.before[1:]
+		}
+	}
+}
+
+// IsContext reports whether message i of kind k is shown only as context
+// around a filter match.
+func (s *messageStore) IsContext(k telemetry.Kind, i int) bool {
+	return s.indexFor(k).context[i]
+}
+
+// SetContext shows n messages around each filter match and re-indexes
+// every kind.
+func (s *messageStore) SetContext(n int) {
+	s.context = max(n, 0)
+	s.Reindex()
+}
+
+func (x *lineIndex) addContext(msg, lines int) {
+	if x.context == nil {
+		x.context = make(map[int]bool)
+	}
+	x.context[msg] = true
+	x.add(msg, lines)
+}
+
+// setContext changes how many messages are shown around each match, from
+// :context.
+func (m *Model) setContext(n int) {
+	m.store.SetContext(n)
+	m.viewport.SetTotal(m.totalLines())
+	if !m.paused {
+		m.viewport.GotoBottom()
+	}
+	m.syncViewport()
+}
+
+// contextNote describes context mode for the filter segment.
+func contextNote(m Model) string {
+	if m.store.context == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" ±%d", m.store.context)
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// slowRender is how long an Update or View may take before the debug log
+// notes it; at 60 frames a second, a frame has about 16ms.
+const slowRender = 50 * time.Millisecond
+
+// noteSlow logs what if it has taken longer than slowRender since start.
+// msg is the message an Update handled, nil for a View.
+func (m Model) noteSlow(what string, start time.Time, msg tea.Msg) {
+	d := time.Since(start)
+	if d <= slowRender {
+		return
+	}
+	args := []any{"took", d.Round(time.Millisecond), "tab", m.Active.String(), "rows", m.store.TotalLines(m.Active)}
+	if msg != nil {
+		args = append(args, "msg", fmt.Sprintf("%T", msg))
+	}
+	m.debug.Warn("slow "+what, args...)
+}
+
+// noteUnparsed logs a frame that did not decode as OTLP, and why.
+func (m Model) noteUnparsed(fm telemetry.Message) {
+	if fm.Kind == telemetry.KindUnknown {
+		m.debug.Info("frame not parsed", "bytes", len(fm.Raw), "diagnostics", fm.Diagnostics)
+	}
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// The detail footer names the message under the cursor while paused: its
+// signal, when it arrived, which services sent it, the trace it belongs to,
+// the pipeline latency of its log records, and its size, so none of that
+// has to be dug out of the JSON. A message that arrived out of order or
+// repeated a span says so first.
+
+// showDetail reports whether the detail footer is on screen.
+func (m *Model) showDetail() bool {
+	return m.paused && !m.zen && m.overlay == overlayNone && !m.tableMode() && m.store.TotalLines(m.Active) > 0
+}
+
+func (m Model) renderDetail() string {
+	msg := m.cur.msg
+	if msg == nil {
+		return ""
+	}
+	parts := []string{msg.Kind.String()}
+	if msg.Warning != "" {
+		parts = append(parts, "⚠ "+msg.Warning)
+	}
+	if !msg.Received.IsZero() {
+		parts = append(parts, "received "+msg.Received.Format(clockLayout))
+	}
+	sum := msg.Summary()
+	if len(sum.Services) > 0 {
+		parts = append(parts, strings.Join(sum.Services, ", "))
+	}
+	if sum.Records > 1 {
+		parts = append(parts, plural(sum.Records, "record"))
+	}
+	if !sum.TraceID.IsEmpty() {
+		parts = append(parts, "trace "+sum.TraceID.String())
+		if !sum.SpanID.IsEmpty() {
+			parts = append(parts, "span "+sum.SpanID.String())
+		}
+	}
+	if d, ok := maxPipelineLatency(*msg); ok {
+		parts = append(parts, "pipeline "+shortDuration(d))
+	}
+	parts = append(parts, plural(len(msg.Raw), "byte"))
+	return styles.Status.Render(ansi.Truncate(strings.Join(parts, statusSeparator), m.width, "…"))
+}
+
+// maxPipelineLatency returns the longest pipeline latency among msg's log
+// records, and false if none has one.
+func maxPipelineLatency(msg telemetry.Message) (time.Duration, bool) {
+	if msg.Kind != telemetry.KindLogs {
+		return 0, false
+	}
+	var longest time.Duration
+	found := false
+	rls := msg.Logs.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		sls := rls.At(i).ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			lrs := sls.At(j).LogRecords()
+			for k := 0; k < lrs.Len(); k++ {
+				if d, ok := telemetry.PipelineLatency(lrs.At(k)); ok {
+					longest, found = max(longest, d), true
+				}
+			}
+		}
+	}
+	return longest, found
+}
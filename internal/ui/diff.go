@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// maxDiffCells bounds the line-matching table. Past it, the differing middle
+// of the two messages is shown as changed wholesale.
+const maxDiffCells = 4 << 20
+
+// diffOp is one step of a line diff.
+type diffOp int
+
+const (
+	diffSame diffOp = iota
+	diffRemoved
+	diffAdded
+)
+
+// diffRow is one row of a side-by-side diff. A row with both sides
+// differing is a changed line.
+type diffRow struct {
+	left, right       string
+	hasLeft, hasRight bool
+	same              bool
+}
+
+// diffLines aligns a and b on their longest common subsequence, after
+// trimming the prefix and suffix they share.
+func diffLines(a, b []string) []diffRow {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var rows []diffRow
+	for _, l := range a[:pre] {
+		rows = append(rows, diffRow{left: l, right: l, hasLeft: true, hasRight: true, same: true})
+	}
+	rows = append(rows, pairOps(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		rows = append(rows, diffRow{left: l, right: l, hasLeft: true, hasRight: true, same: true})
+	}
+	return rows
+}
+
+// pairOps diffs the differing middle and pairs each run of removals with
+// the additions that follow it, so replaced lines sit side by side.
+func pairOps(a, b []string) []diffRow {
+	ops := lcsOps(a, b)
+	var rows []diffRow
+	i, j := 0, 0
+	for k := 0; k < len(ops); {
+		if ops[k] == diffSame {
+			rows = append(rows, diffRow{left: a[i], right: b[j], hasLeft: true, hasRight: true, same: true})
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+		var dels, adds []string
+		for ; k < len(ops) && ops[k] != diffSame; k++ {
+			if ops[k] == diffRemoved {
+				dels = append(dels, a[i])
+				i++
+			} else {
+				adds = append(adds, b[j])
+				j++
+			}
+		}
+		for n := 0; n < max(len(dels), len(adds)); n++ {
+			var r diffRow
+			if n < len(dels) {
+				r.left, r.hasLeft = dels[n], true
+			}
+			if n < len(adds) {
+				r.right, r.hasRight = adds[n], true
+			}
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// lcsOps returns the edit script turning a into b.
+func lcsOps(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for range a {
+			ops = append(ops, diffRemoved)
+		}
+		for range b {
+			ops = append(ops, diffAdded)
+		}
+		return ops
+	}
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	w := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffSame)
+			i, j = i+1, j+1
+		case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+			ops = append(ops, diffRemoved)
+			i++
+		default:
+			ops = append(ops, diffAdded)
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffRemoved)
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffAdded)
+	}
+	return ops
+}
+
+// markMessage remembers the message under the cursor as the left side of
+// the next diff.
+func (m *Model) markMessage() {
+	if m.cur.msg == nil {
+		return
+	}
+	msg := *m.cur.msg
+	m.marked = &msg
+}
+
+// openDiff compares the marked message with the one under the cursor.
+func (m *Model) openDiff() {
+	if m.marked == nil || m.cur.msg == nil {
+		return
+	}
+	m.overlay = overlayDiff
+	m.viewport.SetYOffset(0)
+	m.renderDiff(*m.marked, *m.cur.msg)
+	m.syncViewport()
+}
+
+// renderDiff lays the two messages' JSON side by side, with a gutter mark
+// on every differing line: - removed, + added, ~ changed.
+func (m *Model) renderDiff(a, b telemetry.Message) {
+	rows := diffLines(a.Lines(), b.Lines())
+	changes := 0
+	for _, r := range rows {
+		if !r.same {
+			changes++
+		}
+	}
+	title := fmt.Sprintf("diff · marked (left) vs cursor (right) · %d differing lines · esc close", changes)
+	if changes == 0 {
+		title = "diff · the messages are identical · esc close"
+	}
+	lines := []string{styles.Status.Render(title)}
+
+	half := max((m.width-3)/2, 1)
+	for _, r := range rows {
+		var left, right string
+		switch {
+		case r.same:
+			left, right = diffCell(" ", r.left, half), diffCell(" ", r.right, half)
+		case r.hasLeft && r.hasRight:
+			left = styles.DiffChanged.Render(diffCell("~", r.left, half))
+			right = styles.DiffChanged.Render(diffCell("~", r.right, half))
+		case r.hasLeft:
+			left, right = styles.DiffRemoved.Render(diffCell("-", r.left, half)), diffCell(" ", "", half)
+		default:
+			left, right = diffCell(" ", "", half), styles.DiffAdded.Render(diffCell("+", r.right, half))
+		}
+		lines = append(lines, left+" │ "+right)
+	}
+	m.overlayLines = lines
+}
+
+// diffCell renders one side of a row padded or cut to width columns.
+func diffCell(mark, s string, width int) string {
+	return fitCell(mark+" "+s, width, false)
+}
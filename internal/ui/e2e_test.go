@@ -0,0 +1,657 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jwafle/otail/internal/browser"
+	"github.com/jwafle/otail/internal/clip"
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/script"
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+	"github.com/jwafle/otail/internal/transport"
+	"github.com/jwafle/otail/internal/ui/theme"
+)
+
+// These tests run the whole UI against a fake collector: frames travel over
+// a real websocket through the transport, and keys go through Update as
+// Bubble Tea would deliver them.
+
+// startE2E connects a model on the Logs tab to a collector serving frames,
+// on a screen wide enough that compact lines do not wrap.
+func startE2E(t *testing.T, frames ...[]byte) (*testutil.Program, *testutil.Collector) {
+	t.Helper()
+	return startE2EWith(t, nil, frames...)
+}
+
+// startE2EWith is startE2E, letting setup change the model before it
+// starts.
+func startE2EWith(t *testing.T, setup func(*Model), frames ...[]byte) (*testutil.Program, *testutil.Collector) {
+	t.Helper()
+	// The display format is global; start each test from the default.
+	f := telemetry.CurrentFormat()
+	telemetry.SetFormat(telemetry.Format{})
+	t.Cleanup(func() { telemetry.SetFormat(f) })
+	c := testutil.NewCollector(t, frames...)
+	dial := dialer(transport.DialSource(c.URL, c.Origin, nil))
+	stream, cancel, err := dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cancel)
+	m := newModel(stream, cancel, dial, telemetry.KindLogs)
+	if setup != nil {
+		setup(&m)
+	}
+	return testutil.Start(t, m, 300, 30), c
+}
+
+// captureClipboard makes yanks land in the returned slice for the rest of
+// the test.
+func captureClipboard(t *testing.T) *[]string {
+	var got []string
+	clip.Use("test", func(text []byte) error {
+		got = append(got, string(text))
+		return nil
+	})
+	t.Cleanup(clip.Init)
+	return &got
+}
+
+// captureBrowser makes the URLs the TUI opens land in the returned slice
+// instead of a browser.
+func captureBrowser(t *testing.T) *[]string {
+	var got []string
+	browser.Use(func(url string) error {
+		got = append(got, url)
+		return nil
+	})
+	t.Cleanup(func() { browser.Use(nil) })
+	return &got
+}
+
+var (
+	orderPlaced  = testutil.Log("checkout", "info", "order placed")
+	cardDeclined = testutil.Log("payments", "error", "card declined")
+)
+
+func TestE2ETabs(t *testing.T) {
+	p, _ := startE2E(t, orderPlaced, cardDeclined,
+		testutil.Span("checkout", "GET /cart", 120*time.Millisecond),
+		testutil.Gauge("checkout", "queue.depth", 7))
+
+	p.WaitForText("card declined", "logs (2)", "buf 0/256")
+	p.Press("t")
+	p.WaitForText("GET /cart", "traces (1)")
+	p.Press("m")
+	p.WaitForText("queue.depth", "metrics (1)")
+}
+
+func TestE2EPause(t *testing.T) {
+	p, c := startE2E(t, orderPlaced)
+	p.WaitForText("order placed")
+
+	// A paused tab takes in nothing, so the message sent while paused is
+	// never shown, even after resuming. Frames arrive in order, so once the
+	// span has been counted on its tab the log has arrived too.
+	p.Press("p")
+	p.WaitForText("[PAUSED]")
+	c.Send(cardDeclined, testutil.Span("checkout", "GET /cart", time.Millisecond))
+	p.WaitForText("Traces 1")
+	p.Press("p")
+	p.WaitForText("Streaming")
+	c.Send(testutil.Log("checkout", "info", "cart viewed"))
+	frame := p.WaitForText("cart viewed", "logs (2)")
+	if strings.Contains(frame, "card declined") {
+		t.Fatalf("a message sent while paused was shown:\n%s", frame)
+	}
+}
+
+func TestE2ECursorYank(t *testing.T) {
+	copied := captureClipboard(t)
+	p, _ := startE2E(t, orderPlaced, cardDeclined)
+	p.Press("c")
+	p.WaitForText("card declined", "compact")
+
+	// Pausing puts the cursor on the newest message; up moves to the one
+	// before it.
+	p.Press("p", "up", "y")
+	p.WaitForText("copied 1 line via test")
+	if len(*copied) != 1 || !strings.Contains((*copied)[0], "order placed") {
+		t.Fatalf("copied %q, want the order placed record", *copied)
+	}
+}
+
+func TestE2EFilter(t *testing.T) {
+	p, c := startE2E(t, orderPlaced, cardDeclined)
+	p.Press("c")
+	p.WaitForText("order placed", "card declined")
+
+	p.Press(":")
+	p.Type("filter service=payments")
+	p.Press("enter")
+	frame := p.WaitForText("logs (1/2 matching)", "filter service:payments")
+	if strings.Contains(frame, "order placed") || !strings.Contains(frame, "card declined") {
+		t.Fatalf("filter did not narrow the tab:\n%s", frame)
+	}
+
+	c.Send(testutil.Log("payments", "warn", "retrying charge"), testutil.Log("checkout", "info", "cart viewed"))
+	frame = p.WaitForText("retrying charge", "logs (2/4 matching)")
+	if strings.Contains(frame, "cart viewed") {
+		t.Fatalf("a message the filter excludes was shown:\n%s", frame)
+	}
+
+	p.Press("x")
+	p.WaitForText("order placed", "cart viewed", "logs (4)")
+	if m := p.Quit().(Model); m.Active != telemetry.KindLogs {
+		t.Fatalf("ended on %v, want logs", m.Active)
+	}
+}
+
+func TestE2EUndo(t *testing.T) {
+	p, _ := startE2E(t, orderPlaced, cardDeclined)
+	p.Press("c")
+	p.WaitForText("order placed", "card declined")
+
+	p.Press(":")
+	p.Type("filter service=payments")
+	p.Press("enter")
+	p.WaitForText("logs (1/2 matching)")
+	p.Press("x")
+	p.WaitForText("order placed", "logs (2)")
+
+	p.Press("u")
+	frame := p.WaitForText("undid filter change", "logs (1/2 matching)", "filter service:payments")
+	if strings.Contains(frame, "order placed") {
+		t.Fatalf("undo did not restore the filter:\n%s", frame)
+	}
+	p.Press("u")
+	p.WaitForText("order placed", "logs (2)")
+}
+
+func TestE2ESearch(t *testing.T) {
+	copied := captureClipboard(t)
+	p, _ := startE2E(t, orderPlaced, cardDeclined, testutil.Log("checkout", "info", "order shipped"))
+	p.Press("c")
+	p.WaitForText("order shipped")
+
+	// A search pauses on the newest match; n wraps around to the oldest.
+	p.Press("/")
+	p.Type("order")
+	p.Press("enter")
+	p.WaitForText("/order 2/2")
+	p.Press("n")
+	p.WaitForText("/order 1/2")
+	p.Press("y")
+	p.WaitForText("copied 1 line via test")
+	if len(*copied) != 1 || !strings.Contains((*copied)[0], "order placed") {
+		t.Fatalf("copied %q, want the order placed record", *copied)
+	}
+
+	// The prompt starts from the last query.
+	p.Press("/", "ctrl+u")
+	p.Type("refund")
+	p.Press("enter")
+	p.WaitForText(`no match for "refund"`)
+
+	// Conditions narrow the words, or stand alone.
+	p.Press("/", "ctrl+u")
+	p.Type("order service:checkout severity>=info")
+	p.Press("enter")
+	p.WaitForText("/order service:checkout severity>=info 1/2")
+	p.Press("/", "ctrl+u")
+	p.Type("service=payments")
+	p.Press("enter")
+	p.WaitForText("/service=payments 1/1")
+	p.Press("/", "ctrl+u")
+	p.Type("dur=1s")
+	p.Press("enter")
+	p.WaitForText("search: col 1: dur=: want dur> or dur>= or dur< or dur<=")
+}
+
+func TestE2EDetailFooter(t *testing.T) {
+	p, _ := startE2E(t, testutil.Span("payments", "POST /charge", 80*time.Millisecond))
+	p.Press("t")
+	p.WaitForText("POST /charge")
+
+	p.Press("p")
+	p.WaitForText("traces │ received", "│ payments │", "trace "+testutil.TraceID.String(), "bytes")
+	p.Press("p")
+	p.WaitFor(func(frame string) bool { return !strings.Contains(frame, "│ received") })
+}
+
+func TestE2EZen(t *testing.T) {
+	p, _ := startE2E(t, orderPlaced, cardDeclined)
+	p.Press("c")
+	p.WaitForText("card declined", "logs (2)", "? all keys")
+
+	chrome := func(frame string) bool {
+		return strings.Contains(frame, "logs (2)") || strings.Contains(frame, "? all keys")
+	}
+	p.Press("Z")
+	frame := p.WaitFor(func(frame string) bool { return !chrome(frame) })
+	if !strings.Contains(frame, "card declined") {
+		t.Fatalf("zen mode hid the messages:\n%s", frame)
+	}
+	p.Press("Z")
+	p.WaitForText("card declined", "logs (2)", "? all keys")
+
+	p.Press(":")
+	p.Type("set zen")
+	p.Press("enter")
+	p.WaitFor(func(frame string) bool { return !chrome(frame) && strings.Contains(frame, "card declined") })
+}
+
+func TestE2EExpandValue(t *testing.T) {
+	p, c := startE2E(t)
+	telemetry.SetFormat(telemetry.Format{MaxValue: 40})
+	c.Send(testutil.Log("checkout", "warn", "inventory lookup took longer than the configured budget; serving cached stock levels instead"))
+	p.WaitForText(`"inventory lookup took longer than the co…"`)
+
+	// Pausing puts the cursor on the last line of the message; the body
+	// is 14 lines above it.
+	p.Press("p")
+	p.WaitForText("[PAUSED]")
+	p.Press(slices.Repeat([]string{"up"}, 14)...)
+	p.Press(" ")
+	p.WaitForText(`"inventory lookup took longer than the configured budget; serving cached stock levels instead"`)
+	p.Press(" ")
+	p.WaitForText(`"inventory lookup took longer than the co…"`)
+}
+
+func TestE2EStackTrace(t *testing.T) {
+	p, c := startE2E(t)
+	c.Send(testutil.Log("checkout", "error", strings.Join([]string{
+		"java.lang.IllegalStateException: cart is empty",
+		"\tat com.example.checkout.Cart.total(Cart.java:42)",
+		"\tat com.example.checkout.CheckoutHandler.handle(CheckoutHandler.java:17)",
+		"\tat java.base/java.lang.Thread.run(Thread.java:833)",
+	}, "\n")))
+	p.WaitForText("    at com.example.checkout.CheckoutHandler.handle(CheckoutHandler.java:17)")
+
+	// The cursor starts on the last line of the message; the last frame is
+	// 14 lines above it.
+	p.Press("p")
+	p.WaitForText("[PAUSED]")
+	p.Press(slices.Repeat([]string{"up"}, 14)...)
+	p.Press(" ")
+	frame := p.WaitForText("at com.example.checkout.Cart.total(Cart.java:42)", "… 2 more frames")
+	if strings.Contains(frame, "CheckoutHandler") {
+		t.Fatalf("collapsed trace still shows later frames:\n%s", frame)
+	}
+	p.Press(" ")
+	p.WaitForText("at java.base/java.lang.Thread.run(Thread.java:833)")
+}
+
+func TestE2EANSI(t *testing.T) {
+	p, c := startE2E(t)
+	c.Send(testutil.Log("checkout", "warn", "\x1b[33mWARN\x1b[0m stock low for \x1b[1msku-42\x1b[0m"))
+	p.WaitForText(`"\u001b[33mWARN\u001b[0m stock low for \u001b[1msku-42\u001b[0m"`)
+
+	p.Press(":")
+	p.Type("ansi strip")
+	p.Press("enter")
+	p.WaitForText(`"WARN stock low for sku-42"`)
+
+	p.Press(":", "ctrl+u")
+	p.Type("ansi blink")
+	p.Press("enter")
+	p.WaitForText(`unknown ANSI mode "blink"`, `"WARN stock low for sku-42"`)
+
+	p.Press(":", "ctrl+u")
+	p.Type("ansi escape")
+	p.Press("enter")
+	p.WaitForText(`"\u001b[33mWARN\u001b[0m stock low for \u001b[1msku-42\u001b[0m"`)
+}
+
+func TestE2EBodies(t *testing.T) {
+	p, _ := startE2E(t, orderPlaced, cardDeclined)
+	p.WaitForText(`"stringValue": "card declined"`)
+
+	p.Press("i")
+	frame := p.WaitForText("15:04:05.000 INFO  order placed", "15:04:05.000 ERROR card declined", "bodies only")
+	if strings.Contains(frame, "severityText") {
+		t.Fatalf("body-only view still shows the records:\n%s", frame)
+	}
+	p.Press("i")
+	p.WaitFor(func(frame string) bool {
+		return strings.Contains(frame, `"stringValue": "card declined"`) && !strings.Contains(frame, "bodies only")
+	})
+}
+
+func TestE2EShortIDs(t *testing.T) {
+	copied := captureClipboard(t)
+	full := testutil.TraceID.String()
+	p, _ := startE2E(t, testutil.Span("checkout", "GET /cart", 120*time.Millisecond))
+	p.Press("t")
+	frame := p.WaitForText("GET /cart", `"traceId": "`+full[:8]+`…"`)
+	if strings.Contains(frame, full) {
+		t.Fatalf("trace ID shown in full:\n%s", frame)
+	}
+
+	// Yanking copies IDs in full.
+	p.Press("p", "y")
+	p.WaitForText("via test")
+	if len(*copied) != 1 || !strings.Contains((*copied)[0], `"traceId": "`+full+`"`) {
+		t.Fatalf("copied %q, want the full trace ID %s", *copied, full)
+	}
+
+	p.Press("p", ":")
+	p.Type("set fullids")
+	p.Press("enter")
+	p.WaitForText(`"traceId": "` + full + `"`)
+}
+
+func TestE2ETraceCommand(t *testing.T) {
+	id := testutil.TraceID.String()
+	p, _ := startE2E(t, orderPlaced, testutil.Span("checkout", "GET /cart", 120*time.Millisecond))
+	p.WaitForText("order placed", "logs (1)")
+
+	p.Press(":")
+	p.Type("trace traceparent: 00-" + id + "-1e50dee97f269b58-01")
+	p.Press("enter")
+	p.WaitForText("GET /cart", "traces (1/1 matching)", "filter trace:"+id)
+	p.Press("l")
+	p.WaitForText("logs (0/1 matching)", "filter trace:"+id)
+
+	p.Press(":", "ctrl+u")
+	p.Type("trace 00-nope-01")
+	p.Press("enter")
+	p.WaitForText("want a traceparent header or a 32-digit hex trace ID")
+}
+
+func TestE2EDebugPanel(t *testing.T) {
+	p, _ := startE2E(t, orderPlaced, cardDeclined)
+	p.WaitForText("card declined", "logs (2)")
+
+	p.Press("alt+ctrl+d")
+	p.WaitForText("debug · otail's own performance", "frames  2 received", "parse ", "gc ")
+	p.Press("esc")
+	p.WaitFor(func(frame string) bool {
+		return strings.Contains(frame, "card declined") && !strings.Contains(frame, "otail's own performance")
+	})
+}
+
+func TestE2ESavedSearches(t *testing.T) {
+	p, _ := startE2E(t, orderPlaced, cardDeclined, testutil.Span("payments", "POST /charge", 80*time.Millisecond))
+	p.Press("c")
+	p.WaitForText("order placed")
+
+	p.Press(":")
+	p.Type("save pay service:payments")
+	p.Press("enter")
+	p.Press(":")
+	p.Type("save slow kind:traces dur>50ms")
+	p.Press("enter")
+	p.Press(":")
+	p.Type("save a=b service:payments")
+	p.Press("enter")
+	p.WaitForText("save a=b: a name cannot contain '=' or spaces")
+	p.Press("Q")
+	p.WaitForText("saved searches", "1 pay", "2 slow")
+	counted := regexp.MustCompile(`(?s)1 pay +2\s.*2 slow +1\s`)
+	frame := p.WaitFor(counted.MatchString)
+	if !strings.Contains(frame, "order placed") {
+		t.Fatalf("the sidebar hid the messages:\n%s", frame)
+	}
+
+	// A search of one signal jumps to its tab.
+	p.Press("2")
+	p.WaitForText("traces (1/1 matching)", "preset slow")
+	p.Press(":")
+	p.Type("unsave slow")
+	p.Press("enter")
+	p.WaitFor(func(frame string) bool { return !strings.Contains(frame, "2 slow") })
+
+	if got := p.Quit().(Model).state().Searches; !slices.Equal(got, []string{"pay=service:payments"}) {
+		t.Errorf("saved searches in the state = %q", got)
+	}
+}
+
+func TestE2ECustomTabs(t *testing.T) {
+	setCustomTabs([]filter.Preset{
+		{Name: "Errors", Filter: filter.Filter{Severity: telemetry.SeverityError}},
+		{Name: "Payments", Filter: filter.Filter{Service: "payments"}},
+	})
+	t.Cleanup(func() { setCustomTabs(nil) })
+	p, _ := startE2E(t, orderPlaced, cardDeclined, testutil.Span("payments", "POST /charge", 80*time.Millisecond))
+	p.Press("c")
+	p.WaitForText("order placed", "Errors", "Payments")
+
+	// [ wraps around to the last tab, which holds both signals.
+	p.Press("[")
+	frame := p.WaitForText("Payments (2)", "card declined", "POST /charge")
+	if strings.Contains(frame, "order placed") {
+		t.Fatalf("the Payments tab shows a checkout log:\n%s", frame)
+	}
+	p.Press("[")
+	p.WaitForText("Errors (1)", "card declined")
+	p.Press("]")
+	p.Press("]")
+	p.WaitForText("logs (2)")
+	p.Press("[")
+	p.WaitForText("Payments (2)")
+	p.Press("[")
+	p.WaitForText("Errors (1)")
+
+	if got := p.Quit().(Model).state().Tab; got != "Errors" {
+		t.Errorf("tab in the state = %q, want Errors", got)
+	}
+}
+
+func TestE2EConfigReload(t *testing.T) {
+	t.Cleanup(func() { styles = theme.Default.Styles() })
+	path := filepath.Join(t.TempDir(), "config.json")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The file holds just a theme name; every other setting is fixed.
+	reload := func() (Settings, []string, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return Settings{}, nil, err
+		}
+		th, err := theme.Lookup(string(b))
+		return Settings{Theme: th, Filter: filter.Filter{Service: "payments"}}, []string{"custom-tab"}, err
+	}
+	write("default")
+	p, _ := startE2EWith(t, func(m *Model) {
+		m.config = watchConfig(path, reload, Settings{Theme: theme.Default})
+	}, orderPlaced, cardDeclined)
+	p.Press("c")
+	p.WaitForText("order placed")
+
+	write("solarized")
+	frame := p.WaitForText("config reloaded: theme, filter; restart for custom-tab", "filter service:payments", "card declined")
+	if strings.Contains(frame, "order placed") {
+		t.Fatalf("the reloaded filter did not apply:\n%s", frame)
+	}
+	p.Press("x")
+	p.WaitFor(func(frame string) bool { return !strings.Contains(frame, "config reloaded") })
+
+	// A bad file changes nothing; the tab the user cleared stays clear.
+	write("loud")
+	p.WaitForText(`config: unknown theme "loud"`, "order placed")
+	if got := p.Quit().(Model).theme; got != "solarized" {
+		t.Errorf("theme = %q after a bad reload, want solarized", got)
+	}
+}
+
+func TestE2EScripts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "short.star")
+	if err := os.WriteFile(path, []byte(`
+def format(r):
+    return "%s says %s" % (r["service"], r["text"])
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	set, err := script.Load([]string{"short=" + path}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := startE2EWith(t, func(m *Model) {
+		telemetry.SetFormat(telemetry.Format{Line: set.Line})
+		m.scripts = set
+	}, orderPlaced)
+	p.Press("c")
+	p.WaitForText("checkout says order placed")
+
+	p.Press(":")
+	p.Type("script short off")
+	p.Press("enter")
+	p.WaitFor(func(frame string) bool {
+		return strings.Contains(frame, "order placed") && !strings.Contains(frame, "checkout says")
+	})
+}
+
+func TestE2ERepro(t *testing.T) {
+	copied := captureClipboard(t)
+	p, _ := startE2EWith(t, func(m *Model) { m.reproURL = "http://collector:4318/" }, orderPlaced, testutil.Span("checkout", "GET /cart", 120*time.Millisecond))
+	p.Press("c")
+	p.WaitForText("order placed")
+
+	p.Press("p", "Y")
+	p.WaitForText("copied 3 lines via test")
+	if got := (*copied)[0]; !strings.HasPrefix(got, "curl -sS -X POST 'http://collector:4318/v1/logs'") ||
+		!strings.Contains(got, `"order placed"`) || !strings.HasSuffix(got, "\nEOF") {
+		t.Fatalf("copied %q, want a curl posting the log", got)
+	}
+
+	// otel-cli only sends spans.
+	p.Press(":")
+	p.Type("repro otel-cli")
+	p.Press("enter")
+	p.WaitForText("repro: otel-cli only sends spans")
+
+	p.Press("t")
+	p.WaitForText("GET /cart")
+	p.Press("p", ":")
+	p.Type("repro otel-cli")
+	p.Press("enter")
+	p.WaitForText("copied 1 line via test")
+	if got := (*copied)[1]; !strings.HasPrefix(got, "otel-cli span --endpoint 'http://collector:4318/' --service 'checkout' --name 'GET /cart'") {
+		t.Fatalf("copied %q, want an otel-cli span command", got)
+	}
+}
+
+func TestE2EPipelineLatency(t *testing.T) {
+	p, _ := startE2E(t, testutil.ObservedLog("checkout", "order placed", 1500*time.Millisecond),
+		testutil.ObservedLog("checkout", "order shipped", 3*time.Second), cardDeclined)
+	p.Press("c", "h")
+	p.WaitForText("pipeline latency · all logs · n=2", "max=3s")
+
+	// The detail footer gives each message's own; these wrap onto two
+	// lines each.
+	p.Press("p", "up")
+	p.WaitForText("pipeline 3s")
+	p.Press("up", "up")
+	p.WaitForText("pipeline 1.5s")
+}
+
+func TestE2EOutOfOrder(t *testing.T) {
+	p, c := startE2E(t, orderPlaced, testutil.LogAt("checkout", "info", "cart viewed", testutil.Epoch.Add(-time.Minute)))
+	p.Press("c")
+	p.WaitForText("cart viewed", "⚠ 1 out of order")
+
+	// A retried span is a duplicate; the same name is the same span ID.
+	span := testutil.Span("checkout", "GET /cart", 120*time.Millisecond)
+	c.Send(span, span)
+	p.WaitForText("⚠ 1 out of order, 1 duplicate span")
+
+	p.Press("p")
+	p.WaitForText("logs │ ⚠ out of order: 1m0s behind checkout logs")
+
+	// Clearing a tab forgets what its messages were flagged for.
+	p.Press("ctrl+l")
+	p.WaitFor(func(frame string) bool {
+		return strings.Contains(frame, "⚠ 1 duplicate span") && !strings.Contains(frame, "out of order")
+	})
+	p.Press("t", "ctrl+l")
+	p.WaitFor(func(frame string) bool { return !strings.Contains(frame, "⚠") })
+}
+
+func TestE2ELint(t *testing.T) {
+	p, _ := startE2E(t, orderPlaced, testutil.Log("", "info", "who sent this"))
+	p.Press("c")
+	p.WaitForText("who sent this")
+
+	p.Press("R")
+	p.WaitForText("lint · 1 violation in 2 messages", "missing-service-name", "services: (no service.name)")
+	p.Press("R")
+	p.WaitForText("who sent this")
+}
+
+func TestE2EExportTrace(t *testing.T) {
+	p, _ := startE2E(t, testutil.Span("checkout", "GET /cart", 120*time.Millisecond), testutil.Span("payments", "charge", 40*time.Millisecond))
+	p.Press("c", "t")
+	p.WaitForText("GET /cart", "charge")
+	p.Press("p", "T")
+	p.WaitForText("trace " + testutil.TraceID.String() + " · 2 spans")
+
+	// W offers the command writing OTLP JSON; the path can be changed.
+	p.Press("W")
+	p.WaitForText(":export-trace otlp trace-" + testutil.TraceID.String() + ".json")
+	dir := t.TempDir()
+	p.Press("ctrl+u")
+	p.Type("export-trace otlp " + filepath.Join(dir, "trace.json"))
+	p.Press("enter")
+	p.WaitForText("wrote 2 spans of trace")
+	b, err := os.ReadFile(filepath.Join(dir, "trace.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := telemetry.Parse(b); msg.Kind != telemetry.KindTraces || msg.Traces.SpanCount() != 2 {
+		t.Fatalf("wrote %s, want both spans as OTLP JSON", b)
+	}
+
+	p.Press(":")
+	p.Type("export-trace jaeger " + filepath.Join(dir, "jaeger.json"))
+	p.Press("enter")
+	p.WaitForText("wrote 2 spans of trace")
+	b, err = os.ReadFile(filepath.Join(dir, "jaeger.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); !strings.HasPrefix(s, `{"data":[{"traceID":`) || !strings.Contains(s, `"operationName":"charge"`) || !strings.Contains(s, `"serviceName":"payments"`) {
+		t.Fatalf("wrote %s, want Jaeger JSON", b)
+	}
+}
+
+func TestE2EOpenTrace(t *testing.T) {
+	opened := captureBrowser(t)
+	p, _ := startE2EWith(t, func(m *Model) { m.traceURL = "https://jaeger.local/trace/{traceID}?uiFind={spanID}&service={service}" },
+		testutil.Span("checkout", "GET /cart", 120*time.Millisecond), orderPlaced)
+	p.Press("c")
+	p.WaitForText("order placed")
+
+	// testutil logs are not logged in a trace.
+	p.Press("p", "U")
+	p.WaitForText("open: the message has no trace ID")
+
+	p.Press("t")
+	p.WaitForText("GET /cart")
+	p.Press("p", "U")
+	want := "https://jaeger.local/trace/" + testutil.TraceID.String() + "?uiFind="
+	p.WaitForText("opened " + want)
+	if len(*opened) != 1 || !strings.HasPrefix((*opened)[0], want) || !strings.HasSuffix((*opened)[0], "&service=checkout") {
+		t.Fatalf("opened %q, want the trace's page", *opened)
+	}
+
+	// The waterfall opens the trace it shows.
+	p.Press("T")
+	p.WaitForText("trace " + testutil.TraceID.String())
+	p.Press("U")
+	p.WaitForText("opened " + want)
+	if len(*opened) != 2 || (*opened)[1] != (*opened)[0] {
+		t.Fatalf("opened %q, want the trace's page again", *opened)
+	}
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// editorDoneMsg reports that the editor opened by openInEditor has exited.
+type editorDoneMsg struct{ err error }
+
+// openInEditor writes the message under the cursor, or the selection, to a
+// temp file and opens it in $VISUAL or $EDITOR, suspending the TUI until the
+// editor exits. The file is removed afterwards.
+func (m *Model) openInEditor() tea.Cmd {
+	msgs := m.takeSelection()
+	if len(msgs) == 0 {
+		return nil
+	}
+	argv := editorCommand()
+	ext := ".json"
+	if msgs[0].Kind == telemetry.KindUnknown {
+		ext = ".txt"
+	}
+	f, err := os.CreateTemp("", "otail-*"+ext)
+	if err != nil {
+		return editorFailed(err)
+	}
+	_, err = f.WriteString(strings.Join(selectedLines(msgs), "\n") + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return editorFailed(err)
+	}
+	cmd := exec.Command(argv[0], append(argv[1:], f.Name())...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		os.Remove(f.Name())
+		if err != nil {
+			err = fmt.Errorf("%s: %w", argv[0], err)
+		}
+		return editorDoneMsg{err}
+	})
+}
+
+// editorCommand splits $VISUAL or $EDITOR into a command and its arguments,
+// falling back to vi.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if argv := strings.Fields(os.Getenv(env)); len(argv) > 0 {
+			return argv
+		}
+	}
+	return []string{"vi"}
+}
+
+func editorFailed(err error) tea.Cmd {
+	return func() tea.Msg { return editorDoneMsg{err} }
+}
+
+// editorSegment shows why the last editor failed to open, until the next
+// one opens.
+func editorSegment(m Model) string {
+	if m.editorErr == nil {
+		return ""
+	}
+	return "editor: " + m.editorErr.Error()
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// errorChain decides which spans the error-span view keeps: every span with
+// error status and every ancestor of one, wherever in the buffer it is.
+// Parents usually end, and so arrive, after their children, which scan
+// handles as they come; a parent that arrived first makes scan report the
+// buffer stale.
+type errorChain struct {
+	keep map[spanRef]bool
+	seen map[spanRef]int // message holding each span seen
+}
+
+func newErrorChain() *errorChain {
+	return &errorChain{keep: map[spanRef]bool{}, seen: map[spanRef]int{}}
+}
+
+// rebuild rescans msgs until every ancestor of an error span is kept.
+func (c *errorChain) rebuild(msgs []telemetry.Message) {
+	*c = *newErrorChain()
+	for changed := true; changed; {
+		changed = false
+		for i, msg := range msgs {
+			if _, grew := c.scan(msg, i); grew {
+				changed = true
+			}
+		}
+	}
+}
+
+// scan records the spans of msg, message i, keeping error spans and the
+// parents of kept spans. stale reports that a newly kept parent is in an
+// earlier message, whose view is now out of date; grew that anything new is
+// kept.
+func (c *errorChain) scan(msg telemetry.Message, i int) (stale, grew bool) {
+	for more := true; more; {
+		more = false
+		eachSpan(msg.Traces, func(s ptrace.Span) {
+			ref := spanRef{s.TraceID(), s.SpanID()}
+			c.seen[ref] = i
+			if s.Status().Code() == ptrace.StatusCodeError && !c.keep[ref] {
+				c.keep[ref], more = true, true
+			}
+			if !c.keep[ref] || s.ParentSpanID().IsEmpty() {
+				return
+			}
+			parent := spanRef{s.TraceID(), s.ParentSpanID()}
+			if c.keep[parent] {
+				return
+			}
+			c.keep[parent], more = true, true
+			if j, ok := c.seen[parent]; ok && j < i {
+				stale = true
+			}
+		})
+		grew = grew || more
+	}
+	return stale, grew
+}
+
+// keeps reports whether the error-span view shows s.
+func (c *errorChain) keeps(s ptrace.Span) bool {
+	return c.keep[spanRef{s.TraceID(), s.SpanID()}]
+}
+
+// toggleErrorSpans switches the Traces tab between every span and only
+// error spans with their parent chains.
+func (m *Model) toggleErrorSpans() {
+	m.store.SetErrorSpans(!m.store.ErrorSpans())
+	m.viewport.SetTotal(m.totalLines())
+	if !m.paused {
+		m.viewport.GotoBottom()
+	}
+	m.syncViewport()
+}
+
+func errorSpansSegment(m Model) string {
+	if m.Active != telemetry.KindTraces || !m.store.ErrorSpans() {
+		return ""
+	}
+	return "error spans"
+}
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// exemplar is one exemplar of the message under the cursor, with the
+// metric it was recorded for.
+type exemplar struct {
+	metric  string
+	time    time.Time // zero if not recorded
+	value   string
+	traceID string // hex; "" if the exemplar has none
+	spanID  string
+}
+
+// exemplarPicker lists the exemplars of a metrics message; picking one
+// shows its trace on the Traces tab.
+type exemplarPicker struct {
+	list []exemplar
+	sel  int
+}
+
+// openExemplars collects the exemplars of the message under the cursor.
+func (m *Model) openExemplars() {
+	p := &m.exemplars
+	p.list, p.sel = nil, 0
+	if m.cur.msg != nil && m.cur.msg.Kind == telemetry.KindMetrics {
+		p.list = collectExemplars(m.cur.msg.Metrics)
+	}
+	m.renderExemplars()
+}
+
+func collectExemplars(md pmetric.Metrics) []exemplar {
+	var out []exemplar
+	add := func(name string, exs pmetric.ExemplarSlice) {
+		for i := 0; i < exs.Len(); i++ {
+			ex := exs.At(i)
+			e := exemplar{metric: name}
+			if ts := ex.Timestamp(); ts != 0 {
+				e.time = ts.AsTime()
+			}
+			switch ex.ValueType() {
+			case pmetric.ExemplarValueTypeInt:
+				e.value = strconv.FormatInt(ex.IntValue(), 10)
+			case pmetric.ExemplarValueTypeDouble:
+				e.value = strconv.FormatFloat(ex.DoubleValue(), 'g', -1, 64)
+			}
+			if id := ex.TraceID(); !id.IsEmpty() {
+				e.traceID = id.String()
+			}
+			if id := ex.SpanID(); !id.IsEmpty() {
+				e.spanID = id.String()
+			}
+			out = append(out, e)
+		}
+	}
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					for d := 0; d < m.Gauge().DataPoints().Len(); d++ {
+						add(m.Name(), m.Gauge().DataPoints().At(d).Exemplars())
+					}
+				case pmetric.MetricTypeSum:
+					for d := 0; d < m.Sum().DataPoints().Len(); d++ {
+						add(m.Name(), m.Sum().DataPoints().At(d).Exemplars())
+					}
+				case pmetric.MetricTypeHistogram:
+					for d := 0; d < m.Histogram().DataPoints().Len(); d++ {
+						add(m.Name(), m.Histogram().DataPoints().At(d).Exemplars())
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					for d := 0; d < m.ExponentialHistogram().DataPoints().Len(); d++ {
+						add(m.Name(), m.ExponentialHistogram().DataPoints().At(d).Exemplars())
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (m *Model) renderExemplars() {
+	p := &m.exemplars
+	lines := []string{styles.Status.Render("exemplars · enter or click shows the trace · esc close")}
+	if len(p.list) == 0 {
+		lines = append(lines, "", "no exemplars in this message")
+	}
+	for i, e := range p.list {
+		trace := e.traceID
+		if trace == "" {
+			trace = "(no trace)"
+		}
+		clock := ""
+		if !e.time.IsZero() {
+			clock = e.time.Format(clockLayout)
+		}
+		line := fmt.Sprintf("  %-12s  %-32s %12s  trace %s", clock, truncateValue(e.metric, 32), e.value, trace)
+		if e.spanID != "" {
+			line += "  span " + e.spanID
+		}
+		if i == p.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+// showExemplarTrace switches to the Traces tab filtered to exemplar i's
+// trace. u undoes the filter as usual.
+func (m *Model) showExemplarTrace(i int) {
+	if i < 0 || i >= len(m.exemplars.list) || m.exemplars.list[i].traceID == "" {
+		return
+	}
+	m.closeOverlay()
+	m.switchTab(telemetry.KindTraces)
+	m.setFilter(filter.Filter{TraceID: m.exemplars.list[i].traceID})
+}
+
+// exemplarsKey handles keys while the picker is open and reports whether it
+// consumed msg.
+func (m *Model) exemplarsKey(msg tea.KeyMsg) bool {
+	p := &m.exemplars
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case msg.String() == "enter":
+		m.showExemplarTrace(p.sel)
+		return true
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		p.sel = max(p.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		p.sel = max(min(p.sel+1, len(p.list)-1), 0)
+	default:
+		return false
+	}
+	m.renderExemplars()
+	m.scrollToSelection(p.sel + 1)
+	return true
+}
@@ -0,0 +1,33 @@
+package ui
+
+// String values longer than Format.MaxValue are cut short with an
+// ellipsis, so a SQL statement takes one line until it is wanted, and
+// stack traces are shown a frame per line. space on such a line, while
+// paused, shows the value in full in place or collapses the trace to its
+// first frame, and space again undoes it; elsewhere space still pages
+// down.
+
+// toggleValue opens or cuts short the long value, or collapses or expands
+// the stack trace, on the cursor line, and reports whether there was one.
+func (m *Model) toggleValue() bool {
+	refs := m.store.LineRefs(m.Active, m.cur.line, m.cur.line+1)
+	if len(refs) != 1 {
+		return false
+	}
+	ref := refs[0]
+	msg := m.store.Display(m.Active, ref.msg)
+	line, ok := msg.ToggleValue(ref.line)
+	if !ok {
+		return false
+	}
+	m.store.Recount(m.Active, ref.msg)
+	// Keep the cursor on the first row of the value, which may now wrap
+	// onto fewer rows, or lose the lines, the cursor was down.
+	if start, ok := m.store.MessageStart(m.Active, ref.msg); ok {
+		m.cur.line = start + m.store.rowOf(*msg, line)
+	}
+	m.viewport.SetTotal(m.store.TotalLines(m.Active))
+	m.ensureCursorVisible()
+	m.syncViewport()
+	return true
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// explorerTopN is how many values are listed under each attribute key.
+const explorerTopN = 5
+
+// explorerRow is one line of the attribute explorer below its title. Key
+// headers cannot be selected.
+type explorerRow struct {
+	header     bool
+	key, value string
+	count      int
+	distinct   int  // headers only
+	capped     bool // headers only
+}
+
+// explorer lists the attribute keys of one signal with their most common
+// values; choosing a value filters the message list by it.
+type explorer struct {
+	kind telemetry.Kind
+	rows []explorerRow
+	sel  int // index into rows; -1 when there is nothing to select
+}
+
+// openExplorer snapshots the active signal's attribute counts.
+func (m *Model) openExplorer() {
+	e := explorer{kind: m.Active, sel: -1}
+	for _, ks := range m.store.AttributesFor(m.Active).Top(explorerTopN) {
+		e.rows = append(e.rows, explorerRow{header: true, key: ks.Key, distinct: ks.Distinct, capped: ks.Capped})
+		for _, vc := range ks.Top {
+			if e.sel < 0 {
+				e.sel = len(e.rows)
+			}
+			e.rows = append(e.rows, explorerRow{key: ks.Key, value: vc.Value, count: vc.Count})
+		}
+	}
+	m.explorer = e
+	m.renderExplorer()
+}
+
+func (m *Model) renderExplorer() {
+	e := &m.explorer
+	lines := []string{styles.Status.Render(fmt.Sprintf(
+		"attributes · %s · enter filter · esc close", e.kind))}
+	if len(e.rows) == 0 {
+		lines = append(lines, "", "no attributes seen yet")
+	}
+	for i, r := range e.rows {
+		if r.header {
+			more := ""
+			if r.capped {
+				more = "+"
+			}
+			lines = append(lines, fmt.Sprintf("%s (%d%s values)", r.key, r.distinct, more))
+			continue
+		}
+		line := fmt.Sprintf("    %-40s %6d", truncateValue(r.value, 40), r.count)
+		if want, ok := m.store.Filter(m.Active).Attrs[r.key]; ok && want == r.value {
+			line = "  ✓" + line[3:]
+		}
+		if i == e.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+// explorerKey handles keys while the explorer is open and reports whether
+// it consumed msg.
+func (m *Model) explorerKey(msg tea.KeyMsg) bool {
+	e := &m.explorer
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		m.moveExplorer(-1)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		m.moveExplorer(1)
+	case msg.String() == "enter":
+		if e.sel < 0 {
+			return true
+		}
+		r := e.rows[e.sel]
+		f := m.store.Filter(m.Active)
+		f.Attrs = maps.Clone(f.Attrs)
+		if f.Attrs == nil {
+			f.Attrs = map[string]string{}
+		}
+		if f.Attrs[r.key] == r.value {
+			delete(f.Attrs, r.key) // choosing the active value again removes it
+		} else {
+			f.Attrs[r.key] = r.value
+		}
+		m.closeOverlay()
+		m.setFilter(f)
+	default:
+		return false
+	}
+	return true
+}
+
+// moveExplorer moves the selection by delta value rows, skipping headers,
+// and scrolls it into view.
+func (m *Model) moveExplorer(delta int) {
+	e := &m.explorer
+	for i := e.sel + delta; i >= 0 && i < len(e.rows); i += delta {
+		if !e.rows[i].header {
+			e.sel = i
+			break
+		}
+	}
+	m.renderExplorer()
+	line := e.sel + 1 // below the title
+	if e.sel == m.firstExplorerRow() {
+		line = 0 // keep the title visible at the top
+	}
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+	m.syncViewport()
+}
+
+func (m *Model) firstExplorerRow() int {
+	for i, r := range m.explorer.rows {
+		if !r.header {
+			return i
+		}
+	}
+	return -1
+}
+
+// setFilter applies f to the active tab's message list.
+func (m *Model) setFilter(f filter.Filter) {
+	if m.filterChanged(f) {
+		m.recordFilter()
+	}
+	m.store.SetFilter(m.Active, f)
+	m.viewport.SetTotal(m.totalLines())
+	if !m.paused {
+		m.viewport.GotoBottom()
+	}
+	m.syncViewport()
+}
+
+func truncateValue(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// maxDepthLimit is the deepest limit < and > step through before >
+// removes the limit.
+const maxDepthLimit = 8
+
+// setFormat re-renders every buffer in f, keeping the cursor where it was.
+func (m *Model) setFormat(f telemetry.Format) {
+	telemetry.SetFormat(f)
+	m.live.Reindex()
+	for _, s := range m.snapshots.list {
+		s.store.Reindex()
+	}
+	m.styled.reset(m.textWidth())
+	m.viewport.SetTotal(m.totalLines())
+	if m.paused {
+		m.ensureCursorVisible()
+	} else {
+		m.viewport.GotoBottom()
+	}
+	m.syncViewport()
+}
+
+// shallower lowers the nesting limit, starting from maxDepthLimit.
+func shallower(depth int) int {
+	if depth == 0 {
+		return maxDepthLimit
+	}
+	return max(depth-1, 1)
+}
+
+// deeper raises the nesting limit, removing it past maxDepthLimit.
+func deeper(depth int) int {
+	if depth == 0 || depth >= maxDepthLimit {
+		return 0
+	}
+	return depth + 1
+}
+
+func formatSegment(m Model) string {
+	f := telemetry.CurrentFormat()
+	var parts []string
+	if f.Compact {
+		parts = append(parts, "compact")
+	}
+	if f.Bodies {
+		parts = append(parts, "bodies only")
+	}
+	if f.ReceivedOrder {
+		parts = append(parts, "keys as received")
+	}
+	if f.MaxDepth > 0 {
+		parts = append(parts, fmt.Sprintf("depth %d", f.MaxDepth))
+	}
+	if f.RawAttributes {
+		parts = append(parts, "raw attributes")
+	}
+	if f.Hex {
+		parts = append(parts, "hex")
+	}
+	if f.FullIDs {
+		parts = append(parts, "full IDs")
+	}
+	if m.noWrap {
+		parts = append(parts, "no wrap")
+	}
+	return strings.Join(parts, ", ")
+}
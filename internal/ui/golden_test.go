@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/testutil"
+)
+
+// TestGolden renders each display mode at a few terminal sizes and compares
+// the screen with testdata/TestGolden/MODE/WxH.golden. After a change that
+// is meant to alter the view, rewrite them with
+//
+//	go test ./internal/ui -run Golden -update
+//
+// and review the diff. Times are shown in UTC; see TestMain.
+func TestGolden(t *testing.T) {
+	f := telemetry.CurrentFormat()
+	t.Cleanup(func() { telemetry.SetFormat(f) })
+
+	modes := []struct {
+		name  string
+		input []tea.Msg
+	}{
+		{"json", nil},
+		{"compact", keys("c")},
+		{"nowrap", keys("w")},
+		{"table", keys("v")},
+		{"flat", keys("A")},
+		{"paused", keys("p", "up", "up")},
+		{"filtered", command("filter severity=error")},
+		{"traces", keys("t")},
+		{"trace-command", command("trace 00-5b8efff798038103d269b633813fc60c-1e50dee97f269b58-01")},
+		{"traces-paused", keys("t", "p")},
+		{"waterfall", keys("t", "p", "T")},
+		{"metrics", keys("m")},
+		{"hex", keys("o", "X")},
+		{"help", keys("?")},
+		{"cut", []tea.Msg{formatMsg{MaxValue: 40}}},
+		{"stack", append(command("clear"), stackFrames())},
+		{"stack-collapsed", append(append(command("clear"), stackFrames()), keys(stackCursor...)...)},
+		{"bodies", keys("i")},
+		{"bodies-paused", keys("i", "p", "up")},
+		{"ansi", append(command("clear"), ansiFrames())},
+		{"ansi-color", append(append(command("clear"), ansiFrames()), command("ansi color")...)},
+		{"search", search("card declined")},
+		{"search-next", append(search("checkout"), keys("n")...)},
+		{"zen", keys("Z")},
+		{"zen-prompt", keys("Z", ":")},
+		{"zen-table", keys("Z", "v")},
+	}
+	sizes := [][2]int{{80, 24}, {120, 40}, {40, 12}}
+	for _, mode := range modes {
+		for _, size := range sizes {
+			t.Run(fmt.Sprintf("%s/%dx%d", mode.name, size[0], size[1]), func(t *testing.T) {
+				telemetry.SetFormat(telemetry.Format{})
+				m := newModel(nil, func() {}, nil, telemetry.KindLogs)
+				m = update(m, tea.WindowSizeMsg{Width: size[0], Height: size[1]}, goldenFrames())
+				m = update(m, mode.input...)
+				testutil.Golden(t, screen(m))
+			})
+		}
+	}
+}
+
+// goldenFrames is one batch with something for every tab.
+func goldenFrames() frameBatchMsg {
+	frames := [][]byte{
+		testutil.Log("checkout", "info", "order placed"),
+		testutil.Log("payments", "error", "card declined"),
+		testutil.Log("checkout", "warn", "inventory lookup took longer than the configured budget; serving cached stock levels instead"),
+		testutil.Span("checkout", "GET /cart", 120*time.Millisecond),
+		testutil.Span("payments", "POST /charge", 80*time.Millisecond),
+		testutil.Gauge("checkout", "queue.depth", 7),
+		[]byte("not OTLP at all"),
+	}
+	batch := make(frameBatchMsg, len(frames))
+	for i, b := range frames {
+		batch[i] = telemetry.Parse(b)
+	}
+	return batch
+}
+
+// stackCursor pauses unwrapped, so rows are lines at every size, moves the
+// cursor up from the end of the message onto a frame of its stack trace,
+// and collapses it.
+var stackCursor = append(append([]string{"w", "p"}, slices.Repeat([]string{"up"}, 16)...), " ")
+
+// stackFrames is a batch with a Java exception logged as its body.
+func stackFrames() frameBatchMsg {
+	return frameBatchMsg{telemetry.Parse(testutil.Log("checkout", "error", strings.Join([]string{
+		"java.lang.IllegalStateException: cart is empty",
+		"\tat com.example.checkout.Cart.total(Cart.java:42)",
+		"\tat com.example.checkout.CheckoutHandler.handle(CheckoutHandler.java:17)",
+		"\tat org.springframework.web.servlet.DispatcherServlet.doDispatch(DispatcherServlet.java:1067)",
+		"\tat java.base/java.lang.Thread.run(Thread.java:833)",
+		"Caused by: java.util.NoSuchElementException",
+		"\tat java.base/java.util.ArrayList.getFirst(ArrayList.java:439)",
+		"\t... 4 more",
+	}, "\n")))}
+}
+
+// ansiFrames is a batch with a log body colored with escape sequences.
+func ansiFrames() frameBatchMsg {
+	return frameBatchMsg{telemetry.Parse(testutil.Log("checkout", "warn", "\x1b[33mWARN\x1b[0m stock low for \x1b[1msku-42\x1b[0m"))}
+}
+
+// update feeds msgs to m in order. Commands are dropped, since ticks and
+// stream reads would make the screen depend on timing, except that a
+// reflow is finished on the spot.
+func update(m Model, msgs ...tea.Msg) Model {
+	for _, msg := range msgs {
+		if f, ok := msg.(formatMsg); ok {
+			m.setFormat(telemetry.Format(f))
+			continue
+		}
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+	if m.reflowing.pending {
+		m.finishReflow(m.startReflow()().(reflowDoneMsg))
+	}
+	return m
+}
+
+// formatMsg stands in for the flags that set the render format.
+type formatMsg telemetry.Format
+
+func keys(names ...string) []tea.Msg {
+	msgs := make([]tea.Msg, len(names))
+	for i, name := range names {
+		msgs[i] = testutil.Key(name)
+	}
+	return msgs
+}
+
+// command types line at the : prompt and runs it.
+func command(line string) []tea.Msg {
+	return append(append(keys(":"), text(line)...), keys("enter")...)
+}
+
+// search types query at the / prompt and runs it.
+func search(query string) []tea.Msg {
+	return append(append(keys("/"), text(query)...), keys("enter")...)
+}
+
+func text(s string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range s {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+// receivedAt matches the time a message arrived, as the detail footer
+// shows it.
+var receivedAt = regexp.MustCompile(`received \d\d:\d\d:\d\d\.\d{3}`)
+
+// screen renders m without the parts that change from run to run: styling,
+// which depends on the terminal, the dots on tabs that just received
+// something, arrival times, and trailing blanks.
+func screen(m Model) string {
+	for k, st := range m.tabStates {
+		st.received = time.Time{}
+		m.tabStates[k] = st
+	}
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	return receivedAt.ReplaceAllString(strings.Join(lines, "\n"), "received 00:00:00.000") + "\n"
+}
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// DefaultGroupBy is the resource attribute the grouped view buckets by.
+const DefaultGroupBy = "service.name"
+
+// groupRecent is how many records an expanded group lists.
+const groupRecent = 200
+
+// groupsRefresh is how often the open grouped view takes in new messages.
+const groupsRefresh = time.Second
+
+// groupsTickMsg asks for the grouped view to be rebuilt.
+type groupsTickMsg struct{}
+
+func groupsTick() tea.Cmd {
+	return tea.Tick(groupsRefresh, func(time.Time) tea.Msg { return groupsTickMsg{} })
+}
+
+// groupView buckets the active tab's records by a resource attribute, each
+// group collapsed to its counts until expanded.
+type groupView struct {
+	key     string // resource attribute; "" = DefaultGroupBy
+	kind    telemetry.Kind
+	groups  []aggregate.Group
+	open    map[string]bool // expanded groups by value, kept across rebuilds
+	sel     int
+	headers []int // overlay line of each group's header
+}
+
+func (g *groupView) by() string {
+	if g.key == "" {
+		return DefaultGroupBy
+	}
+	return g.key
+}
+
+// rebuildGroups regroups what the active tab shows, keeping the selection
+// on the same group.
+func (m *Model) rebuildGroups() {
+	g := &m.groups
+	var prev *string
+	if g.kind == m.Active && g.sel < len(g.groups) {
+		prev = &g.groups[g.sel].Value
+	} else if g.kind != m.Active {
+		g.open, g.sel = nil, 0
+	}
+	g.kind = m.Active
+	groups := aggregate.BuildGroups(m.store.Shown(m.Active), g.by(), groupRecent)
+	g.sel = min(g.sel, max(len(groups)-1, 0))
+	for i, gr := range groups {
+		if prev != nil && gr.Value == *prev {
+			g.sel = i
+		}
+	}
+	g.groups = groups
+	m.renderGroups()
+}
+
+func (m *Model) renderGroups() {
+	g := &m.groups
+	lines := []string{styles.Status.Render(fmt.Sprintf("%s grouped by %s · enter expand/collapse · +/- all · esc close", g.kind, g.by()))}
+	g.headers = g.headers[:0]
+	if len(g.groups) == 0 {
+		lines = append(lines, "", "nothing to group yet")
+	}
+	for i, gr := range g.groups {
+		name := gr.Value
+		if name == "" {
+			name = "(no " + g.by() + ")"
+		}
+		arrow := "▸"
+		if g.open[gr.Value] {
+			arrow = "▾"
+		}
+		header := fmt.Sprintf("%s %-32s %s · %s", arrow, truncateValue(name, 32), plural(gr.Messages, "message"), plural(gr.Records, "record"))
+		if i == g.sel {
+			header = styles.Cursor.Render(header)
+		}
+		g.headers = append(g.headers, len(lines))
+		lines = append(lines, header)
+		if !g.open[gr.Value] {
+			continue
+		}
+		if n := gr.Records - len(gr.Recent); n > 0 {
+			lines = append(lines, fmt.Sprintf("    … %s not shown", plural(n, "older record")))
+		}
+		for _, r := range gr.Recent {
+			if g.by() == "service.name" {
+				r.Service = "" // already the group's name
+			}
+			lines = append(lines, truncateValue("    "+r.String(), max(m.viewport.Width, 8)))
+		}
+	}
+	m.overlayLines = lines
+}
+
+// setGroupBy switches the grouped view to another resource attribute and
+// opens it.
+func (m *Model) setGroupBy(key string) tea.Cmd {
+	m.groups.key, m.groups.open, m.groups.sel = key, nil, 0
+	if m.overlay == overlayGroups {
+		m.rebuildGroups()
+		m.syncViewport()
+		return nil
+	}
+	return m.toggleOverlay(overlayGroups)
+}
+
+// groupsKey handles keys while the grouped view is open and reports whether
+// it consumed msg.
+func (m *Model) groupsKey(msg tea.KeyMsg) bool {
+	g := &m.groups
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case msg.String() == "enter" && len(g.groups) > 0:
+		v := g.groups[g.sel].Value
+		if g.open == nil {
+			g.open = map[string]bool{}
+		}
+		g.open[v] = !g.open[v]
+	case msg.String() == "+" || msg.String() == "-":
+		g.open = map[string]bool{}
+		for _, gr := range g.groups {
+			g.open[gr.Value] = msg.String() == "+"
+		}
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		g.sel = max(g.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		g.sel = max(min(g.sel+1, len(g.groups)-1), 0)
+	default:
+		return false
+	}
+	m.renderGroups()
+	if g.sel < len(g.headers) {
+		m.scrollToSelection(g.headers[g.sel])
+	}
+	m.syncViewport()
+	return true
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// timestamps selects what the gutter left of each message shows.
+type timestamps int
+
+const (
+	timestampsOff   timestamps = iota
+	timestampsClock            // wall-clock time the message was received
+	timestampsAge              // how long ago it was received
+	timestampModes
+)
+
+const (
+	clockLayout = "15:04:05.000"
+	ageWidth    = len("59m ago")
+	ageRefresh  = time.Second
+)
+
+// ageTickMsg asks for the visible ages to be redrawn. It carries the ticker
+// generation so that only the newest ticker keeps running.
+type ageTickMsg int
+
+func ageTick(gen int) tea.Cmd {
+	return tea.Tick(ageRefresh, func(time.Time) tea.Msg { return ageTickMsg(gen) })
+}
+
+// cycleTimestamps steps the gutter through off, clock, and age. Ages are
+// redrawn every ageRefresh; only the visible rows are re-rendered.
+func (m *Model) cycleTimestamps() tea.Cmd {
+	m.timestamps = (m.timestamps + 1) % timestampModes
+	m.syncViewport()
+	if m.timestamps == timestampsAge {
+		m.ageTicks++
+		return ageTick(m.ageTicks)
+	}
+	return nil
+}
+
+// gutterWidth is the width of the gutter, including the space after it.
+func (m Model) gutterWidth() int {
+	switch {
+	case m.tableMode():
+		return 0 // the table has its own time column
+	case m.timestamps == timestampsClock:
+		return len(clockLayout) + 1
+	case m.timestamps == timestampsAge:
+		return ageWidth + 1
+	}
+	return 0
+}
+
+// textWidth is the width left for message lines beside the gutter.
+func (m Model) textWidth() int {
+	return max(m.width-m.gutterWidth(), 0)
+}
+
+// gutter renders the gutter for line j of msg. Only a message's first line
+// is labelled; messages without a receive time get a blank gutter.
+func (m Model) gutter(msg *telemetry.Message, j int, now time.Time) string {
+	w := m.gutterWidth()
+	if w == 0 {
+		return ""
+	}
+	if j != 0 || msg.Received.IsZero() {
+		return strings.Repeat(" ", w)
+	}
+	var s string
+	if m.timestamps == timestampsClock {
+		s = msg.Received.Format(clockLayout)
+	} else {
+		s = fmt.Sprintf("%*s", ageWidth, age(now.Sub(msg.Received)))
+	}
+	return styles.Status.Render(s) + " "
+}
+
+// age formats d in its largest whole unit, e.g. "3s ago" or "2m ago".
+func age(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", max(int(d/time.Second), 0))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
@@ -0,0 +1,16 @@
+package ui
+
+import "fmt"
+
+// renderKeyHelp lists every binding by section, for ?.
+func (m *Model) renderKeyHelp() {
+	lines := []string{styles.Status.Render("keys · ctrl+p run one by name · esc close")}
+	for _, s := range Keys.Sections() {
+		lines = append(lines, "", s.title)
+		for _, b := range s.keys {
+			h := b.Help()
+			lines = append(lines, fmt.Sprintf("  %-8s %s", h.Key, h.Desc))
+		}
+	}
+	m.overlayLines = lines
+}
@@ -7,17 +7,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#909090", Dark: "#626262"})
-
-	msgHighlightStyle        = lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "#404040", Dark: "#303030"})
-	msgHighlightJSONKeyStyle = msgHighlightStyle.Bold(true).Foreground(lipgloss.Color("214"))
-
-	cursorStyle        = msgHighlightStyle.Reverse(true)
-	cursorJSONKeyStyle = cursorStyle.Bold(true).Foreground(lipgloss.Color("214"))
-
-	jsonKeyRegex = regexp.MustCompile(`"[^"\\]*"\s*:`)
-)
+var jsonKeyRegex = regexp.MustCompile(`"[^"\\]*"\s*:`)
 
 func highlightJSONKeys(s string, baseStyle, keyStyle lipgloss.Style) string {
 	var b strings.Builder
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// histogramHeight is the number of rows the latency panel occupies: a
+// summary line followed by the buckets.
+const histogramHeight = 8
+
+// histogramScope selects which spans the latency panel summarises.
+type histogramScope int
+
+const (
+	scopeAll histogramScope = iota
+	scopeSpanName
+	scopeService
+)
+
+func (s histogramScope) String() string {
+	switch s {
+	case scopeSpanName:
+		return "span"
+	case scopeService:
+		return "service"
+	default:
+		return "all spans"
+	}
+}
+
+// On the Logs tab the panel shows pipeline latency instead: how long after
+// its time each record was observed, by the collector or the exporter.
+// There the span scope is left out, and the service scope follows the
+// record under the cursor, or the newest.
+
+// showHistogram reports whether the latency panel is on screen.
+func (m *Model) showHistogram() bool {
+	return m.histogram && (m.Active == telemetry.KindTraces || m.Active == telemetry.KindLogs)
+}
+
+// cycleHistScope moves the latency panel to its next scope.
+func (m *Model) cycleHistScope() {
+	m.histScope = (m.histScope + 1) % (scopeService + 1)
+	if m.Active == telemetry.KindLogs && m.histScope == scopeSpanName {
+		m.histScope = scopeService
+	}
+}
+
+// focusService returns the service of the log message under the cursor
+// when paused, otherwise of the newest log message.
+func (m *Model) focusService() (string, bool) {
+	msg := m.cur.msg
+	if msg == nil || msg.Kind != telemetry.KindLogs {
+		logs := m.store.Messages(telemetry.KindLogs)
+		if len(logs) == 0 {
+			return "", false
+		}
+		msg = &logs[len(logs)-1]
+	}
+	services := msg.Services()
+	if len(services) == 0 {
+		return "", false
+	}
+	return services[0], true
+}
+
+// focusSpan returns the name and service of the span the panel follows: the
+// first span of the message under the cursor when paused, otherwise of the
+// newest trace message.
+func (m *Model) focusSpan() (name, service string, ok bool) {
+	msg := m.cur.msg
+	if msg == nil || msg.Kind != telemetry.KindTraces {
+		traces := m.store.Messages(telemetry.KindTraces)
+		if len(traces) == 0 {
+			return "", "", false
+		}
+		msg = &traces[len(traces)-1]
+	}
+	rss := msg.Traces.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			if spans := sss.At(j).Spans(); spans.Len() > 0 {
+				if v, ok := rs.Resource().Attributes().Get("service.name"); ok {
+					service = v.AsString()
+				}
+				return spans.At(0).Name(), service, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// renderHistogram draws the latency panel for the current scope.
+func (m Model) renderHistogram() string {
+	h, label, title, none := m.latency.All(), m.histScope.String(), "latency", "no spans"
+	switch {
+	case m.Active == telemetry.KindLogs:
+		h, label, title, none = m.pipeline.All(), "all logs", "pipeline latency", "no logs with both a time and an observed time"
+		if m.histScope != scopeAll {
+			service, ok := m.focusService()
+			h, label = nil, "service"
+			if ok {
+				h, label = m.pipeline.ByService(service), fmt.Sprintf("service %q", service)
+			}
+		}
+	case m.histScope != scopeAll:
+		name, service, ok := m.focusSpan()
+		switch {
+		case !ok:
+			h = nil
+		case m.histScope == scopeSpanName:
+			h, label = m.latency.ByName(name), fmt.Sprintf("span %q", name)
+		default:
+			h, label = m.latency.ByService(service), fmt.Sprintf("service %q", service)
+		}
+	}
+
+	rows := make([]string, 0, histogramHeight)
+	if h == nil || h.Count() == 0 {
+		rows = append(rows, styles.Status.Render(title+" · "+label+" · "+none))
+	} else {
+		rows = append(rows, styles.Status.Render(fmt.Sprintf(
+			"%s · %s · n=%d p50=%s p90=%s p99=%s max=%s",
+			title, label, h.Count(),
+			shortDuration(h.Quantile(0.5)), shortDuration(h.Quantile(0.9)),
+			shortDuration(h.Quantile(0.99)), shortDuration(h.Max()),
+		)))
+		buckets := h.Buckets(histogramHeight - 1)
+		var peak uint64
+		for _, b := range buckets {
+			peak = max(peak, b.Count)
+		}
+		const labelWidth = 10
+		barWidth := max(m.viewport.Width-labelWidth-12, 1)
+		for _, b := range buckets {
+			n := int(float64(b.Count) / float64(peak) * float64(barWidth))
+			if b.Count > 0 {
+				n = max(n, 1)
+			}
+			rows = append(rows, fmt.Sprintf("%*s │%s %d",
+				labelWidth, "≤"+shortDuration(b.Upper), strings.Repeat("█", n), b.Count))
+		}
+	}
+	for len(rows) < histogramHeight {
+		rows = append(rows, "")
+	}
+	return strings.Join(rows, "\n")
+}
+
+// shortDuration formats d with about three significant digits.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%.3gms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.3gs", d.Seconds())
+	}
+}
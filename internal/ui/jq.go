@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/jq"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// jqPanel is the last jq expression, the message it ran on, and its output.
+type jqPanel struct {
+	expr   string
+	msg    telemetry.Message
+	result []string
+}
+
+// startJQ asks for an expression to run against the message under the
+// cursor, starting from the previous one.
+func (m *Model) startJQ() tea.Cmd {
+	if m.cur.msg == nil {
+		return nil
+	}
+	m.jq.msg = *m.cur.msg
+	return m.openPrompt("jq: ", m.jq.expr, submitJQ)
+}
+
+func submitJQ(m *Model, expr string) tea.Cmd {
+	m.jq.expr = strings.TrimSpace(expr)
+	m.runJQ()
+	return nil
+}
+
+// runJQ evaluates the expression and shows the result in place of the
+// message list.
+func (m *Model) runJQ() {
+	p := &m.jq
+	p.result = nil
+	q, err := jq.Parse(p.expr)
+	if err == nil {
+		p.result, err = q.Lines([]byte(strings.Join(p.msg.JSONLines(), "\n")))
+	}
+	title := fitCell("jq "+p.expr+" · y yank · J edit · esc close", max(m.width, 1), true)
+	lines := []string{styles.Status.Render(title)}
+	switch {
+	case err != nil:
+		lines = append(lines, styles.Banner.Render(err.Error()))
+	case len(p.result) == 0:
+		lines = append(lines, "(no results)")
+	default:
+		lines = append(lines, p.result...)
+	}
+	m.overlay, m.overlayLines = overlayJQ, lines
+	m.viewport.SetYOffset(0)
+	m.syncViewport()
+}
+
+// jqKey handles keys while the result is shown and reports whether it
+// consumed msg.
+func (m *Model) jqKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+	case key.Matches(msg, Keys.Yank):
+		if len(m.jq.result) > 0 {
+			m.copyLines(m.jq.result)
+		}
+	case key.Matches(msg, Keys.JQ):
+		return m.openPrompt("jq: ", m.jq.expr, submitJQ), true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
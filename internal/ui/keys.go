@@ -4,38 +4,140 @@ import "github.com/charmbracelet/bubbles/key"
 
 type KeyMap struct {
 	Logs, Metrics, Traces key.Binding
+	Other                 key.Binding
+	NextTab, PrevTab      key.Binding
 	Pause, Quit, Yank     key.Binding
+	Repro, OpenTrace      key.Binding
+	Reconnect             key.Binding
+	Histogram             key.Binding
+	HistogramScope        key.Binding
+	ServiceMap, Lint      key.Binding
+	Attributes            key.Binding
+	ClearFilter           key.Binding
+	Table, Columns, Sort  key.Binding
+	Snapshot, Snapshots   key.Binding
+	Mark, Diff            key.Binding
+	JQ, Editor, Pipe      key.Binding
+	SortKeys, Flatten     key.Binding
+	Shallower, Deeper     key.Binding
+	Hex, Compact, Bodies  key.Binding
+	Preset, Presets       key.Binding
+	Saved                 key.Binding
+	Sources, Undo         key.Binding
+	Clear, Command        key.Binding
+	Timestamps            key.Binding
+	MetricNames           key.Binding
+	Exemplars, Trace      key.Binding
+	ErrorSpans, Groups    key.Binding
+	Scopes, Bookmarks     key.Binding
+	Bookmark, Jump        key.Binding
+	Search, Next, Prev    key.Binding
+	Visual, Write, Wrap   key.Binding
+	Help, Palette, Zen    key.Binding
+	Expand                key.Binding
+	Debug                 key.Binding // not listed in the help
 }
 
 var Keys = KeyMap{
-	Logs:    key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "logs")),
-	Metrics: key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "metrics")),
-	Traces:  key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "traces")),
-	Pause:   key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "pause")),
-	Quit:    key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
-	Yank:    key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "yank to clipboard")),
+	Logs:           key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "logs")),
+	Metrics:        key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "metrics")),
+	Traces:         key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "traces")),
+	Other:          key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "other")),
+	NextTab:        key.NewBinding(key.WithKeys("]"), key.WithHelp("]", "next tab")),
+	PrevTab:        key.NewBinding(key.WithKeys("["), key.WithHelp("[", "previous tab")),
+	Pause:          key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "pause")),
+	Quit:           key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+	Yank:           key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "yank to clipboard")),
+	Repro:          key.NewBinding(key.WithKeys("Y"), key.WithHelp("Y", "yank a curl that replays it (paused)")),
+	OpenTrace:      key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "open its trace in the browser (--trace-url)")),
+	Reconnect:      key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "reconnect")),
+	Histogram:      key.NewBinding(key.WithKeys("h"), key.WithHelp("h", "latency histogram (logs: pipeline latency)")),
+	HistogramScope: key.NewBinding(key.WithKeys("H"), key.WithHelp("H", "histogram scope")),
+	ServiceMap:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "service map")),
+	Lint:           key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "semantic-convention lint")),
+	Attributes:     key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "attribute explorer")),
+	ClearFilter:    key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "clear filter")),
+	Table:          key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "log table view")),
+	Columns:        key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "pick table columns")),
+	Sort:           key.NewBinding(key.WithKeys("S"), key.WithHelp("S", "sort table (paused)")),
+	Snapshot:       key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "freeze a snapshot")),
+	Snapshots:      key.NewBinding(key.WithKeys("L"), key.WithHelp("L", "list snapshots")),
+	Mark:           key.NewBinding(key.WithKeys("M"), key.WithHelp("M", "mark for diff (paused)")),
+	Diff:           key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "diff with mark (paused)")),
+	JQ:             key.NewBinding(key.WithKeys("J"), key.WithHelp("J", "jq on cursor message (paused)")),
+	Editor:         key.NewBinding(key.WithKeys("O"), key.WithHelp("O", "open in $EDITOR (paused)")),
+	Pipe:           key.NewBinding(key.WithKeys("|"), key.WithHelp("|", "pipe to command (paused)")),
+	SortKeys:       key.NewBinding(key.WithKeys("K"), key.WithHelp("K", "sort JSON keys")),
+	Flatten:        key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "raw/flat attributes")),
+	Shallower:      key.NewBinding(key.WithKeys("<"), key.WithHelp("<", "limit nesting depth")),
+	Deeper:         key.NewBinding(key.WithKeys(">"), key.WithHelp(">", "raise nesting depth")),
+	Hex:            key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "hexdump other frames")),
+	Compact:        key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "one line per message")),
+	Bodies:         key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "logs: time, severity, and body only")),
+	Preset:         key.NewBinding(key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"), key.WithHelp("1-9", "apply filter preset")),
+	Presets:        key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "filter presets")),
+	Saved:          key.NewBinding(key.WithKeys("Q"), key.WithHelp("Q", "saved searches sidebar (:save NAME)")),
+	Sources:        key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "sources (endpoints)")),
+	Undo:           key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "undo filter change or clear")),
+	Clear:          key.NewBinding(key.WithKeys("ctrl+l"), key.WithHelp("ctrl+l", "clear tab")),
+	Timestamps:     key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "timestamps: off/clock/age")),
+	MetricNames:    key.NewBinding(key.WithKeys("B"), key.WithHelp("B", "browse metric names")),
+	Exemplars:      key.NewBinding(key.WithKeys("E"), key.WithHelp("E", "metrics: exemplars → trace (paused)")),
+	ErrorSpans:     key.NewBinding(key.WithKeys("E"), key.WithHelp("E", "traces: error spans only")),
+	Trace:          key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "trace waterfall (paused)")),
+	Groups:         key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "group by service (or :group KEY)")),
+	Scopes:         key.NewBinding(key.WithKeys("I"), key.WithHelp("I", "show/hide instrumentation scopes")),
+	Search:         key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search messages for words")),
+	Next:           key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next search match")),
+	Prev:           key.NewBinding(key.WithKeys("N"), key.WithHelp("N", "previous search match")),
+	Bookmark:       key.NewBinding(key.WithKeys("`"), key.WithHelp("`a-z", "set bookmark (paused)")),
+	Jump:           key.NewBinding(key.WithKeys("'"), key.WithHelp("'a-z", "jump to bookmark")),
+	Bookmarks:      key.NewBinding(key.WithKeys("\""), key.WithHelp("\"", "list bookmarks")),
+	Visual:         key.NewBinding(key.WithKeys("V"), key.WithHelp("V", "select messages (paused)")),
+	Write:          key.NewBinding(key.WithKeys("W"), key.WithHelp("W", "write selection, or the trace shown, to a file (paused)")),
+	Wrap:           key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "wrap/cut long lines")),
+	Expand:         key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "expand/cut long value or stack trace (paused)")),
+	Zen:            key.NewBinding(key.WithKeys("Z"), key.WithHelp("Z", "zen: hide tabs, status, and help")),
+	Command:        key.NewBinding(key.WithKeys(":"), key.WithHelp(":", "command (:filter, :set, :theme, :export, …)")),
+	Debug:          key.NewBinding(key.WithKeys("alt+ctrl+d"), key.WithHelp("ctrl+alt+d", "debug panel")),
+	Help:           key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "all keys")),
+	Palette:        key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("ctrl+p", "command palette")),
 }
 
+// keySection is one category of bindings in the help overlay.
+type keySection struct {
+	title string
+	keys  []key.Binding
+}
+
+// Sections groups every binding by what it is for, in the order the help
+// overlay and the command palette list them.
+func (k KeyMap) Sections() []keySection {
+	return []keySection{
+		{"Tabs and streaming", []key.Binding{k.Logs, k.Metrics, k.Traces, k.Other, k.NextTab, k.PrevTab, k.Pause, k.Reconnect, k.Clear, k.Undo, k.Quit}},
+		{"Filtering", []key.Binding{k.Command, k.ClearFilter, k.Preset, k.Presets, k.Saved, k.Attributes, k.Scopes, k.ErrorSpans}},
+		{"Views", []key.Binding{k.Table, k.Columns, k.Sort, k.Groups, k.ServiceMap, k.Lint, k.Histogram, k.HistogramScope, k.MetricNames, k.Exemplars, k.Trace, k.Snapshot, k.Snapshots, k.Sources, k.Zen}},
+		{"Formatting", []key.Binding{k.SortKeys, k.Flatten, k.Shallower, k.Deeper, k.Hex, k.Compact, k.Bodies, k.Timestamps, k.Wrap, k.Expand}},
+		{"Cursor and selection", []key.Binding{k.Search, k.Next, k.Prev, k.Visual, k.Bookmark, k.Jump, k.Bookmarks, k.Mark, k.Diff}},
+		{"Acting on messages", []key.Binding{k.Yank, k.Repro, k.Write, k.JQ, k.Editor, k.Pipe, k.OpenTrace}},
+		{"Help", []key.Binding{k.Help, k.Palette}},
+	}
+}
+
+// ShortHelp is the footer, which only points at the full list.
 func (k KeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
-		k.Logs,
-		k.Metrics,
-		k.Traces,
 		k.Pause,
 		k.Quit,
-		k.Yank,
+		k.Help,
+		k.Palette,
 	}
 }
 
 func (k KeyMap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{
-		{
-			k.Logs,
-			k.Metrics,
-			k.Traces,
-			k.Pause,
-			k.Quit,
-			k.Yank,
-		},
+	var cols [][]key.Binding
+	for _, s := range k.Sections() {
+		cols = append(cols, s.keys)
 	}
+	return cols
 }
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/lint"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// R opens the lint panel: every buffered message checked against the
+// semantic conventions, with how often each rule was broken, by which
+// services, and a few examples. Nothing is checked while it is closed.
+
+// lintRefresh is how often the open lint panel is rebuilt from the buffer.
+const lintRefresh = 2 * time.Second
+
+// lintTickMsg asks for the lint panel to be rebuilt.
+type lintTickMsg struct{}
+
+func lintTick() tea.Cmd {
+	return tea.Tick(lintRefresh, func(time.Time) tea.Msg { return lintTickMsg{} })
+}
+
+// rebuildLint checks every buffered message and renders the findings.
+func (m *Model) rebuildLint() {
+	var r lint.Report
+	for _, t := range signalTabs {
+		for _, msg := range m.store.Messages(t.kind) {
+			r.Add(msg)
+		}
+	}
+	m.overlayLines = renderLint(&r)
+}
+
+func renderLint(r *lint.Report) []string {
+	lines := []string{styles.Status.Render(fmt.Sprintf(
+		"lint · %s in %s · refreshed every %s",
+		plural(r.Total(), "violation"), plural(r.Messages, "message"), lintRefresh))}
+	findings := r.Findings()
+	if len(findings) == 0 {
+		return append(lines, "", "no violations")
+	}
+	warn := styles.SeverityStyle(telemetry.SeverityWarn)
+	for _, f := range findings {
+		lines = append(lines, "", fmt.Sprintf("%s  %s", warn.Render(fmt.Sprintf("%6d %s", f.Count, f.Rule.Name)), f.Rule.Description))
+		services := make([]string, len(f.Services))
+		for i, s := range f.Services {
+			services[i] = cmp.Or(s, "(no service.name)")
+		}
+		lines = append(lines, "         services: "+strings.Join(services, ", "))
+		for _, e := range f.Examples {
+			lines = append(lines, "         e.g. "+e)
+		}
+	}
+	return lines
+}
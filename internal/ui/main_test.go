@@ -0,0 +1,14 @@
+package ui
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMain shows times in UTC, so rendered views do not depend on where the
+// tests run. It is set before any test starts goroutines that read it.
+func TestMain(m *testing.M) {
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// metricRecent is how many data points a drilled-into metric lists.
+const metricRecent = 200
+
+// metricBrowser lists the metric names in the buffer and, once one is
+// opened, its most recent data points.
+type metricBrowser struct {
+	metrics []aggregate.MetricSummary
+	sel     int  // selected name
+	open    bool // showing the selected name's data points
+}
+
+// openMetricBrowser summarises the metrics buffer, keeping the selection on
+// the same name if it is still there.
+func (m *Model) openMetricBrowser() {
+	msgs := m.store.Messages(telemetry.KindMetrics)
+	mds := make([]pmetric.Metrics, len(msgs))
+	for i, msg := range msgs {
+		mds[i] = msg.Metrics
+	}
+	b := &m.metricBrowser
+	prev := ""
+	if b.sel < len(b.metrics) {
+		prev = b.metrics[b.sel].Name
+	}
+	b.metrics, b.sel, b.open = aggregate.BuildMetrics(mds, metricRecent), 0, false
+	for i, s := range b.metrics {
+		if s.Name == prev {
+			b.sel = i
+		}
+	}
+	m.renderMetricBrowser()
+}
+
+func (m *Model) renderMetricBrowser() {
+	b := &m.metricBrowser
+	if b.open {
+		m.renderMetricPoints()
+		return
+	}
+	lines := []string{styles.Status.Render("metrics · enter data points · esc close")}
+	if len(b.metrics) == 0 {
+		lines = append(lines, "", "no metrics seen yet")
+	}
+	nameWidth := 20
+	for _, s := range b.metrics {
+		nameWidth = max(nameWidth, min(len(s.Name), 60))
+	}
+	for i, s := range b.metrics {
+		line := fmt.Sprintf("  %-*s  %-21s %-8s %8d points", nameWidth, truncateValue(s.Name, 60), s.Type, truncateValue(s.Unit, 8), s.Points)
+		if i == b.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+func (m *Model) renderMetricPoints() {
+	s := m.metricBrowser.metrics[m.metricBrowser.sel]
+	title := fmt.Sprintf("%s · %s", s.Name, s.Type)
+	if s.Unit != "" {
+		title += " · " + s.Unit
+	}
+	lines := []string{styles.Status.Render(title + " · esc back")}
+	if s.Description != "" {
+		lines = append(lines, s.Description)
+	}
+	if len(s.Recent) < s.Points {
+		lines = append(lines, fmt.Sprintf("newest %d of %d data points", len(s.Recent), s.Points))
+	}
+	lines = append(lines, "")
+	for i := len(s.Recent) - 1; i >= 0; i-- {
+		p := s.Recent[i]
+		value := p.Value
+		if p.Rate != "" {
+			value += " (" + p.Rate + ")"
+		}
+		lines = append(lines, fmt.Sprintf("  %s  %-32s %s", p.Time.Format(clockLayout), value, p.Labels))
+	}
+	m.overlayLines = lines
+}
+
+// metricBrowserKey handles keys while the browser is open and reports
+// whether it consumed msg. esc steps back from the data points to the list
+// before closing.
+func (m *Model) metricBrowserKey(msg tea.KeyMsg) bool {
+	b := &m.metricBrowser
+	switch {
+	case msg.String() == "esc" && b.open:
+		m.openMetricBrowser()
+		m.scrollToSelection(b.sel + 1)
+	case msg.String() == "esc":
+		m.closeOverlay()
+	case b.open:
+		return false // the viewport scrolls the data points
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		b.sel = max(b.sel-1, 0)
+		m.renderMetricBrowser()
+		m.scrollToSelection(b.sel + 1)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		b.sel = max(min(b.sel+1, len(b.metrics)-1), 0)
+		m.renderMetricBrowser()
+		m.scrollToSelection(b.sel + 1)
+	case msg.String() == "enter" && len(b.metrics) > 0:
+		b.open = true
+		m.renderMetricBrowser()
+		m.viewport.SetYOffset(0)
+		m.syncViewport()
+	default:
+		return false
+	}
+	return true
+}
+
+// scrollToSelection scrolls overlay line into view, keeping the title
+// visible when the first row is selected.
+func (m *Model) scrollToSelection(line int) {
+	if line == 1 {
+		line = 0
+	}
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+	m.syncViewport()
+}
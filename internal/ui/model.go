@@ -2,16 +2,18 @@ package ui
 
 import (
 	"context"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
-	"golang.design/x/clipboard"
 
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/script"
 	"github.com/jwafle/otail/internal/telemetry"
 	"github.com/jwafle/otail/internal/transport"
 )
@@ -21,31 +23,107 @@ const cursorBuffer = 3
 
 // Model is the Bubble Tea model driving the UI.
 type Model struct {
-	stream *transport.Stream
-	cancel context.CancelFunc
+	stream    *transport.Stream
+	cancel    context.CancelFunc
+	dial      dialFunc
+	newSource func(spec string) (transport.Source, error) // for :endpoint; nil = unavailable
+	scripts   *script.Set                                 // for :script; nil = none
+	reproURL  string                                      // OTLP/HTTP endpoint Y and :repro send to; empty = DefaultReproURL
+	traceURL  string                                      // page U opens a trace on; empty = U only says to set it
+	theme     string                                      // name of the color theme in use
+	debug     *slog.Logger                                // --debug-log; discards when not given
+
+	parser       *parsePool // parses frames from stream; nil without one
+	parseWorkers int        // goroutines in each parser; 0 = one per CPU
 
 	spinner spinner.Model
 	help    help.Model
 	ready   bool
 	paused  bool
+	closed  bool // stream ended; waiting for the user to reconnect
+
+	viewport      Viewport
+	width, height int // terminal size
+
+	histogram bool // latency panel toggled on (shown on the Logs and Traces tabs)
+	histScope histogramScope
+	latency   aggregate.Latency
+	pipeline  aggregate.Pipeline
+	order     aggregate.Order
+
+	timestamps timestamps // what the gutter shows
+	ageTicks   int        // bumped to retire the previous age ticker
 
-	viewport Viewport
+	overlay       overlay  // view replacing the message list, if any
+	overlayLines  []string // rendered overlay content
+	explorer      explorer
+	metricBrowser metricBrowser
+	exemplars     exemplarPicker
+	traceDetail   traceDetail
+	groups        groupView
+	columns       columnPicker
+	scopes        scopePicker
+	bookmarks     bookmarkState
+	search        searchState
+	visual        selection
+	palette       palette
+	noWrap        bool // cut long lines at the screen edge instead of wrapping them
+	zen           bool // hide the chrome around the viewport
+	reflowing     reflowState
 
-	cur    cursor
-	store  messageStore
-	Active telemetry.Kind
+	table        *logTable // log table mode; nil = JSON view
+	tableColumns []string  // columns for a newly opened table
+
+	alerts     alerts
+	snapshots  snapshots
+	config     *configWatch // the config file, applied again as it changes; nil = not watched
+	presets    presets
+	sources    sourcePicker
+	prompt     prompt
+	jq         jqPanel
+	pipe       pipePanel
+	undo       []undoEntry
+	undone     string             // what the last u undid, shown until the next key
+	history    []string           // : commands run, oldest first
+	clipNote   string             // how the last y copied, shown until the next key
+	commandErr error              // why the last : command failed, shown until the next key
+	editorErr  error              // why the last $EDITOR failed; shown in the status bar
+	marked     *telemetry.Message // left side of the next diff
+	session    *session
+	perf       *perfStats // for the debug panel
+	budget     memoryBudget
+	normalizer *telemetry.Normalizer // converts sums as they arrive; nil = as received
+
+	cur       cursor
+	tabStates map[telemetry.Kind]tabState // where inactive tabs were left
+	store     *messageStore               // the buffer on screen: live, or a snapshot
+	live      *messageStore               // every message received
+	styled    *styleCache
+	conn      transport.State
+	Active    telemetry.Kind
 
 	err error
 }
 
-func newModel(stream *transport.Stream, cancel context.CancelFunc, active telemetry.Kind) Model {
-	return Model{
+func newModel(stream *transport.Stream, cancel context.CancelFunc, dial dialFunc, active telemetry.Kind) Model {
+	m := Model{
 		stream:  stream,
 		cancel:  cancel,
+		dial:    dial,
 		spinner: spinner.New(),
 		help:    help.New(),
+		styled:  &styleCache{},
+		session: newSession(),
+		perf:    newPerfStats(),
+		budget:  newMemoryBudget(0),
+		debug:   slog.New(slog.DiscardHandler),
+		presets: presets{counts: &presetCounts{gen: -1}},
 		Active:  active,
 	}
+	m.parser = newParsePool(stream, 0, m.perf)
+	m.live = &messageStore{strings: telemetry.NewInterner()}
+	m.store = m.live
+	return m
 }
 
 func (m *Model) activeMessages() []telemetry.Message {
@@ -53,22 +131,19 @@ func (m *Model) activeMessages() []telemetry.Message {
 }
 
 func (m *Model) totalLines() int {
+	if m.tableMode() {
+		m.table.update(m.store)
+		return len(m.table.view())
+	}
 	return m.store.TotalLines(m.Active)
 }
 
 func (m *Model) cursorMsgIndex() int {
-	line := 0
-	msgs := m.activeMessages()
-	for i, msg := range msgs {
-		if m.cur.line < line+len(msg.IndentedLines) {
-			return i
-		}
-		line += len(msg.IndentedLines)
+	line := min(m.cur.line, m.totalLines()-1)
+	if i, _, ok := m.store.Locate(m.Active, line); ok {
+		return i
 	}
-	if len(msgs) == 0 {
-		return 0
-	}
-	return len(msgs) - 1
+	return 0
 }
 
 func (m *Model) ensureCursorVisible() {
@@ -103,51 +178,285 @@ func (m *Model) cursorDown() {
 	}
 }
 
+// reconnect replaces a closed stream with a freshly dialed one. Buffered
+// messages are kept.
+func (m *Model) reconnect() tea.Cmd {
+	m.cancel()
+	m.debug.Info("reconnecting")
+	stream, cancel, err := m.dial()
+	if err != nil {
+		m.debug.Error("reconnect failed", "err", err)
+		m.err = err
+		return nil
+	}
+	m.session.retire(m.stream)
+	m.parser.stop()
+	m.parser = newParsePool(stream, m.parseWorkers, m.perf)
+	m.stream, m.cancel = stream, cancel
+	m.conn = transport.State{}
+	m.closed = false
+	m.err = nil
+	return tea.Batch(readFrame(m.parser), waitState(m.stream))
+}
+
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
-		readFrame(m.stream),
+		readFrame(m.parser),
+		waitState(m.stream),
+		m.config.tick(),
 	)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer m.noteSlow("update", time.Now(), msg)
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.undone, m.commandErr, m.bookmarks.note, m.visual.note, m.clipNote, m.search.note = "", nil, "", "", "", ""
+		if m.config != nil {
+			m.config.note = ""
+		}
+		if m.prompt.active {
+			return m, m.promptKey(msg)
+		}
+		if m.bookmarks.pending != "" {
+			m.bookmarkLetter(msg)
+			return m, nil
+		}
+		if m.overlay == overlaySnapshots && m.snapshotsKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayPresets && m.presetsKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlaySources && m.sourcesKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayJQ {
+			if cmd, ok := m.jqKey(msg); ok {
+				return m, cmd
+			}
+		}
+		if m.overlay == overlayPipe {
+			if cmd, ok := m.pipeKey(msg); ok {
+				return m, cmd
+			}
+		}
+		if m.overlay == overlayPalette {
+			return m, m.paletteKey(msg)
+		}
+		if (m.overlay == overlayDiff || m.overlay == overlayHelp || m.overlay == overlayPerf) && msg.String() == "esc" {
+			m.closeOverlay()
+			return m, nil
+		}
+		if m.overlay == overlayAttributes && m.explorerKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayColumns && m.columnsKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayScopes && m.scopesKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayBookmarks && m.bookmarksKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayMetrics && m.metricBrowserKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayExemplars && m.exemplarsKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayGroups && m.groupsKey(msg) {
+			return m, nil
+		}
+		if m.overlay == overlayTrace && m.traceDetailKey(msg) {
+			return m, nil
+		}
 		switch {
 		case key.Matches(msg, Keys.Quit):
 			m.cancel()
 			return m, tea.Quit
 		case key.Matches(msg, Keys.Logs):
-			m.Active = telemetry.KindLogs
-			m.syncViewport()
+			m.switchTab(telemetry.KindLogs)
 		case key.Matches(msg, Keys.Metrics):
-			m.Active = telemetry.KindMetrics
-			m.syncViewport()
+			m.switchTab(telemetry.KindMetrics)
 		case key.Matches(msg, Keys.Traces):
-			m.Active = telemetry.KindTraces
-			m.syncViewport()
-		case key.Matches(msg, Keys.Pause):
+			m.switchTab(telemetry.KindTraces)
+		case key.Matches(msg, Keys.Other):
+			m.switchTab(telemetry.KindUnknown)
+		case key.Matches(msg, Keys.NextTab):
+			m.cycleTab(1)
+		case key.Matches(msg, Keys.PrevTab):
+			m.cycleTab(-1)
+		case key.Matches(msg, Keys.Pause) && !m.viewingSnapshot():
 			m.paused = !m.paused
+			m.visual.active = false
+			if !m.paused && m.table != nil {
+				m.table.clearSort()
+			}
 			if m.paused {
 				m.cur.line = m.viewport.YOffset + m.viewport.VisibleLineCount() - 1
 				if m.cur.line < 0 {
 					m.cur.line = 0
 				}
 			}
+		case key.Matches(msg, Keys.ServiceMap):
+			return m, m.toggleOverlay(overlayServiceMap)
+		case key.Matches(msg, Keys.Lint):
+			return m, m.toggleOverlay(overlayLint)
+		case key.Matches(msg, Keys.Debug):
+			return m, m.toggleOverlay(overlayPerf)
+		case key.Matches(msg, Keys.Attributes):
+			return m, m.toggleOverlay(overlayAttributes)
+		case key.Matches(msg, Keys.Table):
+			if m.table == nil {
+				m.table = newLogTable(m.tableColumns)
+			} else {
+				m.table = nil
+			}
+			m.viewport.SetTotal(m.totalLines())
+			if !m.paused {
+				m.viewport.GotoBottom()
+			}
+			m.syncViewport()
+		case m.Active == telemetry.KindMetrics && key.Matches(msg, Keys.MetricNames):
+			return m, m.toggleOverlay(overlayMetrics)
+		case key.Matches(msg, Keys.Columns):
+			return m, m.toggleOverlay(overlayColumns)
+		case key.Matches(msg, Keys.Snapshot):
+			return m, m.startSnapshot()
+		case key.Matches(msg, Keys.Snapshots):
+			return m, m.toggleOverlay(overlaySnapshots)
+		case key.Matches(msg, Keys.Presets):
+			return m, m.toggleOverlay(overlayPresets)
+		case key.Matches(msg, Keys.Saved):
+			m.presets.sidebar = !m.presets.sidebar
+		case key.Matches(msg, Keys.Sources):
+			return m, m.toggleOverlay(overlaySources)
+		case m.paused && m.overlay == overlayNone && !m.tableMode() && key.Matches(msg, Keys.Bookmark):
+			m.bookmarks.pending = "`"
+		case key.Matches(msg, Keys.Jump):
+			m.bookmarks.pending = "'"
+		case m.overlay == overlayNone && key.Matches(msg, Keys.Search):
+			return m, m.startSearch()
+		case m.overlay == overlayNone && key.Matches(msg, Keys.Next):
+			m.searchNext(1)
+		case m.overlay == overlayNone && key.Matches(msg, Keys.Prev):
+			m.searchNext(-1)
+		case key.Matches(msg, Keys.Bookmarks):
+			return m, m.toggleOverlay(overlayBookmarks)
+		case key.Matches(msg, Keys.Wrap):
+			return m, m.toggleWrap()
+		case key.Matches(msg, Keys.Zen):
+			m.toggleZen()
+		case key.Matches(msg, Keys.Scopes):
+			return m, m.toggleOverlay(overlayScopes)
+		case key.Matches(msg, Keys.Help):
+			return m, m.toggleOverlay(overlayHelp)
+		case key.Matches(msg, Keys.Palette):
+			return m, m.toggleOverlay(overlayPalette)
+		case key.Matches(msg, Keys.Groups):
+			return m, m.toggleOverlay(overlayGroups)
+		case key.Matches(msg, Keys.Preset):
+			m.applyPreset(presetIndex(msg))
+		case m.paused && m.tableMode() && key.Matches(msg, Keys.Sort):
+			m.table.cycleSort()
+			m.viewport.SetYOffset(0)
+			m.syncViewport()
+		case key.Matches(msg, Keys.SortKeys):
+			f := telemetry.CurrentFormat()
+			f.ReceivedOrder = !f.ReceivedOrder
+			m.setFormat(f)
+		case key.Matches(msg, Keys.Flatten):
+			f := telemetry.CurrentFormat()
+			f.RawAttributes = !f.RawAttributes
+			m.setFormat(f)
+		case key.Matches(msg, Keys.Shallower):
+			f := telemetry.CurrentFormat()
+			f.MaxDepth = shallower(f.MaxDepth)
+			m.setFormat(f)
+		case key.Matches(msg, Keys.Deeper):
+			f := telemetry.CurrentFormat()
+			f.MaxDepth = deeper(f.MaxDepth)
+			m.setFormat(f)
+		case key.Matches(msg, Keys.Hex):
+			f := telemetry.CurrentFormat()
+			f.Hex = !f.Hex
+			m.setFormat(f)
+		case key.Matches(msg, Keys.Compact):
+			f := telemetry.CurrentFormat()
+			f.Compact = !f.Compact
+			m.setFormat(f)
+		case key.Matches(msg, Keys.Bodies):
+			f := telemetry.CurrentFormat()
+			f.Bodies = !f.Bodies
+			m.setFormat(f)
+		case key.Matches(msg, Keys.Timestamps):
+			return m, m.cycleTimestamps()
+		case key.Matches(msg, Keys.Clear):
+			m.clearBuffers(m.Active)
+		case key.Matches(msg, Keys.Command):
+			return m, m.startCommand("")
+		case key.Matches(msg, Keys.Undo):
+			m.popUndo()
+		case key.Matches(msg, Keys.ClearFilter):
+			m.setFilter(filter.Filter{})
+		case key.Matches(msg, Keys.Histogram):
+			m.histogram = !m.histogram
+			m.syncViewport()
+		case m.showHistogram() && key.Matches(msg, Keys.HistogramScope):
+			m.cycleHistScope()
+		case m.closed && key.Matches(msg, Keys.Reconnect):
+			return m, m.reconnect()
+		case m.paused && key.Matches(msg, Keys.Mark):
+			m.markMessage()
+		case m.paused && m.overlay == overlayNone && key.Matches(msg, Keys.Diff):
+			m.openDiff()
+		case m.paused && m.overlay == overlayNone && key.Matches(msg, Keys.JQ):
+			return m, m.startJQ()
+		case m.paused && m.overlay == overlayNone && key.Matches(msg, Keys.Pipe):
+			return m, m.startPipe()
+		case m.Active == telemetry.KindTraces && key.Matches(msg, Keys.ErrorSpans):
+			m.toggleErrorSpans()
+		case m.paused && m.overlay == overlayNone && m.Active == telemetry.KindMetrics && key.Matches(msg, Keys.Exemplars):
+			return m, m.toggleOverlay(overlayExemplars)
+		case m.paused && m.overlay == overlayNone && m.Active == telemetry.KindTraces && key.Matches(msg, Keys.Trace):
+			return m, m.toggleOverlay(overlayTrace)
+		case m.paused && m.overlay == overlayNone && key.Matches(msg, Keys.Editor):
+			m.editorErr = nil
+			return m, m.openInEditor()
+		case (m.paused || m.overlay == overlayTrace) && key.Matches(msg, Keys.OpenTrace):
+			m.openTrace()
+			return m, nil
+		case m.paused && key.Matches(msg, Keys.Repro):
+			m.yankRepro("curl")
+			return m, nil
 		case m.paused && key.Matches(msg, Keys.Yank):
-			if m.cur.msg == nil {
+			msgs := m.takeSelection()
+			if len(msgs) == 0 {
 				return m, nil
 			}
-			clipboard.Write(clipboard.FmtText, []byte(strings.Join(m.cur.msg.IndentedLines, "\n")))
+			m.copyLines(selectedLines(msgs))
 			return m, nil
-		case m.paused && key.Matches(msg, m.viewport.KeyMap.Up):
+		case m.paused && m.overlay == overlayNone && !m.tableMode() && key.Matches(msg, Keys.Visual):
+			m.toggleSelection()
+		case m.paused && m.overlay == overlayNone && !m.tableMode() && key.Matches(msg, Keys.Expand) && m.toggleValue():
+			return m, nil
+		case m.visual.active && msg.String() == "esc":
+			m.toggleSelection()
+		case m.overlay == overlayTrace && key.Matches(msg, Keys.Write):
+			return m, m.startTraceExport()
+		case m.paused && m.overlay == overlayNone && key.Matches(msg, Keys.Write):
+			return m, m.startExport()
+		case m.paused && m.overlay == overlayNone && !m.tableMode() && key.Matches(msg, m.viewport.KeyMap.Up):
 			m.cursorUp()
 			m.ensureCursorVisible()
 			m.syncViewport()
 			return m, nil
-		case m.paused && key.Matches(msg, m.viewport.KeyMap.Down):
+		case m.paused && m.overlay == overlayNone && !m.tableMode() && key.Matches(msg, m.viewport.KeyMap.Down):
 			m.cursorDown()
 			m.ensureCursorVisible()
 			m.syncViewport()
@@ -157,27 +466,131 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.help, c = m.help.Update(msg)
 		cmds = append(cmds, c)
 
+	case tea.MouseMsg:
+		m.handleMouse(msg)
+
 	case tea.WindowSizeMsg:
-		verticalMargin := 5
+		m.width, m.height = msg.Width, msg.Height
+		m.debug.Debug("resize", "width", msg.Width, "height", msg.Height)
 		if !m.ready {
-			m.viewport = Viewport{viewport.New(msg.Width, msg.Height-verticalMargin)}
+			m.viewport = newViewport(msg.Width, msg.Height-m.chromeRows())
 			m.ready = true
 		} else {
-			m.viewport.Width, m.viewport.Height = msg.Width, msg.Height-verticalMargin
+			cmds = append(cmds, m.resized())
+		}
+		if m.styled.width != m.textWidth() {
+			m.styled.reset(m.textWidth())
 		}
 		m.syncViewport()
 
-	case telemetry.Message:
+	case frameBatchMsg:
+		now := time.Now()
+		m.session.add(msg, now)
+		for _, fm := range msg {
+			fm.Received = now
+			m.noteUnparsed(fm)
+			m.normalizer.Apply(&fm)
+			fm.Warning = m.order.Check(fm)
+			if c := m.checkAlerts(fm); c != nil {
+				cmds = append(cmds, c)
+			}
+			if fm.Kind == telemetry.KindTraces && !m.tabPaused(fm.Kind) {
+				m.latency.AddTraces(fm.Traces)
+			}
+			if fm.Kind == telemetry.KindLogs && !m.tabPaused(fm.Kind) {
+				m.pipeline.AddLogs(fm.Logs)
+			}
+			for _, k := range m.live.AddExcept(fm, m.tabPaused) {
+				m.noteReceived(k)
+			}
+		}
 		if !m.paused {
-			m.store.Add(msg)
-			m.viewport.GotoBottom()
+			if m.overlay == overlayNone {
+				m.viewport.SetTotal(m.totalLines())
+				m.viewport.GotoBottom()
+				m.syncViewport()
+			}
+		}
+		m.enforceBudget()
+		cmds = append(cmds, readFrame(m.parser))
+
+	case configTickMsg:
+		cmds = append(cmds, m.config.check())
+
+	case configMsg:
+		m.applyConfig(msg)
+		cmds = append(cmds, m.config.tick())
+
+	case stateMsg:
+		if msg.stream != m.stream {
+			break
+		}
+		m.conn = msg.state
+		m.session.noteState(msg.state)
+		cmds = append(cmds, waitState(m.stream))
+
+	case streamErrMsg:
+		if msg.stream != m.stream {
+			break
+		}
+		m.err = msg.err
+		m.closed = true
+		m.debug.Info("stream ended", "err", msg.err)
+
+	case pipeDoneMsg:
+		m.showPipe(msg)
+
+	case editorDoneMsg:
+		m.editorErr = msg.err
+
+	case ageTickMsg:
+		if m.timestamps == timestampsAge && int(msg) == m.ageTicks {
 			m.syncViewport()
+			cmds = append(cmds, ageTick(m.ageTicks))
 		}
-		cmds = append(cmds, readFrame(m.stream))
 
-	case error:
-		m.err = msg
-		return m, tea.Quit
+	case sourcesTickMsg:
+		if m.overlay == overlaySources {
+			m.refreshSources()
+			m.syncViewport()
+			cmds = append(cmds, sourcesTick())
+		}
+
+	case groupsTickMsg:
+		if m.overlay == overlayGroups {
+			m.rebuildGroups()
+			m.syncViewport()
+			cmds = append(cmds, groupsTick())
+		}
+
+	case reflowTickMsg:
+		if int(msg) == m.reflowing.gen {
+			cmds = append(cmds, m.startReflow())
+		}
+
+	case reflowDoneMsg:
+		cmds = append(cmds, m.finishReflow(msg))
+
+	case perfTickMsg:
+		if m.overlay == overlayPerf {
+			m.renderPerf()
+			m.syncViewport()
+			cmds = append(cmds, perfTick())
+		}
+
+	case serviceMapTickMsg:
+		if m.overlay == overlayServiceMap {
+			m.rebuildServiceMap()
+			m.syncViewport()
+			cmds = append(cmds, serviceMapTick())
+		}
+
+	case lintTickMsg:
+		if m.overlay == overlayLint {
+			m.rebuildLint()
+			m.syncViewport()
+			cmds = append(cmds, lintTick())
+		}
 
 	case spinner.TickMsg:
 		var c tea.Cmd
@@ -185,12 +598,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, c)
 	}
 
-	var c tea.Cmd
 	oldOffset := m.viewport.YOffset
-	viewport, c := m.viewport.Update(msg)
-	m.viewport = Viewport{viewport}
-	cmds = append(cmds, c)
-	if m.paused {
+	m.viewport.Update(msg)
+	if m.paused && m.overlay == overlayNone {
 		delta := m.viewport.YOffset - oldOffset
 		if delta != 0 {
 			m.cur.line += delta
@@ -203,69 +613,125 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.ensureCursorVisible()
 		m.syncViewport()
+	} else if m.viewport.Stale() {
+		m.syncViewport()
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
 func (m Model) View() string {
+	defer m.noteSlow("render", time.Now(), nil)
 	var b strings.Builder
 
-	b.WriteString(m.RenderTabs())
-	b.WriteString("\n")
-	b.WriteString(m.viewport.View())
+	if !m.zen {
+		b.WriteString(m.RenderTabs())
+		b.WriteString("\n")
+	}
+	if m.tableMode() {
+		b.WriteString(m.renderTableHeader())
+		b.WriteString("\n")
+	}
+	if m.zen {
+		view := m.viewport.View()
+		if s := m.notice(); s != "" {
+			view = overBottomRow(view, s)
+		}
+		b.WriteString(view)
+		if m.showHistogram() {
+			b.WriteString("\n")
+			b.WriteString(m.renderHistogram())
+		}
+		return b.String()
+	}
+	view := m.viewport.View()
+	if m.showSidebar() {
+		view = m.withSidebar(view)
+	}
+	b.WriteString(view)
 	b.WriteString("\n")
-
-	var status strings.Builder
-	if m.paused {
-		status.WriteString("[PAUSED] ")
+	if m.showDetail() {
+		b.WriteString(m.renderDetail())
+		b.WriteString("\n")
+	}
+	if m.showHistogram() {
+		b.WriteString(m.renderHistogram())
+		b.WriteString("\n")
+	}
+	if s := m.notice(); s != "" {
+		b.WriteString(s)
 	} else {
-		status.WriteString(m.spinner.View())
-		status.WriteString(" Streaming ")
+		b.WriteString(m.renderStatusBar())
 	}
-	status.WriteString(m.Active.String())
-	b.WriteString(statusStyle.Render(status.String()))
 	b.WriteString("\n")
 	b.WriteString(m.help.View(Keys))
 
 	return b.String()
 }
 
+// layout sizes the viewport to the space left by the surrounding chrome.
+func (m *Model) layout() {
+	if !m.ready {
+		return
+	}
+	m.viewport.Width, m.viewport.Height = m.width, max(m.height-m.chromeRows(), 1)
+}
+
+// syncViewport renders the rows around the visible window of the active
+// buffer. Lines outside the window are never styled or joined.
 func (m *Model) syncViewport() {
-	src := m.store.Messages(m.Active)
+	defer m.perf.synced(time.Now())
+	m.layout()
+	if m.overlay != overlayNone {
+		m.syncOverlay()
+		return
+	}
+	if m.tableMode() {
+		m.syncTable()
+		return
+	}
+	m.reflow()
+	if m.styled.width != m.textWidth() || m.styled.gen != m.store.gen || m.styled.wrap != m.store.wrap {
+		m.styled.reset(m.textWidth()) // the gutter was toggled, or messages re-indexed
+		m.styled.gen, m.styled.wrap = m.store.gen, m.store.wrap
+	}
 	total := m.store.TotalLines(m.Active)
 	if m.cur.line >= total {
 		m.cur.line = total - 1
 	}
+	m.viewport.SetTotal(total)
 
-	var b strings.Builder
-	line := 0
-	var current *telemetry.Message
-	for i := range src {
-		highlight := m.paused && i == m.cursorMsgIndex()
-		for j, l := range src[i].IndentedLines {
-			padded := l
-			if highlight || (m.paused && line == m.cur.line) {
-				if w := m.viewport.Width; w > 0 {
-					if diff := w - lipgloss.Width(padded); diff > 0 {
-						padded += strings.Repeat(" ", diff)
-					}
-				}
-			}
-			content := padded
-			if m.paused && line == m.cur.line {
-				content = highlightJSONKeys(content, cursorStyle, cursorJSONKeyStyle)
-				current = &src[i]
-			} else if highlight {
-				content = highlightJSONKeys(content, msgHighlightStyle, msgHighlightJSONKeyStyle)
-			}
-			b.WriteString(content)
-			line++
-			if i < len(src)-1 || j < len(src[i].IndentedLines)-1 {
-				b.WriteString("\n")
-			}
+	cursorMsg, selLo, selHi := -1, -1, -1
+	m.cur.msg = nil
+	if m.paused && total > 0 {
+		cursorMsg = m.cursorMsgIndex()
+		m.cur.msg = m.store.Display(m.Active, cursorMsg)
+		if m.visual.active {
+			selLo, selHi = m.selectionBounds()
+		}
+	}
+
+	start, end := m.viewport.Window()
+	refs := m.store.LineRefs(m.Active, start, end)
+	rows := make([]string, len(refs))
+	now := time.Now()
+	for n, ref := range refs {
+		mode := stylePlain
+		switch {
+		case m.paused && start+n == m.cur.line:
+			mode = styleCursor
+		case ref.msg == cursorMsg || ref.msg >= selLo && ref.msg <= selHi:
+			mode = styleHighlight
+		case m.store.IsContext(m.Active, ref.msg):
+			mode = styleContext
+		}
+		msg := m.store.Display(m.Active, ref.msg)
+		gutterLine := ref.line
+		if ref.part > 0 {
+			gutterLine = -1 // a wrapped row
 		}
+		rows[n] = m.gutter(msg, gutterLine, now) +
+			m.styled.line(m.Active, ref.msg, msg, ref.line, ref.part, mode)
 	}
-	m.cur.msg = current
-	m.viewport.SetContent(b.String())
+	m.viewport.SetRows(start, rows)
 }
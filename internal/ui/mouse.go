@@ -0,0 +1,35 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// handleMouse hit-tests a mouse event against the rendered layout. Wheel
+// events are left to the viewport, which scrolls in both modes.
+func (m *Model) handleMouse(msg tea.MouseMsg) {
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return
+	}
+
+	top := m.viewportTop()
+	switch {
+	case !m.zen && msg.Y < tabHeight:
+		if kind, ok := m.tabAt(msg.X); ok {
+			m.switchTab(kind)
+		}
+	case msg.Y >= top && msg.Y < top+m.viewport.Height:
+		line := m.viewport.YOffset + msg.Y - top
+		if m.overlay == overlayExemplars {
+			m.showExemplarTrace(line - 1) // below the title
+			return
+		}
+		if m.overlay == overlayTrace {
+			m.clickTraceLine(line)
+			return
+		}
+		if line >= m.totalLines() {
+			return
+		}
+		m.paused = true
+		m.cur.line = line
+		m.syncViewport()
+	}
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/jwafle/otail/internal/browser"
+)
+
+// U opens the trace of the message under the cursor, or the trace shown
+// by T, in an external APM such as Jaeger or Tempo: Config.TraceURL is
+// filled in with its IDs and opened in the browser. A log record is
+// opened by the trace it was logged in.
+
+// traceURL fills in the placeholders of template: {traceID}, {spanID},
+// and {service}, escaped for use anywhere in a URL.
+func traceURL(template string, trace pcommon.TraceID, span pcommon.SpanID, service string) string {
+	return strings.NewReplacer(
+		"{traceID}", trace.String(),
+		"{spanID}", span.String(),
+		"{service}", url.QueryEscape(service),
+	).Replace(template)
+}
+
+// openTrace opens the selected trace's page.
+func (m *Model) openTrace() {
+	if m.traceURL == "" {
+		m.clipNote = "open: set --trace-url, e.g. https://jaeger.example.com/trace/{traceID}"
+		return
+	}
+	var trace pcommon.TraceID
+	var span pcommon.SpanID
+	var service string
+	switch {
+	case m.overlay == overlayTrace:
+		t := m.traceDetail.trace
+		trace = t.ID
+		for _, s := range t.Spans {
+			if s.ID == m.traceDetail.focus || span.IsEmpty() {
+				span, service = s.ID, s.Service
+			}
+		}
+	case m.cur.msg != nil:
+		sum := m.cur.msg.Summary()
+		trace, span = sum.TraceID, sum.SpanID
+		if len(sum.Services) > 0 {
+			service = sum.Services[0]
+		}
+	default:
+		m.clipNote = "open: pause (p) and put the cursor on a message first"
+		return
+	}
+	if trace.IsEmpty() {
+		m.clipNote = "open: the message has no trace ID"
+		return
+	}
+	u := traceURL(m.traceURL, trace, span, service)
+	if err := browser.Open(u); err != nil {
+		m.clipNote = "open: " + err.Error()
+		return
+	}
+	m.clipNote = "opened " + u
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// overlay is a view that temporarily replaces the message list.
+type overlay int
+
+const (
+	overlayNone overlay = iota
+	overlayServiceMap
+	overlayAttributes
+	overlayColumns
+	overlaySnapshots
+	overlayDiff
+	overlayJQ
+	overlayPipe
+	overlayPresets
+	overlaySources
+	overlayMetrics
+	overlayExemplars
+	overlayTrace
+	overlayGroups
+	overlayScopes
+	overlayBookmarks
+	overlayHelp
+	overlayPalette
+	overlayPerf
+	overlayLint
+)
+
+// toggleOverlay opens o, or closes it if it is already open.
+func (m *Model) toggleOverlay(o overlay) tea.Cmd {
+	if m.overlay == o {
+		m.closeOverlay()
+		return nil
+	}
+	m.overlay = o
+	m.viewport.SetYOffset(0)
+	var cmd tea.Cmd
+	switch o {
+	case overlayServiceMap:
+		m.rebuildServiceMap()
+		cmd = serviceMapTick()
+	case overlayAttributes:
+		m.openExplorer()
+	case overlayMetrics:
+		m.openMetricBrowser()
+	case overlayExemplars:
+		m.openExemplars()
+	case overlayTrace:
+		m.openTraceDetail()
+	case overlayColumns:
+		m.openColumnPicker()
+	case overlaySnapshots:
+		m.openSnapshotPicker()
+	case overlayPresets:
+		m.renderPresetPicker()
+	case overlaySources:
+		m.refreshSources()
+		cmd = sourcesTick()
+	case overlayBookmarks:
+		m.openBookmarks()
+	case overlayHelp:
+		m.renderKeyHelp()
+	case overlayPalette:
+		cmd = m.openPalette()
+	case overlayScopes:
+		m.openScopePicker()
+	case overlayGroups:
+		m.rebuildGroups()
+		cmd = groupsTick()
+	case overlayPerf:
+		m.renderPerf()
+		cmd = perfTick()
+	case overlayLint:
+		m.rebuildLint()
+		cmd = lintTick()
+	}
+	m.syncViewport()
+	return cmd
+}
+
+// closeOverlay returns to the message list, following the stream again
+// unless paused, in which case the cursor is brought back into view.
+func (m *Model) closeOverlay() {
+	m.overlay = overlayNone
+	m.overlayLines = nil
+	m.viewport.SetTotal(m.totalLines())
+	if m.paused {
+		m.ensureCursorVisible()
+	} else {
+		m.viewport.GotoBottom()
+	}
+	m.syncViewport()
+}
+
+// syncOverlay shows the overlay's lines in the viewport.
+func (m *Model) syncOverlay() {
+	if (m.overlay == overlayMetrics || m.overlay == overlayExemplars) && m.Active != telemetry.KindMetrics {
+		m.closeOverlay() // both belong to the Metrics tab
+		return
+	}
+	if m.overlay == overlayTrace && m.Active != telemetry.KindTraces {
+		m.closeOverlay()
+		return
+	}
+	if m.overlay == overlayAttributes && m.explorer.kind != m.Active {
+		m.openExplorer()
+	}
+	if m.overlay == overlayGroups && m.groups.kind != m.Active {
+		m.rebuildGroups()
+	}
+	lines := m.overlayLines
+	m.viewport.SetTotal(len(lines))
+	start, end := m.viewport.Window()
+	m.viewport.SetRows(start, lines[max(start, 0):min(end, len(lines))])
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// paletteAction is one thing the command palette can run: a binding, which
+// it runs by pressing the binding's key, or a : command.
+type paletteAction struct {
+	name    string // what the palette lists and matches against
+	key     string // shortcut shown beside the name, if any
+	press   string // key to press
+	command string // : command to run, or to start typing when it ends in a space
+}
+
+// paletteCommands are the : commands offered alongside the bindings.
+var paletteCommands = []paletteAction{
+	{name: "clear every tab", command: "clear all"},
+	{name: "tail-sample traces", command: "sample "},
+	{name: "show messages around filter matches", command: "context "},
+	{name: "group by an attribute", command: "group "},
+}
+
+// palette is the ctrl+p command palette: a query and the actions that match
+// it, best first.
+type palette struct {
+	input   textinput.Model
+	actions []paletteAction
+	matches []paletteAction
+	sel     int
+}
+
+// paletteActions lists every binding, each preset, and the : commands.
+func (m *Model) paletteActions() []paletteAction {
+	var out []paletteAction
+	for _, s := range Keys.Sections() {
+		for _, b := range s.keys {
+			if h := b.Help().Key; h == Keys.Preset.Help().Key || h == Keys.Palette.Help().Key {
+				continue // presets are listed by name below
+			}
+			out = append(out, paletteAction{name: b.Help().Desc, key: b.Help().Key, press: b.Keys()[0]})
+		}
+	}
+	for i, p := range m.presets.list {
+		if i < 9 {
+			out = append(out, paletteAction{name: "preset " + p.Name, key: fmt.Sprint(i + 1), press: fmt.Sprint(i + 1)})
+		}
+	}
+	for _, c := range paletteCommands {
+		c.key = ":" + strings.TrimSpace(c.command)
+		out = append(out, c)
+	}
+	return out
+}
+
+// openPalette starts an empty query over every action.
+func (m *Model) openPalette() tea.Cmd {
+	in := textinput.New()
+	in.Prompt = "> "
+	m.palette = palette{input: in, actions: m.paletteActions()}
+	m.matchPalette()
+	return m.palette.input.Focus()
+}
+
+// matchPalette keeps the actions whose name or key fuzzily match the query,
+// best first.
+func (m *Model) matchPalette() {
+	p := &m.palette
+	type scored struct {
+		a     paletteAction
+		score int
+	}
+	var found []scored
+	q := p.input.Value()
+	for _, a := range p.actions {
+		s, ok := fuzzyScore(a.name, q)
+		if ks, kok := fuzzyScore(a.key, q); kok && (!ok || ks < s) {
+			s, ok = ks, true
+		}
+		if ok {
+			found = append(found, scored{a, s})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b scored) int { return a.score - b.score })
+	p.matches = p.matches[:0]
+	for _, f := range found {
+		p.matches = append(p.matches, f.a)
+	}
+	p.sel = min(p.sel, max(len(p.matches)-1, 0))
+	m.renderPalette()
+}
+
+// fuzzyScore reports whether the runes of query appear in s in order,
+// ignoring case, and scores the match by how far apart they are and how
+// late the first one comes; lower is better.
+func fuzzyScore(s, query string) (int, bool) {
+	score, pos, last := 0, 0, -1
+	rs := []rune(strings.ToLower(s))
+	for _, q := range strings.ToLower(query) {
+		if unicode.IsSpace(q) {
+			continue
+		}
+		i := slices.Index(rs[pos:], q)
+		if i < 0 {
+			return 0, false
+		}
+		if last < 0 {
+			score += pos + i
+		} else {
+			score += pos + i - last - 1
+		}
+		last = pos + i
+		pos = last + 1
+	}
+	return score, true
+}
+
+func (m *Model) renderPalette() {
+	p := &m.palette
+	lines := []string{
+		styles.Status.Render("command palette · ↑/↓ select · enter run · esc close"),
+		p.input.View(),
+	}
+	if len(p.matches) == 0 {
+		lines = append(lines, "", "no matching actions")
+	}
+	for i, a := range p.matches {
+		line := fmt.Sprintf("  %-44s %s", a.name, a.key)
+		if i == p.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+// paletteKey handles every key while the palette is open; keys that do not
+// move the selection or run it edit the query.
+func (m *Model) paletteKey(msg tea.KeyMsg) tea.Cmd {
+	p := &m.palette
+	switch {
+	case msg.String() == "esc" || key.Matches(msg, Keys.Palette):
+		m.closeOverlay()
+		return nil
+	case msg.String() == "enter":
+		if len(p.matches) == 0 {
+			return nil
+		}
+		a := p.matches[p.sel]
+		m.closeOverlay()
+		return m.runAction(a)
+	case msg.String() == "up":
+		p.sel = max(p.sel-1, 0)
+	case msg.String() == "down":
+		p.sel = max(min(p.sel+1, len(p.matches)-1), 0)
+	default:
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		p.sel = 0
+		m.matchPalette()
+		m.syncViewport()
+		return cmd
+	}
+	m.renderPalette()
+	m.scrollToSelection(p.sel + 2)
+	return nil
+}
+
+// runAction does what a as if its key had been pressed or its command
+// typed.
+func (m *Model) runAction(a paletteAction) tea.Cmd {
+	switch {
+	case strings.HasSuffix(a.command, " "):
+		return m.startCommand(a.command)
+	case a.command != "":
+		return submitCommand(m, a.command)
+	}
+	next, cmd := m.Update(pressKey(a.press))
+	*m = next.(Model)
+	return cmd
+}
+
+// pressKey returns the key message for a binding's key.
+func pressKey(k string) tea.KeyMsg {
+	switch k {
+	case "ctrl+l":
+		return tea.KeyMsg{Type: tea.KeyCtrlL}
+	case "ctrl+c":
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
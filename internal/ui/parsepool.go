@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/transport"
+)
+
+// A parsePool sits between a stream and Update: it parses frames on a few
+// goroutines as they arrive, so the JSON work of a burst runs alongside
+// rendering instead of inside the command that feeds Update. Messages come
+// out in the order their frames came in.
+type parsePool struct {
+	stream  *transport.Stream
+	workers int // 0 = one per CPU
+	perf    *perfStats
+
+	once sync.Once
+	out  chan telemetry.Message // closed once the stream ends; err says why
+	quit chan struct{}          // closed by stop
+	err  error
+}
+
+// newParsePool returns a pool for s; it starts on the first read. It
+// returns nil for a nil stream.
+func newParsePool(s *transport.Stream, workers int, perf *perfStats) *parsePool {
+	if s == nil {
+		return nil
+	}
+	return &parsePool{
+		stream:  s,
+		workers: workers,
+		perf:    perf,
+		out:     make(chan telemetry.Message, maxFramesPerBatch),
+		quit:    make(chan struct{}),
+	}
+}
+
+// buffered reports how many parsed messages are waiting for Update, and how
+// many can wait before the pool stops taking frames from the stream.
+func (p *parsePool) buffered() (n, capacity int) {
+	if p == nil {
+		return 0, 0
+	}
+	return len(p.out), cap(p.out)
+}
+
+// parseJob is one frame and where its message goes.
+type parseJob struct {
+	frame []byte
+	done  chan telemetry.Message
+}
+
+func (p *parsePool) start() {
+	p.once.Do(func() {
+		n := p.workers
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		jobs := make(chan parseJob, n)
+		order := make(chan chan telemetry.Message, n*4)
+		for range n {
+			go p.work(jobs)
+		}
+		go p.dispatch(jobs, order)
+		go p.collect(order)
+	})
+}
+
+// stop abandons the pool, for when its stream is replaced.
+func (p *parsePool) stop() {
+	if p != nil {
+		close(p.quit)
+	}
+}
+
+// dispatch hands each frame to a worker and queues where its message will
+// appear, so collect can put them back in arrival order.
+func (p *parsePool) dispatch(jobs chan<- parseJob, order chan<- chan telemetry.Message) {
+	defer close(order)
+	defer close(jobs)
+	for {
+		var frame []byte
+		select {
+		case b, ok := <-p.stream.Messages():
+			if !ok {
+				p.err = fmt.Errorf("stream closed")
+				return
+			}
+			frame = b
+		case err, ok := <-p.stream.Errors():
+			if !ok {
+				err = fmt.Errorf("stream error channel closed")
+			}
+			p.err = err
+			return
+		case <-p.quit:
+			return
+		}
+		done := make(chan telemetry.Message, 1)
+		select {
+		case jobs <- parseJob{frame, done}:
+		case <-p.quit:
+			return
+		}
+		select {
+		case order <- done:
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+func (p *parsePool) work(jobs <-chan parseJob) {
+	for j := range jobs {
+		start := time.Now()
+		msg := telemetry.Parse(j.frame)
+		p.perf.parsed(len(j.frame), time.Since(start))
+		j.done <- msg
+	}
+}
+
+func (p *parsePool) collect(order <-chan chan telemetry.Message) {
+	defer close(p.out)
+	for done := range order {
+		select {
+		case p.out <- <-done:
+		case <-p.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The debug panel (ctrl+alt+d, left out of the key help) shows how otail
+// itself is doing, to put numbers on "otail feels laggy": the sizes of the
+// frames coming in, how long they take to parse and the viewport to lay
+// out, and what the garbage collector is up to.
+
+// perfRefresh is how often the open debug panel is redrawn.
+const perfRefresh = time.Second
+
+// perfSamples is how many of the latest timings percentiles are taken over.
+const perfSamples = 1024
+
+// sizeBuckets are the upper bounds of the frame size histogram; larger
+// frames land in a last, open bucket.
+var sizeBuckets = []int{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+
+// perfStats collects the debug panel's numbers. Frames are parsed off the
+// Update goroutine, so it is safe for concurrent use; a nil *perfStats
+// ignores everything.
+type perfStats struct {
+	mu     sync.Mutex
+	frames int
+	bytes  int64
+	sizes  []int // frames by sizeBuckets, plus one for larger
+	parse  timings
+	sync   timings
+}
+
+func newPerfStats() *perfStats {
+	return &perfStats{sizes: make([]int, len(sizeBuckets)+1)}
+}
+
+// timings keeps the latest perfSamples durations of something, and the
+// slowest ever.
+type timings struct {
+	ring  [perfSamples]time.Duration
+	n     int // durations recorded in all
+	worst time.Duration
+}
+
+func (t *timings) add(d time.Duration) {
+	t.ring[t.n%perfSamples] = d
+	t.n++
+	t.worst = max(t.worst, d)
+}
+
+// summary returns the median, 90th, and 99th percentiles of the kept
+// durations, and the slowest ever.
+func (t *timings) summary() string {
+	if t.n == 0 {
+		return "none yet"
+	}
+	kept := slices.Clone(t.ring[:min(t.n, perfSamples)])
+	slices.Sort(kept)
+	q := func(p float64) string { return shortDuration(kept[int(p*float64(len(kept)-1))]) }
+	return fmt.Sprintf("p50 %s · p90 %s · p99 %s · max %s · %d in all", q(0.5), q(0.9), q(0.99), shortDuration(t.worst), t.n)
+}
+
+// parsed records a frame of size bytes that took d to parse.
+func (p *perfStats) parsed(size int, d time.Duration) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.frames++
+	p.bytes += int64(size)
+	i, _ := slices.BinarySearch(sizeBuckets, size)
+	p.sizes[i]++
+	p.parse.add(d)
+}
+
+// synced records a viewport layout that began at start.
+func (p *perfStats) synced(start time.Time) {
+	if p == nil {
+		return
+	}
+	d := time.Since(start)
+	p.mu.Lock()
+	p.sync.add(d)
+	p.mu.Unlock()
+}
+
+// perfTickMsg asks for the open debug panel to be redrawn.
+type perfTickMsg struct{}
+
+func perfTick() tea.Cmd {
+	return tea.Tick(perfRefresh, func(time.Time) tea.Msg { return perfTickMsg{} })
+}
+
+// renderPerf draws the debug panel.
+func (m *Model) renderPerf() {
+	p := m.perf
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lines := []string{styles.Status.Render(fmt.Sprintf("debug · otail's own performance · refreshed every %s · esc close", perfRefresh)), ""}
+	mean := int64(0)
+	if p.frames > 0 {
+		mean = p.bytes / int64(p.frames)
+	}
+	lines = append(lines, fmt.Sprintf("frames  %d received · %s in all · %s on average", p.frames, byteSize(p.bytes), byteSize(mean)))
+	most := slices.Max(p.sizes)
+	barWidth := max(m.viewport.Width-24, 10)
+	for i, n := range p.sizes {
+		label := "> " + byteSize(int64(sizeBuckets[len(sizeBuckets)-1]))
+		if i < len(sizeBuckets) {
+			label = "≤ " + byteSize(int64(sizeBuckets[i]))
+		}
+		bar := 0
+		if most > 0 {
+			bar = (n*barWidth + most - 1) / most
+		}
+		lines = append(lines, fmt.Sprintf("  %-10s %s %d", label, strings.Repeat("█", bar), n))
+	}
+
+	lines = append(lines, "",
+		"parse          "+p.parse.summary(),
+		"viewport sync  "+p.sync.summary())
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	lastPause := time.Duration(0)
+	if ms.NumGC > 0 {
+		lastPause = time.Duration(ms.PauseNs[(ms.NumGC+255)%256])
+	}
+	lines = append(lines, "",
+		fmt.Sprintf("memory  %s heap in use · %s allocated in all · %s from the OS · %d goroutines",
+			byteSize(int64(ms.HeapInuse)), byteSize(int64(ms.TotalAlloc)), byteSize(int64(ms.Sys)), runtime.NumGoroutine()),
+		fmt.Sprintf("gc      %d cycles · last paused %s · %s paused in all · next at %s heap",
+			ms.NumGC, shortDuration(lastPause), shortDuration(time.Duration(ms.PauseTotalNs)), byteSize(int64(ms.NextGC))))
+	if st := m.live.strings.Stats(); st.Strings > 0 {
+		lines = append(lines, fmt.Sprintf("strings %d interned · %d repeats shared · %s saved",
+			st.Strings, st.Shared, byteSize(st.Saved)))
+	}
+	if b := m.budget; b.limit > 0 {
+		lines = append(lines, fmt.Sprintf("budget  %s limit · %d old messages downgraded, dropping %s of rendered lines",
+			byteSize(b.limit), b.downgraded, byteSize(b.freed)))
+	}
+	m.overlayLines = lines
+}
+
+// byteSize formats n bytes in B, KiB, or MiB.
+func byteSize(n int64) string {
+	switch {
+	case n < 1<<10:
+		return fmt.Sprintf("%d B", n)
+	case n < 1<<20:
+		return fmt.Sprintf("%.3g KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%.3g MiB", float64(n)/(1<<20))
+	}
+}
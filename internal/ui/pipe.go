@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	pipeTimeout   = 10 * time.Second
+	pipeMaxOutput = 4 << 20 // bytes of output kept from the command
+)
+
+// pipePanel is the last shell command, the message piped into it, and what
+// it printed.
+type pipePanel struct {
+	command string
+	input   []byte // the messages piped in, as received
+	output  []string
+}
+
+// pipeDoneMsg carries the output of a command started by runPipe.
+type pipeDoneMsg struct {
+	command string
+	output  []byte
+	err     error
+}
+
+// startPipe asks for a shell command to pipe the message under the cursor,
+// or the selection one per line, through, starting from the previous one.
+func (m *Model) startPipe() tea.Cmd {
+	msgs := m.takeSelection()
+	if len(msgs) == 0 {
+		return nil
+	}
+	m.pipe.input = selectedRaw(msgs)
+	return m.openPrompt("| ", m.pipe.command, submitPipe)
+}
+
+func submitPipe(m *Model, command string) tea.Cmd {
+	m.pipe.command = strings.TrimSpace(command)
+	if m.pipe.command == "" {
+		return nil
+	}
+	return runPipe(m.pipe.command, m.pipe.input)
+}
+
+// runPipe runs command with sh, feeding it the message as received, and
+// reports stdout and stderr together. Commands that run longer than
+// pipeTimeout are killed.
+func runPipe(command string, input []byte) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), pipeTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stdin = bytes.NewReader(input)
+		out := &limitedBuffer{max: pipeMaxOutput}
+		cmd.Stdout, cmd.Stderr = out, out
+		cmd.WaitDelay = time.Second // in case children outlive sh holding the pipes
+		err := cmd.Run()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = errors.New("killed after " + pipeTimeout.String())
+		}
+		return pipeDoneMsg{command: command, output: out.Bytes(), err: err}
+	}
+}
+
+// showPipe shows a command's output in place of the message list.
+func (m *Model) showPipe(msg pipeDoneMsg) {
+	if msg.command != m.pipe.command {
+		return // superseded by a newer command
+	}
+	out := strings.TrimRight(string(msg.output), "\n")
+	m.pipe.output = nil
+	if out != "" {
+		m.pipe.output = strings.Split(out, "\n")
+	}
+	title := fitCell("| "+msg.command+" · y yank · | edit · esc close", max(m.width, 1), true)
+	lines := []string{styles.Status.Render(title)}
+	if msg.err != nil {
+		lines = append(lines, styles.Banner.Render(msg.err.Error()))
+	}
+	if len(m.pipe.output) == 0 && msg.err == nil {
+		lines = append(lines, "(no output)")
+	}
+	for _, l := range m.pipe.output {
+		lines = append(lines, cleanLine(l))
+	}
+	m.overlay, m.overlayLines = overlayPipe, lines
+	m.viewport.SetYOffset(0)
+	m.syncViewport()
+}
+
+// pipeKey handles keys while the output is shown and reports whether it
+// consumed msg.
+func (m *Model) pipeKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+	case key.Matches(msg, Keys.Yank):
+		if len(m.pipe.output) > 0 {
+			m.copyLines(m.pipe.output)
+		}
+	case key.Matches(msg, Keys.Pipe):
+		return m.openPrompt("| ", m.pipe.command, submitPipe), true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// cleanLine expands tabs and replaces other control characters, which would
+// corrupt the screen, with '.'.
+func cleanLine(s string) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '.'
+		}
+		return r
+	}, s)
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the
+// rest, so a chatty command cannot exhaust memory.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/query"
+)
+
+// presets are the named filters from the config and the searches saved
+// with :save; the first nine are bound to 1-9.
+type presets struct {
+	list    []filter.Preset
+	sel     int
+	saved   map[string]bool // names saved with :save rather than configured
+	sidebar bool            // Q: list them beside the messages
+	counts  *presetCounts
+}
+
+// applyPreset filters the active tab with preset i, or clears the filter if
+// that preset is already applied. A preset of one kind of signal switches
+// to its tab first.
+func (m *Model) applyPreset(i int) {
+	if i < 0 || i >= len(m.presets.list) {
+		return
+	}
+	f := m.presets.list[i].Filter
+	if len(f.Kinds) == 1 && f.Kinds[0] != m.Active {
+		m.switchTab(f.Kinds[0])
+	} else if m.store.Filter(m.Active).Equal(f) {
+		f = filter.Filter{}
+	}
+	m.setFilter(f)
+}
+
+// activePreset returns the name of the preset matching the active tab's
+// filter, if any.
+func (m Model) activePreset() (string, bool) {
+	f := m.store.Filter(m.Active)
+	if f.IsZero() {
+		return "", false
+	}
+	for _, p := range m.presets.list {
+		if p.Filter.Equal(f) {
+			return p.Name, true
+		}
+	}
+	return "", false
+}
+
+// presetIndex returns which preset a 1-9 key selects.
+func presetIndex(msg tea.KeyMsg) int {
+	s := msg.String()
+	if len(s) != 1 || s[0] < '1' || s[0] > '9' {
+		return -1
+	}
+	return int(s[0] - '1')
+}
+
+func (m *Model) renderPresetPicker() {
+	p := &m.presets
+	lines := []string{styles.Status.Render("filter presets · enter apply · esc close")}
+	if len(p.list) == 0 {
+		lines = append(lines, "  no presets; add some with :save NAME, --preset NAME=QUERY, or \"preset\" in the config file")
+	}
+	active, _ := m.activePreset()
+	for i, pr := range p.list {
+		num := " "
+		if i < 9 {
+			num = fmt.Sprint(i + 1)
+		}
+		mark := "  "
+		if pr.Name == active {
+			mark = "▸ "
+		}
+		line := fmt.Sprintf("  %s%s  %-20s %s", mark, num, pr.Name, query.Format(pr.Filter))
+		if i == p.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+// presetsKey handles keys while the picker is open and reports whether it
+// consumed msg.
+func (m *Model) presetsKey(msg tea.KeyMsg) bool {
+	p := &m.presets
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		p.sel = max(p.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		p.sel = max(min(p.sel+1, len(p.list)-1), 0)
+	case msg.String() == "enter":
+		m.closeOverlay()
+		m.applyPreset(p.sel)
+		return true
+	case presetIndex(msg) >= 0:
+		m.closeOverlay()
+		m.applyPreset(presetIndex(msg))
+		return true
+	default:
+		return false
+	}
+	m.renderPresetPicker()
+	line := p.sel + 1 // below the title
+	if p.sel == 0 {
+		line = 0
+	}
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+	m.syncViewport()
+	return true
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prompt is a one-line text input shown in place of the status bar. Enter
+// passes the text to submit; esc dismisses it.
+type prompt struct {
+	input  textinput.Model
+	active bool
+	submit func(m *Model, value string) tea.Cmd
+
+	// Optional: earlier entries up and down step through, and the whole
+	// lines tab can complete value to.
+	history  []string
+	histPos  int // index into history of the entry shown; len(history) = the line being typed
+	complete func(m *Model, value string) []string
+}
+
+// openPrompt asks for a line of text, starting from value.
+func (m *Model) openPrompt(label, value string, submit func(m *Model, value string) tea.Cmd) tea.Cmd {
+	in := textinput.New()
+	in.Prompt = label
+	in.SetValue(value)
+	in.CursorEnd()
+	in.KeyMap.NextSuggestion = key.NewBinding(key.WithKeys("ctrl+n"))
+	in.KeyMap.PrevSuggestion = key.NewBinding(key.WithKeys("ctrl+p"))
+	m.prompt = prompt{input: in, active: true, submit: submit}
+	return m.prompt.input.Focus()
+}
+
+// promptKey feeds a key to the open prompt.
+func (m *Model) promptKey(msg tea.KeyMsg) tea.Cmd {
+	p := &m.prompt
+	switch msg.String() {
+	case "esc":
+		m.prompt = prompt{}
+		return nil
+	case "enter":
+		submit, value := p.submit, p.input.Value()
+		m.prompt = prompt{}
+		return submit(m, value)
+	case "up", "down":
+		if len(p.history) == 0 {
+			return nil
+		}
+		if msg.String() == "up" {
+			p.histPos = max(p.histPos-1, 0)
+		} else {
+			p.histPos = min(p.histPos+1, len(p.history))
+		}
+		p.input.SetValue("")
+		if p.histPos < len(p.history) {
+			p.input.SetValue(p.history[p.histPos])
+		}
+		p.input.CursorEnd()
+		m.suggest()
+		return nil
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	m.suggest()
+	return cmd
+}
+
+// suggest offers the prompt's completions of what has been typed so far.
+func (m *Model) suggest() {
+	p := &m.prompt
+	if p.complete == nil {
+		return
+	}
+	p.input.ShowSuggestions = true
+	p.input.SetSuggestions(p.complete(m, p.input.Value()))
+}
+
+func (m Model) renderPrompt() string {
+	return styles.Status.Render(m.prompt.input.View())
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/alert"
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/ui/theme"
+)
+
+// configCheck is how often the config file is checked for changes.
+const configCheck = time.Second
+
+// configTickMsg asks for the config file to be checked for changes.
+type configTickMsg struct{}
+
+// configMsg carries what a changed config file was read back into.
+type configMsg struct {
+	settings Settings
+	restart  []string // changed options only a restart applies
+	err      error
+}
+
+// configWatch follows the config file, applying the Settings it gives as
+// it changes. A file that does not exist yet is watched for appearing.
+type configWatch struct {
+	path     string
+	reload   func() (Settings, []string, error)
+	modTime  time.Time // of the file when last read; zero = missing
+	size     int64
+	settings Settings // last applied
+	note     string   // shown in the status bar until the next key
+}
+
+func watchConfig(path string, reload func() (Settings, []string, error), s Settings) *configWatch {
+	w := &configWatch{path: path, reload: reload, settings: s}
+	w.changed()
+	return w
+}
+
+// tick waits for the next check; it is nil when nothing is watched.
+func (w *configWatch) tick() tea.Cmd {
+	if w == nil {
+		return nil
+	}
+	return tea.Tick(configCheck, func(time.Time) tea.Msg { return configTickMsg{} })
+}
+
+// changed reports whether the file was written, created, or removed since
+// the last call.
+func (w *configWatch) changed() bool {
+	var mod time.Time
+	var size int64
+	if fi, err := os.Stat(w.path); err == nil {
+		mod, size = fi.ModTime(), fi.Size()
+	}
+	if mod.Equal(w.modTime) && size == w.size {
+		return false
+	}
+	w.modTime, w.size = mod, size
+	return true
+}
+
+// check reads the config file back if it changed, off the UI goroutine, or
+// else waits for the next check.
+func (w *configWatch) check() tea.Cmd {
+	if !w.changed() {
+		return w.tick()
+	}
+	reload := w.reload
+	return func() tea.Msg {
+		s, restart, err := reload()
+		return configMsg{s, restart, err}
+	}
+}
+
+// applyConfig puts the settings of a changed config file into effect. Only
+// what changed since the last settings applied is touched, so a tab the
+// user filtered differently, or a theme picked with :theme, is kept unless
+// the config changes that same setting. A file that does not parse, or
+// gives a bad value, changes nothing.
+func (m *Model) applyConfig(msg configMsg) {
+	w := m.config
+	if msg.err != nil {
+		w.note = fmt.Sprintf("config: %v; kept the settings in use", msg.err)
+		return
+	}
+	old, s := w.settings, msg.settings
+	var applied []string
+	if s.Theme.Name == "" {
+		s.Theme = theme.Default
+	}
+	if s.Theme.Name != old.Theme.Name {
+		styles, m.theme = s.Theme.Styles(), s.Theme.Name
+		m.styled.reset(m.textWidth())
+		applied = append(applied, "theme")
+	}
+	if !s.Filter.Equal(old.Filter) {
+		for _, t := range tabs {
+			if m.live.Filter(t.kind).Equal(old.Filter) {
+				m.live.SetFilter(t.kind, s.Filter)
+			}
+		}
+		applied = append(applied, "filter")
+	}
+	if s.Context != old.Context {
+		m.live.SetContext(s.Context)
+		applied = append(applied, "context")
+	}
+	if !slices.EqualFunc(s.Presets, old.Presets, func(a, b filter.Preset) bool { return a.Name == b.Name && a.Filter.Equal(b.Filter) }) {
+		m.setConfigPresets(s.Presets)
+		applied = append(applied, "presets")
+	}
+	if !slices.Equal(s.TableColumns, old.TableColumns) {
+		m.tableColumns = s.TableColumns
+		if m.table != nil {
+			m.table = newLogTable(s.TableColumns)
+		}
+		applied = append(applied, "columns")
+	}
+	if !slices.EqualFunc(s.Alerts, old.Alerts, func(a, b alert.Rule) bool { return a.String() == b.String() }) || s.AlertCommand != old.AlertCommand {
+		m.alerts.rules, m.alerts.command = s.Alerts, s.AlertCommand
+		applied = append(applied, "alerts")
+	}
+	w.settings = s
+	if len(applied) > 0 {
+		m.viewport.SetTotal(m.totalLines())
+		if !m.paused {
+			m.viewport.GotoBottom()
+		}
+		m.syncViewport()
+	}
+
+	switch {
+	case len(applied) > 0 && len(msg.restart) > 0:
+		w.note = fmt.Sprintf("config reloaded: %s; restart for %s", strings.Join(applied, ", "), strings.Join(msg.restart, ", "))
+	case len(applied) > 0:
+		w.note = "config reloaded: " + strings.Join(applied, ", ")
+	case len(msg.restart) > 0:
+		w.note = "config changed; restart for " + strings.Join(msg.restart, ", ")
+	}
+}
+
+// setConfigPresets replaces the presets from the config, keeping the
+// searches saved with :save, which win over a config preset of the same
+// name.
+func (m *Model) setConfigPresets(ps []filter.Preset) {
+	p := &m.presets
+	saved := slices.DeleteFunc(slices.Clone(p.list), func(pr filter.Preset) bool { return !p.saved[pr.Name] })
+	p.list = slices.DeleteFunc(slices.Clone(ps), func(pr filter.Preset) bool { return p.saved[pr.Name] })
+	p.list = append(p.list, saved...)
+	p.sel = max(min(p.sel, len(p.list)-1), 0)
+	p.counts.gen = -1 // recount
+}
+
+func configSegment(m Model) string {
+	if m.config == nil {
+		return ""
+	}
+	return m.config.note
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Y (paused) yanks a command that sends the message under the cursor, or
+// the selection, to a collector again: a curl posting it to the OTLP/HTTP
+// endpoint as received. :repro otel-cli yanks otel-cli span commands for
+// its spans instead, for replaying through otel-cli's own exporter.
+
+// DefaultReproURL is the OTLP/HTTP endpoint reproduction commands send to
+// unless Config.ReproURL says otherwise.
+const DefaultReproURL = "http://localhost:4318"
+
+// reproFormats lists what :repro can yank.
+var reproFormats = []string{"curl", "otel-cli"}
+
+// yankRepro copies the commands replaying the selection in format.
+func (m *Model) yankRepro(format string) {
+	msgs := m.takeSelection()
+	if len(msgs) == 0 {
+		m.clipNote = "repro: pause (p) and put the cursor on a message first"
+		return
+	}
+	url := m.reproURL
+	if url == "" {
+		url = DefaultReproURL
+	}
+	var lines []string
+	for _, msg := range msgs {
+		var cmd []string
+		var err error
+		switch format {
+		case "curl":
+			cmd, err = curlCommand(msg, url)
+		case "otel-cli":
+			cmd, err = otelCLICommands(msg, url)
+		default:
+			err = fmt.Errorf("unknown format %q (want %s)", format, strings.Join(reproFormats, " or "))
+		}
+		if err != nil {
+			m.clipNote = "repro: " + err.Error()
+			return
+		}
+		lines = append(lines, cmd...)
+	}
+	m.copyLines(lines)
+}
+
+// otlpPaths are the OTLP/HTTP paths each signal is posted to.
+var otlpPaths = map[telemetry.Kind]string{
+	telemetry.KindLogs:    "/v1/logs",
+	telemetry.KindMetrics: "/v1/metrics",
+	telemetry.KindTraces:  "/v1/traces",
+}
+
+// curlCommand returns a curl posting msg as received, its payload in a
+// here-document so that it needs no quoting.
+func curlCommand(msg telemetry.Message, url string) ([]string, error) {
+	path, ok := otlpPaths[msg.Kind]
+	if !ok {
+		return nil, errors.New("not OTLP, so there is nothing to replay")
+	}
+	var payload bytes.Buffer
+	if err := json.Compact(&payload, msg.Raw); err != nil {
+		return nil, err
+	}
+	return []string{
+		"curl -sS -X POST " + shellQuote(strings.TrimRight(url, "/")+path) + " -H 'Content-Type: application/json' --data-binary @- <<'EOF'",
+		payload.String(),
+		"EOF",
+	}, nil
+}
+
+// otelCLICommands returns one otel-cli span command for each span in msg,
+// keeping its IDs, times, kind, status, and string attributes.
+func otelCLICommands(msg telemetry.Message, url string) ([]string, error) {
+	if msg.Kind != telemetry.KindTraces {
+		return nil, errors.New("otel-cli only sends spans; :repro curl sends any signal")
+	}
+	var cmds []string
+	rss := msg.Traces.ResourceSpans()
+	for i := range rss.Len() {
+		rs := rss.At(i)
+		service := "unknown"
+		if v, ok := rs.Resource().Attributes().Get("service.name"); ok {
+			service = v.AsString()
+		}
+		sss := rs.ScopeSpans()
+		for j := range sss.Len() {
+			spans := sss.At(j).Spans()
+			for k := range spans.Len() {
+				cmds = append(cmds, otelCLISpan(spans.At(k), service, url))
+			}
+		}
+	}
+	return cmds, nil
+}
+
+func otelCLISpan(s ptrace.Span, service, url string) string {
+	args := []string{
+		"otel-cli span",
+		"--endpoint " + shellQuote(url),
+		"--service " + shellQuote(service),
+		"--name " + shellQuote(s.Name()),
+		"--kind " + otelCLIKind(s.Kind()),
+		"--start " + s.StartTimestamp().AsTime().UTC().Format(time.RFC3339Nano),
+		"--end " + s.EndTimestamp().AsTime().UTC().Format(time.RFC3339Nano),
+		"--force-trace-id " + s.TraceID().String(),
+		"--force-span-id " + s.SpanID().String(),
+	}
+	if !s.ParentSpanID().IsEmpty() {
+		args = append(args, "--force-parent-span-id "+s.ParentSpanID().String())
+	}
+	if code := s.Status().Code(); code != ptrace.StatusCodeUnset {
+		args = append(args, "--status-code "+strings.ToLower(code.String()))
+		if d := s.Status().Message(); d != "" {
+			args = append(args, "--status-description "+shellQuote(d))
+		}
+	}
+	var attrs []string
+	s.Attributes().Range(func(k string, v pcommon.Value) bool {
+		// otel-cli splits --attrs at commas, so values holding one are left
+		// out rather than sent mangled.
+		if s := v.AsString(); !strings.Contains(s, ",") {
+			attrs = append(attrs, k+"="+s)
+		}
+		return true
+	})
+	if len(attrs) > 0 {
+		args = append(args, "--attrs "+shellQuote(strings.Join(attrs, ",")))
+	}
+	return strings.Join(args, " ")
+}
+
+// otelCLIKind names k as otel-cli's --kind takes it.
+func otelCLIKind(k ptrace.SpanKind) string {
+	switch k {
+	case ptrace.SpanKindServer:
+		return "server"
+	case ptrace.SpanKindClient:
+		return "client"
+	case ptrace.SpanKindProducer:
+		return "producer"
+	case ptrace.SpanKindConsumer:
+		return "consumer"
+	default:
+		return "internal"
+	}
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
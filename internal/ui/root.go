@@ -3,56 +3,259 @@ package ui
 import (
 	"context"
 	"fmt"
-	"log"
-	"net/url"
+	"log/slog"
 	"os"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/jwafle/otail/internal/alert"
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/script"
 	"github.com/jwafle/otail/internal/telemetry"
 	"github.com/jwafle/otail/internal/transport"
+	"github.com/jwafle/otail/internal/ui/theme"
 )
 
-// readFrame returns a command that receives one frame from the stream.
-func readFrame(s *transport.Stream) tea.Cmd {
+// streamErrMsg reports that a stream has failed or closed. It carries the
+// stream so that errors from a stream replaced by a reconnect are ignored.
+type streamErrMsg struct {
+	stream *transport.Stream
+	err    error
+}
+
+// stateMsg wraps a connection state transition together with its stream.
+type stateMsg struct {
+	stream *transport.Stream
+	state  transport.State
+}
+
+// dialFunc opens a new stream from the source Run was given.
+type dialFunc func() (*transport.Stream, context.CancelFunc, error)
+
+// dialer returns a dialFunc that opens src.
+func dialer(src transport.Source) dialFunc {
+	return func() (*transport.Stream, context.CancelFunc, error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := src(ctx)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		return stream, cancel, nil
+	}
+}
+
+// maxFramesPerBatch caps how many frames readFrame drains for a single
+// Update, so a firehose cannot starve key handling and rendering.
+const maxFramesPerBatch = 256
+
+// frameBatchMsg carries every frame that was immediately available on the
+// stream, parsed in arrival order.
+type frameBatchMsg []telemetry.Message
+
+// readFrame returns a command that blocks for one parsed frame from the
+// pool and then drains whatever else is already parsed, up to
+// maxFramesPerBatch.
+func readFrame(p *parsePool) tea.Cmd {
+	if p == nil {
+		return nil
+	}
+	p.start()
 	return func() tea.Msg {
-		select {
-		case b, ok := <-s.Messages():
-			if !ok {
-				return fmt.Errorf("stream closed")
-			}
-			return telemetry.Parse(b)
-		case err, ok := <-s.Errors():
-			if ok {
-				return err
+		first, ok := <-p.out
+		if !ok {
+			return streamErrMsg{p.stream, p.err}
+		}
+		batch := frameBatchMsg{first}
+		for len(batch) < maxFramesPerBatch {
+			select {
+			case msg, ok := <-p.out:
+				if !ok {
+					// Deliver what we have; the next read reports the close.
+					return batch
+				}
+				batch = append(batch, msg)
+			default:
+				return batch
 			}
-			return fmt.Errorf("stream error channel closed")
 		}
+		return batch
 	}
 }
 
-// Run creates the transport, spins up the Bubble Tea program, and blocks until the TUI exits.
-func Run(endpoint string, initial telemetry.Kind) error {
-	if endpoint == "" {
-		endpoint = "ws://127.0.0.1:12001"
+// waitState returns a command that receives the next connection state
+// transition from the stream. It yields nil once the stream has shut down.
+func waitState(s *transport.Stream) tea.Cmd {
+	return func() tea.Msg {
+		st, ok := <-s.States()
+		if !ok {
+			return nil
+		}
+		return stateMsg{s, st}
+	}
+}
+
+// Settings are the parts of Config that a changed config file applies
+// while the TUI runs.
+type Settings struct {
+	Theme        theme.Theme     // zero = theme.Default
+	Filter       filter.Filter   // applied to every tab at startup; zero = none
+	Context      int             // messages shown around each filter match; 0 = none
+	Presets      []filter.Preset // named filters bound to 1-9; nil = none
+	TableColumns []string        // log table columns; nil = DefaultTableColumns
+	Alerts       []alert.Rule    // notification rules; nil = none
+	AlertCommand string          // shell command run on each alert; empty = none
+}
+
+// Config tweaks the TUI; zero-value is sane.
+type Config struct {
+	Settings
+
+	MaxRenderFPS int     // 0 = Bubble Tea default (60)
+	SampleEvery  int     // display 1 of every N messages per signal; 0 = all
+	SampleRate   float64 // probability of displaying a message; 0 = all
+	Table        bool    // start the Logs tab in table mode
+	Format       telemetry.Format
+	Summary      string                // write a session summary on exit: "-" = stdout, else a file path; empty = none
+	NoWrap       bool                  // cut long lines at the screen edge instead of wrapping them
+	Tabs         []filter.Preset       // custom tabs, each showing the messages of any signal its filter matches; nil = none
+	Temporality  telemetry.Temporality // convert sums for display; zero = as received
+	TraceSample  TailPolicy            // which traces the Traces tab shows; zero = all
+	GroupBy      string                // resource attribute G groups by; empty = DefaultGroupBy
+	HideScopes   []string              // instrumentation scopes hidden on every tab, as name or name@version; nil = none
+	MemoryLimit  int64                 // heap bytes near which old messages drop their rendered lines; 0 = no limit
+	ParseWorkers int                   // goroutines parsing frames; 0 = one per CPU
+
+	// StateFile is where the UI's State is saved on exit; empty = not
+	// saved. Restore is applied over the rest of Config at startup; nil =
+	// nothing to restore.
+	StateFile string
+	Restore   *State
+
+	// Debug receives UI lifecycle events, frames that did not parse, and
+	// slow renders; nil = none.
+	Debug *slog.Logger
+
+	// ConfigFile is checked for changes every second while the TUI runs;
+	// Reload reads it back, returning the Settings it gives and the options
+	// whose change only a restart applies. Empty or nil = not watched.
+	ConfigFile string
+	Reload     func() (s Settings, restart []string, err error)
+
+	// ReproURL is the OTLP/HTTP endpoint the commands Y and :repro yank
+	// send to; empty = DefaultReproURL.
+	ReproURL string
+
+	// TraceURL is the page U opens a trace on in an external APM, with
+	// {traceID}, {spanID}, and {service} filled in; empty = none.
+	TraceURL string
+
+	// Scripts are the transform and format scripts in use, which :script
+	// turns on and off; nil = none.
+	Scripts *script.Set
+
+	// Endpoint builds the source :endpoint switches to from an --endpoint
+	// value; nil = :endpoint is unavailable.
+	Endpoint func(spec string) (transport.Source, error)
+}
+
+// Run opens the stream from src, spins up the Bubble Tea program, and blocks
+// until the TUI exits. src is called again whenever the user reconnects.
+func Run(src transport.Source, initial telemetry.Kind, cfg *Config) error {
+	if cfg == nil {
+		cfg = &Config{}
 	}
-	if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
-		return fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+
+	th := cfg.Theme
+	if th.Name == "" {
+		th = theme.Default
 	}
+	styles = th.Styles()
+	telemetry.SetFormat(cfg.Format)
+	setCustomTabs(cfg.Tabs)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	dial := dialer(src)
+	stream, cancel, err := dial()
+	if err != nil {
+		return err
+	}
 
-	stream, err := transport.Dial(ctx, endpoint, "http://localhost/", &transport.Config{
-		PingInterval: 30 * time.Second,
-		Logger:       log.New(os.Stderr, "[transport] ", log.LstdFlags),
-	})
+	m := newModel(stream, cancel, dial, initial)
+	m.newSource = cfg.Endpoint
+	m.scripts = cfg.Scripts
+	m.reproURL = cfg.ReproURL
+	m.traceURL = cfg.TraceURL
+	m.theme = th.Name
+	if cfg.Debug != nil {
+		m.debug = cfg.Debug.With("component", "ui")
+	}
+	m.store.sampler = newSampler(cfg.SampleEvery, cfg.SampleRate)
+	m.store.tailSampler = newTailSampler(cfg.TraceSample)
+	m.groups.key = cfg.GroupBy
+	m.store.SetHiddenScopes(cfg.HideScopes)
+	m.store.context = max(cfg.Context, 0)
+	m.budget = newMemoryBudget(cfg.MemoryLimit)
+	m.parseWorkers = cfg.ParseWorkers
+	m.parser = newParsePool(stream, m.parseWorkers, m.perf)
+	m.noWrap = cfg.NoWrap
+	m.tableColumns = cfg.TableColumns
+	m.alerts = alerts{rules: cfg.Alerts, command: cfg.AlertCommand}
+	m.presets.list = cfg.Presets
+	m.normalizer = telemetry.NewNormalizer(cfg.Temporality)
+	if !cfg.Filter.IsZero() {
+		for _, t := range tabs {
+			m.live.SetFilter(t.kind, cfg.Filter)
+		}
+	}
+	if cfg.Table {
+		m.table = newLogTable(cfg.TableColumns)
+	}
+	if cfg.Restore != nil {
+		m.restore(cfg.Restore)
+	}
+	if cfg.ConfigFile != "" && cfg.Reload != nil {
+		m.config = watchConfig(cfg.ConfigFile, cfg.Reload, cfg.Settings)
+	}
+
+	opts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	if cfg.MaxRenderFPS > 0 {
+		opts = append(opts, tea.WithFPS(cfg.MaxRenderFPS))
+	}
+	m.debug.Info("starting", "tab", m.Active.String())
+	final, err := tea.NewProgram(m, opts...).Run()
 	if err != nil {
-		cancel()
+		m.debug.Error("program failed", "err", err)
 		return err
 	}
+	m.debug.Info("exiting", "after", time.Since(m.session.started).Round(time.Second))
+	if cfg.StateFile != "" {
+		if err := SaveState(cfg.StateFile, final.(Model).state()); err != nil {
+			return fmt.Errorf("saving state: %w", err)
+		}
+	}
+	if cfg.Summary == "" {
+		return nil
+	}
+	return writeSummary(final.(Model), cfg.Summary)
+}
 
-	m := newModel(stream, cancel, initial)
-	_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
-	return err
+// writeSummary writes the session summary to path, or stdout for "-".
+func writeSummary(m Model, path string) error {
+	var st transport.Stats
+	if m.stream != nil {
+		st = m.stream.Stats()
+	}
+	if path == "-" {
+		return m.session.write(os.Stdout, st, time.Now())
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.session.write(f, st, time.Now()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"math/rand"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// sampler thins out what is displayed under heavy load. Sampled-out
+// messages are still stored; they just never reach the viewport.
+type sampler struct {
+	every int     // display 1 of every N messages per kind; <= 1 = all
+	rate  float64 // probability of displaying a message; 0 = disabled
+	seen  map[telemetry.Kind]int
+}
+
+// newSampler returns nil when neither sampling mode is enabled.
+func newSampler(every int, rate float64) *sampler {
+	if every <= 1 && (rate <= 0 || rate >= 1) {
+		return nil
+	}
+	return &sampler{every: every, rate: rate, seen: make(map[telemetry.Kind]int)}
+}
+
+// keep reports whether the next message of kind k should be displayed. A nil
+// sampler keeps everything.
+func (s *sampler) keep(k telemetry.Kind) bool {
+	if s == nil {
+		return true
+	}
+	if s.every > 1 {
+		n := s.seen[k]
+		s.seen[k] = n + 1
+		if n%s.every != 0 {
+			return false
+		}
+	}
+	if s.rate > 0 && s.rate < 1 {
+		return rand.Float64() < s.rate
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/query"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// :save NAME [QUERY] saves a search as a preset, kept in the state file
+// from one run to the next; without QUERY it saves the active tab's
+// filter. Q shows every preset in a sidebar with how many buffered
+// messages it matches, counted as they arrive, and 1-9 jump to one.
+
+// sidebarWidth is how many columns the saved-search sidebar takes, at
+// most; it never takes more than a third of the screen, and is not shown
+// on screens narrower than twice this.
+const sidebarWidth = 32
+
+// presetCounts caches how many live messages each preset matches. It is
+// shared between copies of the Model so View can bring it up to date.
+type presetCounts struct {
+	gen     int                    // store generation the counts were taken at
+	checked map[telemetry.Kind]int // messages counted so far, by kind
+	counts  map[string]int         // by preset name
+}
+
+// save adds a saved search, or replaces the preset of that name.
+func (m *Model) save(name, q string) error {
+	// The state file keeps searches as name=query.
+	if strings.ContainsFunc(name, func(r rune) bool { return r == '=' || unicode.IsSpace(r) }) {
+		return fmt.Errorf("save %s: a name cannot contain '=' or spaces", name)
+	}
+	f := m.store.Filter(m.Active)
+	if q = strings.TrimSpace(q); q != "" {
+		var err error
+		if f, err = query.Parse(q); err != nil {
+			return fmt.Errorf("save %s: %w", name, err)
+		}
+	}
+	if f.IsZero() {
+		return fmt.Errorf("save %s: no filter to save; give a query, as in :save %s service:api", name, name)
+	}
+	p := &m.presets
+	if i := slices.IndexFunc(p.list, func(pr filter.Preset) bool { return pr.Name == name }); i >= 0 {
+		p.list[i].Filter = f
+	} else {
+		p.list = append(p.list, filter.Preset{Name: name, Filter: f})
+	}
+	if p.saved == nil {
+		p.saved = map[string]bool{}
+	}
+	p.saved[name] = true
+	p.counts.gen = -1 // recount
+	return nil
+}
+
+// unsave removes a saved search. Presets from the config stay.
+func (m *Model) unsave(name string) error {
+	p := &m.presets
+	if !p.saved[name] {
+		if slices.ContainsFunc(p.list, func(pr filter.Preset) bool { return pr.Name == name }) {
+			return fmt.Errorf("unsave %s: the preset is from the config, not saved", name)
+		}
+		return fmt.Errorf("unsave %s: no such saved search", name)
+	}
+	delete(p.saved, name)
+	p.list = slices.DeleteFunc(p.list, func(pr filter.Preset) bool { return pr.Name == name })
+	p.sel = max(min(p.sel, len(p.list)-1), 0)
+	return nil
+}
+
+// savedSearches returns the saved searches as NAME=QUERY, for the state
+// file.
+func (p presets) savedSearches() []string {
+	var out []string
+	for _, pr := range p.list {
+		if p.saved[pr.Name] {
+			out = append(out, pr.Name+"="+query.Format(pr.Filter))
+		}
+	}
+	return out
+}
+
+// countPresets brings the match counts up to date with the live store and
+// returns them by preset name. Messages are only ever appended between
+// generations, so each is matched once.
+func (m Model) countPresets() map[string]int {
+	c := m.presets.counts
+	stale := c.gen != m.live.gen
+	for _, t := range signalTabs {
+		stale = stale || c.checked[t.kind] > len(m.live.Messages(t.kind))
+	}
+	if stale {
+		c.gen, c.checked, c.counts = m.live.gen, map[telemetry.Kind]int{}, map[string]int{}
+	}
+	for _, t := range signalTabs {
+		msgs := m.live.Messages(t.kind)
+		for _, msg := range msgs[c.checked[t.kind]:] {
+			for _, pr := range m.presets.list {
+				if pr.Filter.Match(msg) {
+					c.counts[pr.Name]++
+				}
+			}
+		}
+		c.checked[t.kind] = len(msgs)
+	}
+	return c.counts
+}
+
+// showSidebar reports whether the saved-search sidebar is on screen.
+func (m Model) showSidebar() bool {
+	return m.presets.sidebar && !m.zen && m.overlay == overlayNone && m.width >= 2*sidebarWidth
+}
+
+// renderSidebar draws the saved-search sidebar, height rows tall and width
+// wide.
+func (m Model) renderSidebar(width, height int) []string {
+	counts := m.countPresets()
+	active, _ := m.activePreset()
+	rows := []string{styles.Status.Render(ansi.Truncate("saved searches · Q hide", width, "…"))}
+	if len(m.presets.list) == 0 {
+		rows = append(rows, styles.Status.Render(ansi.Truncate("none; :save NAME", width, "…")))
+	}
+	for i, pr := range m.presets.list {
+		num := " "
+		if i < 9 {
+			num = fmt.Sprint(i + 1)
+		}
+		mark := " "
+		if pr.Name == active {
+			mark = "▸"
+		}
+		n := fmt.Sprint(counts[pr.Name])
+		name := ansi.Truncate(pr.Name, max(width-len(n)-4, 1), "…")
+		row := fmt.Sprintf("%s%s %s%*s", mark, num, name, width-3-ansi.StringWidth(name), n)
+		if pr.Name == active {
+			row = styles.Cursor.Render(row)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) > height {
+		rows = rows[:height]
+	}
+	for len(rows) < height {
+		rows = append(rows, "")
+	}
+	return rows
+}
+
+// withSidebar puts the sidebar over the right-hand columns of view, behind
+// a rule.
+func (m Model) withSidebar(view string) string {
+	w := min(sidebarWidth, m.width/3)
+	rows := strings.Split(view, "\n")
+	side := m.renderSidebar(w-2, len(rows))
+	left := m.width - w
+	for i, r := range rows {
+		r = ansi.Truncate(r, left, "")
+		rows[i] = r + strings.Repeat(" ", max(left-ansi.StringWidth(r), 0)) + styles.Status.Render("│ ") + side[i]
+	}
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,227 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	plog "go.opentelemetry.io/collector/pdata/plog"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// scopeKey names an instrumentation scope as name@version, the form the
+// scope picker lists and --hide-scope accepts.
+func scopeKey(sc pcommon.InstrumentationScope) string {
+	name := sc.Name()
+	if name == "" {
+		name = "(unnamed)"
+	}
+	if sc.Version() == "" {
+		return name
+	}
+	return name + "@" + sc.Version()
+}
+
+// scopeName strips the version from a scope key.
+func scopeName(key string) string {
+	for k := len(key) - 1; k > 0; k-- {
+		if key[k] == '@' {
+			return key[:k]
+		}
+	}
+	return key
+}
+
+// eachScope calls fn with the key and item count of every scope in msg.
+func eachScope(msg telemetry.Message, fn func(key string, items int)) {
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		rls := msg.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				fn(scopeKey(sls.At(j).Scope()), sls.At(j).LogRecords().Len())
+			}
+		}
+	case telemetry.KindMetrics:
+		rms := msg.Metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			sms := rms.At(i).ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				fn(scopeKey(sms.At(j).Scope()), sms.At(j).Metrics().Len())
+			}
+		}
+	case telemetry.KindTraces:
+		rss := msg.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				fn(scopeKey(sss.At(j).Scope()), sss.At(j).Spans().Len())
+			}
+		}
+	}
+}
+
+// scopeHidden reports whether the scope named key is hidden, either as
+// name@version or by its name alone.
+func (s *messageStore) scopeHidden(key string) bool {
+	return s.hiddenScopes[key] || s.hiddenScopes[scopeName(key)]
+}
+
+func (s *messageStore) countScopes(msg telemetry.Message) {
+	if s.scopes == nil {
+		s.scopes = map[string]int{}
+	}
+	eachScope(msg, func(key string, items int) { s.scopes[key] += items })
+}
+
+// recountScopes counts scopes afresh, after messages are cleared or
+// restored.
+func (s *messageStore) recountScopes() {
+	s.scopes = map[string]int{}
+	for _, t := range signalTabs {
+		for _, m := range s.Messages(t.kind) {
+			s.countScopes(m)
+		}
+	}
+}
+
+// SetHiddenScopes hides every item from the scopes in keys, on every tab,
+// and re-indexes them.
+func (s *messageStore) SetHiddenScopes(keys []string) {
+	s.hiddenScopes = nil
+	for _, k := range keys {
+		if s.hiddenScopes == nil {
+			s.hiddenScopes = map[string]bool{}
+		}
+		s.hiddenScopes[k] = true
+	}
+	s.Reindex()
+}
+
+// pruneScopes returns a copy of msg without the items of hidden scopes,
+// and false if none are left. msg is returned as is when no scope is
+// hidden.
+func (s *messageStore) pruneScopes(msg telemetry.Message) (telemetry.Message, bool) {
+	hidden := false
+	eachScope(msg, func(key string, _ int) { hidden = hidden || s.scopeHidden(key) })
+	if !hidden {
+		return msg, true
+	}
+	var out telemetry.Message
+	switch msg.Kind {
+	case telemetry.KindLogs:
+		ld := plog.NewLogs()
+		msg.Logs.CopyTo(ld)
+		ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+			rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool { return s.scopeHidden(scopeKey(sl.Scope())) })
+			return rl.ScopeLogs().Len() == 0
+		})
+		out = telemetry.FromLogs(ld)
+	case telemetry.KindMetrics:
+		md := pmetric.NewMetrics()
+		msg.Metrics.CopyTo(md)
+		md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+			rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool { return s.scopeHidden(scopeKey(sm.Scope())) })
+			return rm.ScopeMetrics().Len() == 0
+		})
+		out = telemetry.FromMetrics(md)
+	case telemetry.KindTraces:
+		td := ptrace.NewTraces()
+		msg.Traces.CopyTo(td)
+		td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+			rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool { return s.scopeHidden(scopeKey(ss.Scope())) })
+			return rs.ScopeSpans().Len() == 0
+		})
+		out = telemetry.FromTraces(td)
+	}
+	out.Received = msg.Received
+	kept := 0
+	eachScope(out, func(_ string, items int) { kept += items })
+	return out, kept > 0
+}
+
+// scopePicker lists every instrumentation scope seen, on any tab, with a
+// checkbox to hide it.
+type scopePicker struct {
+	options []string
+	sel     int
+}
+
+// openScopePicker lists the scopes seen so far, and any hidden ones not
+// seen yet, by name.
+func (m *Model) openScopePicker() {
+	opts := slices.Collect(maps.Keys(m.store.scopes))
+	for k := range m.store.hiddenScopes {
+		if !slices.Contains(opts, k) {
+			opts = append(opts, k)
+		}
+	}
+	slices.Sort(opts)
+	m.scopes = scopePicker{options: opts, sel: min(m.scopes.sel, max(len(opts)-1, 0))}
+	m.renderScopePicker()
+}
+
+func (m *Model) renderScopePicker() {
+	lines := []string{styles.Status.Render("instrumentation scopes, all tabs · enter show/hide · esc close")}
+	if len(m.scopes.options) == 0 {
+		lines = append(lines, "", "no scopes seen yet")
+	}
+	for i, opt := range m.scopes.options {
+		mark := "[x]"
+		if m.store.scopeHidden(opt) {
+			mark = "[ ]"
+		}
+		line := fmt.Sprintf("  %s %-48s %s", mark, opt, plural(m.store.scopes[opt], "item"))
+		if i == m.scopes.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+// scopesKey handles keys while the picker is open and reports whether it
+// consumed msg.
+func (m *Model) scopesKey(msg tea.KeyMsg) bool {
+	p := &m.scopes
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		p.sel = max(p.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		p.sel = max(min(p.sel+1, len(p.options)-1), 0)
+	case (msg.String() == "enter" || msg.String() == " ") && len(p.options) > 0:
+		opt := p.options[p.sel]
+		var hidden []string // showing a scope also unhides it by name
+		for k := range m.store.hiddenScopes {
+			if k != opt && k != scopeName(opt) {
+				hidden = append(hidden, k)
+			}
+		}
+		if !m.store.scopeHidden(opt) {
+			hidden = append(hidden, opt)
+		}
+		m.store.SetHiddenScopes(hidden)
+	default:
+		return false
+	}
+	m.renderScopePicker()
+	m.scrollToSelection(p.sel + 1)
+	return true
+}
+
+func scopesSegment(m Model) string {
+	if n := len(m.store.hiddenScopes); n > 0 {
+		return plural(n, "scope") + " hidden"
+	}
+	return ""
+}
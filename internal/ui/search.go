@@ -0,0 +1,390 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/query"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// / searches the active tab for messages holding every word of a query,
+// ignoring case and punctuation, and n and N step through them. The words
+// of each message are indexed as it is added, so a search over a large
+// buffer is a few lookups rather than a scan of every rendered line. The
+// query is in the query package's language, so service=api or dur>200ms
+// narrow the matches too; those conditions are checked once per message
+// and remembered.
+
+// wordIndex maps each word in the frames of one kind to the messages that
+// hold it. Words are runs of letters and digits, lowercased.
+type wordIndex struct {
+	ids   map[string]int32 // word → its position in words
+	words []string
+	posts [][]int32 // messages holding each word, ascending
+	n     int       // one past the latest message added
+
+	// byWord lists positions in words in word order, for prefix lookups.
+	// Words added since the last search are merged in by the next.
+	byWord []int32
+
+	// The last search, kept until another message is added.
+	query   string
+	result  []int
+	ok      bool
+	current bool
+
+	// The messages the last search's other conditions hold for, checked
+	// up to message checked.
+	cond    string
+	held    []int32
+	checked int
+}
+
+// add indexes the words of message i, which must be later than any added
+// before it.
+func (x *wordIndex) add(i int, msg telemetry.Message) {
+	if x.ids == nil {
+		x.ids = make(map[string]int32)
+	}
+	x.current = false
+	x.n = i + 1
+	eachWord(msg.Raw, func(w []byte) {
+		id, ok := x.ids[string(w)]
+		if !ok {
+			id = int32(len(x.words))
+			x.ids[string(w)] = id
+			x.words = append(x.words, string(w))
+			x.posts = append(x.posts, nil)
+		}
+		if p := x.posts[id]; len(p) == 0 || p[len(p)-1] != int32(i) {
+			x.posts[id] = append(p, int32(i))
+		}
+	})
+}
+
+// search is find, remembering the last result.
+func (x *wordIndex) search(query string) ([]int, bool) {
+	if !x.current || x.query != query {
+		x.result, x.ok = x.find(query)
+		x.query, x.current = query, true
+	}
+	return x.result, x.ok
+}
+
+// find returns the messages holding every word of query, each as the start
+// of a longer word if need be, in ascending order. ok is false if query has
+// no words.
+func (x *wordIndex) find(query string) (msgs []int, ok bool) {
+	var want []string
+	eachWord([]byte(query), func(w []byte) { want = append(want, string(w)) })
+	if len(want) == 0 {
+		return nil, false
+	}
+	x.sortWords()
+	posts := make([][]int32, len(want))
+	for i, w := range want {
+		if posts[i] = x.lookup(w); len(posts[i]) == 0 {
+			return nil, true
+		}
+	}
+	// Intersecting from the rarest word keeps every step small.
+	slices.SortFunc(posts, func(a, b []int32) int { return len(a) - len(b) })
+	hits := posts[0]
+	for _, p := range posts[1:] {
+		if hits = intersect(hits, p); len(hits) == 0 {
+			return nil, true
+		}
+	}
+	msgs = make([]int, len(hits))
+	for i, h := range hits {
+		msgs[i] = int(h)
+	}
+	return msgs, true
+}
+
+// lookup returns the messages holding a word that starts with prefix, in
+// ascending order. The result may be the index's own postings; do not
+// modify it.
+func (x *wordIndex) lookup(prefix string) []int32 {
+	lo, _ := slices.BinarySearchFunc(x.byWord, prefix, func(id int32, p string) int {
+		return strings.Compare(x.words[id], p)
+	})
+	hi := lo
+	for hi < len(x.byWord) && strings.HasPrefix(x.words[x.byWord[hi]], prefix) {
+		hi++
+	}
+	switch hi - lo {
+	case 0:
+		return nil
+	case 1:
+		return x.posts[x.byWord[lo]]
+	}
+	var msgs []int32
+	for _, id := range x.byWord[lo:hi] {
+		msgs = append(msgs, x.posts[id]...)
+	}
+	slices.Sort(msgs)
+	return slices.Compact(msgs)
+}
+
+// sortWords merges the words added since the last call into byWord.
+func (x *wordIndex) sortWords() {
+	if len(x.byWord) == len(x.words) {
+		return
+	}
+	byWord := func(a, b int32) int { return strings.Compare(x.words[a], x.words[b]) }
+	added := make([]int32, 0, len(x.words)-len(x.byWord))
+	for id := len(x.byWord); id < len(x.words); id++ {
+		added = append(added, int32(id))
+	}
+	slices.SortFunc(added, byWord)
+	old := x.byWord
+	merged := make([]int32, 0, len(x.words))
+	for len(old) > 0 && len(added) > 0 {
+		if byWord(old[0], added[0]) < 0 {
+			merged, old = append(merged, old[0]), old[1:]
+		} else {
+			merged, added = append(merged, added[0]), added[1:]
+		}
+	}
+	x.byWord = append(append(merged, old...), added...)
+}
+
+// intersect returns the values in both a and b, which are ascending.
+func intersect(a, b []int32) []int32 {
+	var out []int32
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			out = append(out, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	return out
+}
+
+// eachWord calls f with each word of b, lowercased, in a buffer reused
+// between calls. A JSON escape such as \n ends a word.
+func eachWord(b []byte, f func(w []byte)) {
+	var w []byte
+	flush := func() {
+		if len(w) > 0 {
+			f(w)
+			w = w[:0]
+		}
+	}
+	for len(b) > 0 {
+		c := b[0]
+		switch {
+		case c == '\\':
+			flush()
+			n := 2
+			if len(b) > 1 && b[1] == 'u' {
+				n = 6
+			}
+			b = b[min(n, len(b)):]
+			continue
+		case c < utf8.RuneSelf:
+			if 'a' <= c && c <= 'z' || '0' <= c && c <= '9' {
+				w = append(w, c)
+			} else if 'A' <= c && c <= 'Z' {
+				w = append(w, c+'a'-'A')
+			} else {
+				flush()
+			}
+			b = b[1:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			w = utf8.AppendRune(w, unicode.ToLower(r))
+		} else {
+			flush()
+		}
+		b = b[size:]
+	}
+	flush()
+}
+
+// Search returns the messages of kind k that f matches, in ascending
+// order, with the words of f.Texts looked up in the word index. ok is false
+// if f is empty, or has text but no words and nothing else.
+func (s *messageStore) Search(k telemetry.Kind, f filter.Filter) (msgs []int, ok bool) {
+	x := s.WordsFor(k)
+	var hits []int
+	words := false
+	if len(f.Texts) > 0 {
+		if hits, words = x.search(strings.Join(f.Texts, " ")); words {
+			f.Texts = nil
+		}
+	}
+	if f.IsZero() {
+		return slices.Clone(hits), words
+	}
+	if key := f.String(); x.cond != key {
+		x.cond, x.held, x.checked = key, nil, 0
+	}
+	all := s.Messages(k)
+	for ; x.checked < len(all); x.checked++ {
+		if f.Match(all[x.checked]) {
+			x.held = append(x.held, int32(x.checked))
+		}
+	}
+	held := x.held
+	if words {
+		want := make([]int32, len(hits))
+		for i, h := range hits {
+			want[i] = int32(h)
+		}
+		held = intersect(want, held)
+	}
+	msgs = make([]int, len(held))
+	for i, h := range held {
+		msgs[i] = int(h)
+	}
+	return msgs, true
+}
+
+// WordsFor returns the word index of kind k.
+func (s *messageStore) WordsFor(k telemetry.Kind) *wordIndex {
+	if s.words == nil {
+		s.words = make(map[telemetry.Kind]*wordIndex)
+	}
+	x, ok := s.words[k]
+	if !ok {
+		x = &wordIndex{}
+		s.words[k] = x
+	}
+	return x
+}
+
+// searchState is the Model's side of / search.
+type searchState struct {
+	query  string
+	filter filter.Filter // query, parsed
+	note   string        // why the last search went nowhere, until the next key
+}
+
+// startSearch asks for a query, starting from the last one.
+func (m *Model) startSearch() tea.Cmd {
+	return m.openPrompt("/", m.search.query, func(m *Model, q string) tea.Cmd {
+		q = strings.TrimSpace(q)
+		f, err := query.Parse(q)
+		if err != nil {
+			m.search.query, m.search.filter = "", filter.Filter{}
+			m.search.note = "search: " + err.Error()
+			return nil
+		}
+		m.search.query, m.search.filter = q, f
+		if q == "" {
+			return nil
+		}
+		m.searchNext(0)
+		return nil
+	})
+}
+
+// matches returns the displayed messages of the active tab that the query
+// matches, in order.
+func (m Model) matches() ([]int, bool) {
+	msgs, ok := m.store.Search(m.Active, m.search.filter)
+	if !ok {
+		return nil, false
+	}
+	return slices.DeleteFunc(msgs, func(i int) bool {
+		_, shown := m.store.MessageStart(m.Active, i)
+		return !shown
+	}), true
+}
+
+// searchNext moves the cursor to the next match after the cursor's
+// message for dir 1, the one before it for -1, wrapping around. For 0 it
+// starts at the cursor's message when paused and at the newest match when
+// not.
+func (m *Model) searchNext(dir int) {
+	if m.search.query == "" {
+		return
+	}
+	if m.tableMode() {
+		m.search.note = "search needs the JSON view (v)"
+		return
+	}
+	msgs, ok := m.matches()
+	switch {
+	case !ok:
+		m.search.note = "search for letters or digits"
+		return
+	case len(msgs) == 0:
+		m.search.note = fmt.Sprintf("no match for %q", m.search.query)
+		return
+	}
+	pos := len(msgs) - 1
+	if m.paused && m.store.TotalLines(m.Active) > 0 {
+		cur := m.cursorMsgIndex()
+		switch dir {
+		case 0:
+			pos, _ = slices.BinarySearch(msgs, cur)
+		case 1:
+			pos, _ = slices.BinarySearch(msgs, cur+1)
+		default:
+			pos, _ = slices.BinarySearch(msgs, cur)
+			pos--
+		}
+		pos = (pos + len(msgs)) % len(msgs)
+	}
+	m.showMatch(msgs[pos])
+}
+
+// showMatch pauses with the cursor on the first line of message i that
+// holds the query, or on its first line if no line holds it whole.
+func (m *Model) showMatch(i int) {
+	start, ok := m.store.MessageStart(m.Active, i)
+	if !ok {
+		return
+	}
+	msg := m.store.Display(m.Active, i)
+	line := 0
+	texts := m.search.filter.Texts
+	for j, l := range msg.Lines() {
+		if slices.ContainsFunc(texts, func(t string) bool { return strings.Contains(strings.ToLower(l), strings.ToLower(t)) }) {
+			line = j
+			break
+		}
+	}
+	m.paused = true
+	m.cur.line = start + m.store.rowOf(*msg, line)
+	m.viewport.SetTotal(m.totalLines())
+	m.ensureCursorVisible()
+	m.syncViewport()
+}
+
+// searchSegment shows the query and where the cursor is among its
+// matches.
+func searchSegment(m Model) string {
+	if m.search.note != "" {
+		return m.search.note
+	}
+	if m.search.query == "" || !m.paused || m.tableMode() || m.store.TotalLines(m.Active) == 0 {
+		return ""
+	}
+	msgs, ok := m.matches()
+	if !ok || len(msgs) == 0 {
+		return ""
+	}
+	cur := m.cursorMsgIndex()
+	if pos, found := slices.BinarySearch(msgs, cur); found {
+		return fmt.Sprintf("/%s %d/%d", m.search.query, pos+1, len(msgs))
+	}
+	return fmt.Sprintf("/%s %d matches", m.search.query, len(msgs))
+}
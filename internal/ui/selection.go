@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// selection is a visual range of whole messages, from the one the cursor
+// was on when V was pressed to the one it is on now. y, |, O, and W act on
+// every message in it.
+type selection struct {
+	active bool
+	anchor int    // message index within the active kind where V was pressed
+	note   string // what the last W wrote, until the next key
+}
+
+// toggleSelection starts a selection at the cursor message, or ends one.
+// Acting on a selection ends it too, as in vi.
+func (m *Model) toggleSelection() {
+	if m.visual.active || m.cur.msg == nil {
+		m.visual.active = false
+	} else {
+		m.visual = selection{active: true, anchor: m.cursorMsgIndex()}
+	}
+	m.syncViewport()
+}
+
+// selectionBounds returns the first and last message index selected, in
+// either direction from the anchor.
+func (m *Model) selectionBounds() (lo, hi int) {
+	cur := m.cursorMsgIndex()
+	return min(m.visual.anchor, cur), max(m.visual.anchor, cur)
+}
+
+// selectedMessages returns the messages in the selection as shown, or the
+// message under the cursor when nothing is selected.
+func (m *Model) selectedMessages() []telemetry.Message {
+	if !m.visual.active {
+		if m.cur.msg == nil {
+			return nil
+		}
+		return []telemetry.Message{*m.cur.msg}
+	}
+	lo, hi := m.selectionBounds()
+	x := m.store.indexFor(m.Active)
+	var out []telemetry.Message
+	for _, i := range x.msgs[sort.SearchInts(x.msgs, lo):] {
+		if i > hi {
+			break
+		}
+		out = append(out, *m.store.Display(m.Active, i))
+	}
+	return out
+}
+
+// selectedLines renders msgs as they are shown, one after another, but
+// with nothing cut short.
+func selectedLines(msgs []telemetry.Message) []string {
+	var lines []string
+	for _, msg := range msgs {
+		lines = append(lines, msg.FullLines()...)
+	}
+	return lines
+}
+
+// selectedRaw returns msgs as received, one per line.
+func selectedRaw(msgs []telemetry.Message) []byte {
+	var b bytes.Buffer
+	for _, msg := range msgs {
+		b.Write(bytes.TrimRight(msg.Raw, "\r\n"))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// takeSelection returns the selected messages and ends the selection.
+func (m *Model) takeSelection() []telemetry.Message {
+	msgs := m.selectedMessages()
+	if m.visual.active {
+		m.toggleSelection()
+	}
+	return msgs
+}
+
+// startExport asks for a file to write the selection to.
+func (m *Model) startExport() tea.Cmd {
+	msgs := m.takeSelection()
+	if len(msgs) == 0 {
+		return nil
+	}
+	return m.openPrompt("write to: ", "", func(m *Model, path string) tea.Cmd {
+		m.export(msgs, path)
+		return nil
+	})
+}
+
+// export writes msgs as compact OTLP JSON, one per line like otail export,
+// so the file can be opened with otail replay. Frames that are not JSON are
+// skipped.
+func (m *Model) export(msgs []telemetry.Message, path string) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return
+	}
+	path = expandHome(path)
+	var out bytes.Buffer
+	n, skipped := 0, 0
+	for _, msg := range msgs {
+		if err := json.Compact(&out, msg.Raw); err != nil {
+			skipped++
+			continue
+		}
+		out.WriteByte('\n')
+		n++
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		m.visual.note = "write: " + err.Error()
+		return
+	}
+	m.visual.note = fmt.Sprintf("wrote %s to %s", plural(n, "message"), path)
+	if skipped > 0 {
+		m.visual.note += fmt.Sprintf(" (%d not JSON, skipped)", skipped)
+	}
+}
+
+// expandHome replaces a leading ~/ in path with the home directory.
+func expandHome(path string) string {
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
+
+func selectionSegment(m Model) string {
+	if m.visual.active {
+		return fmt.Sprintf("VISUAL %s", plural(len(m.selectedMessages()), "message"))
+	}
+	return m.visual.note
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// serviceMapRefresh is how often the open service map is rebuilt from the
+// trace buffer.
+const serviceMapRefresh = 2 * time.Second
+
+// serviceMapTickMsg asks for the service map to be rebuilt.
+type serviceMapTickMsg struct{}
+
+func serviceMapTick() tea.Cmd {
+	return tea.Tick(serviceMapRefresh, func(time.Time) tea.Msg { return serviceMapTickMsg{} })
+}
+
+// rebuildServiceMap recomputes the map from every buffered trace message
+// and renders it as an adjacency list.
+func (m *Model) rebuildServiceMap() {
+	msgs := m.store.Messages(telemetry.KindTraces)
+	traces := make([]ptrace.Traces, len(msgs))
+	for i := range msgs {
+		traces[i] = msgs[i].Traces
+	}
+	m.overlayLines = renderServiceMap(aggregate.BuildServiceMap(traces))
+}
+
+// renderServiceMap lays the map out as one block per service, listing the
+// services it calls.
+func renderServiceMap(sm aggregate.ServiceMap) []string {
+	lines := []string{styles.Status.Render(fmt.Sprintf(
+		"service map · %d services, %d dependencies · refreshed every %s",
+		len(sm.Services), len(sm.Edges), serviceMapRefresh))}
+	if len(sm.Services) == 0 {
+		return append(lines, "", "no spans yet")
+	}
+	for _, svc := range sm.Services {
+		lines = append(lines, "", svc)
+		callees := sm.Callees(svc)
+		for i, e := range callees {
+			branch := "├─▶ "
+			if i == len(callees)-1 {
+				branch = "└─▶ "
+			}
+			text := fmt.Sprintf("  %s%s  %s", branch, e.To, plural(e.Calls, "call"))
+			if e.Errors > 0 {
+				text += styles.SeverityStyle(telemetry.SeverityError).Render(", " + plural(e.Errors, "error"))
+			}
+			lines = append(lines, text)
+		}
+	}
+	return lines
+}
+
+// plural formats n with noun, adding an s unless n is one.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
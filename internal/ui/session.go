@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/telemetry"
+	"github.com/jwafle/otail/internal/transport"
+)
+
+// topServices is how many services the session summary lists.
+const topServices = 5
+
+// session tallies what arrived over the life of the TUI, for the summary
+// written on exit. It counts every frame, including ones a paused tab
+// discarded.
+type session struct {
+	started    time.Time
+	frames     map[telemetry.Kind]int
+	services   map[string]int  // frames per service.name
+	connects   int             // transitions to Connected, including the first
+	second     time.Time       // start of the second being counted
+	thisSecond int             // frames in that second
+	peak       int             // most frames seen in one second
+	retired    transport.Stats // dropped and corrupt frames on streams since replaced
+	pipeline   aggregate.Pipeline
+}
+
+func newSession() *session {
+	return &session{
+		started:  time.Now(),
+		frames:   map[telemetry.Kind]int{},
+		services: map[string]int{},
+	}
+}
+
+// add counts a batch of frames that arrived at now.
+func (s *session) add(batch frameBatchMsg, now time.Time) {
+	if sec := now.Truncate(time.Second); !sec.Equal(s.second) {
+		s.second, s.thisSecond = sec, 0
+	}
+	s.thisSecond += len(batch)
+	s.peak = max(s.peak, s.thisSecond)
+	for _, fm := range batch {
+		s.frames[fm.Kind]++
+		if fm.Kind == telemetry.KindLogs {
+			s.pipeline.AddLogs(fm.Logs)
+		}
+		for _, svc := range fm.Services() {
+			s.services[svc]++
+		}
+	}
+}
+
+// noteState counts reconnects from the stream's state transitions.
+func (s *session) noteState(st transport.State) {
+	if st.Kind == transport.Connected {
+		s.connects++
+	}
+}
+
+// retire keeps the counters of a stream that is being replaced by a
+// reconnect.
+func (s *session) retire(stream *transport.Stream) {
+	if stream == nil {
+		return
+	}
+	st := stream.Stats()
+	s.retired.Dropped += st.Dropped
+	s.retired.DecompressFailed += st.DecompressFailed
+}
+
+// write prints the summary, with the final stream stats, to w.
+func (s *session) write(w io.Writer, st transport.Stats, now time.Time) error {
+	st.Dropped += s.retired.Dropped
+	st.DecompressFailed += s.retired.DecompressFailed
+	var b strings.Builder
+	fmt.Fprintf(&b, "otail session %s\n", s.started.Format(time.RFC3339))
+	fmt.Fprintf(&b, "%-10s %s\n", "duration", now.Sub(s.started).Round(time.Second))
+	for _, k := range []telemetry.Kind{telemetry.KindLogs, telemetry.KindMetrics, telemetry.KindTraces} {
+		fmt.Fprintf(&b, "%-10s %d frames\n", k, s.frames[k])
+	}
+	fmt.Fprintf(&b, "%-10s %d frames did not parse as OTLP\n", "other", s.frames[telemetry.KindUnknown])
+	fmt.Fprintf(&b, "%-10s %d frames\n", "dropped", st.Dropped)
+	if st.DecompressFailed > 0 {
+		fmt.Fprintf(&b, "%-10s %d frames failed to decompress\n", "corrupt", st.DecompressFailed)
+	}
+	fmt.Fprintf(&b, "%-10s %d\n", "reconnects", max(s.connects-1, 0))
+	fmt.Fprintf(&b, "%-10s %d frames/s\n", "peak", s.peak)
+	if h := s.pipeline.All(); h.Count() > 0 {
+		fmt.Fprintf(&b, "%-10s p50=%s p99=%s max=%s over %d log records\n", "pipeline",
+			shortDuration(h.Quantile(0.5)), shortDuration(h.Quantile(0.99)), shortDuration(h.Max()), h.Count())
+	}
+
+	names := make([]string, 0, len(s.services))
+	for svc := range s.services {
+		names = append(names, svc)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(s.services[b], s.services[a]), strings.Compare(a, b))
+	})
+	for i, svc := range names[:min(len(names), topServices)] {
+		label := ""
+		if i == 0 {
+			label = "services"
+		}
+		fmt.Fprintf(&b, "%-10s %s (%d frames)\n", label, svc, s.services[svc])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
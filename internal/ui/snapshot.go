@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// snapshot is a frozen copy of what the live view displayed when it was
+// taken. New messages never reach it.
+type snapshot struct {
+	name  string
+	taken time.Time
+	store *messageStore
+}
+
+// snapshots holds the taken snapshots and the one on screen.
+type snapshots struct {
+	list    []snapshot
+	viewing int // 1-based index into list; 0 = live
+	sel     int // picker selection; 0 = live
+}
+
+func (m *Model) viewingSnapshot() bool {
+	return m.snapshots.viewing > 0
+}
+
+// startSnapshot copies what is displayed now and asks for a name for it.
+func (m *Model) startSnapshot() tea.Cmd {
+	sn := snapshot{taken: time.Now(), store: m.store.Snapshot()}
+	def := fmt.Sprintf("snapshot-%d", len(m.snapshots.list)+1)
+	return m.openPrompt("snapshot name: ", def, func(m *Model, name string) tea.Cmd {
+		sn.name = strings.TrimSpace(name)
+		if sn.name == "" {
+			sn.name = def
+		}
+		m.snapshots.list = append(m.snapshots.list, sn)
+		return nil
+	})
+}
+
+// viewSnapshot puts snapshot i (1-based) on screen, or the live buffer
+// for 0.
+func (m *Model) viewSnapshot(i int) {
+	sn := &m.snapshots
+	if i == sn.viewing {
+		return
+	}
+	if sn.viewing == 0 {
+		m.saveTab()
+	}
+	sn.viewing = i
+	if m.table != nil {
+		m.table = newLogTable(m.table.columns)
+	}
+	if i == 0 {
+		m.store = m.live
+		m.restoreTab()
+		return
+	}
+	m.store = sn.list[i-1].store
+	m.showFrozen()
+}
+
+// showFrozen shows the active tab of a snapshot from the top, paused so the
+// cursor can move through it.
+func (m *Model) showFrozen() {
+	m.paused = true
+	m.cur.line = 0
+	m.viewport.SetTotal(m.totalLines())
+	m.viewport.SetYOffset(0)
+	m.syncViewport()
+}
+
+func (m *Model) openSnapshotPicker() {
+	m.snapshots.sel = m.snapshots.viewing
+	m.renderSnapshotPicker()
+}
+
+func (m *Model) renderSnapshotPicker() {
+	sn := &m.snapshots
+	lines := []string{styles.Status.Render("snapshots · enter view · esc close")}
+	add := func(i int, text string) {
+		mark := "  "
+		if i == sn.viewing {
+			mark = "▸ "
+		}
+		line := "  " + mark + text
+		if i == sn.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	add(0, "live")
+	for i, s := range sn.list {
+		var counts []string
+		for _, t := range tabs {
+			if n := len(s.store.Messages(t.kind)); n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", n, strings.ToLower(t.title)))
+			}
+		}
+		if len(counts) == 0 {
+			counts = []string{"empty"}
+		}
+		add(i+1, fmt.Sprintf("%s · %s · %s", s.name, s.taken.Format("15:04:05"), strings.Join(counts, ", ")))
+	}
+	m.overlayLines = lines
+}
+
+// snapshotsKey handles keys while the picker is open and reports whether it
+// consumed msg.
+func (m *Model) snapshotsKey(msg tea.KeyMsg) bool {
+	sn := &m.snapshots
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		sn.sel = max(sn.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		sn.sel = min(sn.sel+1, len(sn.list))
+	case msg.String() == "enter":
+		m.closeOverlay()
+		m.viewSnapshot(sn.sel)
+		return true
+	default:
+		return false
+	}
+	m.renderSnapshotPicker()
+	line := sn.sel + 1 // below the title
+	if sn.sel == 0 {
+		line = 0
+	}
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+	m.syncViewport()
+	return true
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jwafle/otail/internal/transport"
+)
+
+// sourcesRefresh is how often the open source list updates its rates.
+const sourcesRefresh = time.Second
+
+// sourcesTickMsg asks for the source list to be refreshed.
+type sourcesTickMsg struct{}
+
+func sourcesTick() tea.Cmd {
+	return tea.Tick(sourcesRefresh, func(time.Time) tea.Msg { return sourcesTickMsg{} })
+}
+
+// sourcePicker lists the sources of a merged stream with their frame rates.
+type sourcePicker struct {
+	sel    int
+	frames []uint64 // frame counts at the last refresh
+	at     time.Time
+	rates  []float64 // frames per second since the refresh before
+}
+
+// sourceList returns the sources of the current stream, or nil when it has
+// just one.
+func (m *Model) sourceList() []transport.SourceStatus {
+	if m.stream == nil {
+		return nil
+	}
+	return m.stream.Sources()
+}
+
+// refreshSources recomputes the per-source rates and renders the list.
+func (m *Model) refreshSources() {
+	p := &m.sources
+	srcs := m.sourceList()
+	now := time.Now()
+	if len(p.frames) == len(srcs) && !p.at.IsZero() {
+		secs := now.Sub(p.at).Seconds()
+		p.rates = make([]float64, len(srcs))
+		for i, s := range srcs {
+			if s.Frames >= p.frames[i] { // counts restart on reconnect
+				p.rates[i] = float64(s.Frames-p.frames[i]) / secs
+			}
+		}
+	}
+	p.frames, p.at = p.frames[:0], now
+	for _, s := range srcs {
+		p.frames = append(p.frames, s.Frames)
+	}
+	m.renderSourcePicker()
+}
+
+func (m *Model) renderSourcePicker() {
+	p := &m.sources
+	srcs := m.sourceList()
+	lines := []string{styles.Status.Render("sources · enter on/off · s solo · a all · esc close")}
+	if len(srcs) == 0 {
+		lines = append(lines, "  one source; pass --endpoint more than once to tail several collectors")
+	}
+	for i, s := range srcs {
+		mark := "[x]"
+		if !s.Enabled {
+			mark = "[ ]"
+		}
+		rate := "  …"
+		if i < len(p.rates) {
+			rate = fmt.Sprintf("%5.1f/s", p.rates[i])
+		}
+		line := fmt.Sprintf("  %s %-24s %-24s %s %8d frames", mark, s.Name, s.State, rate, s.Frames)
+		if i == p.sel {
+			line = styles.Cursor.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.overlayLines = lines
+}
+
+// sourcesKey handles keys while the source list is open and reports whether
+// it consumed msg.
+func (m *Model) sourcesKey(msg tea.KeyMsg) bool {
+	p := &m.sources
+	srcs := m.sourceList()
+	switch {
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		p.sel = max(p.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		p.sel = max(min(p.sel+1, len(srcs)-1), 0)
+	case msg.String() == "enter" || msg.String() == " ":
+		if p.sel < len(srcs) {
+			m.stream.SetEnabled(p.sel, !srcs[p.sel].Enabled)
+		}
+	case msg.String() == "s":
+		for i := range srcs {
+			m.stream.SetEnabled(i, i == p.sel)
+		}
+	case msg.String() == "a":
+		for i := range srcs {
+			m.stream.SetEnabled(i, true)
+		}
+	default:
+		return false
+	}
+	m.renderSourcePicker()
+	m.syncViewport()
+	return true
+}
+
+// sourcesSegment shows how many sources are switched on when some are off.
+func sourcesSegment(m Model) string {
+	srcs := m.sourceList()
+	on := 0
+	for _, s := range srcs {
+		if s.Enabled {
+			on++
+		}
+	}
+	if on == len(srcs) {
+		return ""
+	}
+	return fmt.Sprintf("sources %d/%d", on, len(srcs))
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Span views narrow the Traces tab to some of the spans in each message:
+// the error-span view and trace tail sampling. Whether a span is shown can
+// depend on spans that arrive later, so each view scans messages as they
+// come and reports when an earlier message needs redrawing.
+
+// spanRef identifies a span across messages.
+type spanRef struct {
+	trace pcommon.TraceID
+	span  pcommon.SpanID
+}
+
+func eachSpan(td ptrace.Traces, fn func(ptrace.Span)) {
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		sss := rss.At(i).ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				fn(spans.At(k))
+			}
+		}
+	}
+}
+
+// spanViews reports whether any span view is on.
+func (s *messageStore) spanViews() bool {
+	return s.errorSpans != nil || s.tailSampler != nil
+}
+
+// scanSpans feeds trace message i to the span views, reporting whether an
+// earlier message's view went stale.
+func (s *messageStore) scanSpans(msg telemetry.Message, i int) bool {
+	stale := false
+	if s.errorSpans != nil {
+		st, _ := s.errorSpans.scan(msg, i)
+		stale = stale || st
+	}
+	if s.tailSampler != nil {
+		stale = s.tailSampler.scan(msg, i) || stale
+	}
+	return stale
+}
+
+// rebuildSpans rescans every trace message from scratch.
+func (s *messageStore) rebuildSpans(msgs []telemetry.Message) {
+	if s.errorSpans != nil {
+		s.errorSpans.rebuild(msgs)
+	}
+	if s.tailSampler != nil {
+		s.tailSampler.rebuild(msgs)
+	}
+}
+
+// pruneSpans returns a copy of msg holding only the spans every view
+// keeps, and false if there are none.
+func (s *messageStore) pruneSpans(msg telemetry.Message) (telemetry.Message, bool) {
+	td := ptrace.NewTraces()
+	msg.Traces.CopyTo(td)
+	kept := 0
+	td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(sp ptrace.Span) bool {
+				if s.errorSpans != nil && !s.errorSpans.keeps(sp) ||
+					s.tailSampler != nil && !s.tailSampler.keeps(sp.TraceID()) {
+					return true
+				}
+				kept++
+				return false
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	out := telemetry.FromTraces(td)
+	out.Received = msg.Received
+	return out, kept > 0
+}
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/ui/theme"
+)
+
+// State is the part of the UI remembered from one run to the next: the tab,
+// the filters, and how the screen was set up. Messages are not kept, so
+// restored bookmarks only remember what they marked.
+type State struct {
+	Tab       string                   `json:"tab,omitempty"`
+	Filters   map[string]string        `json:"filters,omitempty"` // by tab, in --filter syntax
+	Theme     string                   `json:"theme,omitempty"`
+	Table     bool                     `json:"table,omitempty"`
+	Columns   []string                 `json:"columns,omitempty"`
+	Bookmarks map[string]savedBookmark `json:"bookmarks,omitempty"` // by letter
+	Searches  []string                 `json:"searches,omitempty"`  // saved with :save, as NAME=QUERY
+}
+
+// savedBookmark is a bookmark as State keeps it.
+type savedBookmark struct {
+	Tab     string `json:"tab"`
+	Summary string `json:"summary"`
+}
+
+// DefaultStatePath is where otail keeps its State between runs:
+// $XDG_STATE_HOME/otail/state.json, or ~/.local/state/otail/state.json.
+func DefaultStatePath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "otail", "state.json")
+}
+
+// LoadState reads the State saved at path. It returns nil, and no error,
+// if nothing has been saved there yet.
+func LoadState(path string) (*State, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st State
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// SaveState writes st to path, creating its directory if need be.
+func SaveState(path string, st State) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false) // filters are full of &
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(st); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// state captures what m should start with next time. Filters and
+// bookmarks come from the live store, not a snapshot being viewed.
+func (m Model) state() State {
+	st := State{Tab: tabName(m.Active), Theme: m.theme, Table: m.table != nil, Columns: m.tableColumns, Searches: m.presets.savedSearches()}
+	if m.table != nil {
+		st.Columns = m.table.columns
+	}
+	for _, t := range tabs {
+		if f := m.live.Filter(t.kind); !f.IsZero() {
+			if st.Filters == nil {
+				st.Filters = map[string]string{}
+			}
+			st.Filters[tabName(t.kind)] = f.String()
+		}
+	}
+	for r, b := range m.live.marks {
+		if st.Bookmarks == nil {
+			st.Bookmarks = map[string]savedBookmark{}
+		}
+		st.Bookmarks[string(r)] = savedBookmark{Tab: tabName(b.kind), Summary: b.summary}
+	}
+	return st
+}
+
+// restore starts m as st left things. Anything st does not name, or names
+// but no longer makes sense, keeps what Config gave it.
+func (m *Model) restore(st *State) {
+	if k, ok := tabNamed(st.Tab); ok {
+		m.Active = k
+	}
+	for name, q := range st.Filters {
+		k, ok := tabNamed(name)
+		if !ok {
+			continue
+		}
+		if f, err := filter.Parse(q); err == nil {
+			m.live.SetFilter(k, f)
+		}
+	}
+	for _, s := range st.Searches {
+		if name, q, ok := strings.Cut(s, "="); ok && name != "" {
+			m.save(name, q) // a search that no longer parses is dropped
+		}
+	}
+	if st.Theme != "" {
+		if th, err := theme.Lookup(st.Theme); err == nil {
+			styles, m.theme = th.Styles(), th.Name
+		}
+	}
+	if len(st.Columns) > 0 {
+		m.tableColumns = st.Columns
+	}
+	if st.Table {
+		m.table = newLogTable(m.tableColumns)
+	}
+	for letter, b := range st.Bookmarks {
+		k, ok := tabNamed(b.Tab)
+		r := []rune(letter)
+		if !ok || len(r) != 1 || r[0] < 'a' || r[0] > 'z' {
+			continue
+		}
+		if m.live.marks == nil {
+			m.live.marks = make(map[rune]bookmark)
+		}
+		m.live.marks[r[0]] = bookmark{kind: k, seq: -1, summary: b.Summary}
+	}
+}
@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jwafle/otail/internal/query"
+	"github.com/jwafle/otail/internal/transport"
+)
+
+// statusSeparator is placed between non-empty status bar segments.
+const statusSeparator = " │ "
+
+// statusSegment renders one piece of the status bar. Segments with nothing
+// to report return an empty string and are skipped.
+type statusSegment func(m Model) string
+
+// statusSegments lists the status bar segments from left to right.
+var statusSegments = []statusSegment{
+	modeSegment,
+	reflowSegment,
+	signalSegment,
+	filterSegment,
+	errorSpansSegment,
+	tailSamplingSegment,
+	scopesSegment,
+	formatSegment,
+	markSegment,
+	connectionSegment,
+	sourcesSegment,
+	bufferSegment,
+	droppedSegment,
+	orderSegment,
+	decompressSegment,
+	editorSegment,
+	undoSegment,
+	bookmarkSegment,
+	searchSegment,
+	selectionSegment,
+	clipSegment,
+	configSegment,
+	commandSegment,
+}
+
+func (m Model) renderStatusBar() string {
+	parts := make([]string, 0, len(statusSegments))
+	for _, seg := range statusSegments {
+		if s := seg(m); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return styles.Status.Render(strings.Join(parts, statusSeparator))
+}
+
+// renderBanner replaces the status bar once the stream has closed.
+func (m Model) renderBanner() string {
+	text := "disconnected — press r to reconnect"
+	if m.err != nil {
+		text += fmt.Sprintf(" (%v)", m.err)
+	}
+	return styles.Banner.Render(text)
+}
+
+func modeSegment(m Model) string {
+	if m.viewingSnapshot() {
+		return "[SNAPSHOT " + m.snapshots.list[m.snapshots.viewing-1].name + "]"
+	}
+	if m.paused {
+		return "[PAUSED]"
+	}
+	return m.spinner.View() + " Streaming"
+}
+
+func signalSegment(m Model) string {
+	stored := len(m.activeMessages())
+	if !m.store.Filter(m.Active).IsZero() || m.store.narrowed(m.Active) {
+		return fmt.Sprintf("%s (%d/%d matching)", tabName(m.Active), m.store.Displayed(m.Active), stored)
+	}
+	if m.store.sampler != nil {
+		return fmt.Sprintf("%s (%d/%d sampled)", tabName(m.Active), m.store.Displayed(m.Active), stored)
+	}
+	return fmt.Sprintf("%s (%d)", tabName(m.Active), stored)
+}
+
+func filterSegment(m Model) string {
+	f := m.store.Filter(m.Active)
+	if f.IsZero() {
+		return ""
+	}
+	if name, ok := m.activePreset(); ok {
+		return "preset " + name + contextNote(m)
+	}
+	return "filter " + query.Format(f) + contextNote(m)
+}
+
+func markSegment(m Model) string {
+	if m.marked == nil {
+		return ""
+	}
+	return "marked " + m.marked.Kind.String() + " (D to diff)"
+}
+
+func connectionSegment(m Model) string {
+	if m.conn.Kind != transport.Reconnecting {
+		return m.conn.String()
+	}
+	wait := time.Until(m.conn.NextRetry).Round(time.Second)
+	if wait <= 0 {
+		return m.conn.String()
+	}
+	return fmt.Sprintf("%s, retry in %s", m.conn, wait)
+}
+
+func bufferSegment(m Model) string {
+	if m.stream == nil {
+		return ""
+	}
+	n, capacity := m.parser.buffered()
+	s := fmt.Sprintf("buf %d/%d", n, capacity)
+	st := m.stream.Stats()
+	if st.Buffered > 0 {
+		s += fmt.Sprintf(" +%d frames", st.Buffered)
+	}
+	if st.Spilled > 0 {
+		s += fmt.Sprintf(" +%d on disk", st.Spilled)
+	}
+	return s
+}
+
+func droppedSegment(m Model) string {
+	if m.stream == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d dropped", m.stream.Stats().Dropped)
+}
+
+// orderSegment counts the messages that arrived well out of order for
+// their source, or repeated a span already seen; the detail footer says
+// which.
+func orderSegment(m Model) string {
+	var parts []string
+	if n := m.order.Late; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d out of order", n))
+	}
+	if n := m.order.Duplicates; n > 0 {
+		parts = append(parts, plural(n, "duplicate span"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "⚠ " + strings.Join(parts, ", ")
+}
+
+func decompressSegment(m Model) string {
+	if m.stream == nil {
+		return ""
+	}
+	if n := m.stream.Stats().DecompressFailed; n > 0 {
+		return fmt.Sprintf("%d failed to decompress", n)
+	}
+	return ""
+}
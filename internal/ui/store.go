@@ -1,41 +1,461 @@
 package ui
 
-import "github.com/jwafle/otail/internal/telemetry"
+import (
+	"slices"
+	"sort"
 
-// messageStore keeps messages separated by kind.
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// lineRef locates one screen row: the message's index within its kind, the
+// line within that message, and which of the line's wrapped rows it is.
+type lineRef struct {
+	msg, line, part int
+}
+
+// lineIndex records the first screen row of every displayed message of one
+// kind. It grows incrementally on Add and answers line lookups by binary
+// search.
+type lineIndex struct {
+	starts []int // starts[i] is the first line of the i-th displayed message
+	msgs   []int // msgs[i] is that message's index within its kind
+	total  int
+
+	context map[int]bool // messages shown only as context around a match
+	before  []int        // latest messages not shown, context for the next match
+	after   int          // messages still to show as context after the last match
+}
+
+func (x *lineIndex) add(msg, lines int) {
+	x.starts = append(x.starts, x.total)
+	x.msgs = append(x.msgs, msg)
+	x.total += lines
+}
+
+// find returns the position in the index of the message containing line.
+func (x *lineIndex) find(line int) int {
+	// First message starting after line, minus one.
+	return sort.Search(len(x.starts), func(i int) bool { return x.starts[i] > line }) - 1
+}
+
+// locate returns the message containing line and the line's offset within
+// it. ok is false when line is out of range.
+func (x *lineIndex) locate(line int) (msg, offset int, ok bool) {
+	if line < 0 || line >= x.total {
+		return 0, 0, false
+	}
+	pos := x.find(line)
+	return x.msgs[pos], line - x.starts[pos], true
+}
+
+// messageStore keeps messages separated by kind, along with a per-kind line
+// index for O(log n) line lookups. Every message is stored, but only those
+// the sampler keeps and the filter matches are indexed for display.
 type messageStore struct {
 	logs    []telemetry.Message
 	metrics []telemetry.Message
 	traces  []telemetry.Message
+	other   []telemetry.Message
+
+	index   map[telemetry.Kind]*lineIndex
+	sampled map[telemetry.Kind][]bool // whether the sampler kept each message
+	sampler *sampler                  // nil = display everything
+	seqs    map[telemetry.Kind][]int  // arrival number of each message, which outlives clears
+	nextSeq int
+	marks   map[rune]bookmark
+	filters map[telemetry.Kind]filter.Filter
+	gen     int // bumped whenever an index is rebuilt
+	context int // messages shown around each filter match, like grep -C; 0 = none
+	wrap    int // width lines wrap at, which the index counts rows for; 0 = no wrapping
+
+	errorSpans   *errorChain                                  // traces show only error spans and their parents; nil = every span
+	tailSampler  *tailSampler                                 // traces show only sampled traces; nil = every trace
+	hiddenScopes map[string]bool                              // instrumentation scopes left out of every kind, by scopeKey
+	scopes       map[string]int                               // records seen per instrumentation scope, by scopeKey
+	views        map[telemetry.Kind]map[int]telemetry.Message // messages as shown while a view narrows them
+
+	attrs   map[telemetry.Kind]*aggregate.Attributes
+	strings *telemetry.Interner // shares repeated strings among messages added; nil = none
+
+	words map[telemetry.Kind]*wordIndex // for / search
+
+	custom map[telemetry.Kind][]telemetry.Message // custom tabs' messages, copies of those their queries match
+	added  []telemetry.Kind                       // the tabs the last message was stored on, returned by AddExcept
 }
 
+// Add stores m on its signal's tab and on every custom tab whose query
+// matches it.
 func (s *messageStore) Add(m telemetry.Message) {
-	switch m.Kind {
-	case telemetry.KindMetrics:
-		s.metrics = append(s.metrics, m)
-	case telemetry.KindTraces:
-		s.traces = append(s.traces, m)
+	s.AddExcept(m, nil)
+}
+
+// AddExcept is Add, leaving m off the tabs skip reports true for, and
+// returns the tabs m was stored on. A nil skip leaves it off none.
+func (s *messageStore) AddExcept(m telemetry.Message, skip func(telemetry.Kind) bool) []telemetry.Kind {
+	if s.strings != nil {
+		s.strings.Intern(m)
+	}
+	kind := m.Kind
+	switch kind {
+	case telemetry.KindMetrics, telemetry.KindTraces, telemetry.KindUnknown:
 	default:
-		s.logs = append(s.logs, m)
+		kind = telemetry.KindLogs
 	}
+	s.added = s.added[:0]
+	if skip == nil || !skip(kind) {
+		s.add(kind, m)
+		s.countScopes(m)
+	}
+	for _, t := range tabs[len(signalTabs):] {
+		if (skip == nil || !skip(t.kind)) && t.query.Match(m) {
+			s.add(t.kind, m)
+		}
+	}
+	s.nextSeq++
+	return s.added
+}
+
+// add stores m as the next message of kind k and indexes it for display.
+func (s *messageStore) add(k telemetry.Kind, m telemetry.Message) {
+	s.added = append(s.added, k)
+	s.put(k, append(s.Messages(k), m))
+	s.AttributesFor(k).Add(m)
+	s.WordsFor(k).add(len(s.Messages(k))-1, m)
+	keep := s.sampler.keep(k)
+	if s.sampled == nil {
+		s.sampled = make(map[telemetry.Kind][]bool)
+	}
+	s.sampled[k] = append(s.sampled[k], keep)
+	if s.seqs == nil {
+		s.seqs = make(map[telemetry.Kind][]int)
+	}
+	s.seqs[k] = append(s.seqs[k], s.nextSeq)
+	i := len(s.Messages(k)) - 1
+	if k == telemetry.KindTraces && s.spanViews() && s.scanSpans(m, i) {
+		s.SetFilter(k, s.filters[k]) // an earlier message gained spans to show
+		return
+	}
+	if s.narrowed(k) {
+		var ok bool
+		if m, ok = s.view(k, i, m); !ok {
+			return
+		}
+	}
+	if keep {
+		s.place(k, i, m)
+	}
+}
+
+// ErrorSpans reports whether traces show only error spans and their parents.
+func (s *messageStore) ErrorSpans() bool {
+	return s.errorSpans != nil
+}
+
+// SetErrorSpans switches traces between every span and only error spans
+// with their parent chains, and re-indexes them.
+func (s *messageStore) SetErrorSpans(on bool) {
+	s.errorSpans = nil
+	if on {
+		s.errorSpans = newErrorChain()
+	}
+	s.SetFilter(telemetry.KindTraces, s.filters[telemetry.KindTraces])
+}
+
+// SetTailPolicy changes which traces are shown and re-indexes them.
+func (s *messageStore) SetTailPolicy(p TailPolicy) {
+	s.tailSampler = newTailSampler(p)
+	s.SetFilter(telemetry.KindTraces, s.filters[telemetry.KindTraces])
+}
+
+// Display returns message i of kind k as it is shown, which while a view
+// narrows k holds only the items the view keeps.
+func (s *messageStore) Display(k telemetry.Kind, i int) *telemetry.Message {
+	if v, ok := s.views[k][i]; ok && s.narrowed(k) {
+		return &v
+	}
+	return &s.Messages(k)[i]
+}
+
+// narrowed reports whether a view hides some items of kind k: hidden
+// scopes, or a span view on traces.
+func (s *messageStore) narrowed(k telemetry.Kind) bool {
+	return len(s.hiddenScopes) > 0 && k != telemetry.KindUnknown || k == telemetry.KindTraces && s.spanViews()
+}
+
+// view records and returns message i of kind k narrowed to the items every
+// view keeps, and false if there are none.
+func (s *messageStore) view(k telemetry.Kind, i int, m telemetry.Message) (telemetry.Message, bool) {
+	var ok bool
+	if m, ok = s.pruneScopes(m); !ok {
+		return m, false
+	}
+	if k == telemetry.KindTraces && s.spanViews() {
+		if m, ok = s.pruneSpans(m); !ok {
+			return m, false
+		}
+	}
+	if s.views == nil {
+		s.views = make(map[telemetry.Kind]map[int]telemetry.Message)
+	}
+	if s.views[k] == nil {
+		s.views[k] = make(map[int]telemetry.Message)
+	}
+	s.views[k][i] = m
+	return m, true
+}
+
+// Snapshot returns a new store holding the messages s currently displays.
+// The messages are shared, not copied; nothing modifies them once stored.
+func (s *messageStore) Snapshot() *messageStore {
+	snap := &messageStore{}
+	for _, t := range signalTabs { // Add copies them to the custom tabs
+		for _, i := range s.indexFor(t.kind).msgs {
+			snap.Add(*s.Display(t.kind, i))
+		}
+	}
+	return snap
+}
+
+// Reindex recounts every kind's rendered lines, after the render format
+// changes.
+func (s *messageStore) Reindex() {
+	for _, t := range tabs {
+		s.SetFilter(t.kind, s.filters[t.kind])
+	}
+}
+
+// Filter returns the display filter of kind k.
+func (s *messageStore) Filter(k telemetry.Kind) filter.Filter {
+	return s.filters[k]
+}
+
+// SetFilter replaces the display filter of kind k and re-indexes it.
+func (s *messageStore) SetFilter(k telemetry.Kind, f filter.Filter) {
+	if s.filters == nil {
+		s.filters = make(map[telemetry.Kind]filter.Filter)
+	}
+	s.filters[k] = f
+	s.gen++
+	x := s.indexFor(k)
+	*x = lineIndex{}
+	msgs := s.Messages(k)
+	delete(s.views, k)
+	if k == telemetry.KindTraces && s.spanViews() {
+		s.rebuildSpans(msgs)
+	}
+	for i, m := range msgs {
+		if s.narrowed(k) {
+			var ok bool
+			if m, ok = s.view(k, i, m); !ok {
+				continue
+			}
+		}
+		if s.sampled[k][i] {
+			s.place(k, i, m)
+		}
+	}
+}
+
+// clearedKind is what Clear removed from one kind, kept so that Restore can
+// put it back.
+type clearedKind struct {
+	kind    telemetry.Kind
+	msgs    []telemetry.Message
+	sampled []bool
+	seqs    []int
+}
+
+// Clear drops every message of kind k, keeping its filter.
+func (s *messageStore) Clear(k telemetry.Kind) clearedKind {
+	c := clearedKind{kind: k, msgs: s.Messages(k), sampled: s.sampled[k], seqs: s.seqs[k]}
+	s.setMessages(k, nil, nil, nil)
+	return c
+}
+
+// Restore puts back messages removed by Clear, ahead of any that arrived
+// since.
+func (s *messageStore) Restore(c clearedKind) {
+	k := c.kind
+	msgs := append(slices.Clip(c.msgs), s.Messages(k)...)
+	sampled := append(slices.Clip(c.sampled), s.sampled[k]...)
+	seqs := append(slices.Clip(c.seqs), s.seqs[k]...)
+	s.setMessages(k, msgs, sampled, seqs)
+}
+
+// setMessages replaces the messages of kind k, recounting its attributes
+// and re-indexing it.
+func (s *messageStore) setMessages(k telemetry.Kind, msgs []telemetry.Message, sampled []bool, seqs []int) {
+	s.put(k, msgs)
+	if s.sampled == nil {
+		s.sampled = make(map[telemetry.Kind][]bool)
+	}
+	s.sampled[k] = sampled
+	if s.seqs == nil {
+		s.seqs = make(map[telemetry.Kind][]int)
+	}
+	s.seqs[k] = seqs
+	a := &aggregate.Attributes{}
+	for _, m := range msgs {
+		a.Add(m)
+	}
+	if s.attrs == nil {
+		s.attrs = make(map[telemetry.Kind]*aggregate.Attributes)
+	}
+	s.attrs[k] = a
+	w := &wordIndex{}
+	for i, m := range msgs {
+		w.add(i, m)
+	}
+	if s.words == nil {
+		s.words = make(map[telemetry.Kind]*wordIndex)
+	}
+	s.words[k] = w
+	s.recountScopes()
+	s.SetFilter(k, s.filters[k])
+}
+
+// AttributesFor returns the attribute value counts for kind k.
+func (s *messageStore) AttributesFor(k telemetry.Kind) *aggregate.Attributes {
+	if s.attrs == nil {
+		s.attrs = make(map[telemetry.Kind]*aggregate.Attributes)
+	}
+	a, ok := s.attrs[k]
+	if !ok {
+		a = &aggregate.Attributes{}
+		s.attrs[k] = a
+	}
+	return a
+}
+
+func (s *messageStore) indexFor(k telemetry.Kind) *lineIndex {
+	if s.index == nil {
+		s.index = make(map[telemetry.Kind]*lineIndex)
+	}
+	x, ok := s.index[k]
+	if !ok {
+		x = &lineIndex{}
+		s.index[k] = x
+	}
+	return x
 }
 
 func (s *messageStore) Messages(k telemetry.Kind) []telemetry.Message {
-	switch k {
-	case telemetry.KindMetrics:
+	switch {
+	case k == telemetry.KindMetrics:
 		return s.metrics
-	case telemetry.KindTraces:
+	case k == telemetry.KindTraces:
 		return s.traces
+	case k == telemetry.KindUnknown:
+		return s.other
+	case isCustom(k):
+		return s.custom[k]
 	default:
 		return s.logs
 	}
 }
 
+// put replaces the messages of kind k, leaving the indexes alone.
+func (s *messageStore) put(k telemetry.Kind, msgs []telemetry.Message) {
+	switch {
+	case k == telemetry.KindMetrics:
+		s.metrics = msgs
+	case k == telemetry.KindTraces:
+		s.traces = msgs
+	case k == telemetry.KindUnknown:
+		s.other = msgs
+	case isCustom(k):
+		if s.custom == nil {
+			s.custom = make(map[telemetry.Kind][]telemetry.Message)
+		}
+		s.custom[k] = msgs
+	default:
+		s.logs = msgs
+	}
+}
+
+// Displayed returns how many messages of kind k are displayed.
+func (s *messageStore) Displayed(k telemetry.Kind) int {
+	return len(s.indexFor(k).msgs)
+}
+
+// Shown returns the messages of kind k as displayed, after filters and span
+// views.
+func (s *messageStore) Shown(k telemetry.Kind) []telemetry.Message {
+	x := s.indexFor(k)
+	out := make([]telemetry.Message, 0, len(x.msgs))
+	for _, i := range x.msgs {
+		out = append(out, *s.Display(k, i))
+	}
+	return out
+}
+
 func (s *messageStore) TotalLines(k telemetry.Kind) int {
-	msgs := s.Messages(k)
-	lines := 0
-	for _, m := range msgs {
-		lines += len(m.IndentedLines)
+	return s.indexFor(k).total
+}
+
+// Locate returns the index of the message of kind k containing line and the
+// line's offset within that message.
+func (s *messageStore) Locate(k telemetry.Kind, line int) (msg, offset int, ok bool) {
+	return s.indexFor(k).locate(line)
+}
+
+// MessageStart returns the first line of message i of kind k. ok is false
+// when the message is not displayed.
+func (s *messageStore) MessageStart(k telemetry.Kind, i int) (line int, ok bool) {
+	x := s.indexFor(k)
+	pos := sort.SearchInts(x.msgs, i)
+	if pos == len(x.msgs) || x.msgs[pos] != i {
+		return 0, false
+	}
+	return x.starts[pos], true
+}
+
+// Recount updates the rows message i of kind k takes after its lines
+// changed, shifting the messages after it.
+func (s *messageStore) Recount(k telemetry.Kind, i int) {
+	x := s.indexFor(k)
+	pos := sort.SearchInts(x.msgs, i)
+	if pos == len(x.msgs) || x.msgs[pos] != i {
+		return
+	}
+	end := x.total
+	if pos+1 < len(x.starts) {
+		end = x.starts[pos+1]
+	}
+	delta := s.rowsOf(*s.Display(k, i)) - (end - x.starts[pos])
+	for p := pos + 1; p < len(x.starts); p++ {
+		x.starts[p] += delta
+	}
+	x.total += delta
+	s.gen++
+}
+
+// LineRefs returns what is on screen rows [start, end) of kind k.
+func (s *messageStore) LineRefs(k telemetry.Kind, start, end int) []lineRef {
+	x := s.indexFor(k)
+	start = max(start, 0)
+	end = min(end, x.total)
+	if start >= end {
+		return nil
+	}
+	pos := x.find(start)
+	skip := start - x.starts[pos]
+	refs := make([]lineRef, 0, end-start)
+	for ; pos < len(x.msgs) && len(refs) < end-start; pos++ {
+		i := x.msgs[pos]
+		for j, l := range s.Display(k, i).Lines() {
+			n := s.lineRows(l)
+			if skip >= n {
+				skip -= n
+				continue
+			}
+			for part := skip; part < n && len(refs) < end-start; part++ {
+				refs = append(refs, lineRef{msg: i, line: j, part: part})
+			}
+			skip = 0
+		}
 	}
-	return lines
+	return refs
 }
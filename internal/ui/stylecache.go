@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// styleMode selects how a rendered line is styled.
+type styleMode int
+
+const (
+	stylePlain     styleMode = iota
+	styleHighlight           // line belongs to the selected message
+	styleCursor              // line is under the cursor
+	styleContext             // line belongs to a message shown as context around a match
+	styleModes
+)
+
+// styleKey identifies one message within its kind.
+type styleKey struct {
+	kind telemetry.Kind
+	msg  int
+}
+
+// styleCache memoizes padded, key-highlighted rows per message and mode so
+// that scrolling and cursor moves do not re-run the JSON key regex. Entries
+// are only valid for the width they were rendered and wrapped at; reset
+// drops them when the width or theme changes.
+type styleCache struct {
+	width   int
+	wrap    int // width lines were wrapped at; 0 = not wrapped
+	gen     int // store generation the entries were rendered from
+	entries map[styleKey]*[styleModes][][]string
+}
+
+func (c *styleCache) reset(width int) {
+	c.width = width
+	c.entries = nil
+}
+
+// line returns row part of line j of message i of kind k styled for mode.
+func (c *styleCache) line(k telemetry.Kind, i int, msg *telemetry.Message, j, part int, mode styleMode) string {
+	raw := msg.Lines()[j]
+	if mode == stylePlain {
+		row := raw
+		if part > 0 || (c.wrap > 0 && len(raw) > c.wrap) {
+			row = wrapLine(raw, c.wrap)[part]
+		}
+		if msg.LibraryFrame(j) {
+			return styles.Library.Render(row)
+		}
+		return row
+	}
+	if c.entries == nil {
+		c.entries = make(map[styleKey]*[styleModes][][]string)
+	}
+	key := styleKey{k, i}
+	e, ok := c.entries[key]
+	if !ok {
+		e = &[styleModes][][]string{}
+		c.entries[key] = e
+	}
+	if e[mode] == nil {
+		e[mode] = make([][]string, len(msg.Lines()))
+	}
+	if e[mode][j] == nil {
+		rows := wrapLine(raw, c.wrap)
+		for n, r := range rows {
+			rows[n] = c.render(r, mode)
+		}
+		e[mode][j] = rows
+	}
+	return e[mode][j][part]
+}
+
+func (c *styleCache) render(s string, mode styleMode) string {
+	if c.width > 0 {
+		if diff := c.width - lipgloss.Width(s); diff > 0 {
+			s += strings.Repeat(" ", diff)
+		}
+	}
+	switch mode {
+	case styleCursor:
+		return highlightJSONKeys(s, styles.Cursor, styles.CursorJSONKey)
+	case styleContext:
+		return styles.Context.Render(s)
+	}
+	return highlightJSONKeys(s, styles.Highlight, styles.HighlightJSONKey)
+}
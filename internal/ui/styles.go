@@ -0,0 +1,6 @@
+package ui
+
+import "github.com/jwafle/otail/internal/ui/theme"
+
+// styles holds the active theme's styles. Run swaps it before the program starts.
+var styles = theme.Default.Styles()
@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// DefaultTableColumns are the log table columns used when none are
+// configured.
+var DefaultTableColumns = []string{"time", "severity", "service", "body"}
+
+// tableColumnGap separates table columns.
+const tableColumnGap = "  "
+
+// logRow is one log record in table mode.
+type logRow struct {
+	time     time.Time
+	severity telemetry.Severity
+	service  string
+	body     string
+	pipeline time.Duration // observed time less time; -1 = unknown
+	attrs    pcommon.Map   // the record's attributes
+	res      pcommon.Map   // its resource's attributes
+	seq      int           // arrival order
+	context  bool          // from a message shown as context around a match
+}
+
+// cell returns the value of column col for the row. Unknown column names are
+// attribute keys, looked up on the record and then on its resource; an
+// "attr." prefix is accepted and ignored.
+func (r *logRow) cell(col string) string {
+	switch col {
+	case "time", "timestamp":
+		if r.time.IsZero() {
+			return ""
+		}
+		return r.time.Format("15:04:05.000")
+	case "severity":
+		return r.severity.String()
+	case "service", "service.name":
+		return r.service
+	case "body":
+		return telemetry.CurrentFormat().ANSI.Text(strings.ReplaceAll(r.body, "\n", " "))
+	case "pipeline":
+		if r.pipeline < 0 {
+			return ""
+		}
+		return shortDuration(r.pipeline)
+	}
+	col = strings.TrimPrefix(col, "attr.")
+	if v, ok := r.attrs.Get(col); ok {
+		return v.AsString()
+	}
+	if v, ok := r.res.Get(col); ok {
+		return v.AsString()
+	}
+	return ""
+}
+
+// logTable renders log records as rows with user-chosen columns. Rows are
+// appended as messages are indexed and rebuilt when the index changes.
+type logTable struct {
+	columns []string
+	rows    []logRow
+	sorted  []logRow // rows ordered by sortCol while paused; nil = arrival order
+
+	built int // index entries already turned into rows
+	gen   int // store generation the rows were built from
+
+	sortCol  int // -1 = arrival order
+	sortDesc bool
+}
+
+func newLogTable(columns []string) *logTable {
+	if len(columns) == 0 {
+		columns = DefaultTableColumns
+	}
+	return &logTable{columns: append([]string(nil), columns...), sortCol: -1}
+}
+
+// update brings the rows in line with the displayed log messages.
+func (t *logTable) update(s *messageStore) {
+	if t.gen != s.gen {
+		t.rows, t.sorted, t.built, t.gen = nil, nil, 0, s.gen
+	}
+	x := s.indexFor(telemetry.KindLogs)
+	for _, i := range x.msgs[t.built:] {
+		n := len(t.rows)
+		t.rows = appendLogRows(t.rows, s.Display(telemetry.KindLogs, i))
+		for j := n; j < len(t.rows); j++ {
+			t.rows[j].context = x.context[i]
+		}
+	}
+	t.built = len(x.msgs)
+}
+
+func appendLogRows(rows []logRow, msg *telemetry.Message) []logRow {
+	rls := msg.Logs.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		res := rls.At(i).Resource().Attributes()
+		svc := ""
+		if v, ok := res.Get("service.name"); ok {
+			svc = v.AsString()
+		}
+		sls := rls.At(i).ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			lrs := sls.At(j).LogRecords()
+			for k := 0; k < lrs.Len(); k++ {
+				lr := lrs.At(k)
+				ts := lr.Timestamp()
+				if ts == 0 {
+					ts = lr.ObservedTimestamp()
+				}
+				var t time.Time
+				if ts != 0 {
+					t = ts.AsTime().Local()
+				}
+				pipeline, ok := telemetry.PipelineLatency(lr)
+				if !ok {
+					pipeline = -1
+				}
+				rows = append(rows, logRow{
+					time:     t,
+					severity: telemetry.SeverityOf(lr.SeverityNumber()),
+					service:  svc,
+					body:     lr.Body().AsString(),
+					pipeline: pipeline,
+					attrs:    lr.Attributes(),
+					res:      res,
+					seq:      len(rows),
+				})
+			}
+		}
+	}
+	return rows
+}
+
+// view returns the rows in display order.
+func (t *logTable) view() []logRow {
+	if t.sorted != nil {
+		return t.sorted
+	}
+	return t.rows
+}
+
+// cycleSort advances the sort through each column ascending, then
+// descending, then back to arrival order.
+func (t *logTable) cycleSort() {
+	switch {
+	case t.sortCol < 0:
+		t.sortCol, t.sortDesc = 0, false
+	case !t.sortDesc:
+		t.sortDesc = true
+	case t.sortCol+1 < len(t.columns):
+		t.sortCol, t.sortDesc = t.sortCol+1, false
+	default:
+		t.sortCol = -1
+	}
+	t.resort()
+}
+
+// resort orders the rows by the sort column; arrival order breaks ties.
+func (t *logTable) resort() {
+	if t.sortCol < 0 || t.sortCol >= len(t.columns) {
+		t.sortCol, t.sorted = -1, nil
+		return
+	}
+	col := t.columns[t.sortCol]
+	t.sorted = append(t.sorted[:0], t.rows...)
+	less := func(a, b *logRow) bool {
+		switch col {
+		case "time", "timestamp":
+			return a.time.Before(b.time)
+		case "severity":
+			return a.severity < b.severity
+		case "pipeline":
+			return a.pipeline < b.pipeline
+		}
+		return a.cell(col) < b.cell(col)
+	}
+	sort.SliceStable(t.sorted, func(i, j int) bool {
+		a, b := &t.sorted[i], &t.sorted[j]
+		if t.sortDesc {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+// clearSort returns to arrival order.
+func (t *logTable) clearSort() {
+	t.sortCol, t.sorted = -1, nil
+}
+
+// widths fits the columns to width: every column but the last is as wide as
+// its widest value among rows (capped at a third of the width), and the last
+// takes what is left.
+func (t *logTable) widths(rows []logRow, width int) []int {
+	w := make([]int, len(t.columns))
+	for i, col := range t.columns {
+		w[i] = lipgloss.Width(t.header(i, col))
+		if i == len(t.columns)-1 {
+			break
+		}
+		for j := range rows {
+			w[i] = max(w[i], lipgloss.Width(rows[j].cell(col)))
+		}
+		w[i] = min(w[i], max(width/3, 1))
+	}
+	used := 0
+	for i := 0; i < len(w)-1; i++ {
+		used += w[i] + len(tableColumnGap)
+	}
+	w[len(w)-1] = max(width-used, 1)
+	return w
+}
+
+// header returns the title of column i, marked when it is the sort column.
+func (t *logTable) header(i int, col string) string {
+	title := strings.ToUpper(strings.TrimPrefix(col, "attr."))
+	if i == t.sortCol {
+		if t.sortDesc {
+			return title + " ▼"
+		}
+		return title + " ▲"
+	}
+	return title
+}
+
+// renderRow lays out cells padded or truncated to widths.
+func renderRow(cells []string, widths []int) string {
+	var b strings.Builder
+	for i, c := range cells {
+		if i > 0 {
+			b.WriteString(tableColumnGap)
+		}
+		b.WriteString(fitCell(c, widths[i], i == len(cells)-1))
+	}
+	return b.String()
+}
+
+// fitCell truncates s to w cells, padding it unless it is the last column.
+func fitCell(s string, w int, last bool) string {
+	if lipgloss.Width(s) > w {
+		// Cut by cells, keeping the escape sequences of colored bodies whole.
+		s = ansi.Truncate(s, w, "…")
+	}
+	if last {
+		return s
+	}
+	return s + strings.Repeat(" ", max(w-lipgloss.Width(s), 0))
+}
+
+// tableMode reports whether the message list shows the log table.
+func (m *Model) tableMode() bool {
+	return m.table != nil && m.Active == telemetry.KindLogs && m.overlay == overlayNone
+}
+
+// renderTableHeader draws the column titles shown above the viewport.
+func (m *Model) renderTableHeader() string {
+	t := m.table
+	start, end := m.viewport.Window()
+	rows := t.view()
+	widths := t.widths(rows[min(start, len(rows)):min(end, len(rows))], m.viewport.Width)
+	cells := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		cells[i] = t.header(i, col)
+	}
+	return styles.Status.Render(fitCell(renderRow(cells, widths), m.viewport.Width, false))
+}
+
+// syncTable shows the visible table rows in the viewport.
+func (m *Model) syncTable() {
+	t := m.table
+	t.update(m.store)
+	rows := t.view()
+	m.viewport.SetTotal(len(rows))
+	start, end := m.viewport.Window()
+	window := rows[min(start, len(rows)):min(end, len(rows))]
+	widths := t.widths(window, m.viewport.Width)
+	out := make([]string, len(window))
+	var b strings.Builder
+	for n := range window {
+		r := &window[n]
+		b.Reset()
+		for i, col := range t.columns {
+			if i > 0 {
+				b.WriteString(tableColumnGap)
+			}
+			cell := fitCell(r.cell(col), widths[i], i == len(t.columns)-1)
+			switch {
+			case r.context:
+				cell = styles.Context.Render(cell)
+			case col == "severity":
+				cell = styles.SeverityStyle(r.severity).Render(cell)
+			}
+			b.WriteString(cell)
+		}
+		out[n] = b.String()
+	}
+	m.viewport.SetRows(start, out)
+}
@@ -1,63 +1,115 @@
 package ui
 
 import (
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/jwafle/otail/internal/filter"
 	"github.com/jwafle/otail/internal/telemetry"
 )
 
-var (
-	activeTabBorder = lipgloss.Border{
-		Top:         "─",
-		Bottom:      " ",
-		Left:        "│",
-		Right:       "│",
-		TopLeft:     "╭",
-		TopRight:    "╮",
-		BottomLeft:  "┘",
-		BottomRight: "└",
-	}
-
-	tabBorder = lipgloss.Border{
-		Top:         "─",
-		Bottom:      "─",
-		Left:        "│",
-		Right:       "│",
-		TopLeft:     "╭",
-		TopRight:    "╮",
-		BottomLeft:  "┴",
-		BottomRight: "┴",
-	}
-
-	tabStyle = lipgloss.NewStyle().
-			Border(tabBorder, true).
-			BorderForeground(lipgloss.Color("214")).
-			Padding(0, 1)
-
-	activeTabStyle = tabStyle.Border(activeTabBorder, true)
-
-	tabGap = tabStyle.
-		BorderTop(false).
-		BorderLeft(false).
-		BorderRight(false)
-)
+// tab is one tab of the tab row: a signal's, or a custom tab showing the
+// messages of any signal its query matches.
+type tab struct {
+	kind  telemetry.Kind
+	title string
+	query filter.Filter // custom tabs only
+}
+
+// signalTabs lists the signal tabs in display order.
+var signalTabs = []tab{
+	{kind: telemetry.KindLogs, title: "Logs"},
+	{kind: telemetry.KindMetrics, title: "Metrics"},
+	{kind: telemetry.KindTraces, title: "Traces"},
+	{kind: telemetry.KindUnknown, title: "Other"},
+}
+
+// tabs lists every tab in display order: the signal tabs, then the custom
+// tabs from the config.
+var tabs = signalTabs
+
+// setCustomTabs makes a custom tab of each preset, after the signal tabs.
+// Custom tabs take the kinds after KindUnknown, in order.
+func setCustomTabs(ps []filter.Preset) {
+	tabs = slices.Clip(signalTabs)
+	for i, p := range ps {
+		tabs = append(tabs, tab{kind: telemetry.KindUnknown + 1 + telemetry.Kind(i), title: p.Name, query: p.Filter})
+	}
+}
+
+// isCustom reports whether k is a custom tab's kind.
+func isCustom(k telemetry.Kind) bool {
+	return k > telemetry.KindUnknown
+}
+
+// tabName names the tab of kind k: its signal, as "logs", or a custom
+// tab's title.
+func tabName(k telemetry.Kind) string {
+	for _, t := range tabs[len(signalTabs):] {
+		if t.kind == k {
+			return t.title
+		}
+	}
+	return k.String()
+}
+
+// tabNamed returns the kind of the tab called name: a signal, as
+// telemetry.ParseKind takes it, or a custom tab's title in any case.
+func tabNamed(name string) (telemetry.Kind, bool) {
+	for _, t := range tabs[len(signalTabs):] {
+		if strings.EqualFold(t.title, name) {
+			return t.kind, true
+		}
+	}
+	k, err := telemetry.ParseKind(name)
+	return k, err == nil
+}
+
+// cycleTab makes the tab d places to the right of the active one active,
+// wrapping around at either end.
+func (m *Model) cycleTab(d int) {
+	i := slices.IndexFunc(tabs, func(t tab) bool { return t.kind == m.Active })
+	m.switchTab(tabs[((i+d)%len(tabs)+len(tabs))%len(tabs)].kind)
+}
+
+// tabHeight is the number of rows occupied by the rendered tab row.
+var tabHeight = lipgloss.Height(styles.Tab.Render(" "))
+
+// activityFlash is how long a tab shows its activity dot after receiving
+// data while another tab is active.
+const activityFlash = time.Second
+
+func (m Model) renderTab(i int) string {
+	if tabs[i].kind == m.Active {
+		return styles.ActiveTab.Render(tabs[i].title)
+	}
+	title := tabs[i].title
+	st := m.tabStates[tabs[i].kind]
+	if st.unread > 0 {
+		title += " " + unreadBadge(st.unread)
+	}
+	if time.Since(st.received) < activityFlash {
+		title += " •"
+	}
+	return styles.Tab.Render(title)
+}
+
+func unreadBadge(n int) string {
+	if n > 999 {
+		return "999+"
+	}
+	return fmt.Sprint(n)
+}
 
 func (m Model) RenderTabs() string {
-	tabs := []string{
-		tabStyle.Render("Logs"),
-		tabStyle.Render("Metrics"),
-		tabStyle.Render("Traces"),
-	}
-	switch m.Active {
-	case telemetry.KindMetrics:
-		tabs[1] = activeTabStyle.Render("Metrics")
-	case telemetry.KindTraces:
-		tabs[2] = activeTabStyle.Render("Traces")
-	default:
-		tabs[0] = activeTabStyle.Render("Logs")
-	}
-	row := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	rendered := make([]string, len(tabs))
+	for i := range tabs {
+		rendered[i] = m.renderTab(i)
+	}
+	row := lipgloss.JoinHorizontal(lipgloss.Top, rendered...)
 	if m.viewport.Width > 0 {
 		gapWidth := m.viewport.Width - lipgloss.Width(row)
 		if gapWidth < 0 {
@@ -65,8 +117,102 @@ func (m Model) RenderTabs() string {
 		}
 		row = lipgloss.JoinHorizontal(lipgloss.Bottom,
 			row,
-			tabGap.Render(strings.Repeat(" ", gapWidth)),
+			styles.TabGap.Render(strings.Repeat(" ", gapWidth)),
 		)
 	}
 	return row
 }
+
+// tabAt returns the kind of the tab rendered at column x, if any.
+func (m Model) tabAt(x int) (telemetry.Kind, bool) {
+	left := 0
+	for i := range tabs {
+		right := left + lipgloss.Width(m.renderTab(i))
+		if x >= left && x < right {
+			return tabs[i].kind, true
+		}
+		left = right
+	}
+	return 0, false
+}
+
+// tabState is where a tab was left when another tab was made active. Each
+// tab's filter lives in the message store.
+type tabState struct {
+	offset int // viewport offset
+	cursor int // cursor line
+	paused bool
+
+	unread   int       // messages received since the tab was last viewed
+	received time.Time // when the tab last received a message
+}
+
+// switchTab makes k the active tab, saving the current tab's position and
+// restoring k's. A tab that has not been visited starts out streaming. In a
+// snapshot every tab opens paused at the top.
+func (m *Model) switchTab(k telemetry.Kind) {
+	if k == m.Active {
+		return
+	}
+	m.visual.active = false
+	if m.viewingSnapshot() {
+		m.Active = k
+		m.showFrozen()
+		return
+	}
+	m.saveTab()
+	m.Active = k
+	m.restoreTab()
+}
+
+// saveTab records the active tab's position.
+func (m *Model) saveTab() {
+	if m.tabStates == nil {
+		m.tabStates = make(map[telemetry.Kind]tabState)
+	}
+	st := tabState{offset: m.viewport.YOffset, cursor: m.cur.line, paused: m.paused}
+	if m.overlay != overlayNone {
+		// The viewport is showing the overlay; keep the list's offset.
+		st.offset = m.tabStates[m.Active].offset
+	}
+	m.tabStates[m.Active] = st
+}
+
+// restoreTab returns the active tab to its saved position and clears its
+// unread count.
+func (m *Model) restoreTab() {
+	st := m.tabStates[m.Active]
+	m.paused, m.cur.line = st.paused, st.cursor
+	m.tabStates[m.Active] = tabState{paused: st.paused}
+	m.viewport.SetTotal(m.totalLines())
+	if m.paused {
+		m.viewport.SetYOffset(st.offset)
+	} else {
+		m.viewport.GotoBottom()
+	}
+	m.syncViewport()
+}
+
+// noteReceived counts a message arriving for kind k toward its tab's unread
+// badge, unless that tab is live on screen.
+func (m *Model) noteReceived(k telemetry.Kind) {
+	if k == m.Active && !m.viewingSnapshot() {
+		return
+	}
+	if m.tabStates == nil {
+		m.tabStates = make(map[telemetry.Kind]tabState)
+	}
+	st := m.tabStates[k]
+	st.unread++
+	st.received = time.Now()
+	m.tabStates[k] = st
+}
+
+// tabPaused reports whether the live tab for kind k is paused, whether or
+// not it is on screen.
+func (m *Model) tabPaused(k telemetry.Kind) bool {
+	if k == m.Active && !m.viewingSnapshot() {
+		return m.paused
+	}
+	return m.tabStates[k].paused
+}
@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// TailPolicy decides which traces the Traces tab shows, judged on the
+// whole trace rather than message by message. A trace is shown if any set
+// policy keeps it; the zero value shows every trace.
+type TailPolicy struct {
+	Errors bool          // traces with at least one error span
+	Slower time.Duration // traces lasting at least this long, first span start to last span end; 0 = off
+	Every  int           // 1 in every N traces, chosen by trace ID; <= 1 = off
+}
+
+// ParseTailPolicy reads a comma-separated policy list such as
+// "errors,slow=250ms,1/10". "" and "off" are the zero policy.
+func ParseTailPolicy(s string) (TailPolicy, error) {
+	var p TailPolicy
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		switch {
+		case part == "" || part == "off" || part == "all":
+		case part == "errors" || part == "error":
+			p.Errors = true
+		case strings.HasPrefix(part, "slow=") || strings.HasPrefix(part, "slow>"):
+			d, err := time.ParseDuration(part[len("slow="):])
+			if err != nil || d <= 0 {
+				return TailPolicy{}, fmt.Errorf("trace sampling %q: want slow=DURATION, e.g. slow=250ms", part)
+			}
+			p.Slower = d
+		case strings.HasPrefix(part, "1/"):
+			n, err := strconv.Atoi(part[len("1/"):])
+			if err != nil || n < 1 {
+				return TailPolicy{}, fmt.Errorf("trace sampling %q: want 1/N, e.g. 1/10", part)
+			}
+			p.Every = n
+		default:
+			return TailPolicy{}, fmt.Errorf("unknown trace sampling policy %q (want errors, slow=DURATION, or 1/N)", part)
+		}
+	}
+	return p, nil
+}
+
+// IsZero reports whether p shows every trace.
+func (p TailPolicy) IsZero() bool {
+	return !p.Errors && p.Slower <= 0 && p.Every <= 1
+}
+
+// String renders p in the form ParseTailPolicy reads.
+func (p TailPolicy) String() string {
+	var parts []string
+	if p.Errors {
+		parts = append(parts, "errors")
+	}
+	if p.Slower > 0 {
+		parts = append(parts, "slow="+p.Slower.String())
+	}
+	if p.Every > 1 {
+		parts = append(parts, fmt.Sprintf("1/%d", p.Every))
+	}
+	if len(parts) == 0 {
+		return "off"
+	}
+	return strings.Join(parts, ",")
+}
+
+// tailSampler applies a TailPolicy to the trace buffer. A trace can start
+// qualifying only when a later span arrives, an error or one that stretches
+// it past the threshold; its spans in earlier messages then need redrawing.
+type tailSampler struct {
+	policy TailPolicy
+	traces map[pcommon.TraceID]*traceTally
+}
+
+// traceTally is what has been seen of one trace.
+type traceTally struct {
+	start, end pcommon.Timestamp
+	err        bool
+	first      int // message holding the trace's first span
+	kept       bool
+}
+
+func newTailSampler(p TailPolicy) *tailSampler {
+	if p.IsZero() {
+		return nil
+	}
+	return &tailSampler{policy: p, traces: map[pcommon.TraceID]*traceTally{}}
+}
+
+// scan tallies the spans of message i, reporting whether a trace with spans
+// in an earlier message has just qualified.
+func (t *tailSampler) scan(msg telemetry.Message, i int) (stale bool) {
+	eachSpan(msg.Traces, func(s ptrace.Span) {
+		id := s.TraceID()
+		tt, ok := t.traces[id]
+		if !ok {
+			tt = &traceTally{start: s.StartTimestamp(), end: s.EndTimestamp(), first: i}
+			t.traces[id] = tt
+		}
+		tt.start = min(tt.start, s.StartTimestamp())
+		tt.end = max(tt.end, s.EndTimestamp())
+		tt.err = tt.err || s.Status().Code() == ptrace.StatusCodeError
+		if !tt.kept && t.qualifies(id, tt) {
+			tt.kept = true
+			stale = stale || tt.first < i
+		}
+	})
+	return stale
+}
+
+func (t *tailSampler) qualifies(id pcommon.TraceID, tt *traceTally) bool {
+	p := t.policy
+	switch {
+	case p.Errors && tt.err:
+		return true
+	case p.Slower > 0 && tt.end > tt.start && time.Duration(tt.end-tt.start) >= p.Slower:
+		return true
+	case p.Every > 1:
+		// Trace IDs are random, so their low bytes pick 1 in N evenly, and
+		// the same traces whenever the buffer is rescanned.
+		return binary.BigEndian.Uint64(id[8:])%uint64(p.Every) == 0
+	}
+	return false
+}
+
+func (t *tailSampler) rebuild(msgs []telemetry.Message) {
+	t.traces = map[pcommon.TraceID]*traceTally{}
+	for i, msg := range msgs {
+		t.scan(msg, i)
+	}
+}
+
+func (t *tailSampler) keeps(id pcommon.TraceID) bool {
+	tt, ok := t.traces[id]
+	return ok && tt.kept
+}
+
+// setTailPolicy changes trace sampling at runtime, from :sample.
+func (m *Model) setTailPolicy(p TailPolicy) {
+	m.store.SetTailPolicy(p)
+	if m.Active == telemetry.KindTraces {
+		m.viewport.SetTotal(m.totalLines())
+		if !m.paused {
+			m.viewport.GotoBottom()
+		}
+		m.syncViewport()
+	}
+}
+
+func tailSamplingSegment(m Model) string {
+	if m.Active != telemetry.KindTraces || m.store.tailSampler == nil {
+		return ""
+	}
+	return "sampling " + m.store.tailSampler.policy.String()
+}
@@ -0,0 +1,140 @@
+package theme
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Styles is the full set of lipgloss styles the UI renders with.
+type Styles struct {
+	Status  lipgloss.Style
+	Banner  lipgloss.Style
+	Context lipgloss.Style // messages shown around filter matches
+	Library lipgloss.Style // library frames of stack traces
+
+	Highlight        lipgloss.Style
+	HighlightJSONKey lipgloss.Style
+	Cursor           lipgloss.Style
+	CursorJSONKey    lipgloss.Style
+
+	Tab       lipgloss.Style
+	ActiveTab lipgloss.Style
+	TabGap    lipgloss.Style
+
+	DiffAdded   lipgloss.Style
+	DiffRemoved lipgloss.Style
+	DiffChanged lipgloss.Style
+
+	Severity SeverityStyles
+}
+
+// SeverityStyles colors text by OTLP log severity range.
+type SeverityStyles struct {
+	Trace, Debug, Info, Warn, Error, Fatal lipgloss.Style
+}
+
+var (
+	activeTabBorder = lipgloss.Border{
+		Top:         "─",
+		Bottom:      " ",
+		Left:        "│",
+		Right:       "│",
+		TopLeft:     "╭",
+		TopRight:    "╮",
+		BottomLeft:  "┘",
+		BottomRight: "└",
+	}
+
+	tabBorder = lipgloss.Border{
+		Top:         "─",
+		Bottom:      "─",
+		Left:        "│",
+		Right:       "│",
+		TopLeft:     "╭",
+		TopRight:    "╮",
+		BottomLeft:  "┴",
+		BottomRight: "┴",
+	}
+)
+
+// Styles derives the UI styles from the theme's palette for the default
+// (stdout) renderer.
+func (t Theme) Styles() Styles {
+	return t.StylesFor(lipgloss.DefaultRenderer())
+}
+
+// StylesFor derives the styles for a specific renderer, whose color profile
+// decides how much of the palette survives.
+func (t Theme) StylesFor(r *lipgloss.Renderer) Styles {
+	highlight := r.NewStyle().Background(t.Highlight)
+
+	cursor := r.NewStyle().Background(t.Cursor)
+	if t.CursorFg != nil {
+		cursor = cursor.Foreground(t.CursorFg)
+	} else {
+		cursor = cursor.Reverse(true)
+	}
+
+	tab := r.NewStyle().
+		Border(tabBorder, true).
+		BorderForeground(t.Accent).
+		Padding(0, 1)
+
+	fg := func(c lipgloss.TerminalColor) lipgloss.Style {
+		return r.NewStyle().Foreground(c)
+	}
+
+	return Styles{
+		Status:  fg(t.Muted),
+		Banner:  fg(t.Severity.Error).Bold(true),
+		Context: fg(t.Muted).Faint(true),
+		Library: fg(t.Muted),
+
+		Highlight:        highlight,
+		HighlightJSONKey: highlight.Bold(true).Foreground(t.JSONKey),
+		Cursor:           cursor,
+		CursorJSONKey:    cursor.Bold(true).Foreground(t.JSONKey),
+
+		Tab:       tab,
+		ActiveTab: tab.Border(activeTabBorder, true),
+		TabGap: tab.
+			BorderTop(false).
+			BorderLeft(false).
+			BorderRight(false),
+
+		DiffAdded:   fg(t.Severity.Info),
+		DiffRemoved: fg(t.Severity.Error),
+		DiffChanged: fg(t.Severity.Warn),
+
+		Severity: SeverityStyles{
+			Trace: fg(t.Severity.Trace),
+			Debug: fg(t.Severity.Debug),
+			Info:  fg(t.Severity.Info),
+			Warn:  fg(t.Severity.Warn),
+			Error: fg(t.Severity.Error).Bold(true),
+			Fatal: fg(t.Severity.Fatal).Bold(true),
+		},
+	}
+}
+
+// SeverityStyle returns the style for a log severity range. Unset severities
+// render unstyled.
+func (s Styles) SeverityStyle(sev telemetry.Severity) lipgloss.Style {
+	switch sev {
+	case telemetry.SeverityTrace:
+		return s.Severity.Trace
+	case telemetry.SeverityDebug:
+		return s.Severity.Debug
+	case telemetry.SeverityInfo:
+		return s.Severity.Info
+	case telemetry.SeverityWarn:
+		return s.Severity.Warn
+	case telemetry.SeverityError:
+		return s.Severity.Error
+	case telemetry.SeverityFatal:
+		return s.Severity.Fatal
+	default:
+		return lipgloss.NewStyle()
+	}
+}
@@ -0,0 +1,140 @@
+// Package theme centralizes the colors and lipgloss styles used by the TUI.
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is a named palette. Styles derives every lipgloss style from it.
+type Theme struct {
+	Name string
+
+	Accent    lipgloss.TerminalColor // tab borders and other chrome
+	Muted     lipgloss.TerminalColor // status line text
+	JSONKey   lipgloss.TerminalColor // object keys in rendered JSON
+	Highlight lipgloss.TerminalColor // background of the selected message
+	Cursor    lipgloss.TerminalColor // background of the cursor line
+	CursorFg  lipgloss.TerminalColor // foreground of the cursor line; nil = reverse video
+
+	Severity Severity
+}
+
+// Severity holds one color per OTLP log severity range.
+type Severity struct {
+	Trace, Debug, Info, Warn, Error, Fatal lipgloss.TerminalColor
+}
+
+// Default matches the palette otail has always shipped with.
+var Default = Theme{
+	Name:      "default",
+	Accent:    lipgloss.Color("214"),
+	Muted:     lipgloss.AdaptiveColor{Light: "#909090", Dark: "#626262"},
+	JSONKey:   lipgloss.Color("214"),
+	Highlight: lipgloss.AdaptiveColor{Light: "#404040", Dark: "#303030"},
+	Cursor:    lipgloss.AdaptiveColor{Light: "#404040", Dark: "#303030"},
+	Severity: Severity{
+		Trace: lipgloss.Color("245"),
+		Debug: lipgloss.Color("39"),
+		Info:  lipgloss.Color("42"),
+		Warn:  lipgloss.Color("214"),
+		Error: lipgloss.Color("196"),
+		Fatal: lipgloss.Color("201"),
+	},
+}
+
+// Solarized uses Ethan Schoonover's solarized accents.
+var Solarized = Theme{
+	Name:      "solarized",
+	Accent:    lipgloss.Color("#268bd2"),
+	Muted:     lipgloss.AdaptiveColor{Light: "#93a1a1", Dark: "#586e75"},
+	JSONKey:   lipgloss.Color("#b58900"),
+	Highlight: lipgloss.AdaptiveColor{Light: "#eee8d5", Dark: "#073642"},
+	Cursor:    lipgloss.AdaptiveColor{Light: "#93a1a1", Dark: "#586e75"},
+	CursorFg:  lipgloss.AdaptiveColor{Light: "#fdf6e3", Dark: "#fdf6e3"},
+	Severity: Severity{
+		Trace: lipgloss.Color("#93a1a1"),
+		Debug: lipgloss.Color("#2aa198"),
+		Info:  lipgloss.Color("#859900"),
+		Warn:  lipgloss.Color("#b58900"),
+		Error: lipgloss.Color("#dc322f"),
+		Fatal: lipgloss.Color("#d33682"),
+	},
+}
+
+// Monochrome relies on text attributes only, for terminals without color.
+var Monochrome = Theme{
+	Name:      "monochrome",
+	Accent:    lipgloss.NoColor{},
+	Muted:     lipgloss.NoColor{},
+	JSONKey:   lipgloss.NoColor{},
+	Highlight: lipgloss.NoColor{},
+	Cursor:    lipgloss.NoColor{},
+	Severity: Severity{
+		Trace: lipgloss.NoColor{},
+		Debug: lipgloss.NoColor{},
+		Info:  lipgloss.NoColor{},
+		Warn:  lipgloss.NoColor{},
+		Error: lipgloss.NoColor{},
+		Fatal: lipgloss.NoColor{},
+	},
+}
+
+// HighContrast maximizes legibility on both light and dark terminals.
+var HighContrast = Theme{
+	Name:      "high-contrast",
+	Accent:    lipgloss.AdaptiveColor{Light: "#000000", Dark: "#ffffff"},
+	Muted:     lipgloss.AdaptiveColor{Light: "#000000", Dark: "#ffffff"},
+	JSONKey:   lipgloss.AdaptiveColor{Light: "#0000d7", Dark: "#ffff00"},
+	Highlight: lipgloss.AdaptiveColor{Light: "#d0d0d0", Dark: "#303030"},
+	Cursor:    lipgloss.AdaptiveColor{Light: "#000000", Dark: "#ffffff"},
+	CursorFg:  lipgloss.AdaptiveColor{Light: "#ffffff", Dark: "#000000"},
+	Severity: Severity{
+		Trace: lipgloss.AdaptiveColor{Light: "#444444", Dark: "#bcbcbc"},
+		Debug: lipgloss.AdaptiveColor{Light: "#005fd7", Dark: "#5fd7ff"},
+		Info:  lipgloss.AdaptiveColor{Light: "#008700", Dark: "#00ff00"},
+		Warn:  lipgloss.AdaptiveColor{Light: "#af5f00", Dark: "#ffff00"},
+		Error: lipgloss.AdaptiveColor{Light: "#d70000", Dark: "#ff0000"},
+		Fatal: lipgloss.AdaptiveColor{Light: "#d700af", Dark: "#ff00ff"},
+	},
+}
+
+var registry = map[string]Theme{}
+
+func init() {
+	for _, t := range []Theme{Default, Solarized, Monochrome, HighContrast} {
+		Register(t)
+	}
+}
+
+// Register makes a custom theme selectable by name, replacing any theme
+// already registered under the same name.
+func Register(t Theme) {
+	registry[strings.ToLower(t.Name)] = t
+}
+
+// Lookup returns the theme registered under name. An empty name selects
+// Default.
+func Lookup(name string) (Theme, error) {
+	if name == "" {
+		return Default, nil
+	}
+	t, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return t, nil
+}
+
+// Names lists the registered theme names in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for n := range registry {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
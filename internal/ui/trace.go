@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// traceDetail is a waterfall of one trace from the buffer, with each span's
+// events on a timeline under it and its links listed to be followed.
+type traceDetail struct {
+	trace     aggregate.Trace
+	focus     pcommon.SpanID // span a followed link pointed at, marked ▸
+	focusLine int            // overlay line of focus; 0 if not buffered
+	links     []traceLink    // followable links, in display order
+	sel       int
+	back      []traceStop // where following links came from; esc returns
+}
+
+// traceLink is a link and the overlay line it is drawn on.
+type traceLink struct {
+	line int
+	link aggregate.SpanLink
+}
+
+type traceStop struct {
+	id    pcommon.TraceID
+	focus pcommon.SpanID
+	sel   int
+}
+
+// openTraceDetail shows the trace of the first span of the message under
+// the cursor.
+func (m *Model) openTraceDetail() {
+	d := &m.traceDetail
+	d.back = nil
+	var id pcommon.TraceID
+	if m.cur.msg != nil && m.cur.msg.Kind == telemetry.KindTraces {
+		id = firstTraceID(m.cur.msg.Traces)
+	}
+	m.showTrace(id, pcommon.SpanID{})
+}
+
+func firstTraceID(td ptrace.Traces) pcommon.TraceID {
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		sss := rss.At(i).ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			if spans := sss.At(j).Spans(); spans.Len() > 0 {
+				return spans.At(0).TraceID()
+			}
+		}
+	}
+	return pcommon.TraceID{}
+}
+
+// showTrace rebuilds the view for trace id from the buffer, marking focus.
+func (m *Model) showTrace(id pcommon.TraceID, focus pcommon.SpanID) {
+	msgs := m.store.Messages(telemetry.KindTraces)
+	tds := make([]ptrace.Traces, len(msgs))
+	for i, msg := range msgs {
+		tds[i] = msg.Traces
+	}
+	d := &m.traceDetail
+	d.trace, d.focus, d.sel = aggregate.BuildTrace(tds, id), focus, 0
+	m.renderTraceDetail()
+}
+
+func (m *Model) renderTraceDetail() {
+	d := &m.traceDetail
+	t := d.trace
+	title := "trace " + t.ID.String()
+	if len(t.Spans) > 0 {
+		title += fmt.Sprintf(" · %s · %s", plural(len(t.Spans), "span"), shortDuration(t.End.Sub(t.Start)))
+	}
+	hint := " · esc close"
+	if len(d.back) > 0 {
+		hint = " · esc back"
+	}
+	lines := []string{styles.Status.Render(title + " · enter or click follows a link · W writes it" + hint)}
+	d.links, d.focusLine = d.links[:0], 0
+	if len(t.Spans) == 0 {
+		lines = append(lines, "", "no spans of this trace in the buffer")
+		m.overlayLines = lines
+		return
+	}
+
+	labelWidth := min(40, max(m.viewport.Width/3, 16))
+	const durWidth = 8
+	barWidth := max(m.viewport.Width-labelWidth-durWidth-4, 10)
+	total := max(t.End.Sub(t.Start), 1)
+	col := func(at time.Time) int {
+		c := int(float64(at.Sub(t.Start)) / float64(total) * float64(barWidth))
+		return min(max(c, 0), barWidth)
+	}
+
+	for _, s := range t.Spans {
+		indent := strings.Repeat("  ", s.Depth)
+		mark := "  "
+		if s.ID == d.focus && !d.focus.IsEmpty() {
+			mark, d.focusLine = "▸ ", len(lines)
+		}
+		from := min(col(s.Start), barWidth-1)
+		to := max(col(s.End), from+1)
+		bar := strings.Repeat(" ", from) + strings.Repeat("█", to-from) + strings.Repeat(" ", barWidth-to)
+		row := fmt.Sprintf("%s│%s│ %*s", fitCell(mark+indent+s.Name+" · "+s.Service, labelWidth, false), bar, durWidth, shortDuration(s.End.Sub(s.Start)))
+		if s.Error {
+			row = styles.Severity.Error.Render(row)
+		}
+		lines = append(lines, row)
+
+		if len(s.Events) > 0 {
+			timeline := []rune(strings.Repeat(" ", from) + strings.Repeat("─", to-from) + strings.Repeat(" ", barWidth-to))
+			for _, e := range s.Events {
+				timeline[min(col(e.Time), barWidth-1)] = '◆'
+			}
+			lines = append(lines, fmt.Sprintf("%s│%s│", fitCell("  "+indent+"  events", labelWidth, false), string(timeline)))
+			for _, e := range s.Events {
+				line := strings.TrimSpace(fmt.Sprintf("◆ +%s %s  %s", shortDuration(e.Time.Sub(s.Start)), e.Name, e.Labels))
+				lines = append(lines, truncateValue("    "+indent+line, m.viewport.Width))
+			}
+		}
+		for _, l := range s.Links {
+			line := strings.TrimSpace(fmt.Sprintf("→ trace %s span %s  %s", l.TraceID, l.SpanID, l.Labels))
+			line = truncateValue("    "+indent+line, m.viewport.Width)
+			if len(d.links) == d.sel {
+				line = styles.Cursor.Render(line)
+			}
+			d.links = append(d.links, traceLink{line: len(lines), link: l})
+			lines = append(lines, line)
+		}
+	}
+	m.overlayLines = lines
+}
+
+// followLink shows link i's trace, remembering where it came from.
+func (m *Model) followLink(i int) {
+	d := &m.traceDetail
+	if i < 0 || i >= len(d.links) {
+		return
+	}
+	l := d.links[i].link
+	d.back = append(d.back, traceStop{id: d.trace.ID, focus: d.focus, sel: i})
+	m.showTrace(l.TraceID, l.SpanID)
+	m.viewport.SetYOffset(0)
+	m.scrollToSelection(d.focusLine)
+}
+
+// traceDetailKey handles keys while the trace is shown and reports whether
+// it consumed msg. esc steps back along followed links before closing.
+func (m *Model) traceDetailKey(msg tea.KeyMsg) bool {
+	d := &m.traceDetail
+	switch {
+	case msg.String() == "esc" && len(d.back) > 0:
+		prev := d.back[len(d.back)-1]
+		d.back = d.back[:len(d.back)-1]
+		m.showTrace(prev.id, prev.focus)
+		d.sel = prev.sel
+		m.renderTraceDetail()
+		m.viewport.SetYOffset(0)
+		if d.sel < len(d.links) {
+			m.scrollToSelection(d.links[d.sel].line)
+		}
+		m.syncViewport()
+		return true
+	case msg.String() == "esc":
+		m.closeOverlay()
+		return true
+	case msg.String() == "enter":
+		m.followLink(d.sel)
+		return true
+	case len(d.links) == 0:
+		return false // the viewport scrolls the waterfall
+	case key.Matches(msg, m.viewport.KeyMap.Up):
+		d.sel = max(d.sel-1, 0)
+	case key.Matches(msg, m.viewport.KeyMap.Down):
+		d.sel = min(d.sel+1, len(d.links)-1)
+	default:
+		return false
+	}
+	m.renderTraceDetail()
+	m.scrollToSelection(d.links[d.sel].line)
+	return true
+}
+
+// clickTraceLine follows the link drawn on overlay line, if any.
+func (m *Model) clickTraceLine(line int) {
+	for i, l := range m.traceDetail.links {
+		if l.line == line {
+			m.followLink(i)
+			return
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/aggregate"
+	"github.com/jwafle/otail/internal/jaeger"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// W in the trace view writes the trace shown to a file, through the :
+// prompt so the format can be changed: :export-trace otlp PATH writes the
+// spans as received in one OTLP JSON document, which otail replay and
+// OTLP/HTTP receivers read; :export-trace jaeger PATH writes Jaeger JSON,
+// which Jaeger UI opens with its JSON file upload.
+
+// traceFormats lists what :export-trace can write.
+var traceFormats = []string{"otlp", "jaeger"}
+
+// startTraceExport opens the : prompt with the command writing the trace
+// shown as OTLP JSON.
+func (m *Model) startTraceExport() tea.Cmd {
+	return m.startCommand(fmt.Sprintf("export-trace otlp trace-%s.json", m.traceDetail.trace.ID))
+}
+
+// exportTrace writes the trace shown in format to path.
+func (m *Model) exportTrace(format, path string) {
+	id := m.traceDetail.trace.ID
+	if m.overlay != overlayTrace || id.IsEmpty() {
+		m.commandErr = fmt.Errorf("export-trace: open a trace with T first")
+		return
+	}
+	msgs := m.store.Messages(telemetry.KindTraces)
+	tds := make([]ptrace.Traces, len(msgs))
+	for i, msg := range msgs {
+		tds[i] = msg.Traces
+	}
+	td := aggregate.CollectTrace(tds, id)
+	if td.SpanCount() == 0 {
+		m.commandErr = fmt.Errorf("export-trace: no spans of trace %s in the buffer", id)
+		return
+	}
+	var b []byte
+	var err error
+	switch format {
+	case "otlp":
+		b, err = (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+	case "jaeger":
+		b, err = jaeger.Marshal(td)
+	default:
+		err = fmt.Errorf("unknown format %q (want %s)", format, strings.Join(traceFormats, " or "))
+	}
+	if err == nil {
+		path = expandHome(path)
+		err = os.WriteFile(path, append(b, '\n'), 0o644)
+	}
+	if err != nil {
+		m.commandErr = fmt.Errorf("export-trace: %w", err)
+		return
+	}
+	m.visual.note = fmt.Sprintf("wrote %s of trace %s to %s", plural(td.SpanCount(), "span"), id, path)
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// :trace takes a trace ID, or a W3C traceparent header as it is copied out
+// of an HTTP response, and filters both the Logs and Traces tabs to that
+// trace, showing the Traces tab. u undoes each tab's filter as usual.
+
+// parseTraceRef returns the lower-case hex trace ID in s, which is either
+// 32 hex digits or a traceparent (version-traceid-parentid-flags),
+// optionally still led by its header name.
+func parseTraceRef(s string) (string, error) {
+	s = strings.Trim(strings.TrimSpace(s), `"'`)
+	if name, value, ok := strings.Cut(s, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "traceparent") {
+		s = strings.TrimSpace(value)
+	}
+	id := strings.ToLower(s)
+	if parts := strings.Split(id, "-"); len(parts) == 4 {
+		if len(parts[0]) != 2 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+			return "", fmt.Errorf("trace %q: not a traceparent (want 00-TRACEID-PARENTID-FLAGS)", s)
+		}
+		id = parts[1]
+	}
+	if len(id) != 32 || strings.Trim(id, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("trace %q: want a traceparent header or a 32-digit hex trace ID", s)
+	}
+	if strings.Trim(id, "0") == "" {
+		return "", fmt.Errorf("trace %q: the all-zero trace ID is invalid", s)
+	}
+	return id, nil
+}
+
+// filterToTrace filters Logs and Traces to trace id and switches to Traces.
+func (m *Model) filterToTrace(id string) {
+	m.closeOverlay()
+	for _, k := range []telemetry.Kind{telemetry.KindLogs, telemetry.KindTraces} {
+		m.switchTab(k)
+		m.setFilter(filter.Filter{TraceID: id})
+	}
+}
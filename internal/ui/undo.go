@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// undoDepth is how many operations u can step back through.
+const undoDepth = 20
+
+// undoEntry reverses one operation that changed what a tab shows.
+type undoEntry struct {
+	what string // shown in the status bar once undone
+	kind telemetry.Kind
+	undo func(m *Model)
+}
+
+// pushUndo records how to reverse an operation on the active tab, dropping
+// the oldest entry once undoDepth are kept.
+func (m *Model) pushUndo(what string, undo func(m *Model)) {
+	if len(m.undo) == undoDepth {
+		m.undo = append(m.undo[:0], m.undo[1:]...)
+	}
+	m.undo = append(m.undo, undoEntry{what: what, kind: m.Active, undo: undo})
+	m.undone = ""
+}
+
+// popUndo reverses the most recent operation, switching back to the tab it
+// was made on.
+func (m *Model) popUndo() {
+	if len(m.undo) == 0 {
+		return
+	}
+	e := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+	m.switchTab(e.kind)
+	e.undo(m)
+	m.undone = e.what
+}
+
+// recordFilter remembers the active tab's filter and position before it is
+// replaced.
+func (m *Model) recordFilter() {
+	store, f := m.store, m.store.Filter(m.Active)
+	offset, line := m.viewport.YOffset, m.cur.line
+	m.pushUndo("filter change", func(m *Model) {
+		store.SetFilter(m.Active, f)
+		m.viewport.SetTotal(m.totalLines())
+		if m.paused {
+			m.viewport.SetYOffset(offset)
+			m.cur.line = min(line, max(m.totalLines()-1, 0))
+			m.ensureCursorVisible()
+		} else {
+			m.viewport.GotoBottom()
+		}
+		m.syncViewport()
+	})
+}
+
+// undoSegment confirms what u just undid, until the next key.
+func undoSegment(m Model) string {
+	if m.undone != "" {
+		return "undid " + m.undone
+	}
+	return ""
+}
+
+// filterChanged reports whether replacing the active tab's filter with f
+// would change anything worth undoing.
+func (m Model) filterChanged(f filter.Filter) bool {
+	return !m.store.Filter(m.Active).Equal(f)
+}
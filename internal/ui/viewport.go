@@ -1,7 +1,141 @@
 package ui
 
-import "github.com/charmbracelet/bubbles/viewport"
+import (
+	"slices"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// overscan is the number of rows rendered above and below the visible
+// window so that small scrolls do not need a re-render.
+const overscan = 10
+
+// Viewport is a virtualized scroll window. It tracks an offset into a
+// buffer of total lines but only holds the rendered rows around the visible
+// window, which the model supplies through SetRows. Its fields and methods
+// mirror bubbles' viewport.Model.
 type Viewport struct {
-	viewport.Model
+	Width, Height   int
+	YOffset         int
+	KeyMap          viewport.KeyMap
+	MouseWheelDelta int
+
+	total int      // lines in the whole buffer
+	start int      // buffer line of rows[0]
+	rows  []string // rendered rows
+}
+
+func newViewport(width, height int) Viewport {
+	km := viewport.DefaultKeyMap()
+	// u undoes; half a page up is ctrl+u alone.
+	km.HalfPageUp = key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("ctrl+u", "½ page up"))
+	return Viewport{
+		Width:           width,
+		Height:          height,
+		KeyMap:          km,
+		MouseWheelDelta: 3,
+	}
+}
+
+func (v Viewport) maxYOffset() int {
+	return max(0, v.total-v.Height)
+}
+
+// SetTotal sets the number of lines in the underlying buffer.
+func (v *Viewport) SetTotal(n int) {
+	v.total = n
+	if v.YOffset > v.maxYOffset() {
+		v.GotoBottom()
+	}
+}
+
+// SetYOffset sets the Y offset, clamped to the buffer.
+func (v *Viewport) SetYOffset(n int) {
+	v.YOffset = min(max(n, 0), v.maxYOffset())
+}
+
+func (v *Viewport) GotoBottom() { v.SetYOffset(v.maxYOffset()) }
+
+func (v Viewport) AtTop() bool    { return v.YOffset <= 0 }
+func (v Viewport) AtBottom() bool { return v.YOffset >= v.maxYOffset() }
+
+// VisibleLineCount returns the number of buffer lines currently in view.
+func (v Viewport) VisibleLineCount() int {
+	return max(0, min(v.Height, v.total-v.YOffset))
+}
+
+// Window returns the buffer line range [start, end) that should be
+// rendered for the current offset, including overscan.
+func (v Viewport) Window() (start, end int) {
+	start = max(0, v.YOffset-overscan)
+	end = min(v.total, v.YOffset+v.Height+overscan)
+	return start, max(start, end)
+}
+
+// Stale reports whether the visible range is not covered by the rendered
+// rows.
+func (v Viewport) Stale() bool {
+	return v.YOffset < v.start || v.YOffset+v.VisibleLineCount() > v.start+len(v.rows)
+}
+
+// SetRows stores the rendered rows beginning at buffer line start.
+func (v *Viewport) SetRows(start int, rows []string) {
+	v.start, v.rows = start, rows
+}
+
+// Update handles the standard viewport keys and the mouse wheel.
+func (v *Viewport) Update(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, v.KeyMap.PageDown):
+			v.SetYOffset(v.YOffset + v.Height)
+		case key.Matches(msg, v.KeyMap.PageUp):
+			v.SetYOffset(v.YOffset - v.Height)
+		case key.Matches(msg, v.KeyMap.HalfPageDown):
+			v.SetYOffset(v.YOffset + v.Height/2)
+		case key.Matches(msg, v.KeyMap.HalfPageUp):
+			v.SetYOffset(v.YOffset - v.Height/2)
+		case key.Matches(msg, v.KeyMap.Down):
+			v.SetYOffset(v.YOffset + 1)
+		case key.Matches(msg, v.KeyMap.Up):
+			v.SetYOffset(v.YOffset - 1)
+		}
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress {
+			break
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			v.SetYOffset(v.YOffset - v.MouseWheelDelta)
+		case tea.MouseButtonWheelDown:
+			v.SetYOffset(v.YOffset + v.MouseWheelDelta)
+		}
+	}
+}
+
+// View renders the visible rows, padded and truncated to the viewport size.
+// Rows are cut at the right edge rather than wrapped, which would push the
+// rows below them out of place; message lines arrive already wrapped.
+func (v Viewport) View() string {
+	var visible []string
+	if from := v.YOffset - v.start; from >= 0 && from < len(v.rows) {
+		visible = slices.Clone(v.rows[from:min(len(v.rows), from+v.Height)])
+	}
+	for i, r := range visible {
+		if len(r) > v.Width {
+			visible[i] = ansi.Truncate(r, v.Width, "")
+		}
+	}
+	return lipgloss.NewStyle().
+		Width(v.Width).
+		Height(v.Height).
+		MaxHeight(v.Height).
+		MaxWidth(v.Width).
+		Render(strings.Join(visible, "\n"))
 }
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Lines longer than the viewport soft-wrap onto as many screen rows as they
+// need. The line index counts rows rather than lines, so the cursor, mouse,
+// and scrolling all work in rows; reflow recounts them when the width
+// changes.
+
+// reflowDelay is how long the width has to stay put after a resize before
+// lines are rewrapped for it, so dragging a window edge rewraps once.
+const reflowDelay = 150 * time.Millisecond
+
+// wrapLine splits l into the rows it takes at width w.
+func wrapLine(l string, w int) []string {
+	if w <= 0 || len(l) <= w {
+		return []string{l} // no wider than its bytes
+	}
+	return strings.Split(ansi.Hardwrap(l, w, true), "\n")
+}
+
+// rowsAt returns how many rows msg takes at width w.
+func rowsAt(msg telemetry.Message, w int) int {
+	lines := msg.Lines()
+	if w <= 0 {
+		return len(lines)
+	}
+	n := 0
+	for _, l := range lines {
+		n += len(wrapLine(l, w))
+	}
+	return n
+}
+
+// lineRows returns how many rows l takes at the store's wrap width.
+func (s *messageStore) lineRows(l string) int {
+	if s.wrap <= 0 || len(l) <= s.wrap {
+		return 1
+	}
+	return len(wrapLine(l, s.wrap))
+}
+
+// rowsOf returns how many rows msg takes.
+func (s *messageStore) rowsOf(msg telemetry.Message) int {
+	return rowsAt(msg, s.wrap)
+}
+
+// rowOf returns the row within msg where its line j starts.
+func (s *messageStore) rowOf(msg telemetry.Message, j int) int {
+	row := 0
+	for _, l := range msg.Lines()[:min(j, len(msg.Lines()))] {
+		row += s.lineRows(l)
+	}
+	return row
+}
+
+// SetWrap re-indexes every kind for lines wrapping at width w; 0 turns
+// wrapping off.
+func (s *messageStore) SetWrap(w int) {
+	if s.wrap == w {
+		return
+	}
+	s.wrap = w
+	s.Reindex()
+}
+
+// applyWrap switches s to wrapping at w, taking the rows of its displayed
+// messages from rows, counted since s was last re-indexed; messages indexed
+// after them are counted here.
+func (s *messageStore) applyWrap(w int, rows map[telemetry.Kind][]int) {
+	s.wrap = w
+	for _, t := range tabs {
+		x := s.indexFor(t.kind)
+		counts := rows[t.kind]
+		x.total = 0
+		for pos, i := range x.msgs {
+			n := 0
+			if pos < len(counts) {
+				n = counts[pos]
+			} else {
+				n = s.rowsOf(*s.Display(t.kind, i))
+			}
+			x.starts[pos] = x.total
+			x.total += n
+		}
+	}
+}
+
+// reflowState debounces rewrapping after a resize and runs it off the UI
+// goroutine, so a large buffer does not freeze the screen while it is
+// counted. Until it finishes, rows keep their old wrapping and are cut at
+// the new width.
+type reflowState struct {
+	gen     int  // bumped on every resize, retiring earlier ticks and results
+	pending bool // the store is not yet wrapped at the current width
+}
+
+// reflowTickMsg starts a reflow once the width has settled.
+type reflowTickMsg int
+
+// reflowDoneMsg carries the rows every displayed message takes at width.
+type reflowDoneMsg struct {
+	gen      int
+	store    *messageStore
+	storeGen int
+	width    int
+	rows     map[telemetry.Kind][]int
+}
+
+// resized debounces a reflow for the new terminal width.
+func (m *Model) resized() tea.Cmd {
+	if m.store.wrap == m.wrapWidth() && !m.reflowing.pending {
+		return nil
+	}
+	m.reflowing.gen++
+	m.reflowing.pending = true
+	gen := m.reflowing.gen
+	return tea.Tick(reflowDelay, func(time.Time) tea.Msg { return reflowTickMsg(gen) })
+}
+
+// startReflow counts rows for the current width in the background. The
+// messages are shared with the store, and nothing modifies them once
+// stored.
+func (m *Model) startReflow() tea.Cmd {
+	m.reflowing.pending = true
+	done := reflowDoneMsg{gen: m.reflowing.gen, store: m.store, storeGen: m.store.gen, width: m.wrapWidth()}
+	shown := make(map[telemetry.Kind][]telemetry.Message)
+	for _, t := range tabs {
+		shown[t.kind] = m.store.Shown(t.kind)
+	}
+	return func() tea.Msg {
+		done.rows = make(map[telemetry.Kind][]int)
+		for k, msgs := range shown {
+			rows := make([]int, len(msgs))
+			for i, msg := range msgs {
+				rows[i] = rowsAt(msg, done.width)
+			}
+			done.rows[k] = rows
+		}
+		return done
+	}
+}
+
+// finishReflow applies a background reflow, or starts another if the
+// width, the store, or its index changed while it ran.
+func (m *Model) finishReflow(msg reflowDoneMsg) tea.Cmd {
+	if msg.gen != m.reflowing.gen {
+		return nil // a newer resize has its own tick coming
+	}
+	if msg.store != m.store || msg.width != m.wrapWidth() || msg.storeGen != m.store.gen {
+		return m.startReflow()
+	}
+	m.keepCursorLine(func() { m.store.applyWrap(msg.width, msg.rows) })
+	m.reflowing.pending = false
+	m.debug.Debug("reflowed", "width", msg.width)
+	m.syncViewport()
+	return nil
+}
+
+// wrapWidth is the width message lines should wrap at, 0 if they should
+// not.
+func (m Model) wrapWidth() int {
+	if m.noWrap {
+		return 0
+	}
+	return m.textWidth()
+}
+
+// reflow re-wraps the active store at the current width, unless a
+// background reflow is on its way.
+func (m *Model) reflow() {
+	w := m.wrapWidth()
+	if m.reflowing.pending || m.store.wrap == w {
+		return
+	}
+	m.keepCursorLine(func() { m.store.SetWrap(w) })
+}
+
+// keepCursorLine runs rewrap, which changes the rows lines take, and then
+// puts the cursor back on the line it was on.
+func (m *Model) keepCursorLine(rewrap func()) {
+	k := m.Active
+	refs := m.store.LineRefs(k, m.cur.line, m.cur.line+1)
+	rewrap()
+	m.viewport.SetTotal(m.store.TotalLines(k))
+	if !m.paused {
+		m.viewport.GotoBottom()
+		return
+	}
+	if len(refs) == 1 {
+		if start, ok := m.store.MessageStart(k, refs[0].msg); ok {
+			m.cur.line = start + m.store.rowOf(*m.store.Display(k, refs[0].msg), refs[0].line)
+		}
+	}
+	m.ensureCursorVisible()
+}
+
+// toggleWrap switches between wrapping long lines and cutting them at the
+// edge of the screen.
+func (m *Model) toggleWrap() tea.Cmd {
+	m.noWrap = !m.noWrap
+	m.reflowing.gen++
+	return m.startReflow()
+}
+
+func reflowSegment(m Model) string {
+	if !m.reflowing.pending {
+		return ""
+	}
+	return m.spinner.View() + " reflowing…"
+}
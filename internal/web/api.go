@@ -0,0 +1,341 @@
+package web
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	pmetric "go.opentelemetry.io/collector/pdata/pmetric"
+	ptrace "go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/query"
+	"github.com/jwafle/otail/internal/store"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+const defaultLogLimit = 100
+
+// apiLog is a flattened log record as returned by /api/logs.
+type apiLog struct {
+	Time       time.Time      `json:"time"`
+	Severity   string         `json:"severity"`
+	Service    string         `json:"service,omitempty"`
+	Body       string         `json:"body"`
+	TraceID    string         `json:"traceId,omitempty"`
+	SpanID     string         `json:"spanId,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// handleLogs serves GET /api/logs. Query parameters:
+//
+//	since  RFC 3339 time, or a duration such as 5m meaning "that long ago"
+//	limit  most recent records to return, default 100
+//	q      case-insensitive substring matched against body and service
+//	query  a query such as 'service=api severity>=warn last:5m'
+//
+// With a store, every stored record is searched, q matches attributes
+// too, and query's attribute conditions see record attributes only.
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, err := parseSince(q.Get("since"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, err := query.Parse(q.Get("query"))
+	if err != nil {
+		http.Error(w, "query: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := defaultLogLimit
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	if s.store != nil {
+		lq := store.LogQuery{Since: since, Limit: limit}
+		if v := q.Get("q"); v != "" {
+			lq.Texts = []string{v}
+		}
+		lq, ok := storeQuery(lq, f)
+		if !ok {
+			writeJSON(w, []apiLog{})
+			return
+		}
+		s.storedLogs(w, r, lq)
+		return
+	}
+	needle := strings.ToLower(q.Get("q"))
+
+	out := []apiLog{}
+	for _, msg := range s.hub.Snapshot() {
+		if msg.Kind != telemetry.KindLogs {
+			continue
+		}
+		msg, ok := f.Apply(msg)
+		if !ok {
+			continue
+		}
+		rls := msg.Logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			rl := rls.At(i)
+			svc := serviceName(rl.Resource())
+			sls := rl.ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					lr := lrs.At(k)
+					ts := lr.Timestamp()
+					if ts == 0 {
+						ts = lr.ObservedTimestamp()
+					}
+					t := ts.AsTime()
+					if !since.IsZero() && t.Before(since) {
+						continue
+					}
+					body := lr.Body().AsString()
+					if needle != "" && !strings.Contains(strings.ToLower(body), needle) &&
+						!strings.Contains(strings.ToLower(svc), needle) {
+						continue
+					}
+					rec := apiLog{
+						Time:     t,
+						Severity: telemetry.SeverityOf(lr.SeverityNumber()).String(),
+						Service:  svc,
+						Body:     body,
+					}
+					if id := lr.TraceID(); !id.IsEmpty() {
+						rec.TraceID = hex.EncodeToString(id[:])
+					}
+					if id := lr.SpanID(); !id.IsEmpty() {
+						rec.SpanID = hex.EncodeToString(id[:])
+					}
+					if lr.Attributes().Len() > 0 {
+						rec.Attributes = lr.Attributes().AsRaw()
+					}
+					out = append(out, rec)
+				}
+			}
+		}
+	}
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	writeJSON(w, out)
+}
+
+// storeQuery narrows q to the records f matches, and reports false if f
+// matches no log record at all.
+func storeQuery(q store.LogQuery, f filter.Filter) (store.LogQuery, bool) {
+	if len(f.Kinds) > 0 && !slices.Contains(f.Kinds, telemetry.KindLogs) || f.MinDuration > 0 || f.MaxDuration > 0 {
+		return q, false
+	}
+	if f.Last > 0 {
+		if t := time.Now().Add(-f.Last); t.After(q.Since) {
+			q.Since = t
+		}
+	}
+	if len(f.Texts) > 0 {
+		q.Texts = f.Texts
+	}
+	if f.Severity != telemetry.SeverityUnset {
+		for sev := f.Severity; sev <= telemetry.SeverityFatal; sev++ {
+			q.Severities = append(q.Severities, sev.String())
+		}
+	}
+	q.Service, q.TraceID, q.Attrs = f.Service, f.TraceID, f.Attrs
+	return q, true
+}
+
+// storedLogs answers /api/logs from the store.
+func (s *Server) storedLogs(w http.ResponseWriter, r *http.Request, q store.LogQuery) {
+	logs, err := s.store.Logs(r.Context(), q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	out := make([]apiLog, len(logs))
+	for i, l := range logs {
+		out[i] = apiLog{
+			Time:       l.Time,
+			Severity:   l.Severity,
+			Service:    l.Service,
+			Body:       l.Body,
+			TraceID:    l.TraceID,
+			SpanID:     l.SpanID,
+			Attributes: l.Attributes,
+		}
+	}
+	writeJSON(w, out)
+}
+
+// handleTrace serves GET /api/traces/{traceID}: every buffered span of the
+// trace, or every stored one with a store, as OTLP JSON.
+func (s *Server) handleTrace(w http.ResponseWriter, r *http.Request) {
+	var id pcommon.TraceID
+	b, err := hex.DecodeString(r.PathValue("traceID"))
+	if err != nil || len(b) != len(id) {
+		http.Error(w, "trace ID must be 32 hex digits", http.StatusBadRequest)
+		return
+	}
+	copy(id[:], b)
+
+	msgs := s.hub.Snapshot()
+	if s.store != nil {
+		if msgs, err = s.store.Trace(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	found := ptrace.NewTraces()
+	n := 0
+	for _, msg := range msgs {
+		if msg.Kind != telemetry.KindTraces {
+			continue
+		}
+		rss := msg.Traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			rs := rss.At(i)
+			var outRS ptrace.ResourceSpans
+			haveRS := false
+			sss := rs.ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				ss := sss.At(j)
+				var outSS ptrace.ScopeSpans
+				haveSS := false
+				spans := ss.Spans()
+				for k := 0; k < spans.Len(); k++ {
+					if spans.At(k).TraceID() != id {
+						continue
+					}
+					if !haveRS {
+						outRS, haveRS = found.ResourceSpans().AppendEmpty(), true
+						rs.Resource().CopyTo(outRS.Resource())
+						outRS.SetSchemaUrl(rs.SchemaUrl())
+					}
+					if !haveSS {
+						outSS, haveSS = outRS.ScopeSpans().AppendEmpty(), true
+						ss.Scope().CopyTo(outSS.Scope())
+						outSS.SetSchemaUrl(ss.SchemaUrl())
+					}
+					spans.At(k).CopyTo(outSS.Spans().AppendEmpty())
+					n++
+				}
+			}
+		}
+	}
+	if n == 0 {
+		http.Error(w, "trace not found", http.StatusNotFound)
+		return
+	}
+	data, err := (&ptrace.JSONMarshaler{}).MarshalTraces(found)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// apiMetric describes one metric name seen in the buffer.
+type apiMetric struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Unit        string   `json:"unit,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Services    []string `json:"services,omitempty"`
+	Points      int      `json:"points"`
+}
+
+// handleMetricNames serves GET /api/metrics/names: the distinct metrics in
+// the buffer, sorted by name.
+func (s *Server) handleMetricNames(w http.ResponseWriter, r *http.Request) {
+	byName := map[string]*apiMetric{}
+	services := map[string]map[string]bool{}
+	for _, msg := range s.hub.Snapshot() {
+		if msg.Kind != telemetry.KindMetrics {
+			continue
+		}
+		rms := msg.Metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			rm := rms.At(i)
+			svc := serviceName(rm.Resource())
+			sms := rm.ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					m := ms.At(k)
+					am, ok := byName[m.Name()]
+					if !ok {
+						am = &apiMetric{Name: m.Name(), Type: m.Type().String(), Unit: m.Unit(), Description: m.Description()}
+						byName[m.Name()] = am
+						services[m.Name()] = map[string]bool{}
+					}
+					am.Points += pointCount(m)
+					if svc != "" && !services[m.Name()][svc] {
+						services[m.Name()][svc] = true
+						am.Services = append(am.Services, svc)
+					}
+				}
+			}
+		}
+	}
+	out := make([]apiMetric, 0, len(byName))
+	for _, am := range byName {
+		sort.Strings(am.Services)
+		out = append(out, *am)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	writeJSON(w, out)
+}
+
+func pointCount(m pmetric.Metric) int {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		return m.Gauge().DataPoints().Len()
+	case pmetric.MetricTypeSum:
+		return m.Sum().DataPoints().Len()
+	case pmetric.MetricTypeHistogram:
+		return m.Histogram().DataPoints().Len()
+	case pmetric.MetricTypeExponentialHistogram:
+		return m.ExponentialHistogram().DataPoints().Len()
+	case pmetric.MetricTypeSummary:
+		return m.Summary().DataPoints().Len()
+	default:
+		return 0
+	}
+}
+
+// parseSince accepts an RFC 3339 time or a duration meaning "ago".
+func parseSince(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func serviceName(r pcommon.Resource) string {
+	if v, ok := r.Attributes().Get("service.name"); ok {
+		return v.AsString()
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
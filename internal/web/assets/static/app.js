@@ -0,0 +1,31 @@
+// Keeps the index page's connection status and per-signal counts live.
+(function () {
+  const state = document.getElementById("state");
+  const counts = {};
+  document.querySelectorAll(".count").forEach((td) => {
+    counts[td.dataset.signal] = td;
+  });
+
+  function setState(text) {
+    state.textContent = text;
+    state.className = "state " + text.split(" ")[0];
+  }
+
+  function connect() {
+    const ws = new WebSocket(location.origin.replace(/^http/, "ws") + "/ws");
+    ws.onmessage = (e) => {
+      const ev = JSON.parse(e.data);
+      if (ev.type === "state") {
+        setState(ev.state);
+      } else if (ev.type === "message" && counts[ev.signal]) {
+        const td = counts[ev.signal];
+        td.textContent = Number(td.textContent) + 1;
+      }
+    };
+    ws.onclose = () => {
+      setState("disconnected");
+      setTimeout(connect, 2000);
+    };
+  }
+  connect();
+})();
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// streamWriter writes a long-lived response, compressing it when the client
+// accepts gzip or deflate, and pushes every Flush through to the network.
+type streamWriter struct {
+	io.Writer
+	zw interface {
+		Flush() error
+		Close() error
+	}
+	flusher http.Flusher
+}
+
+// newStreamWriter negotiates Content-Encoding from the request. It must be
+// called before the response header is written.
+func newStreamWriter(w http.ResponseWriter, r *http.Request, flusher http.Flusher) *streamWriter {
+	w.Header().Add("Vary", "Accept-Encoding")
+	sw := &streamWriter{Writer: w, flusher: flusher}
+	switch enc := acceptedEncoding(r.Header.Get("Accept-Encoding")); enc {
+	case "gzip":
+		zw := gzip.NewWriter(w)
+		sw.Writer, sw.zw = zw, zw
+		w.Header().Set("Content-Encoding", enc)
+	case "deflate":
+		// HTTP's deflate is the zlib format, not a raw deflate stream.
+		zw := zlib.NewWriter(w)
+		sw.Writer, sw.zw = zw, zw
+		w.Header().Set("Content-Encoding", enc)
+	}
+	return sw
+}
+
+// Flush sends everything written so far to the client.
+func (sw *streamWriter) Flush() {
+	if sw.zw != nil {
+		sw.zw.Flush()
+	}
+	sw.flusher.Flush()
+}
+
+// Close finishes the compressed stream, if any.
+func (sw *streamWriter) Close() {
+	if sw.zw != nil {
+		sw.zw.Close()
+	}
+}
+
+// acceptedEncoding picks gzip over deflate from an Accept-Encoding header,
+// honouring exclusions by a q value of zero.
+func acceptedEncoding(header string) string {
+	ok := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		ok[strings.ToLower(strings.TrimSpace(name))] = quality(params) > 0
+	}
+	for _, enc := range []string{"gzip", "deflate"} {
+		if ok[enc] {
+			return enc
+		}
+	}
+	return ""
+}
+
+// quality returns the q value in the parameters of an Accept-Encoding
+// item: 1 if there is none, and 0 if it does not parse.
+func quality(params string) float64 {
+	for _, p := range strings.Split(params, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if !strings.EqualFold(strings.TrimSpace(k), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAcceptedEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"GZIP;q=0.5", "gzip"},
+		{"gzip;q=0", ""},
+		{"gzip;q=0.0, deflate", "deflate"},
+		{"gzip; q=0.000, deflate;q=0", ""},
+		{"gzip;level=1;q=0", ""},
+		{"gzip;q=bogus", ""},
+		{"br, deflate;q=0.1", "deflate"},
+	}
+	for _, tt := range tests {
+		if got := acceptedEncoding(tt.header); got != tt.want {
+			t.Errorf("acceptedEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestStreamWriterEncodings(t *testing.T) {
+	readers := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		// Content-Encoding: deflate is zlib-wrapped (RFC 9110 §8.4.1.2).
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		"":        func(r io.Reader) (io.Reader, error) { return r, nil },
+	}
+	for enc, open := range readers {
+		t.Run(enc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", enc)
+			rec := httptest.NewRecorder()
+			sw := newStreamWriter(rec, r, rec)
+			io.WriteString(sw, "data: hello\n\n")
+			sw.Flush()
+			sw.Close()
+
+			if got := rec.Header().Get("Content-Encoding"); got != enc {
+				t.Fatalf("Content-Encoding = %q, want %q", got, enc)
+			}
+			body, err := open(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "data: hello\n\n" {
+				t.Errorf("body = %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package web
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+//go:embed assets
+var assets embed.FS
+
+var indexTmpl = template.Must(template.ParseFS(assets, "assets/index.html.tmpl"))
+
+// staticFS holds the stylesheet and script served under /static/.
+var staticFS, _ = fs.Sub(assets, "assets/static")
+
+type indexSignal struct {
+	Name string
+	Kind string // telemetry.Kind.String(), as used in /ws events
+	Path string // SSE path segment
+}
+
+type indexData struct {
+	Endpoint   string
+	State      string
+	StateClass string
+	Signals    []indexSignal
+}
+
+// handleIndex renders the landing page.
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	st := s.hub.State().String()
+	data := indexData{
+		Endpoint:   s.endpoint,
+		State:      st,
+		StateClass: strings.Fields(st)[0],
+		Signals: []indexSignal{
+			{"Logs", telemetry.KindLogs.String(), "logs"},
+			{"Metrics", telemetry.KindMetrics.String(), "metrics"},
+			{"Traces", telemetry.KindTraces.String(), "traces"},
+			{"Other", telemetry.KindUnknown.String(), "other"},
+		},
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexTmpl.Execute(w, data); err != nil {
+		s.logger.Printf("render index: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package web serves the hub's telemetry to browsers and other HTTP clients.
+package web
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jwafle/otail/internal/hub"
+	"github.com/jwafle/otail/internal/store"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// Config tweaks behaviour; zero-value is sane.
+type Config struct {
+	Endpoint  string        // collector endpoint shown on the index page
+	KeepAlive time.Duration // SSE keepalive comment interval, default 15 s
+	Logger    *log.Logger   // nil = discard
+	Store     *store.Store  // answers /api/logs and /api/traces from every stored message; nil = retained messages only
+}
+
+// Server routes HTTP requests to handlers backed by a hub.
+type Server struct {
+	hub       *hub.Hub
+	endpoint  string
+	keepAlive time.Duration
+	logger    *log.Logger
+	store     *store.Store
+	mux       *http.ServeMux
+}
+
+// New returns a Server for h.
+func New(h *hub.Hub, cfg *Config) *Server {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	keepAlive := cfg.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = 15 * time.Second
+	}
+	s := &Server{hub: h, endpoint: cfg.Endpoint, keepAlive: keepAlive, logger: logger, store: cfg.Store, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(staticFS)))
+	for _, sig := range []struct {
+		path string
+		kind telemetry.Kind
+	}{
+		{"logs", telemetry.KindLogs},
+		{"metrics", telemetry.KindMetrics},
+		{"traces", telemetry.KindTraces},
+		{"other", telemetry.KindUnknown},
+	} {
+		s.mux.Handle("GET /"+sig.path+"/sse", s.sseHandler(sig.kind))
+	}
+	s.mux.Handle("GET /ws", s.wsHandler())
+	s.mux.HandleFunc("GET /api/logs", s.handleLogs)
+	s.mux.HandleFunc("GET /api/traces/{traceID}", s.handleTrace)
+	s.mux.HandleFunc("GET /api/metrics/names", s.handleMetricNames)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jwafle/otail/internal/hub"
+	"github.com/jwafle/otail/internal/query"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// sseHandler streams one signal as server-sent events. Each client gets its
+// own hub subscription, starting with the hub's recent history; a client
+// that cannot keep up is disconnected rather than silently losing frames.
+//
+// Query parameters narrow the stream server-side using the filter package's
+// syntax, e.g. ?service=checkout&severity=error&attr.http.status_code=500,
+// or a query such as ?query=service:checkout+dur>200ms; frames are trimmed
+// to the matching records.
+//
+// Events are named after the connection state ("state") or the signal
+// ("logs", "metrics", "traces", "unknown"); telemetry data is the frame as
+// compact JSON, with the hub sequence number as its id. A client that
+// reconnects with Last-Event-ID resumes after that message, as far back as
+// the hub retains. Idle connections get a comment every KeepAlive so that
+// proxies do not time them out.
+func (s *Server) sseHandler(kind telemetry.Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) { s.serveSSE(w, r, kind) }
+}
+
+func (s *Server) serveSSE(w http.ResponseWriter, r *http.Request, kind telemetry.Kind) {
+	f, err := query.FromValues(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts := &hub.SubscribeOptions{Replay: true, EvictSlow: true}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		if opts.After, err = strconv.ParseUint(id, 10, 64); err != nil {
+			http.Error(w, "Last-Event-ID must be a sequence number", http.StatusBadRequest)
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := s.hub.Subscribe(opts)
+	defer sub.Close()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	out := newStreamWriter(w, r, flusher)
+	defer out.Close()
+	w.WriteHeader(http.StatusOK)
+	out.Flush()
+
+	s.logger.Printf("%s subscribed to %s", r.RemoteAddr, kind)
+	defer s.logger.Printf("%s unsubscribed from %s", r.RemoteAddr, kind)
+
+	send := func(e hub.Entry) error {
+		if e.Kind != kind {
+			return nil
+		}
+		msg, ok := f.Apply(e.Message)
+		if !ok {
+			return nil
+		}
+		return writeEvent(out, strconv.FormatUint(e.Seq, 10), kind.String(), compact(msg.Raw))
+	}
+	for _, e := range sub.Backlog() {
+		if err := send(e); err != nil {
+			return
+		}
+	}
+	out.Flush()
+
+	keepAlive := time.NewTicker(s.keepAlive)
+	defer keepAlive.Stop()
+	states := sub.States()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := io.WriteString(out, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case st, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			if err := writeEvent(out, "", "state", []byte(st.String())); err != nil {
+				return
+			}
+		case e, ok := <-sub.Messages():
+			if !ok {
+				if sub.Evicted() {
+					s.logger.Printf("%s evicted: fell %d messages behind", r.RemoteAddr, sub.Stats().Capacity)
+					writeEvent(out, "", "evicted", []byte("client too slow"))
+					out.Flush()
+				}
+				return
+			}
+			if err := send(e); err != nil {
+				return
+			}
+		}
+		out.Flush()
+	}
+}
+
+// writeEvent writes a single SSE event, with an id field unless id is
+// empty. Multi-line data is split across data fields as the spec requires.
+func writeEvent(w io.Writer, id, event string, data []byte) error {
+	var b bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "event: %s\n", event)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		b.WriteString("data: ")
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// compact returns raw as single-line JSON, or unchanged if it is not JSON.
+func compact(raw []byte) []byte {
+	var b bytes.Buffer
+	if err := json.Compact(&b, raw); err != nil {
+		return raw
+	}
+	return b.Bytes()
+}
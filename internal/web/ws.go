@@ -0,0 +1,208 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/jwafle/otail/internal/filter"
+	"github.com/jwafle/otail/internal/hub"
+	"github.com/jwafle/otail/internal/query"
+	"github.com/jwafle/otail/internal/telemetry"
+)
+
+// wsEvent is a server-to-client frame on /ws.
+//
+//	{"type":"message","seq":42,"signal":"logs","data":{...OTLP JSON...}}
+//	{"type":"state","state":"connected"}
+//	{"type":"ok","control":"pause"}
+//	{"type":"error","error":"..."}
+type wsEvent struct {
+	Type    string          `json:"type"`
+	Seq     uint64          `json:"seq,omitempty"` // hub sequence number of a message
+	Signal  string          `json:"signal,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Text    string          `json:"text,omitempty"` // frames that are not JSON
+	State   string          `json:"state,omitempty"`
+	Control string          `json:"control,omitempty"`
+	Skipped int             `json:"skipped,omitempty"` // messages discarded while paused
+	Error   string          `json:"error,omitempty"`
+}
+
+// wsControl is a client-to-server frame on /ws.
+//
+//	{"type":"pause"}
+//	{"type":"resume"}
+//	{"type":"signals","signals":["logs","traces"]}  (empty = all)
+//	{"type":"filter","filter":"service=checkout severity>=error"}  (empty = none)
+//	{"type":"filter","q":"timeout"}                 (shorthand for "q:timeout")
+type wsControl struct {
+	Type    string   `json:"type"`
+	Signals []string `json:"signals"`
+	Filter  string   `json:"filter"`
+	Q       string   `json:"q"`
+}
+
+// wsSession is the per-connection view a client controls.
+type wsSession struct {
+	paused  bool
+	skipped int
+	signals map[telemetry.Kind]bool // nil = all
+	filter  filter.Filter
+}
+
+func (s *Server) wsHandler() http.Handler {
+	return websocket.Server{Handshake: sameOrigin, Handler: s.serveWS}
+}
+
+// sameOrigin rejects cross-site browser connections so that an arbitrary
+// page cannot read the stream through the user's browser. Clients that send
+// no Origin, such as command-line tools, are allowed.
+func sameOrigin(cfg *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return errors.New("cross-origin websocket rejected")
+	}
+	cfg.Origin = u
+	return nil
+}
+
+// serveWS streams every signal over one websocket, letting the client
+// pause, pick signals, and filter without reconnecting.
+func (s *Server) serveWS(c *websocket.Conn) {
+	defer c.Close()
+	remote := c.Request().RemoteAddr
+
+	sub := s.hub.Subscribe(&hub.SubscribeOptions{Replay: true, EvictSlow: true})
+	defer sub.Close()
+	s.logger.Printf("%s connected to /ws", remote)
+	defer s.logger.Printf("%s disconnected from /ws", remote)
+
+	controls := make(chan wsControl)
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(done)
+		for {
+			var ctl wsControl
+			if err := websocket.JSON.Receive(c, &ctl); err != nil {
+				return
+			}
+			select {
+			case controls <- ctl:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	sess := &wsSession{}
+	for _, e := range sub.Backlog() {
+		if err := websocket.JSON.Send(c, messageEvent(e)); err != nil {
+			return
+		}
+	}
+	states := sub.States()
+	for {
+		var ev *wsEvent
+		select {
+		case <-done:
+			return
+		case ctl := <-controls:
+			ev = sess.apply(ctl)
+		case st, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			ev = &wsEvent{Type: "state", State: st.String()}
+		case e, ok := <-sub.Messages():
+			if !ok {
+				if sub.Evicted() {
+					websocket.JSON.Send(c, wsEvent{Type: "error", Error: "evicted: client too slow"})
+				}
+				return
+			}
+			if e.Message, ok = sess.wants(e.Message); !ok {
+				continue
+			}
+			ev = messageEvent(e)
+		}
+		if err := websocket.JSON.Send(c, ev); err != nil {
+			return
+		}
+	}
+}
+
+// apply updates the session and returns the acknowledgement to send.
+func (sess *wsSession) apply(ctl wsControl) *wsEvent {
+	ack := &wsEvent{Type: "ok", Control: ctl.Type}
+	switch ctl.Type {
+	case "pause":
+		sess.paused = true
+	case "resume":
+		sess.paused = false
+		ack.Skipped, sess.skipped = sess.skipped, 0
+	case "signals":
+		if len(ctl.Signals) == 0 {
+			sess.signals = nil
+			break
+		}
+		want := map[telemetry.Kind]bool{}
+		for _, name := range ctl.Signals {
+			k, err := telemetry.ParseKind(name)
+			if err != nil {
+				return &wsEvent{Type: "error", Control: ctl.Type, Error: err.Error()}
+			}
+			want[k] = true
+		}
+		sess.signals = want
+	case "filter":
+		f, err := query.Parse(ctl.Filter)
+		if err != nil {
+			return &wsEvent{Type: "error", Control: ctl.Type, Error: err.Error()}
+		}
+		if ctl.Q != "" {
+			f.Texts = []string{ctl.Q}
+		}
+		sess.filter = f
+	default:
+		return &wsEvent{Type: "error", Control: ctl.Type, Error: "unknown control " + ctl.Type}
+	}
+	return ack
+}
+
+// wants returns msg narrowed to the session's filter and whether it should
+// be sent, counting what a pause hides.
+func (sess *wsSession) wants(msg telemetry.Message) (telemetry.Message, bool) {
+	if sess.signals != nil && !sess.signals[msg.Kind] {
+		return msg, false
+	}
+	msg, ok := sess.filter.Apply(msg)
+	if !ok {
+		return msg, false
+	}
+	if sess.paused {
+		sess.skipped++
+		return msg, false
+	}
+	return msg, true
+}
+
+func messageEvent(e hub.Entry) *wsEvent {
+	ev := &wsEvent{Type: "message", Seq: e.Seq, Signal: e.Kind.String()}
+	if json.Valid(e.Raw) {
+		ev.Data = compact(e.Raw)
+	} else {
+		ev.Text = string(e.Raw)
+	}
+	return ev
+}